@@ -0,0 +1,198 @@
+package aof
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/resp"
+)
+
+var ErrBadManifest = errors.New("invalid append only file manifest")
+
+// FileType tells what a file of the manifest holds
+type FileType byte
+
+const (
+	TypeBase FileType = 'b' // snapshot of every db, the incremental files start from it
+	TypeIncr FileType = 'i' // commands logged after the base was taken
+)
+
+// File is a file listed by the manifest, in the same dir as it
+type File struct {
+	Name string
+	Seq  int64
+	Type FileType
+}
+
+// Manifest lists the files the log is made of, the base first and then the incremental files in the order they're replayed
+// the base is optional, the log may start from empty dbs
+type Manifest struct {
+	Base  *File
+	Incrs []File
+}
+
+// ManifestName returns the name of the manifest of the files named after prefix
+func ManifestName(prefix string) string {
+	return prefix + ".manifest"
+}
+
+// BaseName returns the name of the base with the given seq, its extension tells it's a go-redis snapshot rather than a redis rdb
+func BaseName(prefix string, seq int64) string {
+	return fmt.Sprintf("%s.%d.base.goredis", prefix, seq)
+}
+
+// IncrName returns the name of the incremental file with the given seq
+func IncrName(prefix string, seq int64) string {
+	return fmt.Sprintf("%s.%d.incr.aof", prefix, seq)
+}
+
+// NextSeqs returns the seqs the next base and incremental file take
+func (m *Manifest) NextSeqs() (int64, int64) {
+	base, incr := int64(1), int64(1)
+	if m.Base != nil {
+		base = m.Base.Seq + 1
+	}
+	if n := len(m.Incrs); n > 0 {
+		incr = m.Incrs[n-1].Seq + 1
+	}
+	return base, incr
+}
+
+// Files returns the base, if any, along with the incremental files
+func (m *Manifest) Files() []File {
+	files := []File{}
+	if m.Base != nil {
+		files = append(files, *m.Base)
+	}
+	return append(files, m.Incrs...)
+}
+
+// ReadManifest reads the manifest at path, a "file <name> seq <seq> type <b|i>" line for every file
+func ReadManifest(path string) (*Manifest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	m := &Manifest{}
+	r := bufio.NewReader(f)
+	for n := 1; ; n++ {
+		line, err := r.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if strings.TrimSpace(line) != "" {
+			if err := m.addLine(line); err != nil {
+				return nil, fmt.Errorf("%w at line %d: %v", ErrBadManifest, n, err)
+			}
+		}
+		if err == io.EOF {
+			return m, nil
+		}
+	}
+}
+
+// adds the file of a line of the manifest, the incremental files must come in the order of their seqs
+func (m *Manifest) addLine(line string) error {
+	args, err := resp.SplitInline([]byte(strings.TrimRight(line, "\r\n")))
+	if err != nil {
+		return err
+	}
+	if len(args)%2 != 0 {
+		return errors.New("expected key value pairs")
+	}
+
+	file, seq, typ := "", "", ""
+	for i := 0; i < len(args); i += 2 {
+		switch args[i] {
+		case "file":
+			file = args[i+1]
+		case "seq":
+			seq = args[i+1]
+		case "type":
+			typ = args[i+1]
+		}
+	}
+
+	f := File{Name: file}
+	if file == "" || filepath.Base(file) != file {
+		return fmt.Errorf("invalid file name %q", file)
+	}
+	if f.Seq, err = strconv.ParseInt(seq, 10, 64); err != nil || f.Seq < 1 {
+		return fmt.Errorf("invalid seq %q", seq)
+	}
+
+	switch typ {
+	case string(TypeBase):
+		if m.Base != nil {
+			return errors.New("more than one base")
+		}
+		f.Type = TypeBase
+		m.Base = &f
+	case string(TypeIncr):
+		if n := len(m.Incrs); n > 0 && m.Incrs[n-1].Seq >= f.Seq {
+			return fmt.Errorf("seq %d out of order", f.Seq)
+		}
+		f.Type = TypeIncr
+		m.Incrs = append(m.Incrs, f)
+	default:
+		return fmt.Errorf("invalid type %q", typ)
+	}
+	return nil
+}
+
+// WriteManifest replaces the manifest at path
+// it's written aside and renamed once synced, so a crash leaves either the old manifest or the new one
+func WriteManifest(path string, m *Manifest) error {
+	var b strings.Builder
+	for _, f := range m.Files() {
+		b.WriteString(resp.JoinInline([]string{"file", f.Name, "seq", strconv.FormatInt(f.Seq, 10), "type", string(f.Type)}))
+		b.WriteByte('\n')
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "temp-*.manifest")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = io.WriteString(tmp, b.String())
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// LoadDir hands the base and then the commands of the incremental files of m, in dir, to l
+// only the last incremental file may end in the middle of a command, its size up to the last whole command
+// is returned along ErrTruncated then, see Read
+// any other error, a missing file included, is reported as ErrCorrupt since the files don't make up the log anymore
+func LoadDir(dir string, m *Manifest, l Loader) (int64, error) {
+	files := m.Files()
+	size := int64(0)
+	for i, f := range files {
+		var err error
+		size, err = LoadFile(filepath.Join(dir, f.Name), l)
+		if errors.Is(err, ErrTruncated) && f.Type == TypeIncr && i == len(files)-1 {
+			return size, err
+		}
+		if err != nil {
+			return 0, fmt.Errorf("%w: %s: %v", ErrCorrupt, f.Name, err)
+		}
+	}
+	return size, nil
+}
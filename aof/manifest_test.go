@@ -0,0 +1,142 @@
+package aof
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/rdb"
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store"
+)
+
+func TestManifest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ManifestName("appendonly.aof"))
+	m := &Manifest{
+		Base:  &File{Name: BaseName("appendonly.aof", 2), Seq: 2, Type: TypeBase},
+		Incrs: []File{{Name: IncrName("appendonly.aof", 3), Seq: 3, Type: TypeIncr}, {Name: "with space.aof", Seq: 4, Type: TypeIncr}},
+	}
+	if err := WriteManifest(path, m); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	out, _ := os.ReadFile(path)
+	exp := "file appendonly.aof.2.base.goredis seq 2 type b\nfile appendonly.aof.3.incr.aof seq 3 type i\nfile \"with space.aof\" seq 4 type i\n"
+	if string(out) != exp {
+		t.Errorf("Expected %q but got %q", exp, out)
+	}
+
+	read, err := ReadManifest(path)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if !reflect.DeepEqual(read, m) {
+		t.Errorf("Expected %+v but got %+v", m, read)
+	}
+	if base, incr := read.NextSeqs(); base != 3 || incr != 5 {
+		t.Errorf("Expected the next seqs to be 3 and 5 but got %d and %d", base, incr)
+	}
+	if files, _ := os.ReadDir(filepath.Dir(path)); len(files) != 1 {
+		t.Errorf("Expected only the manifest in the dir but got %d files", len(files))
+	}
+}
+
+func TestReadManifestInvalid(t *testing.T) {
+	testCases := []struct {
+		name     string
+		manifest string
+	}{
+		{"unknown type", "file a.aof seq 1 type x\n"},
+		{"invalid seq", "file a.aof seq one type i\n"},
+		{"path", "file ../a.aof seq 1 type i\n"},
+		{"two bases", "file a.rdb seq 1 type b\nfile b.rdb seq 2 type b\n"},
+		{"incrs out of order", "file a.aof seq 2 type i\nfile b.aof seq 1 type i\n"},
+		{"odd fields", "file a.aof seq 1 type\n"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "appendonly.aof.manifest")
+			os.WriteFile(path, []byte(tc.manifest), 0o644)
+
+			if _, err := ReadManifest(path); !errors.Is(err, ErrBadManifest) {
+				t.Errorf("Expected error %v but got %v", ErrBadManifest, err)
+			}
+		})
+	}
+}
+
+func TestLoadDir(t *testing.T) {
+	base := []File{{Name: "base.goredis", Seq: 1, Type: TypeBase}}
+	incrs := []File{{Name: "1.aof", Seq: 1, Type: TypeIncr}, {Name: "2.aof", Seq: 2, Type: TypeIncr}}
+	whole := commands([]string{"SET", "b", "2"})
+	torn := append(whole, "*2\r\n$3\r\nDEL"...)
+
+	testCases := []struct {
+		name     string
+		files    map[string][]byte
+		manifest *Manifest
+		expCmds  []string
+		expSize  int64
+		expErr   error
+	}{
+		{
+			name:     "base then incrs in order",
+			files:    map[string][]byte{"1.aof": commands([]string{"SET", "a", "1"}), "2.aof": whole},
+			manifest: &Manifest{Base: &base[0], Incrs: incrs},
+			expCmds:  []string{"SET a 1", "SET b 2"},
+			expSize:  int64(len(whole)),
+		},
+		{
+			name:     "torn last incr",
+			files:    map[string][]byte{"1.aof": commands([]string{"SET", "a", "1"}), "2.aof": torn},
+			manifest: &Manifest{Base: &base[0], Incrs: incrs},
+			expCmds:  []string{"SET a 1", "SET b 2"},
+			expSize:  int64(len(whole)),
+			expErr:   ErrTruncated,
+		},
+		{
+			name:     "torn incr before the last",
+			files:    map[string][]byte{"1.aof": torn, "2.aof": whole},
+			manifest: &Manifest{Base: &base[0], Incrs: incrs},
+			expCmds:  []string{"SET b 2"},
+			expErr:   ErrCorrupt,
+		},
+		{
+			name:     "missing incr",
+			files:    map[string][]byte{"1.aof": whole},
+			manifest: &Manifest{Base: &base[0], Incrs: incrs},
+			expCmds:  []string{"SET b 2"},
+			expErr:   ErrCorrupt,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			rdb.SaveFile(filepath.Join(dir, "base.goredis"), map[int][]store.Entry{0: {{Key: "k", Value: store.String("v")}}})
+			for name, data := range tc.files {
+				os.WriteFile(filepath.Join(dir, name), data, 0o644)
+			}
+
+			var cmds []string
+			var dbs map[int][]store.Entry
+			size, err := LoadDir(dir, tc.manifest, recordingLoader(&cmds, &dbs))
+			if !errors.Is(err, tc.expErr) {
+				t.Fatalf("Expected error %v but got %v", tc.expErr, err)
+			}
+			if len(dbs[0]) != 1 {
+				t.Errorf("Expected the base to be restored but got %v", dbs)
+			}
+			if !reflect.DeepEqual(cmds, tc.expCmds) {
+				t.Errorf("Expected %q but got %q", tc.expCmds, cmds)
+			}
+			if err == nil || errors.Is(err, ErrTruncated) {
+				if size != tc.expSize {
+					t.Errorf("Expected %d valid bytes but got %d", tc.expSize, size)
+				}
+			}
+		})
+	}
+}
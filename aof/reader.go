@@ -0,0 +1,155 @@
+package aof
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/rdb"
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/resp"
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store"
+)
+
+const maxPreallocArg int = 1024 // args preallocated upfront for a command, the count can't be trusted
+
+var (
+	ErrTruncated = errors.New("unexpected end of the append only file")
+	ErrCorrupt   = errors.New("bad file format reading the append only file")
+)
+
+// Loader gets what's read from the file, in order
+type Loader struct {
+	Restore func(dbs map[int][]store.Entry) error // gets the snapshot the file starts with, if it does
+	Apply   func(args []string) error             // gets every command
+}
+
+// counts the bytes read from r, so the offset of a command can be told through a bufio.Reader
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// Read hands the snapshot and the commands of the file to l
+// it returns the size of the file up to the last whole command, which is all of it unless the error is ErrTruncated
+// a tran the file ends in the middle of is cut off too, as it never ran
+func Read(r io.Reader, l Loader) (int64, error) {
+	cr := &countingReader{r: r}
+	br := bufio.NewReader(cr)
+	offset := func() int64 { return cr.n - int64(br.Buffered()) }
+
+	if head, _ := br.Peek(len(rdb.Magic)); string(head) == rdb.Magic {
+		dbs, err := rdb.Read(br)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %v", ErrCorrupt, err)
+		}
+		if err := l.Restore(dbs); err != nil {
+			return 0, fmt.Errorf("%w: %v", ErrCorrupt, err)
+		}
+	}
+
+	valid, tranStart := offset(), int64(-1)
+	for {
+		start := offset()
+		args, err := readCommand(br)
+		if err == io.EOF {
+			if tranStart >= 0 {
+				return tranStart, ErrTruncated
+			}
+			return valid, nil
+		}
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			if tranStart >= 0 {
+				return tranStart, ErrTruncated
+			}
+			return valid, ErrTruncated
+		}
+		if err != nil {
+			return valid, fmt.Errorf("%w at offset %d: %v", ErrCorrupt, start, err)
+		}
+
+		switch strings.ToUpper(args[0]) {
+		case "MULTI":
+			tranStart = start
+		case "EXEC":
+			tranStart = -1
+		}
+		if err := l.Apply(args); err != nil {
+			return valid, fmt.Errorf("%w at offset %d: %v", ErrCorrupt, start, err)
+		}
+		valid = offset()
+	}
+}
+
+// LoadFile reads the file at path, see Read
+func LoadFile(path string, l Loader) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	return Read(f, l)
+}
+
+// reads a RESP array of bulk strings, io.EOF is returned only if nothing was read
+func readCommand(r *bufio.Reader) ([]string, error) {
+	n, err := readHeader(r, '*')
+	if err != nil {
+		return nil, err
+	}
+	if n < 1 {
+		return nil, errors.New("empty command")
+	}
+
+	args := make([]string, 0, min(n, maxPreallocArg))
+	for range n {
+		size, err := readHeader(r, '$')
+		if err == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, io.ErrUnexpectedEOF
+		}
+		if string(buf[size:]) != "\r\n" {
+			return nil, errors.New("bulk string not followed by CRLF")
+		}
+		args = append(args, string(buf[:size]))
+	}
+	return args, nil
+}
+
+// reads a line like *3 or $5, returning the number after the prefix
+func readHeader(r *bufio.Reader, prefix byte) (int, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		if err == io.EOF && line != "" {
+			return 0, io.ErrUnexpectedEOF
+		}
+		return 0, err
+	}
+
+	num, ok := strings.CutPrefix(strings.TrimSuffix(line, "\r\n"), string(prefix))
+	if !ok {
+		return 0, fmt.Errorf("expected %q but got %q", prefix, line)
+	}
+	n, err := strconv.Atoi(num)
+	if err != nil || n < 0 || int64(n) > resp.DefaultMaxBulkLen {
+		return 0, fmt.Errorf("invalid length %q", num)
+	}
+	return n, nil
+}
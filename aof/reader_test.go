@@ -0,0 +1,125 @@
+package aof
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/rdb"
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store"
+)
+
+// returns a loader keeping the commands it gets as strings
+func recordingLoader(cmds *[]string, dbs *map[int][]store.Entry) Loader {
+	return Loader{
+		Restore: func(d map[int][]store.Entry) error {
+			*dbs = d
+			return nil
+		},
+		Apply: func(args []string) error {
+			*cmds = append(*cmds, strings.Join(args, " "))
+			return nil
+		},
+	}
+}
+
+func commands(cmds ...[]string) []byte {
+	var buf []byte
+	for _, args := range cmds {
+		buf = AppendCommand(buf, args)
+	}
+	return buf
+}
+
+func TestRead(t *testing.T) {
+	valid := commands([]string{"SELECT", "1"}, []string{"SET", "a", "1"}, []string{"MULTI"}, []string{"INCR", "a"}, []string{"EXEC"})
+	tranStart := len(commands([]string{"SELECT", "1"}, []string{"SET", "a", "1"}))
+
+	testCases := []struct {
+		name     string
+		input    []byte
+		expCmds  []string
+		expValid int
+		expErr   error
+	}{
+		{"empty", []byte{}, nil, 0, nil},
+		{"commands", valid, []string{"SELECT 1", "SET a 1", "MULTI", "INCR a", "EXEC"}, len(valid), nil},
+		{"truncated command", valid[:len(valid)-3], []string{"SELECT 1", "SET a 1", "MULTI", "INCR a"}, tranStart, ErrTruncated},
+		{"truncated header", append(commands([]string{"SET", "a", "1"}), "*2\r"...), []string{"SET a 1"}, len(commands([]string{"SET", "a", "1"})), ErrTruncated},
+		{"tran without EXEC", valid[:len(valid)-len(commands([]string{"EXEC"}))], []string{"SELECT 1", "SET a 1", "MULTI", "INCR a"}, tranStart, ErrTruncated},
+		{"bad format", append(commands([]string{"SET", "a", "1"}), "SET a 2\r\n"...), []string{"SET a 1"}, len(commands([]string{"SET", "a", "1"})), ErrCorrupt},
+		{"missing CRLF", []byte("*1\r\n$4\r\nPINGxx"), nil, 0, ErrCorrupt},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var cmds []string
+			var dbs map[int][]store.Entry
+			n, err := Read(bytes.NewReader(tc.input), recordingLoader(&cmds, &dbs))
+
+			if !errors.Is(err, tc.expErr) {
+				t.Errorf("Expected error %v but got %v", tc.expErr, err)
+			}
+			if n != int64(tc.expValid) {
+				t.Errorf("Expected %d valid bytes but got %d", tc.expValid, n)
+			}
+			if !reflect.DeepEqual(cmds, tc.expCmds) {
+				t.Errorf("Expected %q but got %q", tc.expCmds, cmds)
+			}
+		})
+	}
+}
+
+func TestReadPreamble(t *testing.T) {
+	var buf bytes.Buffer
+	snapshot := map[int][]store.Entry{2: {{Key: "k", Value: store.String("v")}}}
+	rdb.Write(&buf, snapshot)
+	buf.Write(commands([]string{"SET", "a", "1"}))
+
+	var cmds []string
+	var dbs map[int][]store.Entry
+	n, err := Read(bytes.NewReader(buf.Bytes()), recordingLoader(&cmds, &dbs))
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if n != int64(buf.Len()) {
+		t.Errorf("Expected %d valid bytes but got %d", buf.Len(), n)
+	}
+	if !reflect.DeepEqual(dbs, snapshot) {
+		t.Errorf("Expected %v but got %v", snapshot, dbs)
+	}
+	if !reflect.DeepEqual(cmds, []string{"SET a 1"}) {
+		t.Errorf("Expected %q but got %q", []string{"SET a 1"}, cmds)
+	}
+
+	// a broken preamble can't be repaired
+	corrupt := bytes.Clone(buf.Bytes())
+	corrupt[len(rdb.Magic)+3] ^= 0xFF
+	if _, err := Read(bytes.NewReader(corrupt), recordingLoader(&cmds, &dbs)); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("Expected error %v but got %v", ErrCorrupt, err)
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "appendonly.aof")
+	whole := commands([]string{"SET", "a", "1"})
+	os.WriteFile(path, append(bytes.Clone(whole), "*2\r\n$3\r\nDEL"...), 0o644)
+
+	var cmds []string
+	var dbs map[int][]store.Entry
+	n, err := LoadFile(path, recordingLoader(&cmds, &dbs))
+	if !errors.Is(err, ErrTruncated) {
+		t.Errorf("Expected error %v but got %v", ErrTruncated, err)
+	}
+	if n != int64(len(whole)) {
+		t.Errorf("Expected %d valid bytes but got %d", len(whole), n)
+	}
+
+	if _, err := LoadFile(filepath.Join(t.TempDir(), "missing.aof"), recordingLoader(&cmds, &dbs)); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected error %v but got %v", os.ErrNotExist, err)
+	}
+}
@@ -0,0 +1,169 @@
+package aof
+
+import (
+	"os"
+	"strconv"
+	"sync"
+)
+
+// Fsync tells how often the appended commands are flushed to the disk
+type Fsync int
+
+const (
+	FsyncEverySec Fsync = iota // once a second, up to a second of writes can be lost
+	FsyncAlways                // before replying to the client, the safest and the slowest
+	FsyncNo                    // whenever the OS decides to
+)
+
+var fsyncNames = []string{"everysec", "always", "no"}
+
+func (f Fsync) String() string {
+	return fsyncNames[f]
+}
+
+// ParseFsync parses always, everysec or no
+func ParseFsync(val string) (Fsync, bool) {
+	for i, name := range fsyncNames {
+		if name == val {
+			return Fsync(i), true
+		}
+	}
+	return 0, false
+}
+
+// Writer appends commands to the file, as RESP arrays of bulk strings like the clients send them
+type Writer struct {
+	mu       sync.Mutex
+	f        *os.File
+	fsync    Fsync
+	unsynced bool   // commands were written since the last fsync
+	size     int64  // size of the file
+	pending  []byte // commands a failed write left out, written ahead of the next ones
+	buf      []byte
+}
+
+// Open opens the file at path for appending, creating it if needed
+func Open(path string, fsync Fsync) (*Writer, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &Writer{f: f, fsync: fsync, size: info.Size()}, nil
+}
+
+// Append writes the commands in one go, they're synced right away with FsyncAlways
+// what a failed write left out is kept and written along the next commands, or by Sync
+func (w *Writer) Append(cmds ...[]string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf[:0], w.pending...)
+	for _, args := range cmds {
+		w.buf = AppendCommand(w.buf, args)
+	}
+	if err := w.write(w.buf); err != nil {
+		return err
+	}
+
+	if w.fsync == FsyncAlways {
+		return w.sync()
+	}
+	return nil
+}
+
+// Sync writes what a failed write left out and flushes the commands appended since the last sync to the disk,
+// called every second with FsyncEverySec, and to retry after an error
+func (w *Writer) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.pending) > 0 {
+		if err := w.write(w.pending); err != nil {
+			return err
+		}
+	}
+	return w.sync()
+}
+
+// writes b, keeping the part left out by an error as pending
+func (w *Writer) write(b []byte) error {
+	n, err := w.f.Write(b)
+	w.size += int64(n)
+	if n > 0 {
+		w.unsynced = true
+	}
+	if err != nil {
+		w.pending = append(w.pending[:0], b[n:]...)
+		return err
+	}
+	w.pending = w.pending[:0]
+	return nil
+}
+
+func (w *Writer) sync() error {
+	if !w.unsynced {
+		return nil
+	}
+	if err := w.f.Sync(); err != nil {
+		return err
+	}
+	w.unsynced = false
+	return nil
+}
+
+// Size returns the size of the file, commands appended included
+func (w *Writer) Size() int64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.size
+}
+
+func (w *Writer) Fsync() Fsync {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.fsync
+}
+
+func (w *Writer) SetFsync(fsync Fsync) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.fsync = fsync
+}
+
+// Close writes and syncs what's left and closes the file
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var err error
+	if len(w.pending) > 0 {
+		err = w.write(w.pending)
+	}
+	if err == nil {
+		err = w.sync()
+	}
+	if cerr := w.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// AppendCommand appends args to buf as a RESP array of bulk strings
+func AppendCommand(buf []byte, args []string) []byte {
+	buf = append(buf, '*')
+	buf = strconv.AppendInt(buf, int64(len(args)), 10)
+	buf = append(buf, "\r\n"...)
+	for _, a := range args {
+		buf = append(buf, '$')
+		buf = strconv.AppendInt(buf, int64(len(a)), 10)
+		buf = append(buf, "\r\n"...)
+		buf = append(buf, a...)
+		buf = append(buf, "\r\n"...)
+	}
+	return buf
+}
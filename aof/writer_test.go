@@ -0,0 +1,106 @@
+package aof
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAppendCommand(t *testing.T) {
+	testCases := []struct {
+		name   string
+		args   []string
+		expOut string
+	}{
+		{"command", []string{"SET", "foo", "bar"}, "*3\r\n$3\r\nSET\r\n$3\r\nfoo\r\n$3\r\nbar\r\n"},
+		{"empty arg", []string{"SET", "", "a b"}, "*3\r\n$3\r\nSET\r\n$0\r\n\r\n$3\r\na b\r\n"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if out := string(AppendCommand(nil, tc.args)); out != tc.expOut {
+				t.Errorf("Expected %q but got %q", tc.expOut, out)
+			}
+		})
+	}
+}
+
+func TestParseFsync(t *testing.T) {
+	for _, f := range []Fsync{FsyncAlways, FsyncEverySec, FsyncNo} {
+		if parsed, ok := ParseFsync(f.String()); !ok || parsed != f {
+			t.Errorf("Expected %v but got %v", f, parsed)
+		}
+	}
+	if _, ok := ParseFsync("sometimes"); ok {
+		t.Errorf("Expected sometimes to be refused")
+	}
+}
+
+func TestWriter(t *testing.T) {
+	for _, fsync := range []Fsync{FsyncAlways, FsyncEverySec, FsyncNo} {
+		t.Run(fsync.String(), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "appendonly.aof")
+
+			w, err := Open(path, fsync)
+			if err != nil {
+				t.Fatalf("Expected no error but got %v", err)
+			}
+			w.Append([]string{"SET", "a", "1"})
+			w.Append([]string{"MULTI"}, []string{"INCR", "a"}, []string{"EXEC"})
+			if err := w.Close(); err != nil {
+				t.Fatalf("Expected no error but got %v", err)
+			}
+
+			// reopening appends to what's there
+			w, err = Open(path, fsync)
+			if err != nil {
+				t.Fatalf("Expected no error but got %v", err)
+			}
+			w.Append([]string{"DEL", "a"})
+			w.Sync()
+			size := w.Size()
+			w.Close()
+
+			out, _ := os.ReadFile(path)
+			exp := "*3\r\n$3\r\nSET\r\n$1\r\na\r\n$1\r\n1\r\n*1\r\n$5\r\nMULTI\r\n*2\r\n$4\r\nINCR\r\n$1\r\na\r\n*1\r\n$4\r\nEXEC\r\n*2\r\n$3\r\nDEL\r\n$1\r\na\r\n"
+			if string(out) != exp {
+				t.Errorf("Expected %q but got %q", exp, out)
+			}
+			if size != int64(len(exp)) {
+				t.Errorf("Expected the size to be %d but got %d", len(exp), size)
+			}
+			if files, _ := os.ReadDir(filepath.Dir(path)); len(files) != 1 {
+				t.Errorf("Expected only the file in the dir but got %d files", len(files))
+			}
+		})
+	}
+}
+
+func TestWriterFailedWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "appendonly.aof")
+	w, err := Open(path, FsyncEverySec)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	// the file can't be written, the commands are kept until it can again
+	w.f.Close()
+	if err := w.Append([]string{"SET", "a", "1"}); err == nil {
+		t.Fatalf("Expected an error but got none")
+	}
+	if err := w.Sync(); err == nil {
+		t.Fatalf("Expected an error but got none")
+	}
+	if w.f, err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644); err != nil {
+		t.Fatalf("Failed to reopen the file: %v", err)
+	}
+	if err := w.Append([]string{"DEL", "a"}); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	w.Close()
+
+	out, _ := os.ReadFile(path)
+	if exp := "*3\r\n$3\r\nSET\r\n$1\r\na\r\n$1\r\n1\r\n*2\r\n$3\r\nDEL\r\n$1\r\na\r\n"; string(out) != exp {
+		t.Errorf("Expected %q but got %q", exp, out)
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+// rdbconv converts the dumps of redis-server into snapshots of go-redis and back
+// the format of the input is told by its header, the output takes the other one
+//
+//	rdbconv dump.rdb            prints the number of keys of each db
+//	rdbconv dump.rdb out.rdb    converts dump.rdb into out.rdb
+//
+// keys whose timeout passed are converted as well, the server skips them when it loads the file
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/rdb"
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/redisrdb"
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store"
+)
+
+const (
+	FORMAT_REDIS   = "redis rdb"
+	FORMAT_GOREDIS = "go-redis snapshot"
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: rdbconv in.rdb [out.rdb]")
+		fmt.Fprintln(os.Stderr, "converts a redis rdb into a go-redis snapshot or the other way round, prints its keys by db without out.rdb")
+	}
+	flag.Parse()
+	if flag.NArg() < 1 || flag.NArg() > 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	dbs, format, err := load(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error while reading %s: %v\n", flag.Arg(0), err)
+		os.Exit(1)
+	}
+
+	if flag.NArg() == 1 {
+		printDbs(dbs, format)
+		return
+	}
+
+	out := FORMAT_REDIS
+	save := redisrdb.SaveFile
+	if format == FORMAT_REDIS {
+		out, save = FORMAT_GOREDIS, rdb.SaveFile
+	}
+	if err := save(flag.Arg(1), dbs); err != nil {
+		fmt.Fprintf(os.Stderr, "Error while writing %s: %v\n", flag.Arg(1), err)
+		os.Exit(1)
+	}
+	fmt.Printf("Converted %d keys of the %s %s into the %s %s\n", countKeys(dbs), format, flag.Arg(0), out, flag.Arg(1))
+}
+
+// reads the file in whichever format its header tells
+func load(path string) (map[int][]store.Entry, string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, "", err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	if header, _ := r.Peek(len(rdb.Magic)); bytes.Equal(header, []byte(rdb.Magic)) {
+		dbs, err := rdb.Read(r)
+		return dbs, FORMAT_GOREDIS, err
+	}
+	dbs, err := redisrdb.Read(r)
+	return dbs, FORMAT_REDIS, err
+}
+
+func printDbs(dbs map[int][]store.Entry, format string) {
+	idxs := make([]int, 0, len(dbs))
+	for idx := range dbs {
+		idxs = append(idxs, idx)
+	}
+	slices.Sort(idxs)
+
+	fmt.Printf("%s with %d keys\n", format, countKeys(dbs))
+	for _, idx := range idxs {
+		expires := 0
+		for _, e := range dbs[idx] {
+			if e.ExpireAt != 0 {
+				expires++
+			}
+		}
+		fmt.Printf("db%d: keys=%d,expires=%d\n", idx, len(dbs[idx]), expires)
+	}
+}
+
+func countKeys(dbs map[int][]store.Entry) int {
+	n := 0
+	for _, entries := range dbs {
+		n += len(entries)
+	}
+	return n
+}
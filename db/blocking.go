@@ -0,0 +1,48 @@
+package db
+
+import "slices"
+
+// like redis, only the keys some client is blocked on are tracked
+// a write giving such a key new items reports it as ready, and the server serves its clients
+
+// marks the keys as waited on by one more client
+func (d *Db) Block(keys ...string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.blocking == nil {
+		d.blocking = make(map[string]int)
+	}
+	for _, k := range keys {
+		d.blocking[k]++
+	}
+}
+
+// marks the keys as waited on by one client less
+func (d *Db) Unblock(keys ...string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, k := range keys {
+		if d.blocking[k]--; d.blocking[k] <= 0 {
+			delete(d.blocking, k)
+		}
+	}
+}
+
+// returns the keys which became ready since the last call, in the order they did
+func (d *Db) ReadyKeys() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	keys := d.ready
+	d.ready = nil
+	return keys
+}
+
+// reports the key as ready if a client is blocked on it
+func (d *Db) signalReady(key string) {
+	if d.blocking[key] > 0 && !slices.Contains(d.ready, key) {
+		d.ready = append(d.ready, key)
+	}
+}
@@ -0,0 +1,36 @@
+package db
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store"
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store/inMemoryStore"
+)
+
+func TestReadyKeys(t *testing.T) {
+	newDB := GetNewDB(inMemoryStore.NewInMemoryStore())
+	newDB.Block("list", "zset", "stream")
+	newDB.Block("list")
+
+	newDB.Push("other", ListTail, "a")
+	newDB.ZAdd("zset", ZAddOptions{}, store.ZItem{Member: "a", Score: 1})
+	newDB.Push("list", ListTail, "a")
+	newDB.Push("list", ListTail, "b")
+	newDB.XAdd("stream", XAddID{AutoMs: true}, []string{"f", "v"}, XAddOptions{})
+
+	if keys := newDB.ReadyKeys(); !slices.Equal(keys, []string{"zset", "list", "stream"}) {
+		t.Errorf("Expected %v but got %v", []string{"zset", "list", "stream"}, keys)
+	}
+	if keys := newDB.ReadyKeys(); len(keys) != 0 {
+		t.Errorf("Expected the ready keys to be reported once but got %v", keys)
+	}
+
+	// list is still blocked on by the second client
+	newDB.Unblock("list", "zset", "stream")
+	newDB.LMove("other", "list", ListHead, ListTail)
+	newDB.ZAdd("zset", ZAddOptions{}, store.ZItem{Member: "b", Score: 1})
+	if keys := newDB.ReadyKeys(); !slices.Equal(keys, []string{"list"}) {
+		t.Errorf("Expected %v but got %v", []string{"list"}, keys)
+	}
+}
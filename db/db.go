@@ -2,44 +2,160 @@ package db
 
 import (
 	"errors"
+	"math"
 	"strconv"
+	"sync"
+	"time"
 
 	"github.com/justsushant/one2n-go-bootcamp/go-redis/store"
 )
 
 var ErrKeyNotFound = errors.New("(nil)")
 var ErrKeyNotInteger = errors.New("value is not an integer or out of range")
-var SetSuccessMessage = "OK"
-var DeleteSuccessMessage = "(integer) 1"
-var DeleteFailedMessage = "(integer) 0"
+var ErrWrongType = errors.New("WRONGTYPE Operation against a key holding the wrong kind of value")
 var DefaultIntegerValue = "1"
-var Integer = "(integer)"
 
 type DbInterface interface {
-	GetAll() map[string]string
+	GetAll() map[string]store.Value
+	DbSize() int
 	Set(key, val string)
+	SetWithOptions(key, val string, opts SetOptions) (old string, existed, written bool, err error)
 	Get(key string) (string, error)
-	Del(key string) string
-	Incr(key string) (string, error)
-	Incrby(key, val string) (string, error)
+	Del(key string) bool
+	Type(key string) string
+	Incr(key string) (int, error)
+	Incrby(key, val string) (int, error)
+	Expire(key string, at int64, cond ExpireCond) bool
+	TTL(key string) int64
+	ExpireTime(key string) int64
+	Persist(key string) bool
+	ActiveExpireCycle(budget time.Duration) int
+
+	Push(key string, end ListEnd, vals ...string) (int, error)
+	Pop(key string, end ListEnd, count int) ([]string, error)
+	LLen(key string) (int, error)
+	LRange(key string, start, stop int) ([]string, error)
+	LIndex(key string, index int) (string, bool, error)
+	LSet(key string, index int, val string) error
+	LRem(key string, count int, val string) (int, error)
+	LTrim(key string, start, stop int) error
+	LInsert(key string, pos ListInsertPos, pivot, val string) (int, error)
+	LPos(key, val string, opts LPosOptions) ([]int, error)
+	LMove(src, dst string, from, to ListEnd) (string, bool, error)
+
+	HSet(key string, pairs ...string) (int, error)
+	HSetNX(key, field, val string) (bool, error)
+	HGet(key, field string) (string, bool, error)
+	HMGet(key string, fields ...string) ([]string, []bool, error)
+	HDel(key string, fields ...string) (int, error)
+	HLen(key string) (int, error)
+	HExists(key, field string) (bool, error)
+	HGetAll(key string) (map[string]string, error)
+	HIncrBy(key, field string, incr int64) (int64, error)
+	HIncrByFloat(key, field string, incr float64) (string, error)
+	HRandField(key string, count int) ([]string, []string, error)
+	HScan(key string, cursor uint64, count int) (uint64, []string, []string, error)
+
+	SAdd(key string, members ...string) (int, error)
+	SRem(key string, members ...string) (int, error)
+	SMembers(key string) ([]string, error)
+	SMIsMember(key string, members ...string) ([]bool, error)
+	SCard(key string) (int, error)
+	SPop(key string, count int) ([]string, error)
+	SRandMember(key string, count int) ([]string, error)
+	SetAlgebra(op SetOp, keys ...string) ([]string, error)
+	SetAlgebraStore(op SetOp, dst string, keys ...string) (int, error)
+	SInterCard(limit int, keys ...string) (int, error)
+
+	ZAdd(key string, opts ZAddOptions, items ...store.ZItem) (int, error)
+	ZIncrBy(key string, opts ZAddOptions, member string, incr float64) (float64, bool, error)
+	ZRem(key string, members ...string) (int, error)
+	ZScore(key, member string) (float64, bool, error)
+	ZMScore(key string, members ...string) ([]float64, []bool, error)
+	ZCard(key string) (int, error)
+	ZCount(key string, r store.ScoreRange) (int, error)
+	ZRank(key, member string, rev bool) (int, float64, bool, error)
+	ZRange(key string, q ZRangeQuery) ([]store.ZItem, error)
+	ZRangeStore(dst, src string, q ZRangeQuery) (int, error)
+	ZPop(key string, count int, max bool) ([]store.ZItem, error)
+	ZSetAlgebraStore(op SetOp, dst string, keys []string, weights []float64, agg ZAggregate) (int, error)
+
+	XAdd(key string, id XAddID, fields []string, opts XAddOptions) (store.StreamID, bool, error)
+	XLen(key string) (int, error)
+	XRange(key string, start, end store.StreamID, count int, rev bool) ([]store.StreamEntry, error)
+	XDel(key string, ids ...store.StreamID) (int, error)
+	XTrim(key string, trim StreamTrim) (int, error)
+	XLastID(key string) (store.StreamID, error)
+	XRead(keys []string, after []store.StreamID, count int) ([]StreamRead, error)
+	XGroupCreate(key, group string, opts XGroupOptions) error
+	XGroupSetID(key, group string, opts XGroupOptions) error
+	XGroupDestroy(key, group string) (bool, error)
+	XReadGroup(group, consumer string, keys []string, ids []XReadGroupID, count int, noAck bool) ([]StreamRead, error)
+	XAck(key, group string, ids ...store.StreamID) (int, error)
+	XPendingSummary(key, group string) (PendingSummary, error)
+	XPending(key, group string, q PendingQuery) ([]PendingInfo, error)
+	XClaim(key, group, consumer string, ids []store.StreamID, opts XClaimOptions) ([]store.StreamEntry, error)
+	XAutoClaim(key, group, consumer string, minIdle int64, start store.StreamID, count int, justID bool) (store.StreamID, []store.StreamEntry, []store.StreamID, error)
+	XInfoStream(key string) (StreamInfo, error)
+	XInfoGroups(key string) ([]GroupInfo, error)
+	XInfoConsumers(key, group string) ([]ConsumerInfo, error)
+
+	Block(keys ...string)
+	Unblock(keys ...string)
+	ReadyKeys() []string
+
+	SetNotifier(n Notifier)
+
+	Watch(key string) uint64
+	Unwatch(key string)
+	Touched(key string, version uint64) bool
+	Flush()
+
+	Snapshot() []store.Entry
+	Restore(entries []store.Entry)
+	Import(entries []store.Entry) []store.Entry
+	Dirty() uint64
 }
 
+// every command holds the lock of the db, so what it reads and writes can't interleave with another command
 type Db struct {
 	store store.Store
+	now   func() int64 // current unix time in ms, overridden in tests
+	mu    sync.Mutex
+
+	blocking map[string]int         // number of clients blocked on each key
+	ready    []string               // keys blocked on which got new items, see ReadyKeys
+	notifier Notifier               // gets the keyspace events, see SetNotifier
+	watched  map[string]*watchedKey // keys watched by some client, see Watch
+	dirty    uint64                 // number of changes, see Dirty
 }
 
-func GetNewDB(store store.Store) Db {
-	return Db{
+func GetNewDB(store store.Store) *Db {
+	return &Db{
 		store: store,
 	}
 }
 
-func (d Db) Set(key, val string) {
-	d.store.Set(key, val)
+// sets the value and clears the timeout of the key
+func (d *Db) Set(key, val string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.store.Set(key, store.String(val))
+	d.store.DelExpiry(key)
+	d.notify(EventString, "set", key)
 }
 
-func (d Db) Get(key string) (string, error) {
-	val, ok := d.store.Get(key)
+func (d *Db) Get(key string) (string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.expireIfNeeded(key)
+
+	val, ok, err := d.getString(key)
+	if err != nil {
+		return "", err
+	}
 	if !ok {
 		return "", ErrKeyNotFound
 	}
@@ -47,55 +163,139 @@ func (d Db) Get(key string) (string, error) {
 	return val, nil
 }
 
-func (d Db) Del(key string) string {
-	_, ok := d.store.Get(key)
+// returns the type of the value of the key, none if it's missing
+func (d *Db) Type(key string) string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.expireIfNeeded(key)
+
+	val, ok := d.store.Get(key)
 	if !ok {
-		return DeleteFailedMessage
+		return "none"
 	}
-
-	d.store.Del(key)
-	return DeleteSuccessMessage
+	return val.Type()
 }
 
-func (d Db) Incr(key string) (string, error) {
+// returns the string value of the key, or ErrWrongType if it holds another type
+func (d *Db) getString(key string) (string, bool, error) {
 	val, ok := d.store.Get(key)
 	if !ok {
-		d.store.Set(key, DefaultIntegerValue)
-		return Integer + " " + DefaultIntegerValue, nil
+		return "", false, nil
 	}
 
-	i, err := strconv.Atoi(val)
-	if err != nil {
-		return "", ErrKeyNotInteger
+	str, ok := val.(store.String)
+	if !ok {
+		return "", false, ErrWrongType
 	}
+	return string(str), true, nil
+}
+
+// returns true if the key existed
+func (d *Db) Del(key string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
 
-	incrVal := i + 1
-	d.store.Set(key, strconv.Itoa(incrVal))
-	return Integer + " " + strconv.Itoa(incrVal), nil
+	d.expireIfNeeded(key)
+
+	_, ok := d.store.Get(key)
+	if !ok {
+		return false
+	}
+
+	d.store.Del(key)
+	d.notify(EventGeneric, "del", key)
+	// consumer groups are gone with the stream, their blocked consumers get an error
+	d.signalReady(key)
+	return true
 }
 
-func (d Db) Incrby(key, i string) (string, error) {
-	num, err := strconv.Atoi(i)
+func (d *Db) Incr(key string) (int, error) {
+	return d.Incrby(key, DefaultIntegerValue)
+}
+
+func (d *Db) Incrby(key, i string) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	num, err := strconv.ParseInt(i, 10, 64)
 	if err != nil {
-		return "", ErrKeyNotInteger
+		return 0, ErrKeyNotInteger
 	}
 
-	val, ok := d.store.Get(key)
+	d.expireIfNeeded(key)
+
+	// timeout of the key is retained, like redis does
+	val, ok, err := d.getString(key)
+	if err != nil {
+		return 0, err
+	}
 	if !ok {
-		d.store.Set(key, strconv.Itoa(num))
-		return Integer + " " + i, nil
+		d.store.Set(key, store.String(strconv.FormatInt(num, 10)))
+		d.notify(EventString, "incrby", key)
+		return int(num), nil
 	}
 
-	vali, err := strconv.Atoi(val)
+	vali, err := strconv.ParseInt(val, 10, 64)
 	if err != nil {
-		return "", ErrKeyNotInteger
+		return 0, ErrKeyNotInteger
 	}
 
+	// the value is a 64 bit integer, like HINCRBY a sum past its range is refused rather than wrapped around
+	if (num > 0 && vali > math.MaxInt64-num) || (num < 0 && vali < math.MinInt64-num) {
+		return 0, ErrIncrOverflow
+	}
 	incrVal := num + vali
-	d.store.Set(key, strconv.Itoa(incrVal))
-	return Integer + " " + strconv.Itoa(incrVal), nil
+	d.store.Set(key, store.String(strconv.FormatInt(incrVal, 10)))
+	d.notify(EventString, "incrby", key)
+	return int(incrVal), nil
+}
+
+// deletes every key, the watched ones which existed are touched
+func (d *Db) Flush() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	all := d.store.GetAll()
+	keys := make([]string, 0, len(all))
+	for k := range all {
+		keys = append(keys, k)
+	}
+	for _, k := range keys {
+		d.touch(k)
+		d.store.Del(k)
+	}
+	d.dirty += uint64(len(keys))
 }
 
-func (d Db) GetAll() map[string]string {
-	return d.store.GetAll()
+// returns the number of keys which haven't expired yet
+func (d *Db) DbSize() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now, n := d.nowMs(), 0
+	for k := range d.store.GetAll() {
+		if at, ok := d.store.GetExpiry(k); ok && at <= now {
+			continue
+		}
+		n++
+	}
+	return n
+}
+
+// returns a copy of the keys which haven't expired yet
+// the values are copied too, so they can be read once the lock is released
+func (d *Db) GetAll() map[string]store.Value {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.nowMs()
+	data := make(map[string]store.Value)
+	for k, v := range d.store.GetAll() {
+		if at, ok := d.store.GetExpiry(k); ok && at <= now {
+			continue
+		}
+		data[k] = v.Clone()
+	}
+	return data
 }
@@ -3,35 +3,78 @@ package db
 import (
 	"errors"
 	"testing"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store"
 )
 
+// holds a single key, val is its value when it's a string and value otherwise
 type mockStore struct {
-	key string
-	val string
+	key    string
+	val    string
+	value  store.Value
+	expiry int64 // 0 means the key has no timeout
 }
 
-func (m *mockStore) Get(key string) (string, bool) {
-	if m.key == key {
-		return m.val, true
+func (m *mockStore) Get(key string) (store.Value, bool) {
+	if m.key != key {
+		return nil, false
 	}
-	return "", false
+	if m.value != nil {
+		return m.value, true
+	}
+	return store.String(m.val), true
 }
 
-func (m *mockStore) Set(key, val string) {
+func (m *mockStore) Set(key string, val store.Value) {
 	m.key = key
-	m.val = val
+	if str, ok := val.(store.String); ok {
+		m.val, m.value = string(str), nil
+	} else {
+		m.val, m.value = "", val
+	}
 }
 
 func (m *mockStore) Del(key string) {
 	if m.key == key {
 		m.key = ""
 		m.val = ""
+		m.value = nil
+		m.expiry = 0
 	}
 }
 
-func (m *mockStore) GetAll() map[string]string {
-	return map[string]string{
-		m.key: m.val,
+func (m *mockStore) GetExpiry(key string) (int64, bool) {
+	if m.key == key && m.expiry != 0 {
+		return m.expiry, true
+	}
+	return 0, false
+}
+
+func (m *mockStore) SetExpiry(key string, at int64) {
+	if m.key == key {
+		m.expiry = at
+	}
+}
+
+func (m *mockStore) DelExpiry(key string) bool {
+	if m.key == key && m.expiry != 0 {
+		m.expiry = 0
+		return true
+	}
+	return false
+}
+
+func (m *mockStore) SampleExpiringKeys(n int) []string {
+	if m.key != "" && m.expiry != 0 && n > 0 {
+		return []string{m.key}
+	}
+	return []string{}
+}
+
+func (m *mockStore) GetAll() map[string]store.Value {
+	val, _ := m.Get(m.key)
+	return map[string]store.Value{
+		m.key: val,
 	}
 }
 
@@ -124,9 +167,9 @@ func TestDeleteCommand(t *testing.T) {
 	t.Run("when key exists", func(t *testing.T) {
 		key := "foo"
 		val := "bar"
-		expOut := DeleteSuccessMessage
+		expOut := true
 
-		mockStore := &mockStore{key, val}
+		mockStore := &mockStore{key: key, val: val}
 		newDB := &Db{store: mockStore}
 
 		out := newDB.Del(key)
@@ -143,13 +186,13 @@ func TestDeleteCommand(t *testing.T) {
 
 	t.Run("when key doesn't exists", func(t *testing.T) {
 		key := "foo"
-		expOut := DeleteSuccessMessage
+		expOut := false
 
 		mockStore := &mockStore{key: "abc", val: "pqr"}
 		newDB := &Db{store: mockStore}
 		out := newDB.Del(key)
 
-		if out != DeleteFailedMessage {
+		if out != expOut {
 			t.Errorf("Expected the value to be %v instead of %v", expOut, out)
 		}
 	})
@@ -159,7 +202,7 @@ func TestIncrCommand(t *testing.T) {
 	t.Run("when val is integer", func(t *testing.T) {
 		key := "foo"
 		val := "4"
-		expOut := "(integer) 5"
+		expOut := 5
 
 		mockStore := &mockStore{key: key, val: val}
 		newDB := &Db{store: mockStore}
@@ -200,7 +243,7 @@ func TestIncrCommand(t *testing.T) {
 
 	t.Run("when val doesn't exist", func(t *testing.T) {
 		key := "foo"
-		expOut := "(integer) 1"
+		expOut := 1
 		
 		mockStore := &mockStore{}
 		newDB := &Db{store: mockStore}
@@ -225,9 +268,9 @@ func TestIncrByCommand(t *testing.T) {
 		key := "foo"
 		val := "5"
 		incrVal := "20"
-		expOut := "(integer) 25"
+		expOut := 25
 
-		mockStore := &mockStore{key, val}
+		mockStore := &mockStore{key: key, val: val}
 		newDB := &Db{store: mockStore}
 
 		out, err := newDB.Incrby(key, incrVal)
@@ -284,10 +327,37 @@ func TestIncrByCommand(t *testing.T) {
 		t.Fatalf("Unexpected error occured : %v", err)
 	})
 
+	t.Run("when the sum overflows", func(t *testing.T) {
+		testCases := []struct {
+			name    string
+			val     string
+			incrVal string
+		}{
+			{name: "past the max", val: "9223372036854775807", incrVal: "1"},
+			{name: "past the min", val: "-9223372036854775808", incrVal: "-1"},
+			{name: "large increment", val: "10", incrVal: "9223372036854775800"},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				mockStore := &mockStore{key: "foo", val: tc.val}
+				newDB := &Db{store: mockStore}
+
+				_, err := newDB.Incrby("foo", tc.incrVal)
+				if !errors.Is(err, ErrIncrOverflow) {
+					t.Errorf("Expected error %v but got %v", ErrIncrOverflow, err)
+				}
+				if mockStore.val != tc.val {
+					t.Errorf("Expected the value to stay %s but got %s", tc.val, mockStore.val)
+				}
+			})
+		}
+	})
+
 	t.Run("when val doesn't exist", func(t *testing.T) {
 		key := "foo"
 		val := "28"
-		expOut := "(integer) 28"
+		expOut := 28
 
 		mockStore := &mockStore{}
 		newDB := &Db{store: mockStore}
@@ -0,0 +1,161 @@
+package db
+
+import "time"
+
+// conditions under which EXPIRE and friends change the timeout of a key
+// XX can be combined with GT or LT
+type ExpireCond int
+
+const (
+	ExpireNX ExpireCond = 1 << iota // only when the key has no timeout
+	ExpireXX                        // only when the key has a timeout
+	ExpireGT                        // only when the new timeout is greater, no timeout counts as infinite
+	ExpireLT                        // only when the new timeout is lower, no timeout counts as infinite
+
+	ExpireAlways ExpireCond = 0
+)
+
+const (
+	TTLKeyNotFound int64 = -2 // reported by TTL and friends for a missing key
+	TTLNoExpiry    int64 = -1 // reported by TTL and friends for a key without timeout
+
+	ActiveExpireKeysPerLoop  int = 20 // keys with a timeout sampled in every loop of the active expiry cycle
+	ActiveExpireStalePercent int = 25 // sampling goes on while more than this percent of a sample was expired
+)
+
+func (d *Db) nowMs() int64 {
+	if d.now != nil {
+		return d.now()
+	}
+	return time.Now().UnixMilli()
+}
+
+// deletes the key if its timeout has passed, reports whether it did
+// called before every access to a key, so expired keys are never seen
+func (d *Db) expireIfNeeded(key string) bool {
+	at, ok := d.store.GetExpiry(key)
+	if !ok || at > d.nowMs() {
+		return false
+	}
+
+	d.store.Del(key)
+	d.notify(EventExpired, "expired", key)
+	return true
+}
+
+// sets the timeout of the key to the unix time at in ms, if cond allows
+// a timeout in the past deletes the key right away
+// returns true if the timeout was set or the key deleted
+func (d *Db) Expire(key string, at int64, cond ExpireCond) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.expireIfNeeded(key)
+
+	if _, ok := d.store.Get(key); !ok {
+		return false
+	}
+
+	current, hasExpiry := d.store.GetExpiry(key)
+	switch {
+	case cond&ExpireNX != 0 && hasExpiry:
+		return false
+	case cond&ExpireXX != 0 && !hasExpiry:
+		return false
+	case cond&ExpireGT != 0 && (!hasExpiry || at <= current):
+		return false
+	case cond&ExpireLT != 0 && hasExpiry && at >= current:
+		return false
+	}
+
+	if at <= d.nowMs() {
+		d.store.Del(key)
+		d.notify(EventGeneric, "del", key)
+		return true
+	}
+
+	d.store.SetExpiry(key, at)
+	d.notify(EventGeneric, "expire", key)
+	return true
+}
+
+// returns the remaining time to live of the key in ms
+// or TTLKeyNotFound / TTLNoExpiry
+func (d *Db) TTL(key string) int64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	at := d.expireTime(key)
+	if at < 0 {
+		return at
+	}
+	return max(at-d.nowMs(), 0)
+}
+
+// returns the unix time in ms at which the key expires
+// or TTLKeyNotFound / TTLNoExpiry
+func (d *Db) ExpireTime(key string) int64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.expireTime(key)
+}
+
+func (d *Db) expireTime(key string) int64 {
+	d.expireIfNeeded(key)
+
+	if _, ok := d.store.Get(key); !ok {
+		return TTLKeyNotFound
+	}
+
+	at, ok := d.store.GetExpiry(key)
+	if !ok {
+		return TTLNoExpiry
+	}
+	return at
+}
+
+// removes the timeout of the key, returns true if it had one
+func (d *Db) Persist(key string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.expireIfNeeded(key)
+
+	if _, ok := d.store.Get(key); !ok || !d.store.DelExpiry(key) {
+		return false
+	}
+	d.notify(EventGeneric, "persist", key)
+	return true
+}
+
+// deletes expired keys nobody accesses anymore, following the adaptive algorithm of redis:
+// random keys with a timeout are sampled and the expired ones deleted, this goes on
+// while the sample had a good share of expired keys, ie. there are likely many more of them
+// the cycle stops once budget is spent, returns the number of deleted keys
+func (d *Db) ActiveExpireCycle(budget time.Duration) int {
+	deadline := time.Now().Add(budget)
+	deleted := 0
+
+	for {
+		sample := d.store.SampleExpiringKeys(ActiveExpireKeysPerLoop)
+		if len(sample) == 0 {
+			return deleted
+		}
+
+		// the lock is taken per sample so clients aren't stalled for the whole cycle
+		expired := 0
+		d.mu.Lock()
+		for _, key := range sample {
+			if d.expireIfNeeded(key) {
+				expired++
+			}
+		}
+		d.mu.Unlock()
+		deleted += expired
+
+		if expired*100 <= len(sample)*ActiveExpireStalePercent || time.Now().After(deadline) {
+			return deleted
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package db
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store"
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store/inMemoryStore"
+)
+
+const testNow int64 = 1_700_000_000_000
+
+func GetTestDBWithClock(m *mockStore) *Db {
+	return &Db{store: m, now: func() int64 { return testNow }}
+}
+
+func TestExpire(t *testing.T) {
+	testCases := []struct {
+		name      string
+		expiry    int64 // current timeout of the key, 0 for none
+		at        int64
+		cond      ExpireCond
+		expOut    bool
+		expExpiry int64
+		isDeleted bool
+	}{
+		{"set timeout", 0, testNow + 1000, ExpireAlways, true, testNow + 1000, false},
+		{"replace timeout", testNow + 5000, testNow + 1000, ExpireAlways, true, testNow + 1000, false},
+		{"timeout in the past deletes the key", 0, testNow - 1, ExpireAlways, true, 0, true},
+		{"NX without timeout", 0, testNow + 1000, ExpireNX, true, testNow + 1000, false},
+		{"NX with timeout", testNow + 5000, testNow + 1000, ExpireNX, false, testNow + 5000, false},
+		{"XX without timeout", 0, testNow + 1000, ExpireXX, false, 0, false},
+		{"XX with timeout", testNow + 5000, testNow + 1000, ExpireXX, true, testNow + 1000, false},
+		{"GT with greater timeout", testNow + 5000, testNow + 9000, ExpireGT, true, testNow + 9000, false},
+		{"GT with lower timeout", testNow + 5000, testNow + 1000, ExpireGT, false, testNow + 5000, false},
+		{"GT without timeout", 0, testNow + 1000, ExpireGT, false, 0, false},
+		{"LT with lower timeout", testNow + 5000, testNow + 1000, ExpireLT, true, testNow + 1000, false},
+		{"LT with greater timeout", testNow + 5000, testNow + 9000, ExpireLT, false, testNow + 5000, false},
+		{"LT without timeout", 0, testNow + 1000, ExpireLT, true, testNow + 1000, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockStore := &mockStore{key: "foo", val: "bar", expiry: tc.expiry}
+			newDB := GetTestDBWithClock(mockStore)
+
+			out := newDB.Expire("foo", tc.at, tc.cond)
+			if out != tc.expOut {
+				t.Errorf("Expected %v but got %v", tc.expOut, out)
+			}
+
+			if tc.isDeleted {
+				if mockStore.key != "" {
+					t.Errorf("Expected the key to be deleted")
+				}
+				return
+			}
+			if mockStore.expiry != tc.expExpiry {
+				t.Errorf("Expected timeout %d but got %d", tc.expExpiry, mockStore.expiry)
+			}
+		})
+	}
+
+	t.Run("missing key", func(t *testing.T) {
+		newDB := GetTestDBWithClock(&mockStore{})
+
+		if newDB.Expire("foo", testNow+1000, ExpireAlways) {
+			t.Errorf("Expected timeout not to be set on a missing key")
+		}
+	})
+}
+
+func TestTTL(t *testing.T) {
+	testCases := []struct {
+		name          string
+		store         *mockStore
+		expTTL        int64
+		expExpireTime int64
+	}{
+		{"missing key", &mockStore{}, TTLKeyNotFound, TTLKeyNotFound},
+		{"key without timeout", &mockStore{key: "foo", val: "bar"}, TTLNoExpiry, TTLNoExpiry},
+		{"key with timeout", &mockStore{key: "foo", val: "bar", expiry: testNow + 1500}, 1500, testNow + 1500},
+		{"expired key", &mockStore{key: "foo", val: "bar", expiry: testNow}, TTLKeyNotFound, TTLKeyNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			newDB := GetTestDBWithClock(tc.store)
+
+			if ttl := newDB.TTL("foo"); ttl != tc.expTTL {
+				t.Errorf("Expected TTL %d but got %d", tc.expTTL, ttl)
+			}
+			if at := newDB.ExpireTime("foo"); at != tc.expExpireTime {
+				t.Errorf("Expected expire time %d but got %d", tc.expExpireTime, at)
+			}
+		})
+	}
+}
+
+func TestPersist(t *testing.T) {
+	testCases := []struct {
+		name   string
+		store  *mockStore
+		expOut bool
+	}{
+		{"key with timeout", &mockStore{key: "foo", val: "bar", expiry: testNow + 1000}, true},
+		{"key without timeout", &mockStore{key: "foo", val: "bar"}, false},
+		{"missing key", &mockStore{}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			newDB := GetTestDBWithClock(tc.store)
+
+			if out := newDB.Persist("foo"); out != tc.expOut {
+				t.Errorf("Expected %v but got %v", tc.expOut, out)
+			}
+			if tc.store.expiry != 0 {
+				t.Errorf("Expected the timeout to be removed but got %d", tc.store.expiry)
+			}
+		})
+	}
+}
+
+func TestLazyExpiry(t *testing.T) {
+	t.Run("GET on expired key", func(t *testing.T) {
+		mockStore := &mockStore{key: "foo", val: "bar", expiry: testNow - 1}
+		newDB := GetTestDBWithClock(mockStore)
+
+		_, err := newDB.Get("foo")
+		if !errors.Is(err, ErrKeyNotFound) {
+			t.Errorf("Expected %v but got %v", ErrKeyNotFound, err)
+		}
+		if mockStore.key != "" {
+			t.Errorf("Expected the expired key to be deleted on access")
+		}
+	})
+
+	t.Run("DEL on expired key", func(t *testing.T) {
+		newDB := GetTestDBWithClock(&mockStore{key: "foo", val: "bar", expiry: testNow - 1})
+
+		if newDB.Del("foo") {
+			t.Errorf("Expected DEL to report the expired key as missing")
+		}
+	})
+
+	t.Run("INCR on expired key starts over", func(t *testing.T) {
+		newDB := GetTestDBWithClock(&mockStore{key: "foo", val: "41", expiry: testNow - 1})
+
+		out, err := newDB.Incr("foo")
+		if err != nil {
+			t.Fatalf("Unexpected error occured: %v", err)
+		}
+		if out != 1 {
+			t.Errorf("Expected %d but got %d", 1, out)
+		}
+	})
+
+	t.Run("INCR retains the timeout", func(t *testing.T) {
+		mockStore := &mockStore{key: "foo", val: "41", expiry: testNow + 1000}
+		newDB := GetTestDBWithClock(mockStore)
+
+		if _, err := newDB.Incr("foo"); err != nil {
+			t.Fatalf("Unexpected error occured: %v", err)
+		}
+		if mockStore.expiry != testNow+1000 {
+			t.Errorf("Expected timeout %d to be retained but got %d", testNow+1000, mockStore.expiry)
+		}
+	})
+
+	t.Run("SET clears the timeout", func(t *testing.T) {
+		mockStore := &mockStore{key: "foo", val: "bar", expiry: testNow + 1000}
+		newDB := GetTestDBWithClock(mockStore)
+
+		newDB.Set("foo", "baz")
+		if mockStore.expiry != 0 {
+			t.Errorf("Expected the timeout to be cleared but got %d", mockStore.expiry)
+		}
+	})
+
+	t.Run("SET with KEEPTTL retains the timeout", func(t *testing.T) {
+		mockStore := &mockStore{key: "foo", val: "bar", expiry: testNow + 1000}
+		newDB := GetTestDBWithClock(mockStore)
+
+		newDB.SetWithOptions("foo", "baz", SetOptions{KeepTTL: true})
+		if mockStore.val != "baz" || mockStore.expiry != testNow+1000 {
+			t.Errorf("Expected value %q with timeout %d but got %q with %d", "baz", testNow+1000, mockStore.val, mockStore.expiry)
+		}
+	})
+
+	t.Run("GetAll skips expired keys", func(t *testing.T) {
+		newDB := GetTestDBWithClock(&mockStore{key: "foo", val: "bar", expiry: testNow - 1})
+
+		if data := newDB.GetAll(); len(data) != 0 {
+			t.Errorf("Expected no keys but got %v", data)
+		}
+	})
+}
+
+func TestActiveExpireCycle(t *testing.T) {
+	testCases := []struct {
+		name      string
+		store     *mockStore
+		expOut    int
+		isDeleted bool
+	}{
+		{"expired key", &mockStore{key: "foo", val: "bar", expiry: testNow - 1}, 1, true},
+		{"key with timeout in the future", &mockStore{key: "foo", val: "bar", expiry: testNow + 1000}, 0, false},
+		{"key without timeout", &mockStore{key: "foo", val: "bar"}, 0, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			newDB := GetTestDBWithClock(tc.store)
+
+			if out := newDB.ActiveExpireCycle(time.Millisecond); out != tc.expOut {
+				t.Errorf("Expected %d keys to be expired but got %d", tc.expOut, out)
+			}
+			if isDeleted := tc.store.key == ""; isDeleted != tc.isDeleted {
+				t.Errorf("Expected the key to be deleted to be %v but got %v", tc.isDeleted, isDeleted)
+			}
+		})
+	}
+
+	t.Run("keeps going while most of the sample is expired", func(t *testing.T) {
+		memStore := inMemoryStore.NewInMemoryStore()
+		newDB := &Db{store: memStore, now: func() int64 { return testNow }}
+		for i := range 100 {
+			key := strconv.Itoa(i)
+			memStore.Set(key, store.String("val"))
+			memStore.SetExpiry(key, testNow-1)
+		}
+
+		if out := newDB.ActiveExpireCycle(time.Second); out != 100 {
+			t.Errorf("Expected %d keys to be expired but got %d", 100, out)
+		}
+		if n := len(memStore.GetAll()); n != 0 {
+			t.Errorf("Expected no keys to be left but got %d", n)
+		}
+	})
+
+	t.Run("stops after one sample once the budget is spent", func(t *testing.T) {
+		memStore := inMemoryStore.NewInMemoryStore()
+		newDB := &Db{store: memStore, now: func() int64 { return testNow }}
+		for i := range 100 {
+			key := strconv.Itoa(i)
+			memStore.Set(key, store.String("val"))
+			memStore.SetExpiry(key, testNow-1)
+		}
+
+		if out := newDB.ActiveExpireCycle(0); out != ActiveExpireKeysPerLoop {
+			t.Errorf("Expected %d keys to be expired but got %d", ActiveExpireKeysPerLoop, out)
+		}
+	})
+}
@@ -0,0 +1,295 @@
+package db
+
+import (
+	"errors"
+	"maps"
+	"math"
+	"math/rand/v2"
+	"strconv"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store"
+)
+
+var ErrHashValueNotInteger = errors.New("hash value is not an integer")
+var ErrHashValueNotFloat = errors.New("hash value is not a float")
+var ErrIncrOverflow = errors.New("increment or decrement would overflow")
+var ErrIncrNaN = errors.New("increment would produce NaN or Infinity")
+
+// returns the hash of the key, nil if the key is missing
+// or ErrWrongType if it holds another type
+func (d *Db) getHash(key string) (store.Hash, error) {
+	d.expireIfNeeded(key)
+
+	val, ok := d.store.Get(key)
+	if !ok {
+		return nil, nil
+	}
+
+	h, ok := val.(store.Hash)
+	if !ok {
+		return nil, ErrWrongType
+	}
+	return h, nil
+}
+
+// returns the hash of the key, creating it if the key is missing
+func (d *Db) getOrCreateHash(key string) (store.Hash, error) {
+	h, err := d.getHash(key)
+	if err != nil || h != nil {
+		return h, err
+	}
+
+	h = store.Hash{}
+	d.store.Set(key, h)
+	return h, nil
+}
+
+// sets the fields to the values, pairs holds a field followed by its value
+// returns the number of fields which were added
+func (d *Db) HSet(key string, pairs ...string) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	h, err := d.getOrCreateHash(key)
+	if err != nil {
+		return 0, err
+	}
+
+	added := 0
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if _, ok := h[pairs[i]]; !ok {
+			added++
+		}
+		h[pairs[i]] = pairs[i+1]
+	}
+	d.notify(EventHash, "hset", key)
+	return added, nil
+}
+
+// sets the field only if it doesn't exist yet, returns true if it did set it
+func (d *Db) HSetNX(key, field, val string) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	h, err := d.getOrCreateHash(key)
+	if err != nil {
+		return false, err
+	}
+
+	if _, ok := h[field]; ok {
+		return false, nil
+	}
+	h[field] = val
+	d.notify(EventHash, "hset", key)
+	return true, nil
+}
+
+func (d *Db) HGet(key, field string) (string, bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	h, err := d.getHash(key)
+	if err != nil {
+		return "", false, err
+	}
+
+	val, ok := h[field]
+	return val, ok, nil
+}
+
+// returns the values of the fields, and whether each of them exists
+func (d *Db) HMGet(key string, fields ...string) ([]string, []bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	h, err := d.getHash(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	vals := make([]string, len(fields))
+	found := make([]bool, len(fields))
+	for i, f := range fields {
+		vals[i], found[i] = h[f]
+	}
+	return vals, found, nil
+}
+
+// deletes the fields, and the key along with the last field
+// returns the number of deleted fields
+func (d *Db) HDel(key string, fields ...string) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	h, err := d.getHash(key)
+	if err != nil || h == nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for _, f := range fields {
+		if _, ok := h[f]; ok {
+			delete(h, f)
+			deleted++
+		}
+	}
+
+	if deleted > 0 {
+		d.notify(EventHash, "hdel", key)
+	}
+	if len(h) == 0 {
+		d.store.Del(key)
+		d.notify(EventGeneric, "del", key)
+	}
+	return deleted, nil
+}
+
+func (d *Db) HLen(key string) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	h, err := d.getHash(key)
+	return len(h), err
+}
+
+func (d *Db) HExists(key, field string) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	h, err := d.getHash(key)
+	if err != nil {
+		return false, err
+	}
+
+	_, ok := h[field]
+	return ok, nil
+}
+
+// returns a copy of the hash, empty if the key is missing
+func (d *Db) HGetAll(key string) (map[string]string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	h, err := d.getHash(key)
+	if err != nil {
+		return nil, err
+	}
+	return maps.Clone(map[string]string(h)), nil
+}
+
+// increments the integer value of the field, a missing field counts as 0
+func (d *Db) HIncrBy(key, field string, incr int64) (int64, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	h, err := d.getOrCreateHash(key)
+	if err != nil {
+		return 0, err
+	}
+
+	var cur int64
+	if val, ok := h[field]; ok {
+		cur, err = strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			return 0, ErrHashValueNotInteger
+		}
+	}
+
+	if (incr > 0 && cur > math.MaxInt64-incr) || (incr < 0 && cur < math.MinInt64-incr) {
+		return 0, ErrIncrOverflow
+	}
+
+	cur += incr
+	h[field] = strconv.FormatInt(cur, 10)
+	d.notify(EventHash, "hincrby", key)
+	return cur, nil
+}
+
+// increments the float value of the field, a missing field counts as 0
+// returns the new value as stored in the field
+func (d *Db) HIncrByFloat(key, field string, incr float64) (string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	h, err := d.getOrCreateHash(key)
+	if err != nil {
+		return "", err
+	}
+
+	var cur float64
+	if val, ok := h[field]; ok {
+		cur, err = strconv.ParseFloat(val, 64)
+		if err != nil || math.IsNaN(cur) || math.IsInf(cur, 0) {
+			return "", ErrHashValueNotFloat
+		}
+	}
+
+	cur += incr
+	if math.IsNaN(cur) || math.IsInf(cur, 0) {
+		return "", ErrIncrNaN
+	}
+
+	// like redis, the value is stored without exponent
+	h[field] = strconv.FormatFloat(cur, 'f', -1, 64)
+	d.notify(EventHash, "hincrbyfloat", key)
+	return h[field], nil
+}
+
+// returns random fields with their values
+// a positive count returns distinct fields, as many as there are at most
+// a negative count returns exactly -count fields which may repeat
+func (d *Db) HRandField(key string, count int) ([]string, []string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	h, err := d.getHash(key)
+	if err != nil || h == nil {
+		return nil, nil, err
+	}
+
+	fields := hashFields(h)
+	if count < 0 {
+		count = -count
+		out := make([]string, count)
+		for i := range out {
+			out[i] = fields[rand.IntN(len(fields))]
+		}
+		fields = out
+	} else {
+		rand.Shuffle(len(fields), func(i, j int) { fields[i], fields[j] = fields[j], fields[i] })
+		fields = fields[:min(count, len(fields))]
+	}
+
+	vals := make([]string, len(fields))
+	for i, f := range fields {
+		vals[i] = h[f]
+	}
+	return fields, vals, nil
+}
+
+// returns about count fields of the hash with their values, starting at cursor
+// returns the cursor to continue from, 0 once the whole hash was scanned
+func (d *Db) HScan(key string, cursor uint64, count int) (uint64, []string, []string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	h, err := d.getHash(key)
+	if err != nil || h == nil {
+		return 0, nil, nil, err
+	}
+
+	next, fields := scanFields(hashFields(h), cursor, count)
+	vals := make([]string, len(fields))
+	for i, f := range fields {
+		vals[i] = h[f]
+	}
+	return next, fields, vals, nil
+}
+
+func hashFields(h store.Hash) []string {
+	fields := make([]string, 0, len(h))
+	for f := range h {
+		fields = append(fields, f)
+	}
+	return fields
+}
@@ -0,0 +1,210 @@
+package db
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store"
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store/inMemoryStore"
+)
+
+// db with the hash "hash" holding pairs of fields and values
+func GetTestDBWithHash(pairs ...string) *Db {
+	s := inMemoryStore.NewInMemoryStore()
+	h := store.Hash{}
+	for i := 0; i+1 < len(pairs); i += 2 {
+		h[pairs[i]] = pairs[i+1]
+	}
+	if len(h) > 0 {
+		s.Set("hash", h)
+	}
+	s.Set("str", store.String("bar"))
+	return &Db{store: s}
+}
+
+func TestHSetHGet(t *testing.T) {
+	newDB := GetTestDBWithHash()
+
+	if n, err := newDB.HSet("hash", "name", "ann", "age", "30"); err != nil || n != 2 {
+		t.Fatalf("Expected %d new fields but got %d, %v", 2, n, err)
+	}
+	if n, _ := newDB.HSet("hash", "name", "bob", "city", "pune"); n != 1 {
+		t.Errorf("Expected %d new fields but got %d", 1, n)
+	}
+	if v, ok, _ := newDB.HGet("hash", "name"); !ok || v != "bob" {
+		t.Errorf("Expected %q but got %q", "bob", v)
+	}
+	if _, ok, _ := newDB.HGet("hash", "missing"); ok {
+		t.Errorf("Expected the field to be missing")
+	}
+
+	vals, found, _ := newDB.HMGet("hash", "age", "missing")
+	if !slices.Equal(vals, []string{"30", ""}) || !slices.Equal(found, []bool{true, false}) {
+		t.Errorf("Expected %v, %v but got %v, %v", []string{"30", ""}, []bool{true, false}, vals, found)
+	}
+}
+
+func TestHSetNX(t *testing.T) {
+	newDB := GetTestDBWithHash("name", "ann")
+
+	if ok, _ := newDB.HSetNX("hash", "name", "bob"); ok {
+		t.Errorf("Expected the existing field not to be set")
+	}
+	if ok, _ := newDB.HSetNX("hash", "age", "30"); !ok {
+		t.Errorf("Expected the new field to be set")
+	}
+	if v, _, _ := newDB.HGet("hash", "name"); v != "ann" {
+		t.Errorf("Expected %q but got %q", "ann", v)
+	}
+}
+
+func TestHDel(t *testing.T) {
+	newDB := GetTestDBWithHash("a", "1", "b", "2")
+
+	if n, _ := newDB.HDel("hash", "a", "missing"); n != 1 {
+		t.Errorf("Expected %d deleted fields but got %d", 1, n)
+	}
+	if n, _ := newDB.HLen("hash"); n != 1 {
+		t.Errorf("Expected length %d but got %d", 1, n)
+	}
+	newDB.HDel("hash", "b")
+	if typ := newDB.Type("hash"); typ != "none" {
+		t.Errorf("Expected the key to be deleted along with its last field but its type is %s", typ)
+	}
+}
+
+func TestHIncrBy(t *testing.T) {
+	testCases := []struct {
+		name   string
+		pairs  []string
+		incr   int64
+		expOut int64
+		expErr error
+	}{
+		{"missing field", nil, 5, 5, nil},
+		{"existing field", []string{"f", "10"}, -3, 7, nil},
+		{"not an integer", []string{"f", "ten"}, 1, 0, ErrHashValueNotInteger},
+		{"overflow", []string{"f", "9223372036854775807"}, 1, 0, ErrIncrOverflow},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			newDB := GetTestDBWithHash(tc.pairs...)
+
+			out, err := newDB.HIncrBy("hash", "f", tc.incr)
+			if !errors.Is(err, tc.expErr) {
+				t.Fatalf("Expected error %v but got %v", tc.expErr, err)
+			}
+			if out != tc.expOut {
+				t.Errorf("Expected %d but got %d", tc.expOut, out)
+			}
+		})
+	}
+}
+
+func TestHIncrByFloat(t *testing.T) {
+	testCases := []struct {
+		name   string
+		pairs  []string
+		incr   float64
+		expOut string
+		expErr error
+	}{
+		{"missing field", nil, 1.5, "1.5", nil},
+		{"existing field", []string{"f", "10.5"}, 0.1, "10.6", nil},
+		{"integral result", []string{"f", "5.0e3"}, 200, "5200", nil},
+		{"not a float", []string{"f", "ten"}, 1, "", ErrHashValueNotFloat},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			newDB := GetTestDBWithHash(tc.pairs...)
+
+			out, err := newDB.HIncrByFloat("hash", "f", tc.incr)
+			if !errors.Is(err, tc.expErr) {
+				t.Fatalf("Expected error %v but got %v", tc.expErr, err)
+			}
+			if out != tc.expOut {
+				t.Errorf("Expected %q but got %q", tc.expOut, out)
+			}
+		})
+	}
+}
+
+func TestHRandField(t *testing.T) {
+	newDB := GetTestDBWithHash("a", "1", "b", "2", "c", "3")
+
+	fields, vals, _ := newDB.HRandField("hash", 5)
+	slices.Sort(fields)
+	if !slices.Equal(fields, []string{"a", "b", "c"}) || len(vals) != 3 {
+		t.Errorf("Expected every field once but got %v", fields)
+	}
+
+	fields, vals, _ = newDB.HRandField("hash", -10)
+	if len(fields) != 10 || len(vals) != 10 {
+		t.Errorf("Expected %d fields but got %d", 10, len(fields))
+	}
+	for i, f := range fields {
+		if v, _, _ := newDB.HGet("hash", f); v != vals[i] {
+			t.Errorf("Expected the value %q of the field %q but got %q", v, f, vals[i])
+		}
+	}
+
+	if fields, _, _ := newDB.HRandField("missing", 1); fields != nil {
+		t.Errorf("Expected no fields but got %v", fields)
+	}
+}
+
+func TestHScan(t *testing.T) {
+	var pairs []string
+	for i := range 100 {
+		pairs = append(pairs, string(rune('a'+i%26))+string(rune('a'+i/26)), "v")
+	}
+	newDB := GetTestDBWithHash(pairs...)
+
+	// every field is returned once, even when fields are added and removed in between
+	seen := map[string]int{}
+	cursor, rounds := uint64(0), 0
+	for {
+		next, fields, _, err := newDB.HScan("hash", cursor, 10)
+		if err != nil {
+			t.Fatalf("Unexpected error occured: %v", err)
+		}
+		for _, f := range fields {
+			seen[f]++
+		}
+
+		newDB.HSet("hash", "new"+string(rune('a'+rounds)), "v")
+		rounds++
+		if cursor = next; cursor == 0 {
+			break
+		}
+	}
+
+	for i := 0; i < len(pairs); i += 2 {
+		if seen[pairs[i]] != 1 {
+			t.Errorf("Expected the field %q to be returned once but got %d", pairs[i], seen[pairs[i]])
+		}
+	}
+	if rounds < 10 {
+		t.Errorf("Expected at least %d rounds but got %d", 10, rounds)
+	}
+}
+
+func TestHashWrongType(t *testing.T) {
+	newDB := GetTestDBWithHash("a", "1")
+
+	if _, err := newDB.HSet("str", "a", "1"); !errors.Is(err, ErrWrongType) {
+		t.Errorf("Expected %v but got %v", ErrWrongType, err)
+	}
+	if _, _, err := newDB.HGet("str", "a"); !errors.Is(err, ErrWrongType) {
+		t.Errorf("Expected %v but got %v", ErrWrongType, err)
+	}
+	if _, err := newDB.Get("hash"); !errors.Is(err, ErrWrongType) {
+		t.Errorf("Expected %v but got %v", ErrWrongType, err)
+	}
+	if _, err := newDB.Push("hash", ListHead, "a"); !errors.Is(err, ErrWrongType) {
+		t.Errorf("Expected %v but got %v", ErrWrongType, err)
+	}
+}
@@ -0,0 +1,382 @@
+package db
+
+import (
+	"errors"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store"
+)
+
+var ErrNoSuchKey = errors.New("no such key")
+var ErrIndexOutOfRange = errors.New("index out of range")
+
+// end of a list to push to or pop from
+type ListEnd int
+
+const (
+	ListHead ListEnd = iota
+	ListTail
+)
+
+// where LINSERT puts the new item relative to the pivot
+type ListInsertPos int
+
+const (
+	ListBefore ListInsertPos = iota
+	ListAfter
+)
+
+// returns the list of the key, nil if the key is missing
+// or ErrWrongType if it holds another type
+func (d *Db) getList(key string) (*store.List, error) {
+	d.expireIfNeeded(key)
+
+	val, ok := d.store.Get(key)
+	if !ok {
+		return nil, nil
+	}
+
+	l, ok := val.(*store.List)
+	if !ok {
+		return nil, ErrWrongType
+	}
+	return l, nil
+}
+
+// lists are never empty, the key is gone with the last item
+func (d *Db) delIfEmpty(key string, l *store.List) {
+	if l.Len() == 0 {
+		d.store.Del(key)
+		d.notify(EventGeneric, "del", key)
+	}
+}
+
+// pushes vals one by one at the end of the list, creating it if needed
+// returns the length of the list
+func (d *Db) Push(key string, end ListEnd, vals ...string) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	l, err := d.getList(key)
+	if err != nil {
+		return 0, err
+	}
+	if l == nil {
+		l = store.NewList()
+		d.store.Set(key, l)
+	}
+
+	for _, v := range vals {
+		if end == ListHead {
+			l.PushFront(v)
+		} else {
+			l.PushBack(v)
+		}
+	}
+	d.notify(EventList, listEvent(end, "push"), key)
+	d.signalReady(key)
+	return l.Len(), nil
+}
+
+// pops up to count items from the end of the list
+// returns nil if the key is missing
+func (d *Db) Pop(key string, end ListEnd, count int) ([]string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	l, err := d.getList(key)
+	if err != nil || l == nil {
+		return nil, err
+	}
+
+	out := make([]string, 0, min(count, l.Len()))
+	for len(out) < count && l.Len() > 0 {
+		if end == ListHead {
+			out = append(out, l.PopFront())
+		} else {
+			out = append(out, l.PopBack())
+		}
+	}
+
+	if len(out) > 0 {
+		d.notify(EventList, listEvent(end, "pop"), key)
+	}
+	d.delIfEmpty(key, l)
+	return out, nil
+}
+
+// returns the length of the list, 0 if the key is missing
+func (d *Db) LLen(key string) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	l, err := d.getList(key)
+	if err != nil || l == nil {
+		return 0, err
+	}
+	return l.Len(), nil
+}
+
+// returns the items between start and stop, both inclusive
+// negative indexes count from the end of the list, -1 being the last item
+func (d *Db) LRange(key string, start, stop int) ([]string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	l, err := d.getList(key)
+	if err != nil || l == nil {
+		return []string{}, err
+	}
+
+	start, stop, ok := listRange(start, stop, l.Len())
+	if !ok {
+		return []string{}, nil
+	}
+	return l.Range(start, stop), nil
+}
+
+// returns the item at index, false if there is none
+func (d *Db) LIndex(key string, index int) (string, bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	l, err := d.getList(key)
+	if err != nil || l == nil {
+		return "", false, err
+	}
+
+	index, ok := listIndex(index, l.Len())
+	if !ok {
+		return "", false, nil
+	}
+	return l.Index(index), true, nil
+}
+
+// replaces the item at index
+func (d *Db) LSet(key string, index int, val string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	l, err := d.getList(key)
+	if err != nil {
+		return err
+	}
+	if l == nil {
+		return ErrNoSuchKey
+	}
+
+	index, ok := listIndex(index, l.Len())
+	if !ok {
+		return ErrIndexOutOfRange
+	}
+	l.SetIndex(index, val)
+	d.notify(EventList, "lset", key)
+	return nil
+}
+
+// removes the first count items equal to val, starting from the tail when count is negative
+// count 0 removes all of them, returns the number of removed items
+func (d *Db) LRem(key string, count int, val string) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	l, err := d.getList(key)
+	if err != nil || l == nil {
+		return 0, err
+	}
+
+	items := l.Values()
+	keep := make([]bool, len(items))
+	removed := 0
+	for i := range items {
+		// walks backwards for a negative count
+		j := i
+		if count < 0 {
+			j = len(items) - 1 - i
+		}
+
+		keep[j] = items[j] != val || (count != 0 && removed == abs(count))
+		if !keep[j] {
+			removed++
+		}
+	}
+	if removed == 0 {
+		return 0, nil
+	}
+
+	kept := make([]string, 0, len(items)-removed)
+	for i, v := range items {
+		if keep[i] {
+			kept = append(kept, v)
+		}
+	}
+	*l = *store.NewList(kept...)
+
+	d.notify(EventList, "lrem", key)
+	d.delIfEmpty(key, l)
+	return removed, nil
+}
+
+// keeps only the items between start and stop, both inclusive
+func (d *Db) LTrim(key string, start, stop int) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	l, err := d.getList(key)
+	if err != nil || l == nil {
+		return err
+	}
+
+	start, stop, ok := listRange(start, stop, l.Len())
+	if !ok {
+		d.store.Del(key)
+		d.notify(EventList, "ltrim", key)
+		d.notify(EventGeneric, "del", key)
+		return nil
+	}
+
+	for range l.Len() - 1 - stop {
+		l.PopBack()
+	}
+	for range start {
+		l.PopFront()
+	}
+	d.notify(EventList, "ltrim", key)
+	return nil
+}
+
+// inserts val before or after the first item equal to pivot
+// returns the length of the list, -1 if the pivot wasn't found or 0 if the key is missing
+func (d *Db) LInsert(key string, pos ListInsertPos, pivot, val string) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	l, err := d.getList(key)
+	if err != nil || l == nil {
+		return 0, err
+	}
+
+	for i := range l.Len() {
+		if l.Index(i) != pivot {
+			continue
+		}
+
+		if pos == ListAfter {
+			i++
+		}
+		l.Insert(i, val)
+		d.notify(EventList, "linsert", key)
+		return l.Len(), nil
+	}
+	return -1, nil
+}
+
+// options of LPOS
+type LPosOptions struct {
+	Rank   int // skips the first rank-1 matches, a negative rank searches from the tail
+	Count  int // number of matches wanted, 0 for all of them
+	MaxLen int // number of items compared at most, 0 for the whole list
+}
+
+// returns the indexes of the items equal to val
+func (d *Db) LPos(key, val string, opts LPosOptions) ([]int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	l, err := d.getList(key)
+	if err != nil || l == nil {
+		return []int{}, err
+	}
+
+	rank, n := abs(opts.Rank), l.Len()
+	if opts.MaxLen > 0 {
+		n = min(n, opts.MaxLen)
+	}
+
+	out := []int{}
+	for i := 0; i < n && (opts.Count == 0 || len(out) < opts.Count); i++ {
+		idx := i
+		if opts.Rank < 0 {
+			idx = l.Len() - 1 - i
+		}
+		if l.Index(idx) != val {
+			continue
+		}
+
+		if rank > 1 {
+			rank--
+			continue
+		}
+		out = append(out, idx)
+	}
+	return out, nil
+}
+
+// pops an item from one end of src and pushes it to one end of dst, as a single step
+// src and dst can be the same list, returns false if src is missing
+func (d *Db) LMove(src, dst string, from, to ListEnd) (string, bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	srcList, err := d.getList(src)
+	if err != nil || srcList == nil {
+		return "", false, err
+	}
+
+	// dst is checked before anything is popped
+	dstList, err := d.getList(dst)
+	if err != nil {
+		return "", false, err
+	}
+
+	var val string
+	if from == ListHead {
+		val = srcList.PopFront()
+	} else {
+		val = srcList.PopBack()
+	}
+
+	if dstList == nil {
+		dstList = store.NewList()
+		d.store.Set(dst, dstList)
+	}
+	if to == ListHead {
+		dstList.PushFront(val)
+	} else {
+		dstList.PushBack(val)
+	}
+
+	d.notify(EventList, listEvent(from, "pop"), src)
+	d.notify(EventList, listEvent(to, "push"), dst)
+	d.delIfEmpty(src, srcList)
+	d.signalReady(dst)
+	return val, true, nil
+}
+
+// turns a possibly negative index into a position in a list of length n
+func listIndex(i, n int) (int, bool) {
+	if i < 0 {
+		i += n
+	}
+	return i, i >= 0 && i < n
+}
+
+// clamps the range [start, stop] to a list of length n, like LRANGE does
+// returns false if nothing is left of it
+func listRange(start, stop, n int) (int, int, bool) {
+	if start < 0 {
+		start = max(start+n, 0)
+	}
+	if stop < 0 {
+		stop += n
+	}
+	stop = min(stop, n-1)
+
+	return start, stop, start <= stop && start < n
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
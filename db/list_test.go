@@ -0,0 +1,306 @@
+package db
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store"
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store/inMemoryStore"
+)
+
+// db with the list "list" holding items
+func GetTestDBWithList(items ...string) *Db {
+	s := inMemoryStore.NewInMemoryStore()
+	s.Set("list", store.NewList(items...))
+	s.Set("str", store.String("bar"))
+	return &Db{store: s}
+}
+
+func listValues(t *testing.T, d *Db) []string {
+	t.Helper()
+	out, err := d.LRange("list", 0, -1)
+	if err != nil {
+		t.Fatalf("Unexpected error occured: %v", err)
+	}
+	return out
+}
+
+func TestPushPop(t *testing.T) {
+	t.Run("push at both ends", func(t *testing.T) {
+		newDB := GetTestDBWithList()
+		newDB.store.Del("list")
+
+		if n, _ := newDB.Push("list", ListHead, "b", "a"); n != 2 {
+			t.Errorf("Expected length %d but got %d", 2, n)
+		}
+		if n, _ := newDB.Push("list", ListTail, "c"); n != 3 {
+			t.Errorf("Expected length %d but got %d", 3, n)
+		}
+		if out := listValues(t, newDB); !slices.Equal(out, []string{"a", "b", "c"}) {
+			t.Errorf("Expected %v but got %v", []string{"a", "b", "c"}, out)
+		}
+	})
+
+	t.Run("pop at both ends", func(t *testing.T) {
+		newDB := GetTestDBWithList("a", "b", "c", "d")
+
+		if out, _ := newDB.Pop("list", ListHead, 1); !slices.Equal(out, []string{"a"}) {
+			t.Errorf("Expected %v but got %v", []string{"a"}, out)
+		}
+		if out, _ := newDB.Pop("list", ListTail, 2); !slices.Equal(out, []string{"d", "c"}) {
+			t.Errorf("Expected %v but got %v", []string{"d", "c"}, out)
+		}
+	})
+
+	t.Run("popping the last item deletes the key", func(t *testing.T) {
+		newDB := GetTestDBWithList("a")
+
+		if out, _ := newDB.Pop("list", ListHead, 5); !slices.Equal(out, []string{"a"}) {
+			t.Errorf("Expected %v but got %v", []string{"a"}, out)
+		}
+		if typ := newDB.Type("list"); typ != "none" {
+			t.Errorf("Expected the key to be deleted but its type is %s", typ)
+		}
+	})
+
+	t.Run("pop on missing key", func(t *testing.T) {
+		newDB := GetTestDBWithList()
+
+		if out, err := newDB.Pop("missing", ListHead, 1); out != nil || err != nil {
+			t.Errorf("Expected nothing but got %v, %v", out, err)
+		}
+	})
+
+	t.Run("wrong type", func(t *testing.T) {
+		newDB := GetTestDBWithList("a")
+
+		if _, err := newDB.Push("str", ListHead, "a"); !errors.Is(err, ErrWrongType) {
+			t.Errorf("Expected %v but got %v", ErrWrongType, err)
+		}
+		if _, err := newDB.Pop("str", ListHead, 1); !errors.Is(err, ErrWrongType) {
+			t.Errorf("Expected %v but got %v", ErrWrongType, err)
+		}
+		if _, err := newDB.Get("list"); !errors.Is(err, ErrWrongType) {
+			t.Errorf("Expected %v but got %v", ErrWrongType, err)
+		}
+		if _, err := newDB.Incr("list"); !errors.Is(err, ErrWrongType) {
+			t.Errorf("Expected %v but got %v", ErrWrongType, err)
+		}
+	})
+}
+
+func TestLRange(t *testing.T) {
+	testCases := []struct {
+		name        string
+		start, stop int
+		expOut      []string
+	}{
+		{"whole list", 0, -1, []string{"a", "b", "c", "d"}},
+		{"middle", 1, 2, []string{"b", "c"}},
+		{"negative indexes", -3, -2, []string{"b", "c"}},
+		{"stop past the end", 2, 100, []string{"c", "d"}},
+		{"start before the head", -100, 0, []string{"a"}},
+		{"start past the end", 5, 10, []string{}},
+		{"start after stop", 2, 1, []string{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			newDB := GetTestDBWithList("a", "b", "c", "d")
+
+			out, err := newDB.LRange("list", tc.start, tc.stop)
+			if err != nil {
+				t.Fatalf("Unexpected error occured: %v", err)
+			}
+			if !slices.Equal(out, tc.expOut) {
+				t.Errorf("Expected %v but got %v", tc.expOut, out)
+			}
+		})
+	}
+}
+
+func TestLIndexLSet(t *testing.T) {
+	newDB := GetTestDBWithList("a", "b", "c")
+
+	if v, ok, _ := newDB.LIndex("list", -1); !ok || v != "c" {
+		t.Errorf("Expected %q but got %q", "c", v)
+	}
+	if _, ok, _ := newDB.LIndex("list", 3); ok {
+		t.Errorf("Expected no item out of range")
+	}
+
+	if err := newDB.LSet("list", -2, "x"); err != nil {
+		t.Fatalf("Unexpected error occured: %v", err)
+	}
+	if out := listValues(t, newDB); !slices.Equal(out, []string{"a", "x", "c"}) {
+		t.Errorf("Expected %v but got %v", []string{"a", "x", "c"}, out)
+	}
+	if err := newDB.LSet("list", 3, "x"); !errors.Is(err, ErrIndexOutOfRange) {
+		t.Errorf("Expected %v but got %v", ErrIndexOutOfRange, err)
+	}
+	if err := newDB.LSet("missing", 0, "x"); !errors.Is(err, ErrNoSuchKey) {
+		t.Errorf("Expected %v but got %v", ErrNoSuchKey, err)
+	}
+}
+
+func TestLRem(t *testing.T) {
+	testCases := []struct {
+		name       string
+		count      int
+		expRemoved int
+		expOut     []string
+	}{
+		{"all", 0, 3, []string{"b", "c"}},
+		{"from the head", 2, 2, []string{"b", "c", "a"}},
+		{"from the tail", -2, 2, []string{"a", "b", "c"}},
+		{"more than there are", 10, 3, []string{"b", "c"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			newDB := GetTestDBWithList("a", "b", "a", "c", "a")
+
+			n, err := newDB.LRem("list", tc.count, "a")
+			if err != nil {
+				t.Fatalf("Unexpected error occured: %v", err)
+			}
+			if n != tc.expRemoved {
+				t.Errorf("Expected %d items to be removed but got %d", tc.expRemoved, n)
+			}
+			if out := listValues(t, newDB); !slices.Equal(out, tc.expOut) {
+				t.Errorf("Expected %v but got %v", tc.expOut, out)
+			}
+		})
+	}
+
+	t.Run("removing every item deletes the key", func(t *testing.T) {
+		newDB := GetTestDBWithList("a", "a")
+
+		newDB.LRem("list", 0, "a")
+		if typ := newDB.Type("list"); typ != "none" {
+			t.Errorf("Expected the key to be deleted but its type is %s", typ)
+		}
+	})
+}
+
+func TestLTrim(t *testing.T) {
+	testCases := []struct {
+		name        string
+		start, stop int
+		expOut      []string
+	}{
+		{"middle", 1, 2, []string{"b", "c"}},
+		{"negative indexes", 0, -2, []string{"a", "b", "c"}},
+		{"empty range", 3, 1, []string{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			newDB := GetTestDBWithList("a", "b", "c", "d")
+
+			if err := newDB.LTrim("list", tc.start, tc.stop); err != nil {
+				t.Fatalf("Unexpected error occured: %v", err)
+			}
+			if out := listValues(t, newDB); !slices.Equal(out, tc.expOut) {
+				t.Errorf("Expected %v but got %v", tc.expOut, out)
+			}
+		})
+	}
+}
+
+func TestLInsert(t *testing.T) {
+	newDB := GetTestDBWithList("a", "c")
+
+	if n, _ := newDB.LInsert("list", ListBefore, "c", "b"); n != 3 {
+		t.Errorf("Expected length %d but got %d", 3, n)
+	}
+	if n, _ := newDB.LInsert("list", ListAfter, "c", "d"); n != 4 {
+		t.Errorf("Expected length %d but got %d", 4, n)
+	}
+	if n, _ := newDB.LInsert("list", ListAfter, "x", "y"); n != -1 {
+		t.Errorf("Expected %d but got %d", -1, n)
+	}
+	if n, _ := newDB.LInsert("missing", ListAfter, "x", "y"); n != 0 {
+		t.Errorf("Expected %d but got %d", 0, n)
+	}
+	if out := listValues(t, newDB); !slices.Equal(out, []string{"a", "b", "c", "d"}) {
+		t.Errorf("Expected %v but got %v", []string{"a", "b", "c", "d"}, out)
+	}
+}
+
+func TestLPos(t *testing.T) {
+	testCases := []struct {
+		name   string
+		opts   LPosOptions
+		expOut []int
+	}{
+		{"first match", LPosOptions{Rank: 1, Count: 1}, []int{1}},
+		{"second match", LPosOptions{Rank: 2, Count: 1}, []int{3}},
+		{"all matches", LPosOptions{Rank: 1}, []int{1, 3, 5}},
+		{"from the tail", LPosOptions{Rank: -1, Count: 2}, []int{5, 3}},
+		{"max len", LPosOptions{Rank: 1, MaxLen: 4}, []int{1, 3}},
+		{"rank past the matches", LPosOptions{Rank: 4}, []int{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			newDB := GetTestDBWithList("a", "x", "b", "x", "c", "x")
+
+			out, err := newDB.LPos("list", "x", tc.opts)
+			if err != nil {
+				t.Fatalf("Unexpected error occured: %v", err)
+			}
+			if !slices.Equal(out, tc.expOut) {
+				t.Errorf("Expected %v but got %v", tc.expOut, out)
+			}
+		})
+	}
+}
+
+func TestLMove(t *testing.T) {
+	t.Run("to another list", func(t *testing.T) {
+		newDB := GetTestDBWithList("a", "b")
+
+		v, ok, err := newDB.LMove("list", "other", ListTail, ListHead)
+		if err != nil || !ok || v != "b" {
+			t.Fatalf("Expected %q but got %q, %v, %v", "b", v, ok, err)
+		}
+		newDB.LMove("list", "other", ListHead, ListHead)
+
+		if out, _ := newDB.LRange("other", 0, -1); !slices.Equal(out, []string{"a", "b"}) {
+			t.Errorf("Expected %v but got %v", []string{"a", "b"}, out)
+		}
+		if typ := newDB.Type("list"); typ != "none" {
+			t.Errorf("Expected the emptied list to be deleted but its type is %s", typ)
+		}
+	})
+
+	t.Run("rotates the same list", func(t *testing.T) {
+		newDB := GetTestDBWithList("a", "b", "c")
+
+		newDB.LMove("list", "list", ListHead, ListTail)
+		if out := listValues(t, newDB); !slices.Equal(out, []string{"b", "c", "a"}) {
+			t.Errorf("Expected %v but got %v", []string{"b", "c", "a"}, out)
+		}
+	})
+
+	t.Run("destination of the wrong type", func(t *testing.T) {
+		newDB := GetTestDBWithList("a")
+
+		if _, _, err := newDB.LMove("list", "str", ListHead, ListTail); !errors.Is(err, ErrWrongType) {
+			t.Errorf("Expected %v but got %v", ErrWrongType, err)
+		}
+		if out := listValues(t, newDB); !slices.Equal(out, []string{"a"}) {
+			t.Errorf("Expected the source to be left as is but got %v", out)
+		}
+	})
+
+	t.Run("missing source", func(t *testing.T) {
+		newDB := GetTestDBWithList()
+
+		if _, ok, err := newDB.LMove("missing", "list", ListHead, ListTail); ok || err != nil {
+			t.Errorf("Expected nothing to be moved but got %v, %v", ok, err)
+		}
+	})
+}
@@ -0,0 +1,56 @@
+package db
+
+// classes of the keyspace events, a class can be turned on or off through notify-keyspace-events
+type EventClass int
+
+const (
+	EventGeneric EventClass = 1 << iota // del, expire, persist and friends, not tied to a type
+	EventString
+	EventList
+	EventSet
+	EventHash
+	EventZSet
+	EventExpired // a key deleted once its timeout passed
+	EventEvicted // a key deleted to free memory, nothing evicts keys yet
+	EventStream
+
+	EventAll = EventGeneric | EventString | EventList | EventSet | EventHash | EventZSet | EventExpired | EventEvicted | EventStream
+)
+
+// gets the events of the writes done to a db
+// called with the lock of the db held, so the events come in the order of the writes
+type Notifier func(class EventClass, event, key string)
+
+// sets the func getting the events of the db, nil for none
+func (d *Db) SetNotifier(n Notifier) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.notifier = n
+}
+
+// every write to a key reports its event, the key is touched for WATCH and counted as a change as well
+func (d *Db) notify(class EventClass, event, key string) {
+	d.touch(key)
+	d.dirty++
+	if d.notifier != nil {
+		d.notifier(class, event, key)
+	}
+}
+
+// returns the number of changes made to the db so far, it never goes down
+// the server tells how many changes weren't saved yet from it
+func (d *Db) Dirty() uint64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.dirty
+}
+
+// name of the event of a push or a pop at the end of a list
+func listEvent(end ListEnd, op string) string {
+	if end == ListHead {
+		return "l" + op
+	}
+	return "r" + op
+}
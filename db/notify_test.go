@@ -0,0 +1,130 @@
+package db
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store"
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store/inMemoryStore"
+)
+
+func TestNotifier(t *testing.T) {
+	testCases := []struct {
+		name      string
+		fill      func(d *Db)
+		write     func(d *Db)
+		expEvents []string
+	}{
+		{
+			name:      "set and incr",
+			write:     func(d *Db) { d.Set("k", "1"); d.Incr("k"); d.Del("k"); d.Del("k") },
+			expEvents: []string{"set k", "incrby k", "del k"},
+		},
+		{
+			name:      "set with a timeout",
+			write:     func(d *Db) { d.SetWithOptions("k", "v", SetOptions{ExpireAt: testNow + 1000}) },
+			expEvents: []string{"set k", "expire k"},
+		},
+		{
+			name: "expire and persist",
+			fill: func(d *Db) { d.Set("k", "v"); d.Set("gone", "v") },
+			write: func(d *Db) {
+				d.Expire("k", testNow+1000, ExpireAlways)
+				d.Persist("k")
+				d.Persist("k")
+				d.Expire("gone", testNow, ExpireAlways)
+			},
+			expEvents: []string{"expire k", "persist k", "del gone"},
+		},
+		{
+			name:      "expired key",
+			fill:      func(d *Db) { d.Set("k", "v"); d.store.SetExpiry("k", testNow-1) },
+			write:     func(d *Db) { d.Get("k") },
+			expEvents: []string{"expired k"},
+		},
+		{
+			name: "list emptied",
+			write: func(d *Db) {
+				d.Push("l", ListTail, "a", "b")
+				d.LMove("l", "m", ListHead, ListTail)
+				d.Pop("l", ListTail, 5)
+				d.Pop("l", ListTail, 5)
+			},
+			expEvents: []string{"rpush l", "lpop l", "rpush m", "rpop l", "del l"},
+		},
+		{
+			name:      "hash",
+			write:     func(d *Db) { d.HSet("h", "f", "1"); d.HIncrBy("h", "f", 2); d.HDel("h", "nope"); d.HDel("h", "f") },
+			expEvents: []string{"hset h", "hincrby h", "hdel h", "del h"},
+		},
+		{
+			name: "set algebra store",
+			fill: func(d *Db) { d.Set("dst", "v") },
+			write: func(d *Db) {
+				d.SAdd("a", "x")
+				d.SAdd("a", "x")
+				d.SetAlgebraStore(SetOpUnion, "dst", "a")
+				d.SetAlgebraStore(SetOpInter, "dst", "a", "b")
+			},
+			expEvents: []string{"sadd a", "sunionstore dst", "del dst"},
+		},
+		{
+			name: "sorted set",
+			write: func(d *Db) {
+				d.ZAdd("z", ZAddOptions{}, store.ZItem{Member: "a", Score: 1})
+				d.ZAdd("z", ZAddOptions{}, store.ZItem{Member: "a", Score: 1})
+				d.ZPop("z", 1, true)
+			},
+			expEvents: []string{"zadd z", "zpopmax z", "del z"},
+		},
+		{
+			name: "stream",
+			write: func(d *Db) {
+				d.XAdd("s", XAddID{AutoMs: true}, []string{"f", "v"}, XAddOptions{Trim: StreamTrim{Strategy: TrimMaxLen}})
+			},
+			expEvents: []string{"xadd s", "xtrim s"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			newDB := &Db{store: inMemoryStore.NewInMemoryStore(), now: func() int64 { return testNow }}
+			if tc.fill != nil {
+				tc.fill(newDB)
+			}
+
+			events := []string{}
+			newDB.SetNotifier(func(class EventClass, event, key string) {
+				events = append(events, event+" "+key)
+			})
+			tc.write(newDB)
+
+			if !slices.Equal(events, tc.expEvents) {
+				t.Errorf("Expected %v but got %v", tc.expEvents, events)
+			}
+		})
+	}
+}
+
+func TestDirty(t *testing.T) {
+	testCases := []struct {
+		name     string
+		write    func(d *Db)
+		expDirty uint64
+	}{
+		{"reads", func(d *Db) { d.Get("k"); d.LRange("l", 0, -1); d.Del("missing") }, 0},
+		{"writes", func(d *Db) { d.Set("k", "1"); d.Incr("k"); d.HSet("h", "f", "v") }, 3},
+		{"write which doesn't change anything", func(d *Db) { d.SAdd("s", "a"); d.SAdd("s", "a") }, 1},
+		{"flush", func(d *Db) { d.Set("a", "1"); d.Set("b", "2"); d.Flush() }, 4},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := &Db{store: inMemoryStore.NewInMemoryStore(), now: func() int64 { return testNow }}
+			tc.write(d)
+			if dirty := d.Dirty(); dirty != tc.expDirty {
+				t.Errorf("Expected %d changes but got %d", tc.expDirty, dirty)
+			}
+		})
+	}
+}
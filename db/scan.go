@@ -0,0 +1,49 @@
+package db
+
+import (
+	"hash/fnv"
+	"slices"
+)
+
+// order in which SCAN like commands walk the fields of a value
+// the cursor is the position of the next field in this order, so a field which exists
+// for the whole scan is returned even when others are added or removed in between
+// the order is never 0, which is the cursor of a finished scan
+func scanOrder(field string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(field))
+	return h.Sum64() | 1
+}
+
+// returns about count fields starting at cursor, and the cursor to continue from
+// fields sharing their order are returned together, so none of them is skipped
+func scanFields(fields []string, cursor uint64, count int) (uint64, []string) {
+	slices.SortFunc(fields, func(a, b string) int {
+		if oa, ob := scanOrder(a), scanOrder(b); oa != ob {
+			if oa < ob {
+				return -1
+			}
+			return 1
+		}
+		return 0
+	})
+
+	start, _ := slices.BinarySearchFunc(fields, cursor, func(f string, c uint64) int {
+		if o := scanOrder(f); o < c {
+			return -1
+		} else if o > c {
+			return 1
+		}
+		return 0
+	})
+
+	end := min(start+count, len(fields))
+	for end < len(fields) && end > start && scanOrder(fields[end]) == scanOrder(fields[end-1]) {
+		end++
+	}
+
+	if end == len(fields) {
+		return 0, fields[start:end]
+	}
+	return scanOrder(fields[end]), fields[start:end]
+}
@@ -0,0 +1,64 @@
+package db
+
+import "github.com/justsushant/one2n-go-bootcamp/go-redis/store"
+
+// conditions under which SET writes the key
+type SetCond int
+
+const (
+	SetAlways SetCond = iota
+	SetNX             // only when the key doesn't exist
+	SetXX             // only when the key exists
+)
+
+// options of the SET command, parsed by the server
+type SetOptions struct {
+	Cond     SetCond
+	ExpireAt int64 // unix time in ms at which the key expires, 0 for no timeout
+	KeepTTL  bool  // retains the current timeout of the key, ignored when ExpireAt is given
+	Get      bool  // the old value is wanted, so it must be a string
+}
+
+// sets the value of the key, if opts.Cond allows, as a single step
+// returns the old value of the key, whether the key existed and whether the value was written
+// a key of any type is overwritten, unless opts.Get asks for its old value
+// a timeout in the past deletes the key right away, like a SET followed by its expiry
+func (d *Db) SetWithOptions(key, val string, opts SetOptions) (old string, existed, written bool, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.expireIfNeeded(key)
+
+	if opts.Get {
+		old, existed, err = d.getString(key)
+		if err != nil {
+			return "", false, false, err
+		}
+	} else {
+		_, existed = d.store.Get(key)
+	}
+
+	if (opts.Cond == SetNX && existed) || (opts.Cond == SetXX && !existed) {
+		return old, existed, false, nil
+	}
+
+	switch {
+	case opts.ExpireAt != 0 && opts.ExpireAt <= d.nowMs():
+		d.store.Del(key)
+		d.notify(EventString, "set", key)
+		d.notify(EventGeneric, "del", key)
+	case opts.ExpireAt != 0:
+		d.store.Set(key, store.String(val))
+		d.store.SetExpiry(key, opts.ExpireAt)
+		d.notify(EventString, "set", key)
+		d.notify(EventGeneric, "expire", key)
+	case opts.KeepTTL:
+		d.store.Set(key, store.String(val))
+		d.notify(EventString, "set", key)
+	default:
+		d.store.Set(key, store.String(val))
+		d.store.DelExpiry(key)
+		d.notify(EventString, "set", key)
+	}
+	return old, existed, true, nil
+}
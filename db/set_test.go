@@ -0,0 +1,68 @@
+package db
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store"
+)
+
+func TestSetWithOptions(t *testing.T) {
+	testCases := []struct {
+		name       string
+		store      *mockStore
+		opts       SetOptions
+		expOld     string
+		expExisted bool
+		expWritten bool
+		expVal     string
+		expExpiry  int64
+	}{
+		{"missing key", &mockStore{}, SetOptions{}, "", false, true, "baz", 0},
+		{"existing key", &mockStore{key: "foo", val: "bar"}, SetOptions{}, "", true, true, "baz", 0},
+		{"existing key with GET", &mockStore{key: "foo", val: "bar"}, SetOptions{Get: true}, "bar", true, true, "baz", 0},
+		{"list is overwritten", &mockStore{key: "foo", value: store.NewList("a")}, SetOptions{}, "", true, true, "baz", 0},
+		{"NX on missing key", &mockStore{}, SetOptions{Cond: SetNX}, "", false, true, "baz", 0},
+		{"NX on existing key", &mockStore{key: "foo", val: "bar"}, SetOptions{Cond: SetNX, Get: true}, "bar", true, false, "bar", 0},
+		{"NX on expired key", &mockStore{key: "foo", val: "bar", expiry: testNow - 1}, SetOptions{Cond: SetNX}, "", false, true, "baz", 0},
+		{"XX on missing key", &mockStore{}, SetOptions{Cond: SetXX}, "", false, false, "", 0},
+		{"XX on existing key", &mockStore{key: "foo", val: "bar"}, SetOptions{Cond: SetXX}, "", true, true, "baz", 0},
+		{"timeout is cleared", &mockStore{key: "foo", val: "bar", expiry: testNow + 1000}, SetOptions{}, "", true, true, "baz", 0},
+		{"timeout is retained with KEEPTTL", &mockStore{key: "foo", val: "bar", expiry: testNow + 1000}, SetOptions{KeepTTL: true}, "", true, true, "baz", testNow + 1000},
+		{"timeout is set", &mockStore{key: "foo", val: "bar", expiry: testNow + 1000}, SetOptions{ExpireAt: testNow + 5000}, "", true, true, "baz", testNow + 5000},
+		{"timeout in the past deletes the key", &mockStore{key: "foo", val: "bar"}, SetOptions{ExpireAt: testNow - 1}, "", true, true, "", 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			newDB := GetTestDBWithClock(tc.store)
+
+			old, existed, written, err := newDB.SetWithOptions("foo", "baz", tc.opts)
+			if err != nil {
+				t.Fatalf("Unexpected error occured: %v", err)
+			}
+			if old != tc.expOld || existed != tc.expExisted || written != tc.expWritten {
+				t.Errorf("Expected (%q, %v, %v) but got (%q, %v, %v)", tc.expOld, tc.expExisted, tc.expWritten, old, existed, written)
+			}
+			if tc.store.val != tc.expVal {
+				t.Errorf("Expected value %q but got %q", tc.expVal, tc.store.val)
+			}
+			if tc.store.expiry != tc.expExpiry {
+				t.Errorf("Expected timeout %d but got %d", tc.expExpiry, tc.store.expiry)
+			}
+		})
+	}
+
+	t.Run("GET on a list", func(t *testing.T) {
+		mockStore := &mockStore{key: "foo", value: store.NewList("a")}
+		newDB := GetTestDBWithClock(mockStore)
+
+		_, _, written, err := newDB.SetWithOptions("foo", "baz", SetOptions{Get: true})
+		if !errors.Is(err, ErrWrongType) {
+			t.Errorf("Expected %v but got %v", ErrWrongType, err)
+		}
+		if written || mockStore.value == nil {
+			t.Errorf("Expected the list to be left as is")
+		}
+	})
+}
@@ -0,0 +1,327 @@
+package db
+
+import (
+	"math/rand/v2"
+	"slices"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store"
+)
+
+// operation of SINTER, SUNION and SDIFF
+type SetOp int
+
+const (
+	SetOpInter SetOp = iota
+	SetOpUnion
+	SetOpDiff
+)
+
+// name of the event of the STORE variant of the op, prefixed with s for sets or z for sorted sets
+func (op SetOp) storeEvent(prefix string) string {
+	return prefix + [...]string{"interstore", "unionstore", "diffstore"}[op]
+}
+
+// returns the set of the key, nil if the key is missing
+// or ErrWrongType if it holds another type
+func (d *Db) getSet(key string) (store.Set, error) {
+	d.expireIfNeeded(key)
+
+	val, ok := d.store.Get(key)
+	if !ok {
+		return nil, nil
+	}
+
+	s, ok := val.(store.Set)
+	if !ok {
+		return nil, ErrWrongType
+	}
+	return s, nil
+}
+
+// adds the members to the set, creating it if needed
+// returns the number of members which weren't there yet
+func (d *Db) SAdd(key string, members ...string) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	s, err := d.getSet(key)
+	if err != nil {
+		return 0, err
+	}
+	if s == nil {
+		s = store.Set{}
+		d.store.Set(key, s)
+	}
+
+	added := 0
+	for _, m := range members {
+		if _, ok := s[m]; !ok {
+			s[m] = struct{}{}
+			added++
+		}
+	}
+	if added > 0 {
+		d.notify(EventSet, "sadd", key)
+	}
+	return added, nil
+}
+
+// removes the members from the set, and the key along with the last member
+// returns the number of removed members
+func (d *Db) SRem(key string, members ...string) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	s, err := d.getSet(key)
+	if err != nil || s == nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, m := range members {
+		if _, ok := s[m]; ok {
+			delete(s, m)
+			removed++
+		}
+	}
+
+	if removed > 0 {
+		d.notify(EventSet, "srem", key)
+	}
+	if len(s) == 0 {
+		d.store.Del(key)
+		d.notify(EventGeneric, "del", key)
+	}
+	return removed, nil
+}
+
+// returns the members of the set in sorted order
+func (d *Db) SMembers(key string) ([]string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	s, err := d.getSet(key)
+	if err != nil {
+		return nil, err
+	}
+	return sortedMembers(s), nil
+}
+
+// reports whether each of the members is in the set
+func (d *Db) SMIsMember(key string, members ...string) ([]bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	s, err := d.getSet(key)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]bool, len(members))
+	for i, m := range members {
+		_, out[i] = s[m]
+	}
+	return out, nil
+}
+
+func (d *Db) SCard(key string) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	s, err := d.getSet(key)
+	return len(s), err
+}
+
+// removes and returns up to count random members
+// returns nil if the key is missing
+func (d *Db) SPop(key string, count int) ([]string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	s, err := d.getSet(key)
+	if err != nil || s == nil {
+		return nil, err
+	}
+
+	out := randomMembers(s, count)
+	for _, m := range out {
+		delete(s, m)
+	}
+
+	if len(out) > 0 {
+		d.notify(EventSet, "spop", key)
+	}
+	if len(s) == 0 {
+		d.store.Del(key)
+		d.notify(EventGeneric, "del", key)
+	}
+	return out, nil
+}
+
+// returns random members without removing them
+// a positive count returns distinct members, as many as there are at most
+// a negative count returns exactly -count members which may repeat
+func (d *Db) SRandMember(key string, count int) ([]string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	s, err := d.getSet(key)
+	if err != nil || s == nil {
+		return nil, err
+	}
+
+	if count >= 0 {
+		return randomMembers(s, count), nil
+	}
+
+	members := setMembers(s)
+	out := make([]string, -count)
+	for i := range out {
+		out[i] = members[rand.IntN(len(members))]
+	}
+	return out, nil
+}
+
+// returns the intersection, union or difference of the sets in sorted order
+// missing keys count as empty sets
+func (d *Db) SetAlgebra(op SetOp, keys ...string) ([]string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	s, err := d.setAlgebra(op, keys)
+	if err != nil {
+		return nil, err
+	}
+	return sortedMembers(s), nil
+}
+
+// stores the result of SetAlgebra in dst, replacing whatever it held along with its timeout
+// an empty result deletes dst, returns the size of the result
+func (d *Db) SetAlgebraStore(op SetOp, dst string, keys ...string) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	s, err := d.setAlgebra(op, keys)
+	if err != nil {
+		return 0, err
+	}
+
+	_, existed := d.store.Get(dst)
+	d.store.Del(dst)
+	switch {
+	case len(s) > 0:
+		d.store.Set(dst, s)
+		d.notify(EventSet, op.storeEvent("s"), dst)
+	case existed:
+		d.notify(EventGeneric, "del", dst)
+	}
+	return len(s), nil
+}
+
+// returns the size of the intersection of the sets
+// the count stops at limit, 0 means no limit
+func (d *Db) SInterCard(limit int, keys ...string) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	sets, err := d.getSets(keys)
+	if err != nil {
+		return 0, err
+	}
+
+	// walks the smallest set and probes the others
+	slices.SortFunc(sets, func(a, b store.Set) int { return len(a) - len(b) })
+	n := 0
+	for m := range sets[0] {
+		if allContain(sets[1:], m) {
+			n++
+			if n == limit {
+				break
+			}
+		}
+	}
+	return n, nil
+}
+
+// every key is type checked, even if the result is known to be empty early on
+func (d *Db) getSets(keys []string) ([]store.Set, error) {
+	sets := make([]store.Set, len(keys))
+	for i, k := range keys {
+		s, err := d.getSet(k)
+		if err != nil {
+			return nil, err
+		}
+		sets[i] = s
+	}
+	return sets, nil
+}
+
+// builds a new set out of the sets of the keys
+func (d *Db) setAlgebra(op SetOp, keys []string) (store.Set, error) {
+	sets, err := d.getSets(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	out := store.Set{}
+	switch op {
+	case SetOpInter:
+		for m := range sets[0] {
+			if allContain(sets[1:], m) {
+				out[m] = struct{}{}
+			}
+		}
+	case SetOpUnion:
+		for _, s := range sets {
+			for m := range s {
+				out[m] = struct{}{}
+			}
+		}
+	case SetOpDiff:
+		for m := range sets[0] {
+			if !anyContains(sets[1:], m) {
+				out[m] = struct{}{}
+			}
+		}
+	}
+	return out, nil
+}
+
+func allContain(sets []store.Set, m string) bool {
+	for _, s := range sets {
+		if _, ok := s[m]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func anyContains(sets []store.Set, m string) bool {
+	for _, s := range sets {
+		if _, ok := s[m]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// returns up to count distinct random members
+func randomMembers(s store.Set, count int) []string {
+	members := setMembers(s)
+	rand.Shuffle(len(members), func(i, j int) { members[i], members[j] = members[j], members[i] })
+	return members[:min(count, len(members))]
+}
+
+func setMembers(s store.Set) []string {
+	members := make([]string, 0, len(s))
+	for m := range s {
+		members = append(members, m)
+	}
+	return members
+}
+
+func sortedMembers(s store.Set) []string {
+	members := setMembers(s)
+	slices.Sort(members)
+	return members
+}
@@ -0,0 +1,167 @@
+package db
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store"
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store/inMemoryStore"
+)
+
+// db with the sets "a" {1 2 3}, "b" {2 3 4}, "c" {3 4 5} and the string "str"
+func GetTestDBWithSets() *Db {
+	s := inMemoryStore.NewInMemoryStore()
+	s.Set("a", store.Set{"1": {}, "2": {}, "3": {}})
+	s.Set("b", store.Set{"2": {}, "3": {}, "4": {}})
+	s.Set("c", store.Set{"3": {}, "4": {}, "5": {}})
+	s.Set("str", store.String("bar"))
+	return &Db{store: s}
+}
+
+func TestSAddSRem(t *testing.T) {
+	newDB := GetTestDBWithSets()
+
+	if n, _ := newDB.SAdd("new", "x", "y", "x"); n != 2 {
+		t.Errorf("Expected %d added members but got %d", 2, n)
+	}
+	if n, _ := newDB.SAdd("new", "y", "z"); n != 1 {
+		t.Errorf("Expected %d added members but got %d", 1, n)
+	}
+	if out, _ := newDB.SMembers("new"); !slices.Equal(out, []string{"x", "y", "z"}) {
+		t.Errorf("Expected %v but got %v", []string{"x", "y", "z"}, out)
+	}
+
+	if n, _ := newDB.SRem("new", "x", "missing"); n != 1 {
+		t.Errorf("Expected %d removed members but got %d", 1, n)
+	}
+	newDB.SRem("new", "y", "z")
+	if typ := newDB.Type("new"); typ != "none" {
+		t.Errorf("Expected the key to be deleted along with its last member but its type is %s", typ)
+	}
+}
+
+func TestSMIsMember(t *testing.T) {
+	newDB := GetTestDBWithSets()
+
+	if out, _ := newDB.SMIsMember("a", "1", "4"); !slices.Equal(out, []bool{true, false}) {
+		t.Errorf("Expected %v but got %v", []bool{true, false}, out)
+	}
+	if out, _ := newDB.SMIsMember("missing", "1"); !slices.Equal(out, []bool{false}) {
+		t.Errorf("Expected %v but got %v", []bool{false}, out)
+	}
+}
+
+func TestSPopSRandMember(t *testing.T) {
+	newDB := GetTestDBWithSets()
+
+	popped, _ := newDB.SPop("a", 2)
+	if len(popped) != 2 {
+		t.Fatalf("Expected %d members but got %v", 2, popped)
+	}
+	if n, _ := newDB.SCard("a"); n != 1 {
+		t.Errorf("Expected %d members to be left but got %d", 1, n)
+	}
+	newDB.SPop("a", 5)
+	if typ := newDB.Type("a"); typ != "none" {
+		t.Errorf("Expected the emptied set to be deleted but its type is %s", typ)
+	}
+
+	if out, _ := newDB.SRandMember("b", 10); len(out) != 3 {
+		t.Errorf("Expected every member once but got %v", out)
+	}
+	if out, _ := newDB.SRandMember("b", -10); len(out) != 10 {
+		t.Errorf("Expected %d members but got %v", 10, out)
+	}
+	if n, _ := newDB.SCard("b"); n != 3 {
+		t.Errorf("Expected SRANDMEMBER to leave the set as is but it has %d members", n)
+	}
+}
+
+func TestSetAlgebra(t *testing.T) {
+	testCases := []struct {
+		name   string
+		op     SetOp
+		keys   []string
+		expOut []string
+	}{
+		{"inter", SetOpInter, []string{"a", "b", "c"}, []string{"3"}},
+		{"inter with missing key", SetOpInter, []string{"a", "missing"}, []string{}},
+		{"union", SetOpUnion, []string{"a", "c"}, []string{"1", "2", "3", "4", "5"}},
+		{"diff", SetOpDiff, []string{"a", "b"}, []string{"1"}},
+		{"diff of missing key", SetOpDiff, []string{"missing", "a"}, []string{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			newDB := GetTestDBWithSets()
+
+			out, err := newDB.SetAlgebra(tc.op, tc.keys...)
+			if err != nil {
+				t.Fatalf("Unexpected error occured: %v", err)
+			}
+			if !slices.Equal(out, tc.expOut) {
+				t.Errorf("Expected %v but got %v", tc.expOut, out)
+			}
+		})
+	}
+
+	t.Run("wrong type", func(t *testing.T) {
+		newDB := GetTestDBWithSets()
+
+		if _, err := newDB.SetAlgebra(SetOpInter, "missing", "str"); !errors.Is(err, ErrWrongType) {
+			t.Errorf("Expected %v but got %v", ErrWrongType, err)
+		}
+	})
+}
+
+func TestSetAlgebraStore(t *testing.T) {
+	t.Run("replaces the destination", func(t *testing.T) {
+		newDB := GetTestDBWithSets()
+		newDB.store.SetExpiry("str", testNow)
+
+		if n, _ := newDB.SetAlgebraStore(SetOpUnion, "str", "a", "b"); n != 4 {
+			t.Errorf("Expected %d members but got %d", 4, n)
+		}
+		if out, _ := newDB.SMembers("str"); !slices.Equal(out, []string{"1", "2", "3", "4"}) {
+			t.Errorf("Expected %v but got %v", []string{"1", "2", "3", "4"}, out)
+		}
+		if _, ok := newDB.store.GetExpiry("str"); ok {
+			t.Errorf("Expected the timeout of the destination to be cleared")
+		}
+	})
+
+	t.Run("destination is one of the sources", func(t *testing.T) {
+		newDB := GetTestDBWithSets()
+
+		newDB.SetAlgebraStore(SetOpDiff, "a", "a", "b")
+		if out, _ := newDB.SMembers("a"); !slices.Equal(out, []string{"1"}) {
+			t.Errorf("Expected %v but got %v", []string{"1"}, out)
+		}
+	})
+
+	t.Run("empty result deletes the destination", func(t *testing.T) {
+		newDB := GetTestDBWithSets()
+
+		if n, _ := newDB.SetAlgebraStore(SetOpInter, "b", "a", "missing"); n != 0 {
+			t.Errorf("Expected %d members but got %d", 0, n)
+		}
+		if typ := newDB.Type("b"); typ != "none" {
+			t.Errorf("Expected the destination to be deleted but its type is %s", typ)
+		}
+	})
+}
+
+func TestSInterCard(t *testing.T) {
+	newDB := GetTestDBWithSets()
+
+	if n, _ := newDB.SInterCard(0, "a", "b"); n != 2 {
+		t.Errorf("Expected %d but got %d", 2, n)
+	}
+	if n, _ := newDB.SInterCard(1, "a", "b"); n != 1 {
+		t.Errorf("Expected %d but got %d", 1, n)
+	}
+	if n, _ := newDB.SInterCard(0, "a", "missing"); n != 0 {
+		t.Errorf("Expected %d but got %d", 0, n)
+	}
+}
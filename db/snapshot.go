@@ -0,0 +1,71 @@
+package db
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store"
+)
+
+// returns a copy of the keys which haven't expired yet along with their timeouts, ordered by key
+// the values are copied, so they can be saved once the lock is released while the db is written
+func (d *Db) Snapshot() []store.Entry {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.nowMs()
+	entries := []store.Entry{}
+	for k, v := range d.store.GetAll() {
+		at, ok := d.store.GetExpiry(k)
+		if ok && at <= now {
+			continue
+		}
+		entries = append(entries, store.Entry{Key: k, Value: v.Clone(), ExpireAt: at})
+	}
+	slices.SortFunc(entries, func(a, b store.Entry) int { return strings.Compare(a.Key, b.Key) })
+	return entries
+}
+
+// adds the entries, replacing the keys which exist already
+// entries which expired meanwhile are skipped, no events are sent since nothing was written by a client
+func (d *Db) Restore(entries []store.Entry) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.nowMs()
+	for _, e := range entries {
+		d.setEntry(e, now)
+	}
+}
+
+// adds the entries like a client writing them, replacing the keys which exist already, and returns those added
+// entries which expired meanwhile are skipped, every key added sends a restore event and wakes the clients blocked on it
+func (d *Db) Import(entries []store.Entry) []store.Entry {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.nowMs()
+	added := []store.Entry{}
+	for _, e := range entries {
+		if d.setEntry(e, now) {
+			d.notify(EventGeneric, "restore", e.Key)
+			d.signalReady(e.Key)
+			added = append(added, e)
+		}
+	}
+	return added
+}
+
+// sets the key of the entry along with its timeout, unless it passed already
+func (d *Db) setEntry(e store.Entry, now int64) bool {
+	if e.ExpireAt != 0 && e.ExpireAt <= now {
+		return false
+	}
+	d.store.Set(e.Key, e.Value)
+	if e.ExpireAt != 0 {
+		d.store.SetExpiry(e.Key, e.ExpireAt)
+	} else {
+		d.store.DelExpiry(e.Key)
+	}
+	return true
+}
@@ -0,0 +1,109 @@
+package db
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store"
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store/inMemoryStore"
+)
+
+func TestSnapshot(t *testing.T) {
+	s := inMemoryStore.NewInMemoryStore()
+	s.Set("str", store.String("bar"))
+	s.Set("list", store.NewList("a", "b"))
+	s.Set("ttl", store.String("soon"))
+	s.SetExpiry("ttl", testNow+1000)
+	s.Set("gone", store.String("old"))
+	s.SetExpiry("gone", testNow-1)
+	d := &Db{store: s, now: func() int64 { return testNow }}
+
+	entries := d.Snapshot()
+	exp := []store.Entry{
+		{Key: "list", Value: store.NewList("a", "b")},
+		{Key: "str", Value: store.String("bar")},
+		{Key: "ttl", Value: store.String("soon"), ExpireAt: testNow + 1000},
+	}
+	if !reflect.DeepEqual(entries, exp) {
+		t.Errorf("Expected %v but got %v", exp, entries)
+	}
+	if n := d.DbSize(); n != len(exp) {
+		t.Errorf("Expected %d keys but got %d", len(exp), n)
+	}
+
+	// the snapshot isn't changed by later writes
+	d.Push("list", ListTail, "c")
+	if got := entries[0].Value.(*store.List).Values(); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("Expected the snapshot to keep %v but got %v", []string{"a", "b"}, got)
+	}
+}
+
+func TestRestore(t *testing.T) {
+	s := inMemoryStore.NewInMemoryStore()
+	s.Set("str", store.String("old"))
+	s.SetExpiry("str", testNow+1000)
+	d := &Db{store: s, now: func() int64 { return testNow }}
+
+	d.Restore([]store.Entry{
+		{Key: "str", Value: store.String("new")},
+		{Key: "ttl", Value: store.String("soon"), ExpireAt: testNow + 5000},
+		{Key: "gone", Value: store.String("old"), ExpireAt: testNow},
+	})
+
+	testCases := []struct {
+		key    string
+		expVal string
+		expTTL int64
+	}{
+		{"str", "new", -1},
+		{"ttl", "soon", 5000},
+		{"gone", "", -2},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.key, func(t *testing.T) {
+			val, _ := d.Get(tc.key)
+			if val != tc.expVal {
+				t.Errorf("Expected %q but got %q", tc.expVal, val)
+			}
+			if ttl := d.TTL(tc.key); ttl != tc.expTTL {
+				t.Errorf("Expected TTL %d but got %d", tc.expTTL, ttl)
+			}
+		})
+	}
+}
+
+func TestImport(t *testing.T) {
+	s := inMemoryStore.NewInMemoryStore()
+	s.Set("str", store.String("old"))
+	s.SetExpiry("str", testNow+1000)
+	d := &Db{store: s, now: func() int64 { return testNow }}
+	events := []string{}
+	d.SetNotifier(func(class EventClass, event, key string) {
+		events = append(events, event+" "+key)
+	})
+	d.Block("list")
+
+	added := d.Import([]store.Entry{
+		{Key: "str", Value: store.String("new")},
+		{Key: "gone", Value: store.String("old"), ExpireAt: testNow},
+		{Key: "list", Value: store.NewList("a")},
+	})
+
+	exp := []store.Entry{{Key: "str", Value: store.String("new")}, {Key: "list", Value: store.NewList("a")}}
+	if !reflect.DeepEqual(added, exp) {
+		t.Errorf("Expected %v but got %v", exp, added)
+	}
+	if ttl := d.TTL("str"); ttl != -1 {
+		t.Errorf("Expected TTL -1 but got %d", ttl)
+	}
+	if expEvents := []string{"restore str", "restore list"}; !slices.Equal(events, expEvents) {
+		t.Errorf("Expected %v but got %v", expEvents, events)
+	}
+	if keys := d.ReadyKeys(); !slices.Equal(keys, []string{"list"}) {
+		t.Errorf("Expected the list to be ready but got %v", keys)
+	}
+	if n := d.Dirty(); n != 2 {
+		t.Errorf("Expected 2 changes but got %d", n)
+	}
+}
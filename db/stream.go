@@ -0,0 +1,255 @@
+package db
+
+import (
+	"errors"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store"
+)
+
+var (
+	ErrStreamIDTooSmall  = errors.New("The ID specified in XADD is equal or smaller than the target stream top item")
+	ErrStreamIDZero      = errors.New("The ID specified in XADD must be greater than 0-0")
+	ErrStreamIDExhausted = errors.New("The stream has exhausted the last possible ID, unable to add more items")
+)
+
+// id of a new entry as given to XADD
+type XAddID struct {
+	ID      store.StreamID
+	AutoMs  bool // *, the whole id is generated from the current time
+	AutoSeq bool // ms-*, only the seq part is generated
+}
+
+// how XADD and XTRIM trim a stream
+type TrimStrategy int
+
+const (
+	TrimNone   TrimStrategy = iota
+	TrimMaxLen              // keeps the newest MaxLen entries
+	TrimMinID               // evicts the entries with an id less than MinID
+)
+
+type StreamTrim struct {
+	Strategy TrimStrategy
+	MaxLen   int
+	MinID    store.StreamID
+	Limit    int // caps the number of evicted entries, 0 for no cap
+}
+
+// options of the XADD command, parsed by the server
+type XAddOptions struct {
+	NoMkStream bool // the stream isn't created if it's missing
+	Trim       StreamTrim
+}
+
+// entries read from a stream by XREAD and XREADGROUP
+type StreamRead struct {
+	Key     string
+	Entries []store.StreamEntry
+}
+
+// returns the stream of the key, nil if the key is missing
+// or ErrWrongType if it holds another type
+func (d *Db) getStream(key string) (*store.Stream, error) {
+	d.expireIfNeeded(key)
+
+	val, ok := d.store.Get(key)
+	if !ok {
+		return nil, nil
+	}
+
+	s, ok := val.(*store.Stream)
+	if !ok {
+		return nil, ErrWrongType
+	}
+	return s, nil
+}
+
+// appends an entry made of the field value pairs, then trims the stream as opts tell
+// returns the id of the entry, or false if the stream is missing and opts.NoMkStream is set
+func (d *Db) XAdd(key string, id XAddID, fields []string, opts XAddOptions) (store.StreamID, bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	s, err := d.getStream(key)
+	if err != nil || (s == nil && opts.NoMkStream) {
+		return store.StreamID{}, false, err
+	}
+
+	last := store.StreamID{}
+	if s != nil {
+		last = s.LastID
+	}
+	newID, err := nextStreamID(last, id, d.nowMs())
+	if err != nil {
+		return store.StreamID{}, false, err
+	}
+
+	if s == nil {
+		s = store.NewStream()
+		d.store.Set(key, s)
+	}
+	s.Add(newID, fields)
+	d.notify(EventStream, "xadd", key)
+	if trimStream(s, opts.Trim) > 0 {
+		d.notify(EventStream, "xtrim", key)
+	}
+	d.signalReady(key)
+	return newID, true, nil
+}
+
+// returns the id of an entry added after last, as id asks
+func nextStreamID(last store.StreamID, id XAddID, now int64) (store.StreamID, error) {
+	switch {
+	case id.AutoMs:
+		if ms := uint64(max(now, 0)); ms > last.Ms {
+			return store.StreamID{Ms: ms}, nil
+		}
+		next, ok := last.Next()
+		if !ok {
+			return next, ErrStreamIDExhausted
+		}
+		return next, nil
+	case id.AutoSeq:
+		if id.ID.Ms > last.Ms {
+			return id.ID, nil
+		}
+		if id.ID.Ms < last.Ms {
+			return id.ID, ErrStreamIDTooSmall
+		}
+		next, ok := last.Next()
+		if !ok || next.Ms != last.Ms {
+			return next, ErrStreamIDTooSmall
+		}
+		return next, nil
+	case id.ID.IsZero():
+		return id.ID, ErrStreamIDZero
+	case id.ID.Compare(last) <= 0:
+		return id.ID, ErrStreamIDTooSmall
+	default:
+		return id.ID, nil
+	}
+}
+
+func trimStream(s *store.Stream, trim StreamTrim) int {
+	switch trim.Strategy {
+	case TrimMaxLen:
+		return s.TrimMaxLen(trim.MaxLen, trim.Limit)
+	case TrimMinID:
+		return s.TrimMinID(trim.MinID, trim.Limit)
+	default:
+		return 0
+	}
+}
+
+func (d *Db) XLen(key string) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	s, err := d.getStream(key)
+	if err != nil || s == nil {
+		return 0, err
+	}
+	return s.Len(), nil
+}
+
+// returns the entries with an id in [start, end], at most count of them unless count is negative
+// rev returns them from end down to start
+func (d *Db) XRange(key string, start, end store.StreamID, count int, rev bool) ([]store.StreamEntry, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	s, err := d.getStream(key)
+	if err != nil || s == nil {
+		return []store.StreamEntry{}, err
+	}
+	return s.Range(start, end, count, rev), nil
+}
+
+// deletes the entries, returns the number of deleted entries
+// the stream is kept even once empty, like in redis
+func (d *Db) XDel(key string, ids ...store.StreamID) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	s, err := d.getStream(key)
+	if err != nil || s == nil {
+		return 0, err
+	}
+
+	n := s.Delete(ids...)
+	if n > 0 {
+		d.notify(EventStream, "xdel", key)
+	}
+	return n, nil
+}
+
+// trims the stream, returns the number of evicted entries
+func (d *Db) XTrim(key string, trim StreamTrim) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	s, err := d.getStream(key)
+	if err != nil || s == nil {
+		return 0, err
+	}
+
+	n := trimStream(s, trim)
+	if n > 0 {
+		d.notify(EventStream, "xtrim", key)
+	}
+	return n, nil
+}
+
+// returns the id of the last entry added to the stream, 0-0 if the stream is missing
+// it stands for the $ id of XREAD
+func (d *Db) XLastID(key string) (store.StreamID, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	s, err := d.getStream(key)
+	if err != nil || s == nil {
+		return store.StreamID{}, err
+	}
+	return s.LastID, nil
+}
+
+// returns the entries of each stream with an id greater than the matching one in after
+// at most count entries are read from each stream unless count is 0
+// streams without such entries are left out
+func (d *Db) XRead(keys []string, after []store.StreamID, count int) ([]StreamRead, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	streams := make([]*store.Stream, len(keys))
+	for i, key := range keys {
+		s, err := d.getStream(key)
+		if err != nil {
+			return nil, err
+		}
+		streams[i] = s
+	}
+
+	out := []StreamRead{}
+	for i, s := range streams {
+		if s == nil {
+			continue
+		}
+
+		start, ok := after[i].Next()
+		if !ok {
+			continue
+		}
+		if entries := s.Range(start, store.MaxStreamID, readCount(count), false); len(entries) > 0 {
+			out = append(out, StreamRead{Key: keys[i], Entries: entries})
+		}
+	}
+	return out, nil
+}
+
+// turns the COUNT of XREAD and friends into a count for Range, 0 reads everything
+func readCount(count int) int {
+	if count <= 0 {
+		return -1
+	}
+	return count
+}
@@ -0,0 +1,584 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store"
+)
+
+var (
+	ErrXGroupNoKey = errors.New("The XGROUP subcommand requires the key to exist. Note that for CREATE you may want to use the MKSTREAM option to create an empty stream automatically.")
+	ErrBusyGroup   = errors.New("BUSYGROUP Consumer Group name already exists")
+)
+
+// the stream or the group is missing, the message carries the NOGROUP code of redis
+func errNoGroup(key, group string) error {
+	return fmt.Errorf("NOGROUP No such key '%s' or consumer group '%s'", key, group)
+}
+
+// options of XGROUP CREATE and XGROUP SETID, parsed by the server
+type XGroupOptions struct {
+	ID             store.StreamID
+	LastEntry      bool // $, the group starts after the last entry of the stream
+	MkStream       bool // only for CREATE, creates the stream if it's missing
+	EntriesRead    int64
+	HasEntriesRead bool // otherwise EntriesRead is estimated from the id
+}
+
+// id given to XREADGROUP for a stream
+type XReadGroupID struct {
+	ID  store.StreamID
+	New bool // >, reads the entries never delivered to the group, ID is ignored
+}
+
+// options of the XCLAIM command, parsed by the server
+type XClaimOptions struct {
+	Idle       int64 // sets the delivery time to Idle ms ago, -1 if not given
+	Time       int64 // sets the delivery time to this unix time in ms, -1 if not given
+	RetryCount int   // sets the delivery count, -1 if not given
+	Force      bool  // claims entries which aren't pending, as long as they're in the stream
+	JustID     bool  // doesn't count a delivery
+	LastID     store.StreamID
+	HasLastID  bool  // raises the last delivered id of the group to LastID
+	MinIdle    int64 // only claims entries idle for at least this many ms
+}
+
+// summary of the pending entries of a group, as reported by XPENDING
+type PendingSummary struct {
+	Count     int
+	Min, Max  store.StreamID
+	Consumers []ConsumerPending // sorted by name
+}
+
+type ConsumerPending struct {
+	Name  string
+	Count int
+}
+
+// a pending entry as reported by XPENDING with a range
+type PendingInfo struct {
+	ID            store.StreamID
+	Consumer      string
+	Idle          int64 // ms since the last delivery
+	DeliveryCount int
+}
+
+// a range of pending entries asked for by XPENDING
+type PendingQuery struct {
+	Start, End store.StreamID
+	Count      int
+	Consumer   string // only the entries of this consumer, all of them if empty
+	MinIdle    int64  // only the entries idle for at least this many ms
+}
+
+// the stream as reported by XINFO STREAM
+type StreamInfo struct {
+	Length          int
+	LastID          store.StreamID
+	MaxDeletedID    store.StreamID
+	EntriesAdded    uint64
+	RecordedFirstID store.StreamID
+	Groups          int
+	First, Last     *store.StreamEntry // nil if the stream is empty
+}
+
+// a group as reported by XINFO GROUPS
+type GroupInfo struct {
+	Name        string
+	Consumers   int
+	Pending     int
+	LastID      store.StreamID
+	EntriesRead int64 // -1 if it's unknown
+	Lag         int64 // entries not delivered to the group yet, -1 if it's unknown
+}
+
+// a consumer as reported by XINFO CONSUMERS
+type ConsumerInfo struct {
+	Name     string
+	Pending  int
+	Idle     int64 // ms since the last attempted interaction
+	Inactive int64 // ms since the last successful interaction, -1 if there was none
+}
+
+// returns the stream of the key and its group
+// a missing stream or group gives a NOGROUP error
+func (d *Db) getGroup(key, group string) (*store.Stream, *store.ConsumerGroup, error) {
+	s, err := d.getStream(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if s == nil || s.Groups[group] == nil {
+		return nil, nil, errNoGroup(key, group)
+	}
+	return s, s.Groups[group], nil
+}
+
+// creates the consumer group, which will deliver the entries after opts.ID
+func (d *Db) XGroupCreate(key, group string, opts XGroupOptions) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	s, err := d.getStream(key)
+	if err != nil {
+		return err
+	}
+	if s == nil {
+		if !opts.MkStream {
+			return ErrXGroupNoKey
+		}
+		s = store.NewStream()
+		d.store.Set(key, s)
+	}
+
+	if _, ok := s.Groups[group]; ok {
+		return ErrBusyGroup
+	}
+
+	id, entriesRead := groupPosition(s, opts)
+	s.Groups[group] = store.NewConsumerGroup(id, entriesRead)
+	d.notify(EventStream, "xgroup-create", key)
+	return nil
+}
+
+// moves the last delivered id of the group
+func (d *Db) XGroupSetID(key, group string, opts XGroupOptions) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	s, err := d.getStream(key)
+	if err != nil {
+		return err
+	}
+	if s == nil {
+		return ErrXGroupNoKey
+	}
+
+	g, ok := s.Groups[group]
+	if !ok {
+		return errNoGroup(key, group)
+	}
+	g.LastID, g.EntriesRead = groupPosition(s, opts)
+	d.notify(EventStream, "xgroup-setid", key)
+	return nil
+}
+
+// destroys the group along with its consumers and pending entries
+// returns false if there was no such group
+func (d *Db) XGroupDestroy(key, group string) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	s, err := d.getStream(key)
+	if err != nil {
+		return false, err
+	}
+	if s == nil {
+		return false, ErrXGroupNoKey
+	}
+
+	if _, ok := s.Groups[group]; !ok {
+		return false, nil
+	}
+	delete(s.Groups, group)
+	d.notify(EventStream, "xgroup-destroy", key)
+	// consumers blocked on the group get an error
+	d.signalReady(key)
+	return true, nil
+}
+
+// returns the last delivered id and entries read of a group placed as opts tell
+func groupPosition(s *store.Stream, opts XGroupOptions) (store.StreamID, int64) {
+	id := opts.ID
+	if opts.LastEntry {
+		id = s.LastID
+	}
+	if opts.HasEntriesRead {
+		return id, opts.EntriesRead
+	}
+	return id, estimateEntriesRead(s, id)
+}
+
+// reads entries for the consumer of the group, the consumer is created if needed
+// a New id reads entries never delivered to the group, and makes them pending unless noAck is set
+// any other id reads again the entries pending for the consumer after it,
+// entries deleted from the stream since then come back without fields
+// at most count entries are read from each stream unless count is 0
+func (d *Db) XReadGroup(group, consumer string, keys []string, ids []XReadGroupID, count int, noAck bool) ([]StreamRead, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	groups := make([]*store.ConsumerGroup, len(keys))
+	streams := make([]*store.Stream, len(keys))
+	for i, key := range keys {
+		s, g, err := d.getGroup(key, group)
+		if err != nil {
+			return nil, err
+		}
+		streams[i], groups[i] = s, g
+	}
+
+	now := d.nowMs()
+	out := []StreamRead{}
+	for i, s := range streams {
+		g := groups[i]
+		c := g.Consumer(consumer, now)
+
+		if !ids[i].New {
+			out = append(out, StreamRead{Key: keys[i], Entries: pendingEntries(s, c, ids[i].ID, count)})
+			continue
+		}
+
+		start, ok := g.LastID.Next()
+		if !ok {
+			continue
+		}
+		entries := s.Range(start, store.MaxStreamID, readCount(count), false)
+		if len(entries) == 0 {
+			continue
+		}
+
+		for _, e := range entries {
+			if g.EntriesRead >= 0 && !hasTombstonesAfter(s, g.LastID) {
+				g.EntriesRead++
+			} else {
+				g.EntriesRead = estimateEntriesRead(s, e.ID)
+			}
+			g.LastID = e.ID
+
+			if !noAck {
+				g.Deliver(e.ID, consumer, now)
+			}
+		}
+		c.ActiveTime = now
+		out = append(out, StreamRead{Key: keys[i], Entries: entries})
+	}
+	return out, nil
+}
+
+// returns the entries pending for the consumer with an id greater than after
+func pendingEntries(s *store.Stream, c *store.Consumer, after store.StreamID, count int) []store.StreamEntry {
+	out := []store.StreamEntry{}
+	for _, id := range c.PendingIDs() {
+		if id.Compare(after) <= 0 {
+			continue
+		}
+		if count > 0 && len(out) == count {
+			break
+		}
+
+		e, ok := s.Get(id)
+		if !ok {
+			e = store.StreamEntry{ID: id}
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+// acknowledges the entries, returns the number of entries which were pending
+func (d *Db) XAck(key, group string, ids ...store.StreamID) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	s, err := d.getStream(key)
+	if err != nil || s == nil || s.Groups[group] == nil {
+		return 0, err
+	}
+
+	n := 0
+	for _, id := range ids {
+		if s.Groups[group].Ack(id) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// returns a summary of the pending entries of the group
+func (d *Db) XPendingSummary(key, group string) (PendingSummary, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	_, g, err := d.getGroup(key, group)
+	if err != nil {
+		return PendingSummary{}, err
+	}
+
+	ids := g.PendingIDs()
+	summary := PendingSummary{Count: len(ids)}
+	if len(ids) == 0 {
+		return summary, nil
+	}
+	summary.Min, summary.Max = ids[0], ids[len(ids)-1]
+
+	for name, c := range g.Consumers {
+		if len(c.Pending) > 0 {
+			summary.Consumers = append(summary.Consumers, ConsumerPending{Name: name, Count: len(c.Pending)})
+		}
+	}
+	slices.SortFunc(summary.Consumers, func(a, b ConsumerPending) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return summary, nil
+}
+
+// returns the pending entries of the group matching the query, in order
+func (d *Db) XPending(key, group string, q PendingQuery) ([]PendingInfo, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	_, g, err := d.getGroup(key, group)
+	if err != nil {
+		return nil, err
+	}
+
+	now := d.nowMs()
+	out := []PendingInfo{}
+	for _, id := range g.PendingIDs() {
+		if len(out) == q.Count {
+			break
+		}
+		if id.Compare(q.Start) < 0 || id.Compare(q.End) > 0 {
+			continue
+		}
+
+		p := g.Pending[id]
+		idle := now - p.DeliveryTime
+		if (q.Consumer != "" && p.Consumer != q.Consumer) || idle < q.MinIdle {
+			continue
+		}
+		out = append(out, PendingInfo{ID: id, Consumer: p.Consumer, Idle: idle, DeliveryCount: p.DeliveryCount})
+	}
+	return out, nil
+}
+
+// gives the pending entries idle for at least opts.MinIdle ms to the consumer
+// entries deleted from the stream are dropped from the pending entries instead
+// returns the claimed entries
+func (d *Db) XClaim(key, group, consumer string, ids []store.StreamID, opts XClaimOptions) ([]store.StreamEntry, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	s, g, err := d.getGroup(key, group)
+	if err != nil {
+		return nil, err
+	}
+
+	now := d.nowMs()
+	if opts.HasLastID && opts.LastID.Compare(g.LastID) > 0 {
+		g.LastID = opts.LastID
+	}
+
+	deliveryTime := now
+	switch {
+	case opts.Time >= 0:
+		deliveryTime = opts.Time
+	case opts.Idle >= 0:
+		deliveryTime = now - opts.Idle
+	}
+
+	c := g.Consumer(consumer, now)
+	out := []store.StreamEntry{}
+	for _, id := range ids {
+		e, inStream := s.Get(id)
+		p, pending := g.Pending[id]
+		switch {
+		case !pending && !(opts.Force && inStream):
+			continue
+		case !inStream:
+			g.Ack(id)
+			continue
+		case pending && now-p.DeliveryTime < opts.MinIdle:
+			continue
+		}
+
+		p = g.Claim(id, consumer, deliveryTime)
+		if opts.RetryCount >= 0 {
+			p.DeliveryCount = opts.RetryCount
+		} else if !opts.JustID {
+			p.DeliveryCount++
+		}
+		out = append(out, e)
+	}
+
+	if len(out) > 0 {
+		c.ActiveTime = now
+	}
+	return out, nil
+}
+
+// scans the pending entries from start and claims up to count of those idle for at least minIdle ms
+// returns the id to continue the scan from, 0-0 once it's done, the claimed entries
+// and the ids of the entries which were deleted from the stream, and so dropped from the pending entries
+func (d *Db) XAutoClaim(key, group, consumer string, minIdle int64, start store.StreamID, count int, justID bool) (store.StreamID, []store.StreamEntry, []store.StreamID, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	s, g, err := d.getGroup(key, group)
+	if err != nil {
+		return store.StreamID{}, nil, nil, err
+	}
+
+	now := d.nowMs()
+	c := g.Consumer(consumer, now)
+
+	// like redis, a scan looks at no more than 10 entries for each one it may claim
+	attempts := count * 10
+	claimed, deleted := []store.StreamEntry{}, []store.StreamID{}
+	next := store.StreamID{}
+
+	ids := g.PendingIDs()
+	for i, id := range ids {
+		if id.Compare(start) < 0 {
+			continue
+		}
+		if attempts == 0 || len(claimed) == count {
+			next = ids[i]
+			break
+		}
+		attempts--
+
+		e, ok := s.Get(id)
+		if !ok {
+			g.Ack(id)
+			deleted = append(deleted, id)
+			continue
+		}
+		if now-g.Pending[id].DeliveryTime < minIdle {
+			continue
+		}
+
+		p := g.Claim(id, consumer, now)
+		if !justID {
+			p.DeliveryCount++
+		}
+		claimed = append(claimed, e)
+	}
+
+	if len(claimed) > 0 {
+		c.ActiveTime = now
+	}
+	return next, claimed, deleted, nil
+}
+
+func (d *Db) XInfoStream(key string) (StreamInfo, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	s, err := d.getStream(key)
+	if err != nil {
+		return StreamInfo{}, err
+	}
+	if s == nil {
+		return StreamInfo{}, ErrNoSuchKey
+	}
+
+	info := StreamInfo{
+		Length:       s.Len(),
+		LastID:       s.LastID,
+		MaxDeletedID: s.MaxDeletedID,
+		EntriesAdded: s.EntriesAdded,
+		Groups:       len(s.Groups),
+	}
+	if first, last, ok := s.Ends(); ok {
+		info.RecordedFirstID = first.ID
+		info.First, info.Last = &first, &last
+	}
+	return info, nil
+}
+
+// returns the groups of the stream sorted by name
+func (d *Db) XInfoGroups(key string) ([]GroupInfo, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	s, err := d.getStream(key)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		return nil, ErrNoSuchKey
+	}
+
+	out := []GroupInfo{}
+	for name, g := range s.Groups {
+		out = append(out, GroupInfo{
+			Name:        name,
+			Consumers:   len(g.Consumers),
+			Pending:     len(g.Pending),
+			LastID:      g.LastID,
+			EntriesRead: g.EntriesRead,
+			Lag:         groupLag(s, g),
+		})
+	}
+	slices.SortFunc(out, func(a, b GroupInfo) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return out, nil
+}
+
+// returns the consumers of the group sorted by name
+func (d *Db) XInfoConsumers(key, group string) ([]ConsumerInfo, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	_, g, err := d.getGroup(key, group)
+	if err != nil {
+		return nil, err
+	}
+
+	now := d.nowMs()
+	out := []ConsumerInfo{}
+	for name, c := range g.Consumers {
+		info := ConsumerInfo{Name: name, Pending: len(c.Pending), Idle: now - c.SeenTime, Inactive: -1}
+		if c.ActiveTime >= 0 {
+			info.Inactive = now - c.ActiveTime
+		}
+		out = append(out, info)
+	}
+	slices.SortFunc(out, func(a, b ConsumerInfo) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return out, nil
+}
+
+// estimates the number of entries added to the stream up to id, -1 if it can't be known
+// this is streamEstimateDistanceFromFirstEverEntry of redis
+func estimateEntriesRead(s *store.Stream, id store.StreamID) int64 {
+	if s.EntriesAdded == 0 {
+		return 0
+	}
+
+	first, _, ok := s.Ends()
+	if !ok || id.Compare(s.LastID) >= 0 {
+		return int64(s.EntriesAdded)
+	}
+
+	// without deleted entries among the ones left, everything before the first entry was read
+	if s.MaxDeletedID.Compare(first.ID) < 0 && id.Compare(first.ID) < 0 {
+		return int64(s.EntriesAdded) - int64(s.Len())
+	}
+	return -1
+}
+
+// returns the number of entries the group hasn't been delivered yet, -1 if it can't be known
+func groupLag(s *store.Stream, g *store.ConsumerGroup) int64 {
+	if s.EntriesAdded == 0 {
+		return 0
+	}
+	if g.EntriesRead >= 0 && !hasTombstonesAfter(s, g.LastID) {
+		return int64(s.EntriesAdded) - g.EntriesRead
+	}
+	if n := estimateEntriesRead(s, g.LastID); n >= 0 {
+		return int64(s.EntriesAdded) - n
+	}
+	return -1
+}
+
+// reports whether an entry after id was deleted, which breaks counting the entries read
+func hasTombstonesAfter(s *store.Stream, id store.StreamID) bool {
+	return !s.MaxDeletedID.IsZero() && s.MaxDeletedID.Compare(id) > 0
+}
@@ -0,0 +1,181 @@
+package db
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store"
+)
+
+// db of GetTestDBWithStream with the group "g" created at 0-0, and a clock which can be moved
+func GetTestDBWithGroup(clock *int64) *Db {
+	newDB := GetTestDBWithStream()
+	newDB.now = func() int64 { return *clock }
+	newDB.XGroupCreate("s", "g", XGroupOptions{})
+	return newDB
+}
+
+var readNew = []XReadGroupID{{New: true}}
+
+func TestXGroupCreate(t *testing.T) {
+	newDB := GetTestDBWithStream()
+
+	if err := newDB.XGroupCreate("s", "g", XGroupOptions{LastEntry: true}); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if err := newDB.XGroupCreate("s", "g", XGroupOptions{}); !errors.Is(err, ErrBusyGroup) {
+		t.Errorf("Expected %v but got %v", ErrBusyGroup, err)
+	}
+	if err := newDB.XGroupCreate("new", "g", XGroupOptions{}); !errors.Is(err, ErrXGroupNoKey) {
+		t.Errorf("Expected %v but got %v", ErrXGroupNoKey, err)
+	}
+	if err := newDB.XGroupCreate("new", "g", XGroupOptions{MkStream: true}); err != nil || newDB.Type("new") != "stream" {
+		t.Errorf("Expected MKSTREAM to create the stream but got %v", err)
+	}
+
+	groups, _ := newDB.XInfoGroups("s")
+	if len(groups) != 1 || groups[0].LastID != (store.StreamID{Ms: 1, Seq: 3}) || groups[0].EntriesRead != 3 || groups[0].Lag != 0 {
+		t.Errorf("Expected the group to start after the last entry but got %+v", groups)
+	}
+
+	if ok, _ := newDB.XGroupDestroy("s", "g"); !ok {
+		t.Errorf("Expected the group to be destroyed")
+	}
+	if ok, _ := newDB.XGroupDestroy("s", "g"); ok {
+		t.Errorf("Expected the group to be gone")
+	}
+}
+
+func TestXReadGroup(t *testing.T) {
+	clock := testNow
+	newDB := GetTestDBWithGroup(&clock)
+
+	reads, err := newDB.XReadGroup("g", "alice", []string{"s"}, readNew, 2, false)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if out := streamIDs(reads[0].Entries); !slices.Equal(out, []string{"1-1", "1-2"}) {
+		t.Errorf("Expected %v but got %v", []string{"1-1", "1-2"}, out)
+	}
+
+	reads, _ = newDB.XReadGroup("g", "bob", []string{"s"}, readNew, 0, false)
+	if out := streamIDs(reads[0].Entries); !slices.Equal(out, []string{"1-3"}) {
+		t.Errorf("Expected bob to get the entry left but got %v", out)
+	}
+	if reads, _ = newDB.XReadGroup("g", "bob", []string{"s"}, readNew, 0, false); len(reads) != 0 {
+		t.Errorf("Expected nothing new but got %v", reads)
+	}
+
+	// the history of a consumer shows deleted entries without fields
+	newDB.XDel("s", store.StreamID{Ms: 1, Seq: 1})
+	reads, _ = newDB.XReadGroup("g", "alice", []string{"s"}, []XReadGroupID{{}}, 0, false)
+	if entries := reads[0].Entries; len(entries) != 2 || entries[0].Fields != nil || entries[1].Fields == nil {
+		t.Errorf("Expected 1-1 without fields and 1-2 with its fields but got %v", entries)
+	}
+
+	if n, _ := newDB.XAck("s", "g", store.StreamID{Ms: 1, Seq: 1}, store.StreamID{Ms: 1, Seq: 1}, store.StreamID{Ms: 9}); n != 1 {
+		t.Errorf("Expected %d acknowledged entries but got %d", 1, n)
+	}
+
+	summary, _ := newDB.XPendingSummary("s", "g")
+	expConsumers := []ConsumerPending{{Name: "alice", Count: 1}, {Name: "bob", Count: 1}}
+	if summary.Count != 2 || summary.Min != (store.StreamID{Ms: 1, Seq: 2}) || !slices.Equal(summary.Consumers, expConsumers) {
+		t.Errorf("Expected 2 entries pending for alice and bob from 1-2 but got %+v", summary)
+	}
+
+	if _, err := newDB.XReadGroup("missing", "alice", []string{"s"}, readNew, 0, false); err == nil || !strings.HasPrefix(err.Error(), "NOGROUP") {
+		t.Errorf("Expected a NOGROUP error but got %v", err)
+	}
+}
+
+func TestXReadGroupNoAck(t *testing.T) {
+	clock := testNow
+	newDB := GetTestDBWithGroup(&clock)
+
+	newDB.XReadGroup("g", "alice", []string{"s"}, readNew, 0, true)
+	if summary, _ := newDB.XPendingSummary("s", "g"); summary.Count != 0 {
+		t.Errorf("Expected nothing pending but got %+v", summary)
+	}
+
+	groups, _ := newDB.XInfoGroups("s")
+	if groups[0].EntriesRead != 3 || groups[0].Lag != 0 {
+		t.Errorf("Expected 3 entries read and no lag but got %+v", groups[0])
+	}
+}
+
+func TestXPendingXClaim(t *testing.T) {
+	clock := testNow
+	newDB := GetTestDBWithGroup(&clock)
+	newDB.XReadGroup("g", "alice", []string{"s"}, readNew, 0, false)
+	clock += 1000
+
+	pending, _ := newDB.XPending("s", "g", PendingQuery{End: store.MaxStreamID, Count: 2, MinIdle: 500})
+	if len(pending) != 2 || pending[0].Idle != 1000 || pending[0].DeliveryCount != 1 || pending[0].Consumer != "alice" {
+		t.Errorf("Expected 2 entries idle for 1000 ms but got %+v", pending)
+	}
+
+	ids := []store.StreamID{{Ms: 1, Seq: 1}, {Ms: 1, Seq: 2}}
+	claimed, _ := newDB.XClaim("s", "g", "bob", ids, XClaimOptions{Idle: -1, Time: -1, RetryCount: -1, MinIdle: 2000})
+	if len(claimed) != 0 {
+		t.Errorf("Expected nothing idle enough to be claimed but got %v", claimed)
+	}
+
+	claimed, _ = newDB.XClaim("s", "g", "bob", ids, XClaimOptions{Idle: -1, Time: -1, RetryCount: -1, MinIdle: 1000})
+	if out := streamIDs(claimed); !slices.Equal(out, []string{"1-1", "1-2"}) {
+		t.Errorf("Expected %v but got %v", []string{"1-1", "1-2"}, out)
+	}
+
+	pending, _ = newDB.XPending("s", "g", PendingQuery{End: store.MaxStreamID, Count: 10, Consumer: "bob"})
+	if len(pending) != 2 || pending[0].Idle != 0 || pending[0].DeliveryCount != 2 {
+		t.Errorf("Expected bob to have 2 entries delivered twice but got %+v", pending)
+	}
+}
+
+func TestXAutoClaim(t *testing.T) {
+	clock := testNow
+	newDB := GetTestDBWithGroup(&clock)
+	newDB.XReadGroup("g", "alice", []string{"s"}, readNew, 0, false)
+	newDB.XDel("s", store.StreamID{Ms: 1, Seq: 2})
+	clock += 1000
+
+	next, claimed, deleted, err := newDB.XAutoClaim("s", "g", "bob", 1000, store.StreamID{}, 1, false)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if out := streamIDs(claimed); !slices.Equal(out, []string{"1-1"}) || next != (store.StreamID{Ms: 1, Seq: 2}) {
+		t.Errorf("Expected to claim 1-1 and continue from 1-2 but got %v and %v", out, next)
+	}
+
+	next, claimed, deleted, _ = newDB.XAutoClaim("s", "g", "bob", 1000, next, 1, true)
+	if out := streamIDs(claimed); !slices.Equal(out, []string{"1-3"}) || !next.IsZero() {
+		t.Errorf("Expected to claim 1-3 and finish the scan but got %v and %v", out, next)
+	}
+	if !slices.Equal(deleted, []store.StreamID{{Ms: 1, Seq: 2}}) {
+		t.Errorf("Expected 1-2 to be reported as deleted but got %v", deleted)
+	}
+
+	consumers, _ := newDB.XInfoConsumers("s", "g")
+	if len(consumers) != 2 || consumers[1].Name != "bob" || consumers[1].Pending != 2 || consumers[0].Pending != 0 {
+		t.Errorf("Expected bob to own both pending entries but got %+v", consumers)
+	}
+}
+
+func TestXInfoStream(t *testing.T) {
+	clock := testNow
+	newDB := GetTestDBWithGroup(&clock)
+	newDB.XDel("s", store.StreamID{Ms: 1, Seq: 3})
+
+	info, err := newDB.XInfoStream("s")
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if info.Length != 2 || info.EntriesAdded != 3 || info.Groups != 1 || info.Last.ID != (store.StreamID{Ms: 1, Seq: 2}) || info.MaxDeletedID != (store.StreamID{Ms: 1, Seq: 3}) {
+		t.Errorf("Unexpected stream info %+v", info)
+	}
+
+	if _, err := newDB.XInfoStream("missing"); !errors.Is(err, ErrNoSuchKey) {
+		t.Errorf("Expected %v but got %v", ErrNoSuchKey, err)
+	}
+}
@@ -0,0 +1,123 @@
+package db
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store"
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store/inMemoryStore"
+)
+
+// db with the clock stopped at testNow, the stream "s" with the entries 1-1 to 1-3 and the string "str"
+func GetTestDBWithStream() *Db {
+	m := inMemoryStore.NewInMemoryStore()
+	s := store.NewStream()
+	for seq := range 3 {
+		s.Add(store.StreamID{Ms: 1, Seq: uint64(seq + 1)}, []string{"n", string(rune('a' + seq))})
+	}
+	m.Set("s", s)
+	m.Set("str", store.String("bar"))
+	return &Db{store: m, now: func() int64 { return testNow }}
+}
+
+func streamIDs(entries []store.StreamEntry) []string {
+	out := []string{}
+	for _, e := range entries {
+		out = append(out, e.ID.String())
+	}
+	return out
+}
+
+func TestXAdd(t *testing.T) {
+	testCases := []struct {
+		name   string
+		key    string
+		id     XAddID
+		opts   XAddOptions
+		expID  string
+		expOk  bool
+		expErr error
+	}{
+		{name: "generated id", key: "s", id: XAddID{AutoMs: true}, expID: "1700000000000-0", expOk: true},
+		{name: "generated seq", key: "s", id: XAddID{ID: store.StreamID{Ms: 1}, AutoSeq: true}, expID: "1-4", expOk: true},
+		{name: "generated seq of a new ms", key: "s", id: XAddID{ID: store.StreamID{Ms: 7}, AutoSeq: true}, expID: "7-0", expOk: true},
+		{name: "generated seq of a smaller ms", key: "s", id: XAddID{ID: store.StreamID{Ms: 0}, AutoSeq: true}, expErr: ErrStreamIDTooSmall},
+		{name: "explicit id", key: "s", id: XAddID{ID: store.StreamID{Ms: 2, Seq: 5}}, expID: "2-5", expOk: true},
+		{name: "explicit id too small", key: "s", id: XAddID{ID: store.StreamID{Ms: 1, Seq: 3}}, expErr: ErrStreamIDTooSmall},
+		{name: "0-0 is never valid", key: "new", id: XAddID{}, expErr: ErrStreamIDZero},
+		{name: "0-* on a new stream", key: "new", id: XAddID{AutoSeq: true}, expID: "0-1", expOk: true},
+		{name: "NOMKSTREAM", key: "new", id: XAddID{AutoMs: true}, opts: XAddOptions{NoMkStream: true}},
+		{name: "wrong type", key: "str", id: XAddID{AutoMs: true}, expErr: ErrWrongType},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			newDB := GetTestDBWithStream()
+
+			id, ok, err := newDB.XAdd(tc.key, tc.id, []string{"f", "v"}, tc.opts)
+			if !errors.Is(err, tc.expErr) {
+				t.Fatalf("Expected error %v but got %v", tc.expErr, err)
+			}
+			if ok != tc.expOk || (ok && id.String() != tc.expID) {
+				t.Errorf("Expected %q, %v but got %q, %v", tc.expID, tc.expOk, id, ok)
+			}
+		})
+	}
+
+	t.Run("NOMKSTREAM doesn't create the key", func(t *testing.T) {
+		newDB := GetTestDBWithStream()
+		newDB.XAdd("new", XAddID{AutoMs: true}, []string{"f", "v"}, XAddOptions{NoMkStream: true})
+		if typ := newDB.Type("new"); typ != "none" {
+			t.Errorf("Expected the key to be missing but its type is %s", typ)
+		}
+	})
+
+	t.Run("trims after adding", func(t *testing.T) {
+		newDB := GetTestDBWithStream()
+		trim := StreamTrim{Strategy: TrimMaxLen, MaxLen: 2}
+		newDB.XAdd("s", XAddID{AutoMs: true}, []string{"f", "v"}, XAddOptions{Trim: trim})
+
+		entries, _ := newDB.XRange("s", store.StreamID{}, store.MaxStreamID, -1, false)
+		if out := streamIDs(entries); !slices.Equal(out, []string{"1-3", "1700000000000-0"}) {
+			t.Errorf("Expected the two newest entries but got %v", out)
+		}
+	})
+}
+
+func TestXDelXTrim(t *testing.T) {
+	newDB := GetTestDBWithStream()
+
+	if n, _ := newDB.XDel("s", store.StreamID{Ms: 1, Seq: 2}, store.StreamID{Ms: 9}); n != 1 {
+		t.Errorf("Expected %d deleted entries but got %d", 1, n)
+	}
+	if n, _ := newDB.XTrim("s", StreamTrim{Strategy: TrimMinID, MinID: store.StreamID{Ms: 1, Seq: 3}}); n != 1 {
+		t.Errorf("Expected %d evicted entries but got %d", 1, n)
+	}
+	if n, _ := newDB.XTrim("s", StreamTrim{Strategy: TrimMaxLen}); n != 1 {
+		t.Errorf("Expected %d evicted entries but got %d", 1, n)
+	}
+	if typ := newDB.Type("s"); typ != "stream" {
+		t.Errorf("Expected an empty stream to be kept but its type is %s", typ)
+	}
+}
+
+func TestXRead(t *testing.T) {
+	newDB := GetTestDBWithStream()
+	newDB.XAdd("t", XAddID{ID: store.StreamID{Ms: 5}}, []string{"f", "v"}, XAddOptions{})
+
+	reads, err := newDB.XRead([]string{"s", "t", "missing"}, []store.StreamID{{Ms: 1, Seq: 1}, {Ms: 5}, {}}, 1)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if len(reads) != 1 || reads[0].Key != "s" {
+		t.Fatalf("Expected to read only from s but got %v", reads)
+	}
+	if out := streamIDs(reads[0].Entries); !slices.Equal(out, []string{"1-2"}) {
+		t.Errorf("Expected %v but got %v", []string{"1-2"}, out)
+	}
+
+	if _, err := newDB.XRead([]string{"str"}, []store.StreamID{{}}, 0); !errors.Is(err, ErrWrongType) {
+		t.Errorf("Expected %v but got %v", ErrWrongType, err)
+	}
+}
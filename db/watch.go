@@ -0,0 +1,62 @@
+package db
+
+// like redis, only the keys some client watches are tracked
+// every write to such a key bumps its version, so a client can tell whether the key was touched since WATCH
+
+type watchedKey struct {
+	watchers int    // number of clients watching the key
+	version  uint64 // bumped by every write to the key
+}
+
+// marks the key as watched by one more client, returns its current version
+func (d *Db) Watch(key string) uint64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// a key which expired before WATCH isn't touched by its later deletion
+	d.expireIfNeeded(key)
+
+	if d.watched == nil {
+		d.watched = make(map[string]*watchedKey)
+	}
+	w, ok := d.watched[key]
+	if !ok {
+		w = &watchedKey{}
+		d.watched[key] = w
+	}
+	w.watchers++
+	return w.version
+}
+
+// marks the key as watched by one client less
+func (d *Db) Unwatch(key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	w, ok := d.watched[key]
+	if !ok {
+		return
+	}
+	if w.watchers--; w.watchers <= 0 {
+		delete(d.watched, key)
+	}
+}
+
+// reports whether the key was written, expired or flushed since WATCH returned version
+func (d *Db) Touched(key string, version uint64) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// a key whose timeout passed counts as touched even if nobody deleted it yet
+	d.expireIfNeeded(key)
+
+	w, ok := d.watched[key]
+	return !ok || w.version != version
+}
+
+// bumps the version of the key if a client watches it
+func (d *Db) touch(key string) {
+	if w, ok := d.watched[key]; ok {
+		w.version++
+	}
+}
@@ -0,0 +1,63 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store"
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store/inMemoryStore"
+)
+
+func TestWatch(t *testing.T) {
+	testCases := []struct {
+		name       string
+		write      func(d *Db)
+		expTouched bool
+	}{
+		{name: "untouched", write: func(d *Db) {}},
+		{name: "other key written", write: func(d *Db) { d.Set("other", "v") }},
+		{name: "read only", write: func(d *Db) { d.Get("k"); d.TTL("k") }},
+		{name: "write not done", write: func(d *Db) { d.SetWithOptions("k", "v", SetOptions{Cond: SetNX}) }},
+		{name: "written", write: func(d *Db) { d.Set("k", "new") }, expTouched: true},
+		{name: "deleted", write: func(d *Db) { d.Del("k") }, expTouched: true},
+		{name: "timeout set", write: func(d *Db) { d.Expire("k", testNow+1000, ExpireAlways) }, expTouched: true},
+		{name: "expired", write: func(d *Db) { d.store.SetExpiry("k", testNow) }, expTouched: true},
+		{name: "flushed", write: func(d *Db) { d.Flush() }, expTouched: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			newDB := &Db{store: inMemoryStore.NewInMemoryStore(), now: func() int64 { return testNow }}
+			newDB.Set("k", "v")
+
+			version := newDB.Watch("k")
+			tc.write(newDB)
+
+			if touched := newDB.Touched("k", version); touched != tc.expTouched {
+				t.Errorf("Expected touched to be %v but got %v", tc.expTouched, touched)
+			}
+		})
+	}
+
+	t.Run("missing key created", func(t *testing.T) {
+		newDB := GetNewDB(inMemoryStore.NewInMemoryStore())
+		version := newDB.Watch("z")
+		newDB.ZAdd("z", ZAddOptions{}, store.ZItem{Member: "a", Score: 1})
+		if !newDB.Touched("z", version) {
+			t.Errorf("Expected the key to be touched")
+		}
+	})
+
+	t.Run("forgotten once unwatched by everyone", func(t *testing.T) {
+		newDB := GetNewDB(inMemoryStore.NewInMemoryStore())
+		newDB.Watch("k")
+		version := newDB.Watch("k")
+		newDB.Unwatch("k")
+		if newDB.Touched("k", version) {
+			t.Errorf("Expected the key to be watched by the second client still")
+		}
+		newDB.Unwatch("k")
+		if len(newDB.watched) != 0 {
+			t.Errorf("Expected no watched keys but got %v", newDB.watched)
+		}
+	})
+}
@@ -0,0 +1,445 @@
+package db
+
+import (
+	"errors"
+	"math"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store"
+)
+
+var ErrScoreNaN = errors.New("resulting score is not a number (NaN)")
+
+// options of the ZADD command, parsed by the server
+type ZAddOptions struct {
+	Cond SetCond // NX only adds new members, XX only updates existing ones
+	GT   bool    // only updates a score if the new one is greater
+	LT   bool    // only updates a score if the new one is less
+	CH   bool    // counts the updated members along with the added ones
+}
+
+// how ZRANGE picks its items
+type ZRangeBy int
+
+const (
+	ZRangeByRank ZRangeBy = iota
+	ZRangeByScore
+	ZRangeByLex
+)
+
+// a ZRANGE query, parsed by the server
+type ZRangeQuery struct {
+	By          ZRangeBy
+	Start, Stop int // ranks for ZRangeByRank, negative ones count from the end
+	Score       store.ScoreRange
+	Lex         store.LexRange
+	Rev         bool // ranks and walks from the highest score down
+	Offset      int  // items to skip, from LIMIT
+	Count       int  // items to return at most, negative for all of them
+}
+
+// how ZUNIONSTORE and ZINTERSTORE combine the scores of a member
+type ZAggregate int
+
+const (
+	ZAggSum ZAggregate = iota
+	ZAggMin
+	ZAggMax
+)
+
+// returns the sorted set of the key, nil if the key is missing
+// or ErrWrongType if it holds another type
+func (d *Db) getZSet(key string) (*store.ZSet, error) {
+	d.expireIfNeeded(key)
+
+	val, ok := d.store.Get(key)
+	if !ok {
+		return nil, nil
+	}
+
+	z, ok := val.(*store.ZSet)
+	if !ok {
+		return nil, ErrWrongType
+	}
+	return z, nil
+}
+
+// returns whether a member with the score cur may move to score, as opts allow
+func (opts ZAddOptions) allows(exists bool, cur, score float64) bool {
+	switch {
+	case exists && opts.Cond == SetNX, !exists && opts.Cond == SetXX:
+		return false
+	case !exists:
+		return true
+	}
+	return (!opts.GT || score > cur) && (!opts.LT || score < cur)
+}
+
+// adds the members with their scores, or updates the scores of existing ones
+// returns the number of added members, and of updated ones too with opts.CH
+func (d *Db) ZAdd(key string, opts ZAddOptions, items ...store.ZItem) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	z, err := d.getZSet(key)
+	if err != nil || (z == nil && opts.Cond == SetXX) {
+		return 0, err
+	}
+	if z == nil {
+		z = store.NewZSet()
+		d.store.Set(key, z)
+	}
+
+	n, changed := 0, false
+	for _, item := range items {
+		cur, exists := z.Score(item.Member)
+		if !opts.allows(exists, cur, item.Score) {
+			continue
+		}
+		if !exists || (opts.CH && cur != item.Score) {
+			n++
+		}
+		changed = changed || !exists || cur != item.Score
+		z.Add(item.Member, item.Score)
+	}
+	if changed {
+		d.notify(EventZSet, "zadd", key)
+	}
+	d.signalReady(key)
+	return n, nil
+}
+
+// increments the score of the member, a missing member counts as 0
+// returns the new score, or false if opts didn't allow the update
+func (d *Db) ZIncrBy(key string, opts ZAddOptions, member string, incr float64) (float64, bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	z, err := d.getZSet(key)
+	if err != nil {
+		return 0, false, err
+	}
+
+	var cur float64
+	exists := false
+	if z != nil {
+		cur, exists = z.Score(member)
+	}
+
+	score := cur + incr
+	if math.IsNaN(score) {
+		return 0, false, ErrScoreNaN
+	}
+	if !opts.allows(exists, cur, score) {
+		return 0, false, nil
+	}
+
+	if z == nil {
+		z = store.NewZSet()
+		d.store.Set(key, z)
+	}
+	z.Add(member, score)
+	d.notify(EventZSet, "zincr", key)
+	d.signalReady(key)
+	return score, true, nil
+}
+
+// removes the members, and the key along with the last one
+// returns the number of removed members
+func (d *Db) ZRem(key string, members ...string) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	z, err := d.getZSet(key)
+	if err != nil || z == nil {
+		return 0, err
+	}
+
+	n := 0
+	for _, m := range members {
+		if z.Remove(m) {
+			n++
+		}
+	}
+
+	if n > 0 {
+		d.notify(EventZSet, "zrem", key)
+	}
+	if z.Len() == 0 {
+		d.store.Del(key)
+		d.notify(EventGeneric, "del", key)
+	}
+	return n, nil
+}
+
+func (d *Db) ZScore(key, member string) (float64, bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	z, err := d.getZSet(key)
+	if err != nil || z == nil {
+		return 0, false, err
+	}
+
+	score, ok := z.Score(member)
+	return score, ok, nil
+}
+
+// returns the scores of the members, and whether each of them exists
+func (d *Db) ZMScore(key string, members ...string) ([]float64, []bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	z, err := d.getZSet(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	scores := make([]float64, len(members))
+	found := make([]bool, len(members))
+	if z != nil {
+		for i, m := range members {
+			scores[i], found[i] = z.Score(m)
+		}
+	}
+	return scores, found, nil
+}
+
+func (d *Db) ZCard(key string) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	z, err := d.getZSet(key)
+	if err != nil || z == nil {
+		return 0, err
+	}
+	return z.Len(), nil
+}
+
+// returns the number of members with a score in r
+func (d *Db) ZCount(key string, r store.ScoreRange) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	z, err := d.getZSet(key)
+	if err != nil || z == nil {
+		return 0, err
+	}
+	return z.CountByScore(r), nil
+}
+
+// returns the 0 based rank of the member and its score, rev ranks from the highest score down
+func (d *Db) ZRank(key, member string, rev bool) (int, float64, bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	z, err := d.getZSet(key)
+	if err != nil || z == nil {
+		return 0, 0, false, err
+	}
+
+	rank, ok := z.Rank(member)
+	if !ok {
+		return 0, 0, false, nil
+	}
+	if rev {
+		rank = z.Len() - 1 - rank
+	}
+	score, _ := z.Score(member)
+	return rank, score, true, nil
+}
+
+// returns the items picked by the query, empty if the key is missing
+func (d *Db) ZRange(key string, q ZRangeQuery) ([]store.ZItem, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	z, err := d.getZSet(key)
+	if err != nil || z == nil {
+		return []store.ZItem{}, err
+	}
+	return zrange(z, q), nil
+}
+
+// stores the items picked by the query at dst, replacing whatever dst held
+// returns the number of stored items
+func (d *Db) ZRangeStore(dst, src string, q ZRangeQuery) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	z, err := d.getZSet(src)
+	if err != nil {
+		return 0, err
+	}
+
+	out := store.NewZSet()
+	if z != nil {
+		for _, item := range zrange(z, q) {
+			out.Add(item.Member, item.Score)
+		}
+	}
+
+	d.storeZSet(dst, out, "zrangestore")
+	return out.Len(), nil
+}
+
+// pops up to count members with the lowest scores, or the highest ones with max
+func (d *Db) ZPop(key string, count int, max bool) ([]store.ZItem, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	z, err := d.getZSet(key)
+	if err != nil || z == nil || count == 0 {
+		return []store.ZItem{}, err
+	}
+
+	items := z.Range(0, min(count, z.Len())-1, max)
+	for _, item := range items {
+		z.Remove(item.Member)
+	}
+
+	if len(items) > 0 && max {
+		d.notify(EventZSet, "zpopmax", key)
+	} else if len(items) > 0 {
+		d.notify(EventZSet, "zpopmin", key)
+	}
+	if z.Len() == 0 {
+		d.store.Del(key)
+		d.notify(EventGeneric, "del", key)
+	}
+	return items, nil
+}
+
+// stores the union or intersection of the sorted sets at dst, replacing whatever dst held
+// the scores of each input are multiplied by its weight, then combined by agg
+// sets may be given as inputs too, their members score 1
+// returns the number of members in the result
+func (d *Db) ZSetAlgebraStore(op SetOp, dst string, keys []string, weights []float64, agg ZAggregate) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	inputs := make([]map[string]float64, len(keys))
+	for i, key := range keys {
+		scores, err := d.zsetScores(key)
+		if err != nil {
+			return 0, err
+		}
+
+		w := 1.0
+		if i < len(weights) {
+			w = weights[i]
+		}
+		for m, score := range scores {
+			scores[m] = zeroIfNaN(score * w)
+		}
+		inputs[i] = scores
+	}
+
+	result := map[string]float64{}
+	for i, scores := range inputs {
+		for m, score := range scores {
+			cur, ok := result[m]
+			switch {
+			case op == SetOpInter && i > 0 && !ok:
+				// the member is missing from an earlier input
+			case !ok:
+				result[m] = score
+			default:
+				result[m] = aggregate(agg, cur, score)
+			}
+		}
+
+		if op == SetOpInter {
+			for m := range result {
+				if _, ok := scores[m]; !ok {
+					delete(result, m)
+				}
+			}
+		}
+	}
+
+	out := store.NewZSet()
+	for m, score := range result {
+		out.Add(m, score)
+	}
+	d.storeZSet(dst, out, op.storeEvent("z"))
+	return out.Len(), nil
+}
+
+// returns a copy of the scores of a sorted set, or of a set with every member scoring 1
+func (d *Db) zsetScores(key string) (map[string]float64, error) {
+	d.expireIfNeeded(key)
+
+	scores := map[string]float64{}
+	val, ok := d.store.Get(key)
+	if !ok {
+		return scores, nil
+	}
+
+	switch v := val.(type) {
+	case *store.ZSet:
+		for _, item := range v.Items() {
+			scores[item.Member] = item.Score
+		}
+	case store.Set:
+		for m := range v {
+			scores[m] = 1
+		}
+	default:
+		return nil, ErrWrongType
+	}
+	return scores, nil
+}
+
+// replaces the key and its timeout with z, or just deletes it if z is empty
+// event is notified when z is stored
+func (d *Db) storeZSet(key string, z *store.ZSet, event string) {
+	_, existed := d.store.Get(key)
+	d.store.Del(key)
+	d.store.DelExpiry(key)
+	switch {
+	case z.Len() > 0:
+		d.store.Set(key, z)
+		d.notify(EventZSet, event, key)
+		d.signalReady(key)
+	case existed:
+		d.notify(EventGeneric, "del", key)
+	}
+}
+
+func zrange(z *store.ZSet, q ZRangeQuery) []store.ZItem {
+	if q.Offset < 0 {
+		return []store.ZItem{}
+	}
+
+	switch q.By {
+	case ZRangeByScore:
+		return z.RangeByScore(q.Score, q.Rev, q.Offset, q.Count)
+	case ZRangeByLex:
+		return z.RangeByLex(q.Lex, q.Rev, q.Offset, q.Count)
+	}
+
+	start, stop, ok := listRange(q.Start, q.Stop, z.Len())
+	if !ok {
+		return []store.ZItem{}
+	}
+	return z.Range(start, stop, q.Rev)
+}
+
+func aggregate(agg ZAggregate, a, b float64) float64 {
+	switch agg {
+	case ZAggMin:
+		return math.Min(a, b)
+	case ZAggMax:
+		return math.Max(a, b)
+	default:
+		return zeroIfNaN(a + b)
+	}
+}
+
+// inf * 0 and inf - inf give NaN, which redis turns into 0
+func zeroIfNaN(f float64) float64 {
+	if math.IsNaN(f) {
+		return 0
+	}
+	return f
+}
@@ -0,0 +1,287 @@
+package db
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store"
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store/inMemoryStore"
+)
+
+// db with the sorted sets "z" {a:1 b:2 c:3} and "y" {b:10 c:20 d:30},
+// the set "s" {c d} and the string "str"
+func GetTestDBWithZSets() *Db {
+	s := inMemoryStore.NewInMemoryStore()
+	s.Set("z", zsetOf(store.ZItem{Member: "a", Score: 1}, store.ZItem{Member: "b", Score: 2}, store.ZItem{Member: "c", Score: 3}))
+	s.Set("y", zsetOf(store.ZItem{Member: "b", Score: 10}, store.ZItem{Member: "c", Score: 20}, store.ZItem{Member: "d", Score: 30}))
+	s.Set("s", store.Set{"c": {}, "d": {}})
+	s.Set("str", store.String("bar"))
+	return &Db{store: s}
+}
+
+func zsetOf(items ...store.ZItem) *store.ZSet {
+	z := store.NewZSet()
+	for _, item := range items {
+		z.Add(item.Member, item.Score)
+	}
+	return z
+}
+
+func zitems(key string, d *Db) []store.ZItem {
+	items, _ := d.ZRange(key, ZRangeQuery{Start: 0, Stop: -1, Count: -1})
+	return items
+}
+
+func TestZAdd(t *testing.T) {
+	testCases := []struct {
+		name   string
+		opts   ZAddOptions
+		items  []store.ZItem
+		expN   int
+		expOut []store.ZItem
+	}{
+		{
+			name:   "adds and updates",
+			items:  []store.ZItem{{Member: "a", Score: 5}, {Member: "d", Score: 0}},
+			expN:   1,
+			expOut: []store.ZItem{{Member: "d", Score: 0}, {Member: "b", Score: 2}, {Member: "c", Score: 3}, {Member: "a", Score: 5}},
+		},
+		{
+			name:   "CH counts updates",
+			opts:   ZAddOptions{CH: true},
+			items:  []store.ZItem{{Member: "a", Score: 5}, {Member: "b", Score: 2}, {Member: "d", Score: 0}},
+			expN:   2,
+			expOut: []store.ZItem{{Member: "d", Score: 0}, {Member: "b", Score: 2}, {Member: "c", Score: 3}, {Member: "a", Score: 5}},
+		},
+		{
+			name:   "NX only adds",
+			opts:   ZAddOptions{Cond: SetNX},
+			items:  []store.ZItem{{Member: "a", Score: 5}, {Member: "d", Score: 0}},
+			expN:   1,
+			expOut: []store.ZItem{{Member: "d", Score: 0}, {Member: "a", Score: 1}, {Member: "b", Score: 2}, {Member: "c", Score: 3}},
+		},
+		{
+			name:   "XX only updates",
+			opts:   ZAddOptions{Cond: SetXX, CH: true},
+			items:  []store.ZItem{{Member: "a", Score: 5}, {Member: "d", Score: 0}},
+			expN:   1,
+			expOut: []store.ZItem{{Member: "b", Score: 2}, {Member: "c", Score: 3}, {Member: "a", Score: 5}},
+		},
+		{
+			name:   "GT only raises scores but still adds",
+			opts:   ZAddOptions{GT: true, CH: true},
+			items:  []store.ZItem{{Member: "a", Score: 0}, {Member: "b", Score: 4}, {Member: "d", Score: 0}},
+			expN:   2,
+			expOut: []store.ZItem{{Member: "d", Score: 0}, {Member: "a", Score: 1}, {Member: "c", Score: 3}, {Member: "b", Score: 4}},
+		},
+		{
+			name:   "LT only lowers scores",
+			opts:   ZAddOptions{LT: true, CH: true},
+			items:  []store.ZItem{{Member: "a", Score: 0}, {Member: "b", Score: 4}},
+			expN:   1,
+			expOut: []store.ZItem{{Member: "a", Score: 0}, {Member: "b", Score: 2}, {Member: "c", Score: 3}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			newDB := GetTestDBWithZSets()
+
+			n, err := newDB.ZAdd("z", tc.opts, tc.items...)
+			if err != nil {
+				t.Fatalf("Expected no error but got %v", err)
+			}
+			if n != tc.expN {
+				t.Errorf("Expected %d but got %d", tc.expN, n)
+			}
+			if out := zitems("z", newDB); !slices.Equal(out, tc.expOut) {
+				t.Errorf("Expected %v but got %v", tc.expOut, out)
+			}
+		})
+	}
+
+	t.Run("XX doesn't create the key", func(t *testing.T) {
+		newDB := GetTestDBWithZSets()
+		newDB.ZAdd("new", ZAddOptions{Cond: SetXX}, store.ZItem{Member: "a", Score: 1})
+		if typ := newDB.Type("new"); typ != "none" {
+			t.Errorf("Expected the key to be missing but its type is %s", typ)
+		}
+	})
+
+	t.Run("wrong type", func(t *testing.T) {
+		newDB := GetTestDBWithZSets()
+		if _, err := newDB.ZAdd("str", ZAddOptions{}, store.ZItem{Member: "a", Score: 1}); !errors.Is(err, ErrWrongType) {
+			t.Errorf("Expected %v but got %v", ErrWrongType, err)
+		}
+	})
+}
+
+func TestZIncrBy(t *testing.T) {
+	newDB := GetTestDBWithZSets()
+
+	if score, ok, _ := newDB.ZIncrBy("z", ZAddOptions{}, "a", 2.5); !ok || score != 3.5 {
+		t.Errorf("Expected %v but got %v", 3.5, score)
+	}
+	if score, ok, _ := newDB.ZIncrBy("new", ZAddOptions{}, "a", -1); !ok || score != -1 {
+		t.Errorf("Expected %v but got %v", -1, score)
+	}
+	if _, ok, _ := newDB.ZIncrBy("z", ZAddOptions{GT: true}, "a", -1); ok {
+		t.Errorf("Expected GT to refuse lowering the score")
+	}
+	if _, ok, _ := newDB.ZIncrBy("missing", ZAddOptions{Cond: SetXX}, "a", 1); ok || newDB.Type("missing") != "none" {
+		t.Errorf("Expected XX to refuse adding the member")
+	}
+
+	newDB.ZIncrBy("z", ZAddOptions{}, "b", math.Inf(1))
+	if _, _, err := newDB.ZIncrBy("z", ZAddOptions{}, "b", math.Inf(-1)); !errors.Is(err, ErrScoreNaN) {
+		t.Errorf("Expected %v but got %v", ErrScoreNaN, err)
+	}
+}
+
+func TestZRemZCard(t *testing.T) {
+	newDB := GetTestDBWithZSets()
+
+	if n, _ := newDB.ZRem("z", "a", "missing"); n != 1 {
+		t.Errorf("Expected %d removed members but got %d", 1, n)
+	}
+	if n, _ := newDB.ZCard("z"); n != 2 {
+		t.Errorf("Expected %d members but got %d", 2, n)
+	}
+
+	newDB.ZRem("z", "b", "c")
+	if typ := newDB.Type("z"); typ != "none" {
+		t.Errorf("Expected the key to be deleted along with its last member but its type is %s", typ)
+	}
+}
+
+func TestZRank(t *testing.T) {
+	newDB := GetTestDBWithZSets()
+
+	if rank, score, ok, _ := newDB.ZRank("z", "b", false); !ok || rank != 1 || score != 2 {
+		t.Errorf("Expected rank %d and score %v but got %d and %v", 1, 2, rank, score)
+	}
+	if rank, _, _, _ := newDB.ZRank("z", "a", true); rank != 2 {
+		t.Errorf("Expected rank %d but got %d", 2, rank)
+	}
+	if _, _, ok, _ := newDB.ZRank("z", "missing", false); ok {
+		t.Errorf("Expected the missing member to have no rank")
+	}
+}
+
+func TestZRange(t *testing.T) {
+	testCases := []struct {
+		name   string
+		q      ZRangeQuery
+		expOut []string
+	}{
+		{"by rank", ZRangeQuery{Start: 1, Stop: -1, Count: -1}, []string{"b", "c"}},
+		{"by rank reversed", ZRangeQuery{Start: 0, Stop: 1, Rev: true, Count: -1}, []string{"c", "b"}},
+		{"by rank out of range", ZRangeQuery{Start: 5, Stop: 10, Count: -1}, []string{}},
+		{"by score", ZRangeQuery{By: ZRangeByScore, Score: store.ScoreRange{Min: 1, Max: 3, MinEx: true}, Count: -1}, []string{"b", "c"}},
+		{"by score with limit", ZRangeQuery{By: ZRangeByScore, Score: store.ScoreRange{Min: math.Inf(-1), Max: math.Inf(1)}, Rev: true, Offset: 1, Count: 1}, []string{"b"}},
+		{"negative offset", ZRangeQuery{By: ZRangeByScore, Score: store.ScoreRange{Min: 1, Max: 3}, Offset: -1, Count: -1}, []string{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			newDB := GetTestDBWithZSets()
+
+			items, err := newDB.ZRange("z", tc.q)
+			if err != nil {
+				t.Fatalf("Expected no error but got %v", err)
+			}
+			out := []string{}
+			for _, item := range items {
+				out = append(out, item.Member)
+			}
+			if !slices.Equal(out, tc.expOut) {
+				t.Errorf("Expected %v but got %v", tc.expOut, out)
+			}
+		})
+	}
+}
+
+func TestZPop(t *testing.T) {
+	newDB := GetTestDBWithZSets()
+
+	if out, _ := newDB.ZPop("z", 2, true); !slices.Equal(out, []store.ZItem{{Member: "c", Score: 3}, {Member: "b", Score: 2}}) {
+		t.Errorf("Expected c and b but got %v", out)
+	}
+	if out, _ := newDB.ZPop("z", 5, false); !slices.Equal(out, []store.ZItem{{Member: "a", Score: 1}}) {
+		t.Errorf("Expected a but got %v", out)
+	}
+	if typ := newDB.Type("z"); typ != "none" {
+		t.Errorf("Expected the key to be deleted along with its last member but its type is %s", typ)
+	}
+}
+
+func TestZSetAlgebraStore(t *testing.T) {
+	testCases := []struct {
+		name    string
+		op      SetOp
+		keys    []string
+		weights []float64
+		agg     ZAggregate
+		expOut  []store.ZItem
+	}{
+		{
+			name:   "union sums",
+			op:     SetOpUnion,
+			keys:   []string{"z", "y"},
+			expOut: []store.ZItem{{Member: "a", Score: 1}, {Member: "b", Score: 12}, {Member: "c", Score: 23}, {Member: "d", Score: 30}},
+		},
+		{
+			name:    "union with weights and max",
+			op:      SetOpUnion,
+			keys:    []string{"z", "y"},
+			weights: []float64{10, 1},
+			agg:     ZAggMax,
+			expOut:  []store.ZItem{{Member: "a", Score: 10}, {Member: "b", Score: 20}, {Member: "c", Score: 30}, {Member: "d", Score: 30}},
+		},
+		{
+			name:   "intersection with min",
+			op:     SetOpInter,
+			keys:   []string{"z", "y"},
+			agg:    ZAggMin,
+			expOut: []store.ZItem{{Member: "b", Score: 2}, {Member: "c", Score: 3}},
+		},
+		{
+			name:   "intersection with a set",
+			op:     SetOpInter,
+			keys:   []string{"y", "s"},
+			expOut: []store.ZItem{{Member: "c", Score: 21}, {Member: "d", Score: 31}},
+		},
+		{
+			name:   "intersection with a missing key",
+			op:     SetOpInter,
+			keys:   []string{"z", "missing"},
+			expOut: []store.ZItem{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			newDB := GetTestDBWithZSets()
+
+			n, err := newDB.ZSetAlgebraStore(tc.op, "z", tc.keys, tc.weights, tc.agg)
+			if err != nil {
+				t.Fatalf("Expected no error but got %v", err)
+			}
+			if n != len(tc.expOut) {
+				t.Errorf("Expected %d members but got %d", len(tc.expOut), n)
+			}
+			if out := zitems("z", newDB); !slices.Equal(out, tc.expOut) {
+				t.Errorf("Expected %v but got %v", tc.expOut, out)
+			}
+		})
+	}
+
+	t.Run("wrong type", func(t *testing.T) {
+		newDB := GetTestDBWithZSets()
+		if _, err := newDB.ZSetAlgebraStore(SetOpUnion, "dst", []string{"z", "str"}, nil, ZAggSum); !errors.Is(err, ErrWrongType) {
+			t.Errorf("Expected %v but got %v", ErrWrongType, err)
+		}
+	})
+}
@@ -1,13 +1,16 @@
 package main
 
 // run the app using make run
-// load 50k key-val pairs in the go-redis
+// load 50k key-val pairs in the go-redis, pipelined over a few connections
+// and then over a single connection in one round trip
 // then do the following:
 // 1. time to fetch an already existing key
 // 2. time to set a new key
 // 3. time to get a newly set key
 
 import (
+	"bufio"
+	"bytes"
 	"fmt"
 	"log"
 	"net"
@@ -44,6 +47,7 @@ func main() {
 	// wg.Wait()
 
 	// loading 50k key-val pairs into go-redis
+	// every batch is pipelined over its own connection, ie. one round trip per batch
 	startTimeToLoad := time.Now()
 	for i := 0; i < 5; i++ {
 		numStart := i * BatchSize
@@ -52,16 +56,9 @@ func main() {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			for i := numStart; i <= numEnd; i++ {
-				// key := "key" + string(i)
-				key := fmt.Sprintf("key%d", i)
-				// val := string(i)
-				val := fmt.Sprintf("%d", i)
-
-				err := setKeyAndVal(key, val)
-				if err != nil {
-					log.Printf("error at number %d:%v\n", i, err)
-				}
+			err := loadKeys(numStart, numEnd)
+			if err != nil {
+				log.Printf("error while loading keys %d to %d:%v\n", numStart, numEnd, err)
 			}
 		}()
 	}
@@ -69,10 +66,18 @@ func main() {
 	timeElapsedToLoad := time.Since(startTimeToLoad)
 	log.Println("Time taken to load 50k key-val pairs: ", timeElapsedToLoad)
 
+	// loading 50k key-val pairs in a single round trip
+	startTimeToLoad = time.Now()
+	err := loadKeys(0, 5*BatchSize-1)
+	if err != nil {
+		log.Println("error while loading keys in a single round trip:", err)
+	}
+	log.Println("Time taken to load 50k key-val pairs in a single round trip: ", time.Since(startTimeToLoad))
+
 	// time taken to get a newly set key
 	id := BatchSize*3 - 1
 	existingKey := fmt.Sprintf("key%d", id)
-	existingVal := fmt.Sprintf("\"%d\"", id)
+	existingVal := fmt.Sprintf("%d", id)
 	timeElapsedToGetExistingKey := calcTimeForGetKey(existingKey, existingVal)
 	log.Printf("Time taken to get an existing key-val pair (id %d): %v\n", id, timeElapsedToGetExistingKey)
 
@@ -81,7 +86,7 @@ func main() {
 	log.Println("Time taken to set a key-val pair: ", timeElapsedToSet)
 
 	// time taken to get a newly set key
-	timeElapsedToGet := calcTimeForGetKey("load", "\"test\"")
+	timeElapsedToGet := calcTimeForGetKey("load", "test")
 	log.Println("Time taken to get a key-val pair: ", timeElapsedToGet)
 }
 
@@ -107,56 +112,109 @@ func calcTimeForGetKey(key, val string) time.Duration {
 	return time.Since(start)
 }
 
-func setKeyAndVal(key, val string) error {
-	buff := make([]byte, 1024)
-	data := []byte(fmt.Sprintf("SET %s %s", key, val))
+// writes a SET for every key in [numStart, numEnd] in one go, then reads all the replies
+func loadKeys(numStart, numEnd int) error {
+	conn, err := net.Dial("tcp", ServerAddr)
+	if err != nil {
+		return fmt.Errorf("error while establishing conn: %v", err)
+	}
+	defer conn.Close()
+
+	var buf bytes.Buffer
+	for i := numStart; i <= numEnd; i++ {
+		key := fmt.Sprintf("key%d", i)
+		val := fmt.Sprintf("%d", i)
+		buf.WriteString(encodeCommand("SET", key, val))
+	}
 
+	// replies are read while the requests are being written, so neither side blocks
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := conn.Write(buf.Bytes())
+		errCh <- err
+	}()
+
+	r := bufio.NewReader(conn)
+	for i := numStart; i <= numEnd; i++ {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("error while reading from conn object: %v", err)
+		}
+		if line != "+OK\r\n" {
+			return fmt.Errorf("expected %q but got %q", "+OK", line)
+		}
+	}
+
+	if err := <-errCh; err != nil {
+		return fmt.Errorf("error while writing to conn object: %v", err)
+	}
+	return nil
+}
+
+func setKeyAndVal(key, val string) error {
 	conn, err := net.Dial("tcp", ServerAddr)
 	if err != nil {
 		return fmt.Errorf("error while establishing conn: %v", err)
 	}
 	defer conn.Close()
 
-	_, err = conn.Write(data)
+	_, err = conn.Write([]byte(encodeCommand("SET", key, val)))
 	if err != nil {
 		return fmt.Errorf("error while writing to conn object: %v", err)
 	}
 
-	n, err := conn.Read(buff)
+	line, err := bufio.NewReader(conn).ReadString('\n')
 	if err != nil {
 		return fmt.Errorf("error while reading from conn object: %v", err)
 	}
 
-	if string(buff[:n]) != "OK\n" {
-		return fmt.Errorf("expected %q but got %q", "OK", string(buff[:n]))
+	if line != "+OK\r\n" {
+		return fmt.Errorf("expected %q but got %q", "+OK", line)
 	}
 
 	return nil
 }
 
 func getKey(key, val string) error {
-	buff := make([]byte, 1024)
-	data := []byte(fmt.Sprintf("GET %s", key))
-
 	conn, err := net.Dial("tcp", ServerAddr)
 	if err != nil {
 		return fmt.Errorf("error while establishing conn: %v", err)
 	}
 	defer conn.Close()
 
-	_, err = conn.Write(data)
+	_, err = conn.Write([]byte(encodeCommand("GET", key)))
 	if err != nil {
 		return fmt.Errorf("error while writing to conn object: %v", err)
 	}
 
-	n, err := conn.Read(buff)
+	// bulk string reply: $<len>\r\n<val>\r\n
+	r := bufio.NewReader(conn)
+	header, err := r.ReadString('\n')
 	if err != nil {
 		return fmt.Errorf("error while reading from conn object: %v", err)
 	}
+	expHeader := fmt.Sprintf("$%d\r\n", len(val))
+	if header != expHeader {
+		return fmt.Errorf("expected %q but got %q", expHeader, header)
+	}
 
-	if strings.TrimSpace(string(buff[:n])) != val {
-		return fmt.Errorf("expected %q but got %q", val, strings.TrimSpace(string(buff[:n])))
+	body, err := r.ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("error while reading from conn object: %v", err)
+	}
+	if strings.TrimSuffix(body, "\r\n") != val {
+		return fmt.Errorf("expected %q but got %q", val, strings.TrimSuffix(body, "\r\n"))
 	}
 
 	return nil
 }
+
+// encodes the args as a RESP array of bulk strings
+func encodeCommand(args ...string) string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("*%d\r\n", len(args)))
+	for _, a := range args {
+		builder.WriteString(fmt.Sprintf("$%d\r\n%s\r\n", len(a), a))
+	}
+	return builder.String()
+}
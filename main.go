@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 
 	"github.com/joho/godotenv"
 	"github.com/justsushant/one2n-go-bootcamp/go-redis/db"
@@ -11,7 +14,11 @@ import (
 	"github.com/justsushant/one2n-go-bootcamp/go-redis/store/inMemoryStore"
 )
 
-const DEFAULT_PORT = "8080"
+const (
+	DEFAULT_PORT     = "8080"
+	DEFAULT_PROTOCOL = "resp"
+	DEFAULT_EXECUTOR = "goroutines"
+)
 
 func main() {
 	// load env file
@@ -23,6 +30,12 @@ func main() {
 	// set the port
 	port := getEnv("PORT", DEFAULT_PORT)
 
+	// "text" keeps the human readable replies for netcat users
+	protocol := getEnv("PROTOCOL", DEFAULT_PROTOCOL)
+
+	// "single" applies every command on one goroutine like redis, instead of on the goroutine of each conn
+	executor := getEnv("EXECUTOR", DEFAULT_EXECUTOR)
+
 	// start listening
 	ln, err := net.Listen("tcp", port)
 	if err != nil {
@@ -33,8 +46,37 @@ func main() {
 	s := &server.Server{
 		Db:       map[int]db.DbInterface{0: db.GetNewDB(inMemoryStore.NewInMemoryStore())},
 		Listener: ln,
+		TextMode: protocol == "text",
+
+		SingleThreaded: executor == "single",
+	}
+
+	// server params can be set with env vars, eg. PROTO_MAX_BULK_LEN for proto-max-bulk-len
+	for _, name := range server.ConfigParams() {
+		env := strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+		if val, ok := os.LookupEnv(env); ok {
+			if err := s.SetConfig(name, val); err != nil {
+				fmt.Printf("Invalid value for %s: %v\n", env, err)
+			}
+		}
+	}
+
+	// the dbs are restored from the append only files if it's on, or from the last snapshot, saved on demand or by the save rules
+	// a corrupt file stops the startup so it isn't overwritten
+	if err := s.Load(); err != nil {
+		fmt.Printf("Error while loading the data: %v\n", err)
+		os.Exit(1)
 	}
 
+	// stop the background jobs cleanly on shutdown
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sig
+		fmt.Println("Shutting down")
+		s.Close()
+	}()
+
 	// start the server
 	s.Start()
 }
@@ -45,4 +87,4 @@ func getEnv(key, fallback string) string {
 		return fallback
 	}
 	return value
-}
\ No newline at end of file
+}
@@ -0,0 +1,50 @@
+package rdb
+
+import (
+	"errors"
+	"hash/crc64"
+)
+
+// a snapshot holds every db in a compact binary form, laid out as
+//
+//	header   "GOREDIS" followed by the version as a uvarint
+//	db       opSelectDB, the db index and the number of keys as uvarints, then the keys
+//	key      optional opExpireMs with the unix time in ms as 8 little endian bytes,
+//	         the type of the value, the key and the value
+//	footer   opEOF followed by the CRC64 (ECMA) of everything before it as 8 little endian bytes
+//
+// strings are a uvarint length followed by the bytes, lengths and counts are uvarints
+// scores are float64 bits as 8 little endian bytes
+
+const (
+	Magic   string = "GOREDIS"
+	Version uint64 = 1 // bumped whenever the layout changes, newer snapshots are refused
+
+	maxPrealloc      int    = 1024      // items preallocated upfront for a collection, the count can't be trusted before the checksum is
+	maxPreallocBytes uint64 = 64 * 1024 // bytes preallocated upfront for a string
+)
+
+const (
+	opSelectDB byte = 0xFE
+	opExpireMs byte = 0xFC
+	opEOF      byte = 0xFF
+)
+
+// types of the values, as saved before each key
+const (
+	typeString byte = iota
+	typeList
+	typeSet
+	typeHash
+	typeZSet
+	typeStream
+)
+
+var (
+	ErrNotSnapshot        = errors.New("not a snapshot file")
+	ErrUnsupportedVersion = errors.New("unsupported snapshot version")
+	ErrChecksum           = errors.New("snapshot checksum mismatch")
+	ErrCorrupt            = errors.New("snapshot is corrupt")
+)
+
+var crcTable = crc64.MakeTable(crc64.ECMA)
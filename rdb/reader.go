@@ -0,0 +1,268 @@
+package rdb
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc64"
+	"io"
+	"math"
+	"os"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store"
+)
+
+// reader decodes a snapshot, the checksum is kept over every byte read
+// the first error is sticky, so the decoding goes on with zero values and is checked once in a while
+type reader struct {
+	r   *bufio.Reader
+	crc hash.Hash64
+	err error
+}
+
+// Read decodes the entries of every db, by index
+// nothing is returned unless the whole snapshot is read and its checksum matches
+// a bufio.Reader is read as is, so what follows the snapshot is left in it
+func Read(r io.Reader) (map[int][]store.Entry, error) {
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	rd := &reader{r: br, crc: crc64.New(crcTable)}
+
+	magic := rd.bytes(len(Magic))
+	if rd.err != nil || string(magic) != Magic {
+		return nil, ErrNotSnapshot
+	}
+	if v := rd.uint(); rd.err == nil && v > Version {
+		return nil, fmt.Errorf("%w %d", ErrUnsupportedVersion, v)
+	}
+
+	dbs := map[int][]store.Entry{}
+	for rd.err == nil {
+		op := rd.byte()
+		if op == opEOF {
+			break
+		}
+		if op != opSelectDB {
+			rd.fail(fmt.Errorf("unknown opcode %#x", op))
+			break
+		}
+
+		idx, n := int(rd.uint()), rd.uint()
+		entries := make([]store.Entry, 0, min(n, uint64(maxPrealloc)))
+		for i := uint64(0); i < n && rd.err == nil; i++ {
+			entries = append(entries, rd.entry())
+		}
+		dbs[idx] = append(dbs[idx], entries...)
+	}
+	if rd.err != nil {
+		return nil, rd.err
+	}
+
+	sum := rd.crc.Sum64()
+	var footer [8]byte
+	if _, err := io.ReadFull(rd.r, footer[:]); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+	if binary.LittleEndian.Uint64(footer[:]) != sum {
+		return nil, ErrChecksum
+	}
+	return dbs, nil
+}
+
+// LoadFile reads the snapshot at path, the error wraps os.ErrNotExist if there's none
+func LoadFile(path string) (map[int][]store.Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+func (r *reader) fail(err error) {
+	if r.err == nil {
+		r.err = fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+}
+
+func (r *reader) entry() store.Entry {
+	var e store.Entry
+	typ := r.byte()
+	if typ == opExpireMs {
+		e.ExpireAt = int64(r.fixed())
+		typ = r.byte()
+	}
+	e.Key = r.string()
+
+	switch typ {
+	case typeString:
+		e.Value = store.String(r.string())
+	case typeList:
+		e.Value = store.NewList(r.strings()...)
+	case typeSet:
+		n := r.uint()
+		set := make(store.Set, min(n, uint64(maxPrealloc)))
+		for i := uint64(0); i < n && r.err == nil; i++ {
+			set[r.string()] = struct{}{}
+		}
+		e.Value = set
+	case typeHash:
+		n := r.uint()
+		h := make(store.Hash, min(n, uint64(maxPrealloc)))
+		for i := uint64(0); i < n && r.err == nil; i++ {
+			field := r.string()
+			h[field] = r.string()
+		}
+		e.Value = h
+	case typeZSet:
+		n := r.uint()
+		z := store.NewZSet()
+		for i := uint64(0); i < n && r.err == nil; i++ {
+			member := r.string()
+			z.Add(member, math.Float64frombits(r.fixed()))
+		}
+		e.Value = z
+	case typeStream:
+		e.Value = r.stream()
+	default:
+		r.fail(fmt.Errorf("unknown value type %d of key %q", typ, e.Key))
+	}
+	return e
+}
+
+func (r *reader) stream() *store.Stream {
+	s := store.NewStream()
+	n := r.uint()
+	for i := uint64(0); i < n && r.err == nil; i++ {
+		id := r.id()
+		s.Add(id, r.strings())
+	}
+	s.LastID = r.id()
+	s.MaxDeletedID = r.id()
+	s.EntriesAdded = r.uint()
+
+	groups := r.uint()
+	for i := uint64(0); i < groups && r.err == nil; i++ {
+		name := r.string()
+		g := store.NewConsumerGroup(r.id(), r.int())
+
+		pending := r.uint()
+		for j := uint64(0); j < pending && r.err == nil; j++ {
+			id := r.id()
+			p := &store.PendingEntry{Consumer: r.string(), DeliveryTime: r.int()}
+			p.DeliveryCount = int(r.uint())
+			g.Pending[id] = p
+		}
+
+		consumers := r.uint()
+		for j := uint64(0); j < consumers && r.err == nil; j++ {
+			name := r.string()
+			c := &store.Consumer{SeenTime: r.int(), ActiveTime: r.int(), Pending: map[store.StreamID]struct{}{}}
+			ids := r.uint()
+			for k := uint64(0); k < ids && r.err == nil; k++ {
+				c.Pending[r.id()] = struct{}{}
+			}
+			g.Consumers[name] = c
+		}
+		s.Groups[name] = g
+	}
+	return s
+}
+
+// ReadByte lets the varints be read through the reader, so they're covered by the checksum
+func (r *reader) ReadByte() (byte, error) {
+	b, err := r.r.ReadByte()
+	if err == nil {
+		r.crc.Write([]byte{b})
+	}
+	return b, err
+}
+
+func (r *reader) byte() byte {
+	if r.err != nil {
+		return 0
+	}
+	b, err := r.ReadByte()
+	if err != nil {
+		r.fail(err)
+	}
+	return b
+}
+
+func (r *reader) bytes(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(r.r, buf); err != nil {
+		r.fail(err)
+		return nil
+	}
+	r.crc.Write(buf)
+	return buf
+}
+
+func (r *reader) uint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		r.fail(err)
+	}
+	return n
+}
+
+func (r *reader) int() int64 {
+	if r.err != nil {
+		return 0
+	}
+	n, err := binary.ReadVarint(r)
+	if err != nil {
+		r.fail(err)
+	}
+	return n
+}
+
+func (r *reader) fixed() uint64 {
+	buf := r.bytes(8)
+	if buf == nil {
+		return 0
+	}
+	return binary.LittleEndian.Uint64(buf)
+}
+
+// read in chunks, so a corrupt length fails at the end of the file instead of being allocated upfront
+func (r *reader) string() string {
+	n := r.uint()
+	if r.err != nil {
+		return ""
+	}
+	if n > math.MaxInt32 {
+		r.fail(errors.New("string too long"))
+		return ""
+	}
+	buf := make([]byte, 0, min(n, maxPreallocBytes))
+	for uint64(len(buf)) < n && r.err == nil {
+		chunk := r.bytes(int(min(n-uint64(len(buf)), maxPreallocBytes)))
+		buf = append(buf, chunk...)
+	}
+	return string(buf)
+}
+
+func (r *reader) strings() []string {
+	n := r.uint()
+	ss := make([]string, 0, min(n, uint64(maxPrealloc)))
+	for i := uint64(0); i < n && r.err == nil; i++ {
+		ss = append(ss, r.string())
+	}
+	return ss
+}
+
+func (r *reader) id() store.StreamID {
+	return store.StreamID{Ms: r.uint(), Seq: r.uint()}
+}
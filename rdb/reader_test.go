@@ -0,0 +1,67 @@
+package rdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store"
+)
+
+func TestReadErrors(t *testing.T) {
+	var buf bytes.Buffer
+	Write(&buf, getTestDbs())
+	valid := buf.Bytes()
+
+	// the header of a snapshot from a newer version, the checksum doesn't matter as it's refused upfront
+	newer := append([]byte(Magic), binary.AppendUvarint(nil, Version+1)...)
+
+	flipped := bytes.Clone(valid)
+	flipped[len(flipped)/2] ^= 0xFF
+
+	unknownOp := append([]byte(Magic), binary.AppendUvarint(nil, Version)...)
+	unknownOp = append(unknownOp, 0x01)
+
+	testCases := []struct {
+		name   string
+		input  []byte
+		expErr error
+	}{
+		{"empty", []byte{}, ErrNotSnapshot},
+		{"wrong magic", []byte("REDIS0011"), ErrNotSnapshot},
+		{"newer version", newer, ErrUnsupportedVersion},
+		{"flipped byte", flipped, ErrChecksum},
+		{"truncated", valid[:len(valid)-20], ErrCorrupt},
+		{"missing checksum", valid[:len(valid)-8], ErrCorrupt},
+		{"unknown opcode", unknownOp, ErrCorrupt},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dbs, err := Read(bytes.NewReader(tc.input))
+			if !errors.Is(err, tc.expErr) {
+				t.Errorf("Expected error %v but got %v", tc.expErr, err)
+			}
+			if dbs != nil {
+				t.Errorf("Expected no dbs but got %v", dbs)
+			}
+		})
+	}
+}
+
+func TestReadEmptyValues(t *testing.T) {
+	dbs := map[int][]store.Entry{0: {{Key: "", Value: store.String("")}, {Key: "list", Value: store.NewList()}}}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, dbs); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	got, err := Read(&buf)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if len(got[0]) != 2 || got[0][0].Key != "" || got[0][1].Value.(*store.List).Len() != 0 {
+		t.Errorf("Expected the empty key and the empty list back but got %v", got)
+	}
+}
@@ -0,0 +1,198 @@
+package rdb
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"hash/crc64"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store"
+)
+
+// writer encodes a snapshot, errors of the underlying writer are sticky and reported by Flush
+type writer struct {
+	w   *bufio.Writer
+	buf [binary.MaxVarintLen64]byte
+}
+
+// Write encodes the entries of every db, by index, empty dbs are left out
+func Write(w io.Writer, dbs map[int][]store.Entry) error {
+	crc := crc64.New(crcTable)
+	wr := &writer{w: bufio.NewWriter(io.MultiWriter(w, crc))}
+
+	wr.w.WriteString(Magic)
+	wr.uint(Version)
+
+	idxs := make([]int, 0, len(dbs))
+	for idx, entries := range dbs {
+		if len(entries) > 0 {
+			idxs = append(idxs, idx)
+		}
+	}
+	slices.Sort(idxs)
+
+	for _, idx := range idxs {
+		wr.w.WriteByte(opSelectDB)
+		wr.uint(uint64(idx))
+		wr.uint(uint64(len(dbs[idx])))
+		for _, e := range dbs[idx] {
+			if err := wr.entry(e); err != nil {
+				return err
+			}
+		}
+	}
+	wr.w.WriteByte(opEOF)
+
+	// the checksum covers everything written so far, so it's taken once the rest is flushed
+	if err := wr.w.Flush(); err != nil {
+		return err
+	}
+	binary.LittleEndian.PutUint64(wr.buf[:8], crc.Sum64())
+	_, err := w.Write(wr.buf[:8])
+	return err
+}
+
+// SaveFile writes the snapshot to a temp file next to path and renames it once it's synced,
+// so path always holds a complete snapshot
+func SaveFile(path string, dbs map[int][]store.Entry) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "temp-*.rdb")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if err := Write(f, dbs); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+func (w *writer) entry(e store.Entry) error {
+	if e.ExpireAt != 0 {
+		w.w.WriteByte(opExpireMs)
+		w.fixed(uint64(e.ExpireAt))
+	}
+
+	switch v := e.Value.(type) {
+	case store.String:
+		w.w.WriteByte(typeString)
+		w.string(e.Key)
+		w.string(string(v))
+	case *store.List:
+		w.w.WriteByte(typeList)
+		w.string(e.Key)
+		w.strings(v.Values())
+	case store.Set:
+		w.w.WriteByte(typeSet)
+		w.string(e.Key)
+		w.uint(uint64(len(v)))
+		for member := range v {
+			w.string(member)
+		}
+	case store.Hash:
+		w.w.WriteByte(typeHash)
+		w.string(e.Key)
+		w.uint(uint64(len(v)))
+		for field, val := range v {
+			w.string(field)
+			w.string(val)
+		}
+	case *store.ZSet:
+		w.w.WriteByte(typeZSet)
+		w.string(e.Key)
+		items := v.Items()
+		w.uint(uint64(len(items)))
+		for _, item := range items {
+			w.string(item.Member)
+			w.fixed(math.Float64bits(item.Score))
+		}
+	case *store.Stream:
+		w.w.WriteByte(typeStream)
+		w.string(e.Key)
+		w.stream(v)
+	default:
+		return fmt.Errorf("can't save the %s value of key %q", e.Value.Type(), e.Key)
+	}
+	return nil
+}
+
+// entries are followed by the stream metadata, then by the consumer groups
+func (w *writer) stream(s *store.Stream) {
+	entries := s.Entries()
+	w.uint(uint64(len(entries)))
+	for _, e := range entries {
+		w.id(e.ID)
+		w.strings(e.Fields)
+	}
+	w.id(s.LastID)
+	w.id(s.MaxDeletedID)
+	w.uint(s.EntriesAdded)
+
+	w.uint(uint64(len(s.Groups)))
+	for name, g := range s.Groups {
+		w.string(name)
+		w.id(g.LastID)
+		w.int(g.EntriesRead)
+
+		w.uint(uint64(len(g.Pending)))
+		for id, p := range g.Pending {
+			w.id(id)
+			w.string(p.Consumer)
+			w.int(p.DeliveryTime)
+			w.uint(uint64(p.DeliveryCount))
+		}
+
+		w.uint(uint64(len(g.Consumers)))
+		for name, c := range g.Consumers {
+			w.string(name)
+			w.int(c.SeenTime)
+			w.int(c.ActiveTime)
+			w.uint(uint64(len(c.Pending)))
+			for id := range c.Pending {
+				w.id(id)
+			}
+		}
+	}
+}
+
+func (w *writer) uint(n uint64) {
+	w.w.Write(binary.AppendUvarint(w.buf[:0], n))
+}
+
+func (w *writer) int(n int64) {
+	w.w.Write(binary.AppendVarint(w.buf[:0], n))
+}
+
+func (w *writer) fixed(n uint64) {
+	w.w.Write(binary.LittleEndian.AppendUint64(w.buf[:0], n))
+}
+
+func (w *writer) string(s string) {
+	w.uint(uint64(len(s)))
+	w.w.WriteString(s)
+}
+
+func (w *writer) strings(ss []string) {
+	w.uint(uint64(len(ss)))
+	for _, s := range ss {
+		w.string(s)
+	}
+}
+
+func (w *writer) id(id store.StreamID) {
+	w.uint(id.Ms)
+	w.uint(id.Seq)
+}
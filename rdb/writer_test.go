@@ -0,0 +1,124 @@
+package rdb
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store"
+)
+
+// returns dbs holding a key of every type
+func getTestDbs() map[int][]store.Entry {
+	z := store.NewZSet()
+	z.Add("a", 1.5)
+	z.Add("b", -2)
+
+	s := store.NewStream()
+	s.Add(store.StreamID{Ms: 1, Seq: 1}, []string{"f", "v"})
+	s.Add(store.StreamID{Ms: 2, Seq: 0}, []string{"f", "w", "g", "x"})
+	s.MaxDeletedID = store.StreamID{Ms: 1, Seq: 0}
+	g := store.NewConsumerGroup(store.StreamID{Ms: 1, Seq: 1}, 1)
+	g.Consumer("alice", 100)
+	g.Deliver(store.StreamID{Ms: 1, Seq: 1}, "alice", 200)
+	s.Groups["g"] = g
+
+	return map[int][]store.Entry{
+		0: {
+			{Key: "str", Value: store.String("bar")},
+			{Key: "ttl", Value: store.String(""), ExpireAt: 1_700_000_000_000},
+			{Key: "list", Value: store.NewList("a", "b", "c")},
+			{Key: "set", Value: store.Set{"x": {}, "y": {}}},
+		},
+		3: {
+			{Key: "hash", Value: store.Hash{"f": "v", "g": "w"}},
+			{Key: "zset", Value: z},
+			{Key: "stream", Value: s},
+		},
+	}
+}
+
+// sorted sets are compared by their items, as their skiplists differ
+func equalDbs(a, b map[int][]store.Entry) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for idx, entries := range a {
+		if len(entries) != len(b[idx]) {
+			return false
+		}
+		for i, e := range entries {
+			other := b[idx][i]
+			if z, ok := e.Value.(*store.ZSet); ok {
+				oz, ok := other.Value.(*store.ZSet)
+				if !ok || e.Key != other.Key || e.ExpireAt != other.ExpireAt || !reflect.DeepEqual(z.Items(), oz.Items()) {
+					return false
+				}
+				continue
+			}
+			if !reflect.DeepEqual(e, other) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestWrite(t *testing.T) {
+	testCases := []struct {
+		name string
+		dbs  map[int][]store.Entry
+		exp  map[int][]store.Entry
+	}{
+		{"every type", getTestDbs(), getTestDbs()},
+		{"no dbs", map[int][]store.Entry{}, map[int][]store.Entry{}},
+		{"empty dbs are left out", map[int][]store.Entry{0: {}, 1: {{Key: "k", Value: store.String("v")}}}, map[int][]store.Entry{1: {{Key: "k", Value: store.String("v")}}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Write(&buf, tc.dbs); err != nil {
+				t.Fatalf("Expected no error but got %v", err)
+			}
+
+			dbs, err := Read(&buf)
+			if err != nil {
+				t.Fatalf("Expected no error but got %v", err)
+			}
+			if !equalDbs(dbs, tc.exp) {
+				t.Errorf("Expected %v but got %v", tc.exp, dbs)
+			}
+		})
+	}
+}
+
+func TestSaveFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "dump.rdb")
+
+	if err := SaveFile(path, getTestDbs()); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if err := SaveFile(path, map[int][]store.Entry{1: {{Key: "k", Value: store.String("v")}}}); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	// the second save replaced the first one, no temp file is left behind
+	dbs, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if exp := (map[int][]store.Entry{1: {{Key: "k", Value: store.String("v")}}}); !reflect.DeepEqual(dbs, exp) {
+		t.Errorf("Expected %v but got %v", exp, dbs)
+	}
+	if files, _ := os.ReadDir(dir); len(files) != 1 {
+		t.Errorf("Expected only the snapshot in the dir but got %d files", len(files))
+	}
+
+	if _, err := LoadFile(filepath.Join(dir, "missing.rdb")); !os.IsNotExist(err) {
+		t.Errorf("Expected a not exist error but got %v", err)
+	}
+}
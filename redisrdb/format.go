@@ -0,0 +1,96 @@
+package redisrdb
+
+import (
+	"errors"
+	"hash/crc64"
+)
+
+// redisrdb reads and writes the dump files of redis itself, as opposed to rdb which has a format of its own
+// a dump is laid out as
+//
+//	header   "REDIS" followed by the version as 4 ascii digits
+//	aux      opAux with a name and a value, like redis-ver, skipped when read
+//	db       opSelectDB with the db index, optional opResizeDB with the number of keys and timeouts, then the keys
+//	key      optional opExpireMs with the unix time in ms as 8 little endian bytes, or opExpire in seconds as 4,
+//	         the type of the value, the key and the value
+//	footer   opEOF followed by the CRC64 (jones) of everything before it as 8 little endian bytes, 0 if it wasn't computed
+//
+// lengths take 1, 2, 5 or 9 bytes depending on their size, the top 2 bits of the first byte telling which
+// strings are a length followed by the bytes, or a special encoding of an integer or of an LZF compressed string
+// small collections are saved as a single string holding a ziplist, a listpack or an intset, see pack.go
+
+const (
+	Magic        string = "REDIS"
+	MaxVersion   int    = 11 // newest version read, the one of redis 7.2
+	WriteVersion int    = 9  // version written, redis 5.0 and later load it
+
+	checksumVersion int = 5 // versions before it have no checksum after opEOF
+	maxStringLen    int = 512 * 1024 * 1024
+	maxPrealloc     int = 1024 // items preallocated upfront for a collection, the count can't be trusted before the checksum is
+)
+
+const (
+	opFunction2 byte = 0xF6
+	opFreq      byte = 0xF7
+	opIdle      byte = 0xF8
+	opModuleAux byte = 0xF9
+	opAux       byte = 0xFA
+	opResizeDB  byte = 0xFB
+	opExpireMs  byte = 0xFC
+	opExpire    byte = 0xFD
+	opSelectDB  byte = 0xFE
+	opEOF       byte = 0xFF
+)
+
+// types of the values, as saved before each key
+const (
+	typeString         byte = 0
+	typeList           byte = 1
+	typeSet            byte = 2
+	typeZSet           byte = 3
+	typeHash           byte = 4
+	typeZSet2          byte = 5 // scores as binary doubles instead of strings
+	typeListZiplist    byte = 10
+	typeSetIntset      byte = 11
+	typeZSetZiplist    byte = 12
+	typeHashZiplist    byte = 13
+	typeListQuicklist  byte = 14
+	typeHashListpack   byte = 16
+	typeZSetListpack   byte = 17
+	typeListQuicklist2 byte = 18
+	typeSetListpack    byte = 20
+)
+
+// encodings of a length whose top 2 bits are 11, the value which follows isn't a plain string
+const (
+	encInt8  byte = 0
+	encInt16 byte = 1
+	encInt32 byte = 2
+	encLZF   byte = 3
+)
+
+// containers of the nodes of a quicklist, a packed node is a listpack while a plain one is a single element
+const (
+	containerPlain  uint64 = 1
+	containerPacked uint64 = 2
+)
+
+var (
+	ErrNotRDB             = errors.New("not a redis rdb file")
+	ErrUnsupportedVersion = errors.New("unsupported rdb version")
+	ErrUnsupportedType    = errors.New("unsupported value type")
+	ErrChecksum           = errors.New("rdb checksum mismatch")
+	ErrCorrupt            = errors.New("rdb is corrupt")
+)
+
+// redis uses the reflected jones polynomial with neither the initial nor the final inversion,
+// which hash/crc64 applies on both ends, so they're undone around it
+var crcTable = crc64.MakeTable(0x95ac9329ac4bc9b5)
+
+// checksum is the CRC64 of what's written to it
+type checksum uint64
+
+func (c *checksum) Write(p []byte) (int, error) {
+	*c = checksum(^crc64.Update(^uint64(*c), crcTable, p))
+	return len(p), nil
+}
@@ -0,0 +1,227 @@
+package redisrdb
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// decoders of the strings redis saves small collections as, and of LZF compressed strings
+// they work on the whole string read from the file, every offset is checked against its end
+
+var errShortPack = errors.New("encoded value ends early")
+
+// cursor over an encoded value, the first error is sticky
+type cursor struct {
+	b   []byte
+	pos int
+	err error
+}
+
+func (c *cursor) take(n int) []byte {
+	if c.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(c.b)-c.pos {
+		c.err = errShortPack
+		return nil
+	}
+	p := c.b[c.pos : c.pos+n]
+	c.pos += n
+	return p
+}
+
+func (c *cursor) byte() byte {
+	p := c.take(1)
+	if p == nil {
+		return 0
+	}
+	return p[0]
+}
+
+// returns the n bytes as a little endian signed integer
+func (c *cursor) int(n int) int64 {
+	p := c.take(n)
+	if p == nil {
+		return 0
+	}
+	var u uint64
+	for i := n - 1; i >= 0; i-- {
+		u = u<<8 | uint64(p[i])
+	}
+	shift := 64 - 8*n
+	return int64(u<<shift) >> shift
+}
+
+// ziplist is laid out as the total bytes (4), the offset of the last entry (4), the number of entries (2),
+// the entries and 0xFF
+// an entry is the length of the previous one (1 byte, or 0xFE and 4 bytes), its encoding and its data
+func ziplistEntries(b []byte) ([]string, error) {
+	c := &cursor{b: b}
+	c.take(10)
+	items := []string{}
+	for c.err == nil {
+		prev := c.byte()
+		if prev == 0xFF {
+			break
+		}
+		if prev == 0xFE {
+			c.take(4)
+		}
+
+		enc := c.byte()
+		switch {
+		case enc>>6 == 0:
+			items = append(items, string(c.take(int(enc&0x3F))))
+		case enc>>6 == 1:
+			n := int(enc&0x3F)<<8 | int(c.byte())
+			items = append(items, string(c.take(n)))
+		case enc>>6 == 2:
+			n := c.take(4)
+			if n != nil {
+				items = append(items, string(c.take(int(binary.BigEndian.Uint32(n)))))
+			}
+		case enc == 0xC0:
+			items = append(items, strconv.FormatInt(c.int(2), 10))
+		case enc == 0xD0:
+			items = append(items, strconv.FormatInt(c.int(4), 10))
+		case enc == 0xE0:
+			items = append(items, strconv.FormatInt(c.int(8), 10))
+		case enc == 0xF0:
+			items = append(items, strconv.FormatInt(c.int(3), 10))
+		case enc == 0xFE:
+			items = append(items, strconv.FormatInt(c.int(1), 10))
+		case enc >= 0xF1 && enc <= 0xFD:
+			// immediate values 0 to 12
+			items = append(items, strconv.Itoa(int(enc&0x0F)-1))
+		default:
+			return nil, fmt.Errorf("unknown ziplist encoding %#x", enc)
+		}
+	}
+	if c.err != nil {
+		return nil, fmt.Errorf("ziplist: %v", c.err)
+	}
+	return items, nil
+}
+
+// listpack is laid out as the total bytes (4), the number of entries (2), the entries and 0xFF
+// an entry is its encoding, its data and the length of both, written backwards in 1 to 5 bytes
+func listpackEntries(b []byte) ([]string, error) {
+	c := &cursor{b: b}
+	c.take(6)
+	items := []string{}
+	for c.err == nil {
+		start := c.pos
+		enc := c.byte()
+		switch {
+		case enc == 0xFF:
+			return items, nil
+		case enc&0x80 == 0:
+			items = append(items, strconv.Itoa(int(enc)))
+		case enc&0xC0 == 0x80:
+			items = append(items, string(c.take(int(enc&0x3F))))
+		case enc&0xE0 == 0xC0:
+			// 13 bits two's complement
+			n := int64(enc&0x1F)<<8 | int64(c.byte())
+			if n >= 1<<12 {
+				n -= 1 << 13
+			}
+			items = append(items, strconv.FormatInt(n, 10))
+		case enc&0xF0 == 0xE0:
+			n := int(enc&0x0F)<<8 | int(c.byte())
+			items = append(items, string(c.take(n)))
+		case enc == 0xF0:
+			n := c.take(4)
+			if n != nil {
+				items = append(items, string(c.take(int(binary.LittleEndian.Uint32(n)))))
+			}
+		case enc == 0xF1:
+			items = append(items, strconv.FormatInt(c.int(2), 10))
+		case enc == 0xF2:
+			items = append(items, strconv.FormatInt(c.int(3), 10))
+		case enc == 0xF3:
+			items = append(items, strconv.FormatInt(c.int(4), 10))
+		case enc == 0xF4:
+			items = append(items, strconv.FormatInt(c.int(8), 10))
+		default:
+			return nil, fmt.Errorf("unknown listpack encoding %#x", enc)
+		}
+		c.take(backlenSize(c.pos - start))
+	}
+	return nil, fmt.Errorf("listpack: %v", c.err)
+}
+
+// number of bytes the length of an entry of a listpack takes
+func backlenSize(n int) int {
+	switch {
+	case n <= 127:
+		return 1
+	case n < 16383:
+		return 2
+	case n < 2097151:
+		return 3
+	case n < 268435455:
+		return 4
+	default:
+		return 5
+	}
+}
+
+// intset is laid out as the size of each integer (4), their number (4) and the integers in little endian
+func intsetEntries(b []byte) ([]string, error) {
+	c := &cursor{b: b}
+	size, n := c.int(4), c.int(4)
+	if size != 2 && size != 4 && size != 8 {
+		return nil, fmt.Errorf("unknown intset encoding %d", size)
+	}
+	if n < 0 {
+		return nil, fmt.Errorf("invalid intset length %d", n)
+	}
+	items := make([]string, 0, min(n, int64(maxPrealloc)))
+	for i := int64(0); i < n && c.err == nil; i++ {
+		items = append(items, strconv.FormatInt(c.int(int(size)), 10))
+	}
+	if c.err != nil {
+		return nil, fmt.Errorf("intset: %v", c.err)
+	}
+	return items, nil
+}
+
+// decompresses LZF data into a string of the given length
+// a control byte under 32 is followed by that many literal bytes plus one,
+// otherwise its top 3 bits are the length of a back reference, 7 meaning a byte follows to add to it,
+// and its low 5 bits along with the next byte are the offset of the reference
+func lzfDecompress(in []byte, n int) ([]byte, error) {
+	out := make([]byte, 0, n)
+	c := &cursor{b: in}
+	for c.pos < len(in) && c.err == nil {
+		ctrl := int(c.byte())
+		if ctrl < 32 {
+			out = append(out, c.take(ctrl+1)...)
+		} else {
+			length := ctrl >> 5
+			if length == 7 {
+				length += int(c.byte())
+			}
+			ref := len(out) - (ctrl&0x1F)<<8 - int(c.byte()) - 1
+			if ref < 0 {
+				return nil, errors.New("lzf reference before the start")
+			}
+			// the reference may overlap what it produces, so it's copied a byte at a time
+			for i := 0; i < length+2; i++ {
+				out = append(out, out[ref+i])
+			}
+		}
+		if len(out) > n {
+			return nil, errors.New("lzf data longer than told")
+		}
+	}
+	if c.err != nil {
+		return nil, fmt.Errorf("lzf: %v", c.err)
+	}
+	if len(out) != n {
+		return nil, fmt.Errorf("lzf data of %d bytes instead of %d", len(out), n)
+	}
+	return out, nil
+}
@@ -0,0 +1,398 @@
+package redisrdb
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store"
+)
+
+// reader decodes a dump, the checksum is kept over every byte read
+// the first error is sticky, so the decoding goes on with zero values and is checked once in a while
+type reader struct {
+	r   *bufio.Reader
+	crc checksum
+	err error
+}
+
+// Read decodes the keys of every db of a dump written by redis, by index
+// the keys keep their timeouts, even those which passed already
+// nothing is returned unless the whole dump is read and its checksum matches
+func Read(r io.Reader) (map[int][]store.Entry, error) {
+	rd := &reader{r: bufio.NewReader(r)}
+
+	header := rd.bytes(len(Magic) + 4)
+	if rd.err != nil || string(header[:len(Magic)]) != Magic {
+		return nil, ErrNotRDB
+	}
+	version, err := strconv.Atoi(string(header[len(Magic):]))
+	if err != nil || version < 1 {
+		return nil, ErrNotRDB
+	}
+	if version > MaxVersion {
+		return nil, fmt.Errorf("%w %d", ErrUnsupportedVersion, version)
+	}
+
+	dbs := map[int][]store.Entry{}
+	idx, expireAt := 0, int64(0)
+	for done := false; !done && rd.err == nil; {
+		switch op := rd.byte(); op {
+		case opEOF:
+			done = true
+		case opSelectDB:
+			idx = int(rd.length())
+			if _, ok := dbs[idx]; !ok {
+				dbs[idx] = []store.Entry{}
+			}
+		case opResizeDB:
+			rd.length()
+			rd.length()
+		case opAux:
+			rd.string()
+			rd.string()
+		case opFunction2:
+			// functions aren't supported, their code is skipped
+			rd.string()
+		case opIdle:
+			rd.length()
+		case opFreq:
+			rd.byte()
+		case opExpireMs:
+			expireAt = rd.int(8)
+		case opExpire:
+			expireAt = rd.int(4) * 1000
+		case opModuleAux:
+			rd.fail(errors.New("module data isn't supported"))
+		default:
+			key := rd.string()
+			val := rd.value(op, key)
+			if rd.err == nil {
+				dbs[idx] = append(dbs[idx], store.Entry{Key: key, Value: val, ExpireAt: expireAt})
+			}
+			expireAt = 0
+		}
+	}
+	if rd.err != nil {
+		return nil, rd.err
+	}
+	if version < checksumVersion {
+		return dbs, nil
+	}
+
+	sum := uint64(rd.crc)
+	var footer [8]byte
+	if _, err := io.ReadFull(rd.r, footer[:]); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+	// redis may be told not to compute the checksum, it's saved as 0 then
+	if got := binary.LittleEndian.Uint64(footer[:]); got != 0 && got != sum {
+		return nil, ErrChecksum
+	}
+	return dbs, nil
+}
+
+// LoadFile reads the dump at path
+func LoadFile(path string) (map[int][]store.Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+func (r *reader) fail(err error) {
+	if r.err == nil {
+		if errors.Is(err, ErrUnsupportedType) {
+			r.err = err
+			return
+		}
+		r.err = fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+}
+
+// decodes the value of the key, whatever its encoding, into the matching store value
+func (r *reader) value(typ byte, key string) store.Value {
+	switch typ {
+	case typeString:
+		return store.String(r.string())
+	case typeList:
+		return store.NewList(r.strings(r.length())...)
+	case typeListZiplist:
+		return store.NewList(r.packed(ziplistEntries)...)
+	case typeListQuicklist, typeListQuicklist2:
+		l := store.NewList()
+		n := r.length()
+		for i := uint64(0); i < n && r.err == nil; i++ {
+			container := containerPacked
+			if typ == typeListQuicklist2 {
+				container = r.length()
+			}
+			switch {
+			case container == containerPlain:
+				l.PushBack(r.string())
+			case container != containerPacked:
+				r.fail(fmt.Errorf("unknown quicklist container %d of key %q", container, key))
+			case typ == typeListQuicklist:
+				for _, item := range r.packed(ziplistEntries) {
+					l.PushBack(item)
+				}
+			default:
+				for _, item := range r.packed(listpackEntries) {
+					l.PushBack(item)
+				}
+			}
+		}
+		return l
+	case typeSet:
+		return newSet(r.strings(r.length()))
+	case typeSetIntset:
+		return newSet(r.packed(intsetEntries))
+	case typeSetListpack:
+		return newSet(r.packed(listpackEntries))
+	case typeHash:
+		return r.hash(r.strings(2*r.length()), key)
+	case typeHashZiplist:
+		return r.hash(r.packed(ziplistEntries), key)
+	case typeHashListpack:
+		return r.hash(r.packed(listpackEntries), key)
+	case typeZSet, typeZSet2:
+		z := store.NewZSet()
+		n := r.length()
+		for i := uint64(0); i < n && r.err == nil; i++ {
+			member := r.string()
+			var score float64
+			if typ == typeZSet2 {
+				score = math.Float64frombits(uint64(r.int(8)))
+			} else {
+				score = r.score()
+			}
+			if math.IsNaN(score) {
+				r.fail(fmt.Errorf("nan score in sorted set %q", key))
+			}
+			z.Add(member, score)
+		}
+		return z
+	case typeZSetZiplist:
+		return r.zset(r.packed(ziplistEntries), key)
+	case typeZSetListpack:
+		return r.zset(r.packed(listpackEntries), key)
+	default:
+		r.fail(fmt.Errorf("%w %d of key %q", ErrUnsupportedType, typ, key))
+		return nil
+	}
+}
+
+// reads a string holding a small collection and decodes its items
+func (r *reader) packed(decode func([]byte) ([]string, error)) []string {
+	b := r.string()
+	if r.err != nil {
+		return nil
+	}
+	items, err := decode([]byte(b))
+	if err != nil {
+		r.fail(err)
+	}
+	return items
+}
+
+func newSet(members []string) store.Set {
+	set := make(store.Set, len(members))
+	for _, m := range members {
+		set[m] = struct{}{}
+	}
+	return set
+}
+
+// builds a hash out of fields followed by their values
+func (r *reader) hash(items []string, key string) store.Hash {
+	if len(items)%2 != 0 {
+		r.fail(fmt.Errorf("hash %q with a field missing its value", key))
+		return nil
+	}
+	h := make(store.Hash, len(items)/2)
+	for i := 0; i < len(items); i += 2 {
+		h[items[i]] = items[i+1]
+	}
+	return h
+}
+
+// builds a sorted set out of members followed by their scores
+func (r *reader) zset(items []string, key string) *store.ZSet {
+	z := store.NewZSet()
+	if len(items)%2 != 0 {
+		r.fail(fmt.Errorf("sorted set %q with a member missing its score", key))
+		return z
+	}
+	for i := 0; i < len(items); i += 2 {
+		score, err := strconv.ParseFloat(items[i+1], 64)
+		if err != nil || math.IsNaN(score) {
+			r.fail(fmt.Errorf("invalid score %q in sorted set %q", items[i+1], key))
+			return z
+		}
+		z.Add(items[i], score)
+	}
+	return z
+}
+
+// score of the first sorted set type, its length in a byte followed by the digits
+// 253 to 255 stand for nan, +inf and -inf
+func (r *reader) score() float64 {
+	switch n := r.byte(); n {
+	case 253:
+		return math.NaN()
+	case 254:
+		return math.Inf(1)
+	case 255:
+		return math.Inf(-1)
+	default:
+		b := r.bytes(int(n))
+		score, err := strconv.ParseFloat(string(b), 64)
+		if r.err == nil && err != nil {
+			r.fail(fmt.Errorf("invalid score %q", b))
+		}
+		return score
+	}
+}
+
+func (r *reader) byte() byte {
+	if r.err != nil {
+		return 0
+	}
+	b, err := r.r.ReadByte()
+	if err != nil {
+		r.fail(err)
+		return 0
+	}
+	r.crc.Write([]byte{b})
+	return b
+}
+
+func (r *reader) bytes(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(r.r, buf); err != nil {
+		r.fail(err)
+		return nil
+	}
+	r.crc.Write(buf)
+	return buf
+}
+
+// returns the n bytes as a little endian signed integer
+func (r *reader) int(n int) int64 {
+	buf := r.bytes(n)
+	if buf == nil {
+		return 0
+	}
+	c := &cursor{b: buf}
+	return c.int(n)
+}
+
+// reads a length, failing on the special encodings of strings
+func (r *reader) length() uint64 {
+	n, encoded := r.encodedLength()
+	if encoded {
+		r.fail(fmt.Errorf("unexpected string encoding %d", n))
+		return 0
+	}
+	return n
+}
+
+// reads a length, or the kind of a special encoding if the top 2 bits of its first byte are set
+func (r *reader) encodedLength() (uint64, bool) {
+	b := r.byte()
+	switch b >> 6 {
+	case 0:
+		return uint64(b & 0x3F), false
+	case 1:
+		return uint64(b&0x3F)<<8 | uint64(r.byte()), false
+	case 3:
+		return uint64(b & 0x3F), true
+	}
+
+	switch b {
+	case 0x80:
+		buf := r.bytes(4)
+		if buf != nil {
+			return uint64(binary.BigEndian.Uint32(buf)), false
+		}
+	case 0x81:
+		buf := r.bytes(8)
+		if buf != nil {
+			return binary.BigEndian.Uint64(buf), false
+		}
+	default:
+		r.fail(fmt.Errorf("unknown length encoding %#x", b))
+	}
+	return 0, false
+}
+
+// reads a string, which may be saved as an integer or compressed
+func (r *reader) string() string {
+	n, encoded := r.encodedLength()
+	if r.err != nil {
+		return ""
+	}
+	if !encoded {
+		if n > uint64(maxStringLen) {
+			r.fail(errors.New("string too long"))
+			return ""
+		}
+		return r.chunked(int(n))
+	}
+
+	switch byte(n) {
+	case encInt8:
+		return strconv.FormatInt(r.int(1), 10)
+	case encInt16:
+		return strconv.FormatInt(r.int(2), 10)
+	case encInt32:
+		return strconv.FormatInt(r.int(4), 10)
+	case encLZF:
+		clen, ulen := r.length(), r.length()
+		if clen > uint64(maxStringLen) || ulen > uint64(maxStringLen) {
+			r.fail(errors.New("string too long"))
+			return ""
+		}
+		data := r.chunked(int(clen))
+		if r.err != nil {
+			return ""
+		}
+		out, err := lzfDecompress([]byte(data), int(ulen))
+		if err != nil {
+			r.fail(err)
+			return ""
+		}
+		return string(out)
+	default:
+		r.fail(fmt.Errorf("unknown string encoding %d", n))
+		return ""
+	}
+}
+
+// read in chunks, so a corrupt length fails at the end of the file instead of being allocated upfront
+func (r *reader) chunked(n int) string {
+	const chunk = 64 * 1024
+	buf := make([]byte, 0, min(n, chunk))
+	for len(buf) < n && r.err == nil {
+		buf = append(buf, r.bytes(min(n-len(buf), chunk))...)
+	}
+	return string(buf)
+}
+
+func (r *reader) strings(n uint64) []string {
+	ss := make([]string, 0, min(n, uint64(maxPrealloc)))
+	for i := uint64(0); i < n && r.err == nil; i++ {
+		ss = append(ss, r.string())
+	}
+	return ss
+}
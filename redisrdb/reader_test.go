@@ -0,0 +1,369 @@
+package redisrdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store"
+)
+
+// builds a dump of the given version out of its body, the checksum is appended
+func rdbFile(version int, body ...[]byte) []byte {
+	b := []byte(fmt.Sprintf("REDIS%04d", version))
+	for _, p := range body {
+		b = append(b, p...)
+	}
+	b = append(b, opEOF)
+	var crc checksum
+	crc.Write(b)
+	return binary.LittleEndian.AppendUint64(b, uint64(crc))
+}
+
+// plain string of less than 16384 bytes
+func rdbString(s string) []byte {
+	if len(s) < 64 {
+		return append([]byte{byte(len(s))}, s...)
+	}
+	return append([]byte{0x40 | byte(len(s)>>8), byte(len(s))}, s...)
+}
+
+func concat(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
+
+// ziplist of strings under 64 bytes and integers, each int in the encoding redis picks for it
+func ziplist(items ...any) []byte {
+	entries, prev := []byte{}, 0
+	for _, item := range items {
+		var e []byte
+		switch v := item.(type) {
+		case string:
+			e = append([]byte{byte(prev), byte(len(v))}, v...)
+		case int64:
+			e = []byte{byte(prev)}
+			switch {
+			case v >= 0 && v <= 12:
+				e = append(e, 0xF1+byte(v))
+			case v >= math.MinInt8 && v <= math.MaxInt8:
+				e = append(e, 0xFE, byte(v))
+			case v >= math.MinInt16 && v <= math.MaxInt16:
+				e = binary.LittleEndian.AppendUint16(append(e, 0xC0), uint16(v))
+			case v >= -1<<23 && v < 1<<23:
+				e = append(e, 0xF0, byte(v), byte(v>>8), byte(v>>16))
+			case v >= math.MinInt32 && v <= math.MaxInt32:
+				e = binary.LittleEndian.AppendUint32(append(e, 0xD0), uint32(v))
+			default:
+				e = binary.LittleEndian.AppendUint64(append(e, 0xE0), uint64(v))
+			}
+		}
+		entries, prev = append(entries, e...), len(e)
+	}
+	header := binary.LittleEndian.AppendUint32(nil, uint32(11+len(entries)))
+	header = binary.LittleEndian.AppendUint32(header, 0)
+	header = binary.LittleEndian.AppendUint16(header, uint16(len(items)))
+	return append(append(header, entries...), 0xFF)
+}
+
+// listpack of strings under 4096 bytes and integers, each int in the encoding redis picks for it
+func listpack(items ...any) []byte {
+	entries := []byte{}
+	for _, item := range items {
+		var e []byte
+		switch v := item.(type) {
+		case string:
+			if len(v) < 64 {
+				e = append([]byte{0x80 | byte(len(v))}, v...)
+			} else {
+				e = append([]byte{0xE0 | byte(len(v)>>8), byte(len(v))}, v...)
+			}
+		case int64:
+			switch {
+			case v >= 0 && v <= 127:
+				e = []byte{byte(v)}
+			case v >= -4096 && v < 4096:
+				u := uint16(v) & 0x1FFF
+				e = []byte{0xC0 | byte(u>>8), byte(u)}
+			case v >= math.MinInt16 && v <= math.MaxInt16:
+				e = binary.LittleEndian.AppendUint16([]byte{0xF1}, uint16(v))
+			case v >= -1<<23 && v < 1<<23:
+				e = []byte{0xF2, byte(v), byte(v >> 8), byte(v >> 16)}
+			case v >= math.MinInt32 && v <= math.MaxInt32:
+				e = binary.LittleEndian.AppendUint32([]byte{0xF3}, uint32(v))
+			default:
+				e = binary.LittleEndian.AppendUint64([]byte{0xF4}, uint64(v))
+			}
+		}
+		entries = append(append(entries, e...), byte(len(e)))
+	}
+	header := binary.LittleEndian.AppendUint32(nil, uint32(7+len(entries)))
+	header = binary.LittleEndian.AppendUint16(header, uint16(len(items)))
+	return append(append(header, entries...), 0xFF)
+}
+
+func intset(size int, ints ...int64) []byte {
+	b := binary.LittleEndian.AppendUint32(nil, uint32(size))
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(ints)))
+	for _, n := range ints {
+		for i := 0; i < size; i++ {
+			b = append(b, byte(n>>(8*i)))
+		}
+	}
+	return b
+}
+
+func packed(b []byte) []byte {
+	return rdbString(string(b))
+}
+
+func zset(items ...store.ZItem) *store.ZSet {
+	z := store.NewZSet()
+	for _, item := range items {
+		z.Add(item.Member, item.Score)
+	}
+	return z
+}
+
+// sorted sets are compared by their items, as their skiplists differ
+func equalDbs(a, b map[int][]store.Entry) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for idx, entries := range a {
+		if len(entries) != len(b[idx]) {
+			return false
+		}
+		for i, e := range entries {
+			other := b[idx][i]
+			if z, ok := e.Value.(*store.ZSet); ok {
+				oz, ok := other.Value.(*store.ZSet)
+				if !ok || e.Key != other.Key || e.ExpireAt != other.ExpireAt || !reflect.DeepEqual(z.Items(), oz.Items()) {
+					return false
+				}
+				continue
+			}
+			if !reflect.DeepEqual(e, other) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestRead(t *testing.T) {
+	long := strings.Repeat("x", 300)
+	testCases := []struct {
+		name    string
+		version int
+		body    [][]byte
+		exp     map[int][]store.Entry
+	}{
+		{
+			name:    "strings and integers encoded as strings",
+			version: 11,
+			body: [][]byte{
+				{opSelectDB, 0}, {typeString}, rdbString("s"), rdbString("hello"),
+				{typeString}, rdbString("i8"), {0xC0, 0xF6},
+				{typeString}, rdbString("i16"), {0xC1, 0x39, 0x30},
+				{typeString}, rdbString("i32"), {0xC2, 0x00, 0x00, 0x00, 0x80},
+				{typeString}, rdbString("long"), rdbString(long),
+				// "abc" as literals followed by a reference to it 9 bytes long
+				{typeString}, rdbString("lzf"), {0xC3, 7, 12, 2, 'a', 'b', 'c', 0xE0, 0, 2},
+			},
+			exp: map[int][]store.Entry{0: {
+				{Key: "s", Value: store.String("hello")},
+				{Key: "i8", Value: store.String("-10")},
+				{Key: "i16", Value: store.String("12345")},
+				{Key: "i32", Value: store.String("-2147483648")},
+				{Key: "long", Value: store.String(long)},
+				{Key: "lzf", Value: store.String("abcabcabcabc")},
+			}},
+		},
+		{
+			name:    "aux fields, resizedb, idle, freq and functions skipped",
+			version: 11,
+			body: [][]byte{
+				{opAux}, rdbString("redis-ver"), rdbString("7.2.4"), {opAux}, rdbString("redis-bits"), {0xC0, 64},
+				{opFunction2}, rdbString("#!lua name=lib"),
+				{opSelectDB, 0, opResizeDB, 2, 0},
+				{opIdle, 10, typeString}, rdbString("a"), rdbString("1"),
+				{opFreq, 5, typeString}, rdbString("b"), rdbString("2"),
+			},
+			exp: map[int][]store.Entry{0: {
+				{Key: "a", Value: store.String("1")},
+				{Key: "b", Value: store.String("2")},
+			}},
+		},
+		{
+			name:    "expiries in ms and in seconds",
+			version: 9,
+			body: [][]byte{
+				{opSelectDB, 0},
+				{opExpireMs}, binary.LittleEndian.AppendUint64(nil, 1700000000123), {typeString}, rdbString("ms"), rdbString("v"),
+				{opExpire}, binary.LittleEndian.AppendUint32(nil, 1700000000), {typeString}, rdbString("sec"), rdbString("v"),
+				{typeString}, rdbString("none"), rdbString("v"),
+			},
+			exp: map[int][]store.Entry{0: {
+				{Key: "ms", Value: store.String("v"), ExpireAt: 1700000000123},
+				{Key: "sec", Value: store.String("v"), ExpireAt: 1700000000000},
+				{Key: "none", Value: store.String("v")},
+			}},
+		},
+		{
+			name:    "several dbs",
+			version: 10,
+			body: [][]byte{
+				{opSelectDB, 0, typeString}, rdbString("a"), rdbString("1"),
+				{opSelectDB, 3, typeString}, rdbString("b"), rdbString("2"),
+				{opSelectDB, 0, typeString}, rdbString("c"), rdbString("3"),
+				{opSelectDB, 5},
+			},
+			exp: map[int][]store.Entry{
+				0: {{Key: "a", Value: store.String("1")}, {Key: "c", Value: store.String("3")}},
+				3: {{Key: "b", Value: store.String("2")}},
+				5: {},
+			},
+		},
+		{
+			name:    "lists",
+			version: 11,
+			body: [][]byte{
+				{opSelectDB, 0},
+				{typeList}, rdbString("plain"), {2}, rdbString("a"), rdbString("b"),
+				{typeListZiplist}, rdbString("zl"), packed(ziplist("a", int64(5), int64(-100), int64(1000), int64(-70000), int64(1<<30), int64(1<<40))),
+				{typeListQuicklist}, rdbString("ql"), {2}, packed(ziplist("a", "b")), packed(ziplist(int64(3))),
+				{typeListQuicklist2}, rdbString("ql2"), {2, byte(containerPacked)}, packed(listpack("a", int64(7), int64(-3000), int64(20000), int64(-70000), int64(1<<30), int64(1<<40))),
+				{byte(containerPlain)}, rdbString(long),
+			},
+			exp: map[int][]store.Entry{0: {
+				{Key: "plain", Value: store.NewList("a", "b")},
+				{Key: "zl", Value: store.NewList("a", "5", "-100", "1000", "-70000", "1073741824", "1099511627776")},
+				{Key: "ql", Value: store.NewList("a", "b", "3")},
+				{Key: "ql2", Value: store.NewList("a", "7", "-3000", "20000", "-70000", "1073741824", "1099511627776", long)},
+			}},
+		},
+		{
+			name:    "sets",
+			version: 11,
+			body: [][]byte{
+				{opSelectDB, 0},
+				{typeSet}, rdbString("plain"), {2}, rdbString("a"), rdbString("b"),
+				{typeSetIntset}, rdbString("is16"), packed(intset(2, -1, 2, 300)),
+				{typeSetIntset}, rdbString("is64"), packed(intset(8, 1<<40)),
+				{typeSetListpack}, rdbString("lp"), packed(listpack("a", int64(1))),
+			},
+			exp: map[int][]store.Entry{0: {
+				{Key: "plain", Value: store.Set{"a": {}, "b": {}}},
+				{Key: "is16", Value: store.Set{"-1": {}, "2": {}, "300": {}}},
+				{Key: "is64", Value: store.Set{"1099511627776": {}}},
+				{Key: "lp", Value: store.Set{"a": {}, "1": {}}},
+			}},
+		},
+		{
+			name:    "hashes",
+			version: 11,
+			body: [][]byte{
+				{opSelectDB, 0},
+				{typeHash}, rdbString("plain"), {1}, rdbString("f"), rdbString("v"),
+				{typeHashZiplist}, rdbString("zl"), packed(ziplist("f", int64(1), "g", "w")),
+				{typeHashListpack}, rdbString("lp"), packed(listpack("f", "v", "n", int64(-5))),
+			},
+			exp: map[int][]store.Entry{0: {
+				{Key: "plain", Value: store.Hash{"f": "v"}},
+				{Key: "zl", Value: store.Hash{"f": "1", "g": "w"}},
+				{Key: "lp", Value: store.Hash{"f": "v", "n": "-5"}},
+			}},
+		},
+		{
+			name:    "sorted sets",
+			version: 11,
+			body: [][]byte{
+				{opSelectDB, 0},
+				{typeZSet}, rdbString("z1"), {3}, rdbString("a"), {3}, []byte("1.5"), rdbString("b"), {254}, rdbString("c"), {255},
+				{typeZSet2}, rdbString("z2"), {1}, rdbString("a"), binary.LittleEndian.AppendUint64(nil, math.Float64bits(-2.25)),
+				{typeZSetZiplist}, rdbString("zl"), packed(ziplist("a", int64(1), "b", "2.5")),
+				{typeZSetListpack}, rdbString("lp"), packed(listpack("a", int64(-1), "b", "inf")),
+			},
+			exp: map[int][]store.Entry{0: {
+				{Key: "z1", Value: zset(store.ZItem{Member: "a", Score: 1.5}, store.ZItem{Member: "b", Score: math.Inf(1)}, store.ZItem{Member: "c", Score: math.Inf(-1)})},
+				{Key: "z2", Value: zset(store.ZItem{Member: "a", Score: -2.25})},
+				{Key: "zl", Value: zset(store.ZItem{Member: "a", Score: 1}, store.ZItem{Member: "b", Score: 2.5})},
+				{Key: "lp", Value: zset(store.ZItem{Member: "a", Score: -1}, store.ZItem{Member: "b", Score: math.Inf(1)})},
+			}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dbs, err := Read(bytes.NewReader(rdbFile(tc.version, tc.body...)))
+			if err != nil {
+				t.Fatalf("Expected no error but got %v", err)
+			}
+			if !equalDbs(dbs, tc.exp) {
+				t.Errorf("Expected %v but got %v", tc.exp, dbs)
+			}
+		})
+	}
+}
+
+func TestReadChecksum(t *testing.T) {
+	data := rdbFile(11, []byte{opSelectDB, 0, typeString}, rdbString("a"), rdbString("1"))
+
+	// redis saves a checksum of 0 when rdbchecksum is off
+	unchecked := append(bytes.Clone(data[:len(data)-8]), make([]byte, 8)...)
+	if _, err := Read(bytes.NewReader(unchecked)); err != nil {
+		t.Errorf("Expected no error but got %v", err)
+	}
+
+	// dumps before version 5 have no checksum at all
+	old := []byte("REDIS0004\xfe\x00\x00\x01a\x011\xff")
+	if dbs, err := Read(bytes.NewReader(old)); err != nil || len(dbs[0]) != 1 {
+		t.Errorf("Expected a key but got %v and error %v", dbs, err)
+	}
+
+	data[len(data)-1] ^= 1
+	if _, err := Read(bytes.NewReader(data)); !errors.Is(err, ErrChecksum) {
+		t.Errorf("Expected error %v but got %v", ErrChecksum, err)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	valid := rdbFile(11, []byte{opSelectDB, 0, typeString}, rdbString("a"), rdbString("1"))
+
+	testCases := []struct {
+		name   string
+		data   []byte
+		expErr error
+	}{
+		{"empty", nil, ErrNotRDB},
+		{"other format", []byte("GOREDIS\x01\xff"), ErrNotRDB},
+		{"version not a number", []byte("REDIS00x1\xff"), ErrNotRDB},
+		{"newer version", rdbFile(12), ErrUnsupportedVersion},
+		{"truncated", valid[:len(valid)-10], ErrCorrupt},
+		{"no checksum", valid[:len(valid)-8], ErrCorrupt},
+		{"stream", rdbFile(11, []byte{opSelectDB, 0, 21}, rdbString("s")), ErrUnsupportedType},
+		{"module aux", rdbFile(11, []byte{opModuleAux}), ErrCorrupt},
+		{"length encoding", rdbFile(11, []byte{opSelectDB, 0xC0}), ErrCorrupt},
+		{"lzf length mismatch", rdbFile(11, []byte{opSelectDB, 0, typeString}, rdbString("k"), []byte{0xC3, 2, 5, 0, 'a'}), ErrCorrupt},
+		{"lzf reference before the start", rdbFile(11, []byte{opSelectDB, 0, typeString}, rdbString("k"), []byte{0xC3, 2, 3, 0x20, 5}), ErrCorrupt},
+		{"ziplist cut", rdbFile(11, []byte{opSelectDB, 0, typeListZiplist}, rdbString("k"), packed(ziplist("abc")[:13])), ErrCorrupt},
+		{"listpack cut", rdbFile(11, []byte{opSelectDB, 0, typeSetListpack}, rdbString("k"), packed(listpack("abc")[:8])), ErrCorrupt},
+		{"intset encoding", rdbFile(11, []byte{opSelectDB, 0, typeSetIntset}, rdbString("k"), packed(intset(3, 1))), ErrCorrupt},
+		{"hash with a field alone", rdbFile(11, []byte{opSelectDB, 0, typeHashListpack}, rdbString("k"), packed(listpack("f"))), ErrCorrupt},
+		{"nan score", rdbFile(11, []byte{opSelectDB, 0, typeZSet}, rdbString("k"), []byte{1}, rdbString("a"), []byte{253}), ErrCorrupt},
+		{"quicklist container", rdbFile(11, []byte{opSelectDB, 0, typeListQuicklist2}, rdbString("k"), []byte{1, 3}, rdbString("a")), ErrCorrupt},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := Read(bytes.NewReader(tc.data)); !errors.Is(err, tc.expErr) {
+				t.Errorf("Expected error %v but got %v", tc.expErr, err)
+			}
+		})
+	}
+}
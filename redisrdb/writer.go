@@ -0,0 +1,181 @@
+package redisrdb
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store"
+)
+
+// writer encodes a dump, errors of the underlying writer are sticky and reported by Flush
+type writer struct {
+	w   *bufio.Writer
+	buf [8]byte
+}
+
+// Write encodes the entries of every db, by index, as a dump of WriteVersion which redis-server can load
+// values are saved in their plain encodings, which any redis reading the version loads, and strings aren't compressed
+// streams aren't supported, ErrUnsupportedType is returned on the first one
+func Write(w io.Writer, dbs map[int][]store.Entry) error {
+	var crc checksum
+	wr := &writer{w: bufio.NewWriter(io.MultiWriter(w, &crc))}
+
+	fmt.Fprintf(wr.w, "%s%04d", Magic, WriteVersion)
+	wr.aux("redis-bits", "64")
+
+	idxs := make([]int, 0, len(dbs))
+	for idx, entries := range dbs {
+		if len(entries) > 0 {
+			idxs = append(idxs, idx)
+		}
+	}
+	slices.Sort(idxs)
+
+	for _, idx := range idxs {
+		expires := 0
+		for _, e := range dbs[idx] {
+			if e.ExpireAt != 0 {
+				expires++
+			}
+		}
+		wr.w.WriteByte(opSelectDB)
+		wr.length(uint64(idx))
+		wr.w.WriteByte(opResizeDB)
+		wr.length(uint64(len(dbs[idx])))
+		wr.length(uint64(expires))
+		for _, e := range dbs[idx] {
+			if err := wr.entry(e); err != nil {
+				return err
+			}
+		}
+	}
+	wr.w.WriteByte(opEOF)
+
+	// the checksum covers everything written so far, so it's taken once the rest is flushed
+	if err := wr.w.Flush(); err != nil {
+		return err
+	}
+	binary.LittleEndian.PutUint64(wr.buf[:8], uint64(crc))
+	_, err := w.Write(wr.buf[:8])
+	return err
+}
+
+// SaveFile writes the dump to a temp file next to path and renames it once it's synced,
+// so path always holds a complete dump
+func SaveFile(path string, dbs map[int][]store.Entry) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "temp-*.rdb")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if err := Write(f, dbs); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+func (w *writer) aux(name, val string) {
+	w.w.WriteByte(opAux)
+	w.string(name)
+	w.string(val)
+}
+
+func (w *writer) entry(e store.Entry) error {
+	if e.ExpireAt != 0 {
+		w.w.WriteByte(opExpireMs)
+		w.w.Write(binary.LittleEndian.AppendUint64(w.buf[:0], uint64(e.ExpireAt)))
+	}
+
+	switch v := e.Value.(type) {
+	case store.String:
+		w.w.WriteByte(typeString)
+		w.string(e.Key)
+		w.string(string(v))
+	case *store.List:
+		w.w.WriteByte(typeList)
+		w.string(e.Key)
+		items := v.Values()
+		w.length(uint64(len(items)))
+		for _, item := range items {
+			w.string(item)
+		}
+	case store.Set:
+		w.w.WriteByte(typeSet)
+		w.string(e.Key)
+		w.length(uint64(len(v)))
+		for member := range v {
+			w.string(member)
+		}
+	case store.Hash:
+		w.w.WriteByte(typeHash)
+		w.string(e.Key)
+		w.length(uint64(len(v)))
+		for field, val := range v {
+			w.string(field)
+			w.string(val)
+		}
+	case *store.ZSet:
+		w.w.WriteByte(typeZSet2)
+		w.string(e.Key)
+		items := v.Items()
+		w.length(uint64(len(items)))
+		for _, item := range items {
+			w.string(item.Member)
+			w.w.Write(binary.LittleEndian.AppendUint64(w.buf[:0], math.Float64bits(item.Score)))
+		}
+	default:
+		return fmt.Errorf("%w, can't save the %s value of key %q", ErrUnsupportedType, e.Value.Type(), e.Key)
+	}
+	return nil
+}
+
+// lengths under 64 take a byte, under 16384 two, then a marker byte and 4 or 8 big endian bytes
+func (w *writer) length(n uint64) {
+	switch {
+	case n < 1<<6:
+		w.w.WriteByte(byte(n))
+	case n < 1<<14:
+		w.w.Write([]byte{0x40 | byte(n>>8), byte(n)})
+	case n <= math.MaxUint32:
+		w.w.WriteByte(0x80)
+		w.w.Write(binary.BigEndian.AppendUint32(w.buf[:0], uint32(n)))
+	default:
+		w.w.WriteByte(0x81)
+		w.w.Write(binary.BigEndian.AppendUint64(w.buf[:0], n))
+	}
+}
+
+// strings holding a 32 bits integer in its canonical form are saved as the integer, like redis does
+func (w *writer) string(s string) {
+	if n, err := strconv.ParseInt(s, 10, 32); err == nil && strconv.FormatInt(n, 10) == s {
+		switch {
+		case n >= math.MinInt8 && n <= math.MaxInt8:
+			w.w.Write([]byte{0xC0 | encInt8, byte(n)})
+		case n >= math.MinInt16 && n <= math.MaxInt16:
+			w.w.WriteByte(0xC0 | encInt16)
+			w.w.Write(binary.LittleEndian.AppendUint16(w.buf[:0], uint16(n)))
+		default:
+			w.w.WriteByte(0xC0 | encInt32)
+			w.w.Write(binary.LittleEndian.AppendUint32(w.buf[:0], uint32(n)))
+		}
+		return
+	}
+	w.length(uint64(len(s)))
+	w.w.WriteString(s)
+}
@@ -0,0 +1,119 @@
+package redisrdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store"
+)
+
+func TestChecksum(t *testing.T) {
+	// check value of the jones variant redis uses
+	var crc checksum
+	crc.Write([]byte("1234"))
+	crc.Write([]byte("56789"))
+	if exp := uint64(0xe9c6d914c4b8d9ca); uint64(crc) != exp {
+		t.Errorf("Expected %#x but got %#x", exp, uint64(crc))
+	}
+}
+
+func TestWriteLayout(t *testing.T) {
+	var buf bytes.Buffer
+	dbs := map[int][]store.Entry{
+		0: {},
+		2: {{Key: "a", Value: store.String("1"), ExpireAt: 1700000000123}},
+	}
+	if err := Write(&buf, dbs); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	exp := concat(
+		[]byte("REDIS0009"), []byte{opAux}, rdbString("redis-bits"), []byte{0xC0, 64},
+		[]byte{opSelectDB, 2, opResizeDB, 1, 1},
+		[]byte{opExpireMs}, binary.LittleEndian.AppendUint64(nil, 1700000000123), []byte{typeString}, rdbString("a"), []byte{0xC0, 1},
+		[]byte{opEOF},
+	)
+	var crc checksum
+	crc.Write(exp)
+	exp = binary.LittleEndian.AppendUint64(exp, uint64(crc))
+
+	if !bytes.Equal(buf.Bytes(), exp) {
+		t.Errorf("Expected %q but got %q", exp, buf.Bytes())
+	}
+}
+
+func TestWriteRead(t *testing.T) {
+	huge := strings.Repeat("y", 20000)
+	dbs := map[int][]store.Entry{
+		0: {
+			{Key: "str", Value: store.String("hello")},
+			{Key: "ints", Value: store.NewList("0", "-128", "127", "128", "-32769", "2147483647", "2147483648", "007", "-0", "+1")},
+			{Key: "huge", Value: store.String(huge)},
+			{Key: "empty", Value: store.String("")},
+			{Key: "ttl", Value: store.String("v"), ExpireAt: 1700000000123},
+		},
+		15: {
+			{Key: "set", Value: store.Set{"a": {}, "1": {}}},
+			{Key: "hash", Value: store.Hash{"f": "v", "n": "10"}},
+			{Key: "zset", Value: zset(store.ZItem{Member: "a", Score: 1.5}, store.ZItem{Member: "b", Score: math.Inf(-1)})},
+			{Key: strings.Repeat("k", 100), Value: store.NewList(strings.Repeat("v", 70))},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, dbs); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	read, err := Read(&buf)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if !equalDbs(read, dbs) {
+		t.Errorf("Expected %v but got %v", dbs, read)
+	}
+}
+
+func TestWriteStream(t *testing.T) {
+	s := store.NewStream()
+	s.Add(store.StreamID{Ms: 1, Seq: 1}, []string{"f", "v"})
+	err := Write(&bytes.Buffer{}, map[int][]store.Entry{0: {{Key: "s", Value: s}}})
+	if !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("Expected error %v but got %v", ErrUnsupportedType, err)
+	}
+}
+
+func TestSaveFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "dump.rdb")
+	dbs := map[int][]store.Entry{0: {{Key: "a", Value: store.String("1")}}}
+
+	if err := SaveFile(path, dbs); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	read, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if !reflect.DeepEqual(read, dbs) {
+		t.Errorf("Expected %v but got %v", dbs, read)
+	}
+
+	// a failed write leaves the previous dump and no temp file
+	s := store.NewStream()
+	if err := SaveFile(path, map[int][]store.Entry{0: {{Key: "s", Value: s}}}); err == nil {
+		t.Fatalf("Expected an error but got none")
+	}
+	if read, _ := LoadFile(path); !reflect.DeepEqual(read, dbs) {
+		t.Errorf("Expected %v but got %v", dbs, read)
+	}
+	if files, _ := os.ReadDir(dir); len(files) != 1 {
+		t.Errorf("Expected only the dump in the dir but got %d files", len(files))
+	}
+}
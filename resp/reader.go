@@ -0,0 +1,321 @@
+package resp
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	MaxInlineSize     int   = 64 * 1024         // max length of an inline command or a header line
+	DefaultMaxBulkLen int64 = 512 * 1024 * 1024 // max length of a single bulk string, unless configured
+	maxPreallocArg    int   = 1024              // args preallocated upfront for a multibulk request
+	maxPreallocBulk   int64 = 64 * 1024         // bytes preallocated upfront for a bulk string
+)
+
+var ErrProtocol = errors.New("Protocol error")
+
+// Reader reads client requests, either RESP arrays of bulk strings
+// or inline commands separated by spaces
+// a request can span any number of reads from the underlying reader
+type Reader struct {
+	r          *bufio.Reader
+	MaxBulkLen int64 // bulk strings longer than this are rejected
+}
+
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r), MaxBulkLen: DefaultMaxBulkLen}
+}
+
+// reads the next command from the stream
+// empty lines and empty arrays are skipped
+func (r *Reader) ReadCommand() ([]string, error) {
+	for {
+		line, err := r.readLine()
+		if err != nil {
+			return nil, err
+		}
+
+		var args []string
+		if len(line) > 0 && line[0] == '*' {
+			args, err = r.readMultiBulk(line)
+		} else {
+			args, err = SplitInline(line)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if len(args) > 0 {
+			return args, nil
+		}
+	}
+}
+
+// Buffered returns the number of bytes already read from the underlying reader
+// but not consumed yet, ie. pipelined requests waiting to be read
+func (r *Reader) Buffered() int {
+	return r.r.Buffered()
+}
+
+// Peek waits until more input is available without consuming it
+// returns the error of the underlying reader if the stream ended instead
+func (r *Reader) Peek() error {
+	_, err := r.r.Peek(1)
+	return err
+}
+
+// reads a line terminated by \n, trailing \r is dropped
+func (r *Reader) readLine() ([]byte, error) {
+	var line []byte
+	for {
+		chunk, err := r.r.ReadSlice('\n')
+		line = append(line, chunk...)
+		if len(line) > MaxInlineSize {
+			return nil, fmt.Errorf("%w: too big inline request", ErrProtocol)
+		}
+
+		if err == nil {
+			break
+		}
+		if err != bufio.ErrBufferFull {
+			if err == io.EOF && len(line) > 0 {
+				return nil, io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+	}
+
+	line = line[:len(line)-1]
+	if len(line) > 0 && line[len(line)-1] == '\r' {
+		line = line[:len(line)-1]
+	}
+	return line, nil
+}
+
+// reads the bulk strings of an array whose header line is already read
+func (r *Reader) readMultiBulk(header []byte) ([]string, error) {
+	n, err := strconv.ParseInt(string(header[1:]), 10, 64)
+	if err != nil || n > math.MaxInt32 {
+		return nil, fmt.Errorf("%w: invalid multibulk length", ErrProtocol)
+	}
+	if n <= 0 {
+		return nil, nil
+	}
+
+	args := make([]string, 0, min(int(n), maxPreallocArg))
+	for range n {
+		line, err := r.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if len(line) == 0 || line[0] != '$' {
+			got := "EOL"
+			if len(line) > 0 {
+				got = string(line[0])
+			}
+			return nil, fmt.Errorf("%w: expected '$', got '%s'", ErrProtocol, got)
+		}
+
+		size, err := strconv.ParseInt(string(line[1:]), 10, 64)
+		if err != nil || size < 0 || size > r.MaxBulkLen {
+			return nil, fmt.Errorf("%w: invalid bulk length", ErrProtocol)
+		}
+
+		arg, err := r.readBulk(size)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	return args, nil
+}
+
+// reads a bulk string payload of the given size followed by \r\n
+// the buffer grows as the data arrives, so a huge declared size alone doesn't allocate it all
+func (r *Reader) readBulk(size int64) (string, error) {
+	var buf bytes.Buffer
+	buf.Grow(int(min(size, maxPreallocBulk)) + 2)
+
+	// the payload is followed by \r\n
+	if _, err := io.CopyN(&buf, r.r, size+2); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return "", err
+	}
+
+	b := buf.Bytes()
+	if b[size] != '\r' || b[size+1] != '\n' {
+		return "", fmt.Errorf("%w: expected CRLF after bulk string", ErrProtocol)
+	}
+	return string(b[:size]), nil
+}
+
+// SplitInline splits an inline command into args
+// follows redis-cli quoting rules: "double quotes" support escapes like \n and \x00,
+// 'single quotes' are taken literally and a closing quote must be followed by a space
+func SplitInline(line []byte) ([]string, error) {
+	errUnbalanced := fmt.Errorf("%w: unbalanced quotes in request", ErrProtocol)
+	args := []string{}
+
+	i := 0
+	for {
+		// skip blanks between args
+		for i < len(line) && isSpace(line[i]) {
+			i++
+		}
+		if i == len(line) {
+			return args, nil
+		}
+
+		var (
+			current    []byte
+			inDoubleQt bool
+			inSingleQt bool
+			done       bool
+		)
+		for !done {
+			switch {
+			case inDoubleQt:
+				switch {
+				case i == len(line):
+					return nil, errUnbalanced
+				case line[i] == '\\' && i+3 < len(line) && line[i+1] == 'x' && isHex(line[i+2]) && isHex(line[i+3]):
+					current = append(current, hexVal(line[i+2])<<4|hexVal(line[i+3]))
+					i += 3
+				case line[i] == '\\' && i+1 < len(line):
+					i++
+					current = append(current, unescape(line[i]))
+				case line[i] == '"':
+					// closing quote must be followed by a space or nothing
+					if i+1 < len(line) && !isSpace(line[i+1]) {
+						return nil, errUnbalanced
+					}
+					done = true
+				default:
+					current = append(current, line[i])
+				}
+			case inSingleQt:
+				switch {
+				case i == len(line):
+					return nil, errUnbalanced
+				case line[i] == '\\' && i+1 < len(line) && line[i+1] == '\'':
+					i++
+					current = append(current, '\'')
+				case line[i] == '\'':
+					if i+1 < len(line) && !isSpace(line[i+1]) {
+						return nil, errUnbalanced
+					}
+					done = true
+				default:
+					current = append(current, line[i])
+				}
+			default:
+				switch {
+				case i == len(line) || isSpace(line[i]):
+					done = true
+				case line[i] == '"':
+					inDoubleQt = true
+				case line[i] == '\'':
+					inSingleQt = true
+				default:
+					current = append(current, line[i])
+				}
+			}
+			if i < len(line) {
+				i++
+			}
+		}
+		args = append(args, string(current))
+	}
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\v' || c == '\f'
+}
+
+func isHex(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+func hexVal(c byte) byte {
+	switch {
+	case c >= 'a':
+		return c - 'a' + 10
+	case c >= 'A':
+		return c - 'A' + 10
+	default:
+		return c - '0'
+	}
+}
+
+func unescape(c byte) byte {
+	switch c {
+	case 'n':
+		return '\n'
+	case 'r':
+		return '\r'
+	case 't':
+		return '\t'
+	case 'b':
+		return '\b'
+	case 'a':
+		return '\a'
+	default:
+		return c
+	}
+}
+
+// JoinInline turns args back into an inline command, which SplitInline splits into the same args
+// args with blanks, quotes or bytes which aren't printable are double quoted and escaped
+func JoinInline(args []string) string {
+	var b strings.Builder
+	for i, a := range args {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		if !needsQuotes(a) {
+			b.WriteString(a)
+			continue
+		}
+
+		b.WriteByte('"')
+		for j := 0; j < len(a); j++ {
+			switch c := a[j]; {
+			case c == '\\' || c == '"':
+				b.WriteByte('\\')
+				b.WriteByte(c)
+			case c == '\n':
+				b.WriteString("\\n")
+			case c == '\r':
+				b.WriteString("\\r")
+			case c == '\t':
+				b.WriteString("\\t")
+			case c < 0x20 || c > 0x7e:
+				fmt.Fprintf(&b, "\\x%02x", c)
+			default:
+				b.WriteByte(c)
+			}
+		}
+		b.WriteByte('"')
+	}
+	return b.String()
+}
+
+func needsQuotes(a string) bool {
+	if a == "" {
+		return true
+	}
+	for i := 0; i < len(a); i++ {
+		if c := a[i]; c <= ' ' || c > 0x7e || c == '"' || c == '\'' || c == '\\' {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,175 @@
+package resp
+
+import (
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestReadCommand(t *testing.T) {
+	testCases := []struct {
+		name   string
+		input  string
+		expOut [][]string
+	}{
+		{"array of bulk strings", "*3\r\n$3\r\nSET\r\n$3\r\nfoo\r\n$3\r\nbar\r\n", [][]string{{"SET", "foo", "bar"}}},
+		{"bulk string with spaces and CRLF", "*2\r\n$3\r\nGET\r\n$8\r\nfoo\r\nbar\r\n", [][]string{{"GET", "foo\r\nbar"}}},
+		{"empty bulk string", "*2\r\n$3\r\nGET\r\n$0\r\n\r\n", [][]string{{"GET", ""}}},
+		{"inline command", "PING\r\n", [][]string{{"PING"}}},
+		{"inline command without CR", "GET foo\n", [][]string{{"GET", "foo"}}},
+		{"inline command with quotes", "SET foo \"bar baz\"\r\n", [][]string{{"SET", "foo", "bar baz"}}},
+		{"empty lines and arrays are skipped", "\r\n*0\r\nPING\r\n", [][]string{{"PING"}}},
+		{"mixed commands", "*1\r\n$4\r\nPING\r\nGET foo\r\n", [][]string{{"PING"}, {"GET", "foo"}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := NewReader(strings.NewReader(tc.input))
+
+			for _, exp := range tc.expOut {
+				out, err := r.ReadCommand()
+				if err != nil {
+					t.Fatalf("Unexpected error occured: %v", err)
+				}
+				if !slices.Equal(exp, out) {
+					t.Errorf("Expected %q but got %q", exp, out)
+				}
+			}
+
+			_, err := r.ReadCommand()
+			if !errors.Is(err, io.EOF) {
+				t.Errorf("Expected %v at end of input but got %v", io.EOF, err)
+			}
+		})
+	}
+}
+
+func TestReadCommandErrors(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input string
+		err   error
+	}{
+		{"invalid multibulk length", "*abc\r\n", ErrProtocol},
+		{"missing bulk prefix", "*1\r\n:1\r\n", ErrProtocol},
+		{"invalid bulk length", "*1\r\n$-5\r\n", ErrProtocol},
+		{"bulk string longer than declared", "*1\r\n$3\r\nfoobar\r\n", ErrProtocol},
+		{"unbalanced quotes", "SET \"foo bar\r\n", ErrProtocol},
+		{"truncated bulk string", "*1\r\n$10\r\nfoo", io.ErrUnexpectedEOF},
+		{"truncated line", "*1\r\n$3", io.ErrUnexpectedEOF},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := NewReader(strings.NewReader(tc.input))
+
+			_, err := r.ReadCommand()
+			if err == nil {
+				t.Fatal("Expected error but got none")
+			}
+			if !errors.Is(err, tc.err) {
+				t.Errorf("Expected error %v but got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestSplitInline(t *testing.T) {
+	testCases := []struct {
+		name    string
+		input   string
+		expOut  []string
+		isError bool
+	}{
+		{"command without quotes", "SET foo bar", []string{"SET", "foo", "bar"}, false},
+		{"extra spaces", "  SET   foo\tbar  ", []string{"SET", "foo", "bar"}, false},
+		{"double quotes", "SET \"foo in quotes\" bar", []string{"SET", "foo in quotes", "bar"}, false},
+		{"single quotes", "SET foo 'it\\'s'", []string{"SET", "foo", "it's"}, false},
+		{"escapes in double quotes", "SET foo \"a\\nb\\x00c\"", []string{"SET", "foo", "a\nb\x00c"}, false},
+		{"empty quoted arg", "SET foo \"\"", []string{"SET", "foo", ""}, false},
+		{"closing quote followed by text", "SET foo bar\"in\"quotes", nil, true},
+		{"unbalanced quotes", "SET \"foo in quotes \"bar in quotes\"", nil, true},
+		{"unterminated single quote", "SET foo 'bar", nil, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := SplitInline([]byte(tc.input))
+
+			if tc.isError {
+				if !errors.Is(err, ErrProtocol) {
+					t.Fatalf("Expected protocol error but got %v", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Unexpected error occured: %v", err)
+			}
+			if !slices.Equal(tc.expOut, out) {
+				t.Errorf("Expected %q but got %q", tc.expOut, out)
+			}
+		})
+	}
+}
+
+func TestJoinInline(t *testing.T) {
+	testCases := []struct {
+		name   string
+		input  []string
+		expOut string
+	}{
+		{"plain args", []string{"SET", "foo", "bar"}, "SET foo bar"},
+		{"spaces", []string{"SET", "foo bar", "baz"}, "SET \"foo bar\" baz"},
+		{"empty arg", []string{"SET", "foo", ""}, "SET foo \"\""},
+		{"quotes and escapes", []string{"SET", "it's", "a\"b\\c"}, "SET \"it's\" \"a\\\"b\\\\c\""},
+		{"binary", []string{"SET", "k", "a\nb\x00\xff\t"}, "SET k \"a\\nb\\x00\\xff\\t\""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			out := JoinInline(tc.input)
+			if out != tc.expOut {
+				t.Errorf("Expected %q but got %q", tc.expOut, out)
+			}
+
+			// the args are split back as they were
+			args, err := SplitInline([]byte(out))
+			if err != nil {
+				t.Fatalf("Unexpected error occured: %v", err)
+			}
+			if !slices.Equal(tc.input, args) {
+				t.Errorf("Expected %q but got %q", tc.input, args)
+			}
+		})
+	}
+}
+
+func TestReadCommandMaxBulkLen(t *testing.T) {
+	input := "*2\r\n$3\r\nGET\r\n$5\r\nhello\r\n"
+
+	t.Run("bulk string within the limit", func(t *testing.T) {
+		r := NewReader(strings.NewReader(input))
+		r.MaxBulkLen = 5
+
+		out, err := r.ReadCommand()
+		if err != nil {
+			t.Fatalf("Unexpected error occured: %v", err)
+		}
+		if !slices.Equal([]string{"GET", "hello"}, out) {
+			t.Errorf("Expected %q but got %q", []string{"GET", "hello"}, out)
+		}
+	})
+
+	t.Run("bulk string over the limit", func(t *testing.T) {
+		r := NewReader(strings.NewReader(input))
+		r.MaxBulkLen = 4
+
+		_, err := r.ReadCommand()
+		if !errors.Is(err, ErrProtocol) {
+			t.Errorf("Expected protocol error but got %v", err)
+		}
+	})
+}
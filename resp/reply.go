@@ -0,0 +1,116 @@
+package resp
+
+import "strings"
+
+// Protocol selects how replies are encoded on a connection
+type Protocol int
+
+const (
+	Text  Protocol = 0 // human readable format, similar to redis-cli output
+	RESP2 Protocol = 2
+	RESP3 Protocol = 3
+)
+
+// Reply is a value that can be written back to a client
+type Reply interface {
+	reply()
+}
+
+// SimpleString is a short status reply, eg. OK or QUEUED
+type SimpleString string
+
+// Error is an error reply, the message starts with the error code, eg. ERR
+type Error string
+
+// Integer is a signed 64 bit integer reply
+type Integer int64
+
+// BulkString is a binary safe string reply
+type BulkString string
+
+// Verbatim is a block of plain text which is printed as is in text mode
+type Verbatim string
+
+// Array is an ordered collection of replies
+type Array []Reply
+
+// Map is an ordered collection of key value pairs, sent as a flat array in RESP2
+type Map []MapItem
+
+type MapItem struct {
+	Key   Reply
+	Value Reply
+}
+
+// Set is an unordered collection of unique replies, sent as an array in RESP2
+type Set []Reply
+
+// Double is a floating point reply, sent as a bulk string in RESP2
+type Double float64
+
+// Boolean is sent as the integer 1 or 0 in RESP2
+type Boolean bool
+
+// BigNumber is an integer of arbitrary size in its decimal form, sent as a bulk string in RESP2
+type BigNumber string
+
+// Push is an out of band message like a pub/sub message, sent as an array in RESP2
+type Push []Reply
+
+type nilReply struct{}
+
+type nilArrayReply struct{}
+
+var (
+	// Nil is the reply for a missing value
+	Nil Reply = nilReply{}
+	// NilArray is the reply for a missing collection, eg. an aborted transaction
+	NilArray Reply = nilArrayReply{}
+)
+
+func (SimpleString) reply()  {}
+func (Error) reply()         {}
+func (Integer) reply()       {}
+func (BulkString) reply()    {}
+func (Verbatim) reply()      {}
+func (Array) reply()         {}
+func (Map) reply()           {}
+func (Set) reply()           {}
+func (Double) reply()        {}
+func (Boolean) reply()       {}
+func (BigNumber) reply()     {}
+func (Push) reply()          {}
+func (nilReply) reply()      {}
+func (nilArrayReply) reply() {}
+
+// error codes which are sent as is, any other error message gets the generic ERR code
+var errorCodes = []string{"ERR", "EXECABORT", "NOPROTO", "WRONGTYPE", "BUSYGROUP", "NOGROUP", "UNBLOCKED", "MISCONF"}
+
+// NewError builds an error reply out of err
+func NewError(err error) Error {
+	msg := err.Error()
+	for _, code := range errorCodes {
+		if strings.HasPrefix(msg, code+" ") {
+			return Error(msg)
+		}
+	}
+	return Error("ERR " + msg)
+}
+
+// BulkStrings turns a list of strings into an array of bulk strings
+func BulkStrings(vals []string) Array {
+	arr := make(Array, len(vals))
+	for i, v := range vals {
+		arr[i] = BulkString(v)
+	}
+	return arr
+}
+
+// BulkStringMap turns key value pairs into a map of bulk strings
+func BulkStringMap(pairs ...string) Map {
+	m := make(Map, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		m = append(m, MapItem{BulkString(pairs[i]), BulkString(pairs[i+1])})
+	}
+	return m
+}
@@ -0,0 +1,227 @@
+package resp
+
+import (
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	MssgEmptyArray string = "(empty array)"
+	MssgNil        string = "(nil)"
+)
+
+// Writer encodes replies onto the underlying writer
+type Writer struct {
+	w        io.Writer
+	protocol Protocol
+}
+
+func NewWriter(w io.Writer, p Protocol) *Writer {
+	return &Writer{w: w, protocol: p}
+}
+
+// writes a single reply in the protocol of the writer
+func (w *Writer) WriteReply(r Reply) error {
+	_, err := w.w.Write(AppendReply(nil, w.protocol, r))
+	return err
+}
+
+// AppendReply appends the encoding of r in protocol p to b
+// RESP3 only types are downgraded to their RESP2 counterparts when p is RESP2
+func AppendReply(b []byte, p Protocol, r Reply) []byte {
+	if p == Text {
+		b = appendText(b, r, 0)
+		return append(b, '\n')
+	}
+	return appendRESP(b, p, r)
+}
+
+func appendRESP(b []byte, p Protocol, r Reply) []byte {
+	resp3 := p == RESP3
+
+	switch v := r.(type) {
+	case SimpleString:
+		return appendLine(b, '+', stripNewlines(string(v)))
+	case Error:
+		return appendLine(b, '-', stripNewlines(string(v)))
+	case Integer:
+		return appendLine(b, ':', strconv.FormatInt(int64(v), 10))
+	case BulkString:
+		return appendBulk(b, '$', string(v))
+	case Verbatim:
+		if resp3 {
+			return appendBulk(b, '=', "txt:"+string(v))
+		}
+		return appendBulk(b, '$', string(v))
+	case Array:
+		return appendAggregate(b, '*', p, v)
+	case Set:
+		if resp3 {
+			return appendAggregate(b, '~', p, v)
+		}
+		return appendAggregate(b, '*', p, v)
+	case Push:
+		if resp3 {
+			return appendAggregate(b, '>', p, v)
+		}
+		return appendAggregate(b, '*', p, v)
+	case Map:
+		if resp3 {
+			b = appendLine(b, '%', strconv.Itoa(len(v)))
+		} else {
+			b = appendLine(b, '*', strconv.Itoa(len(v)*2))
+		}
+		for _, item := range v {
+			b = appendRESP(b, p, item.Key)
+			b = appendRESP(b, p, item.Value)
+		}
+		return b
+	case Double:
+		if resp3 {
+			return appendLine(b, ',', FormatDouble(float64(v)))
+		}
+		return appendBulk(b, '$', FormatDouble(float64(v)))
+	case Boolean:
+		switch {
+		case resp3 && bool(v):
+			return append(b, "#t\r\n"...)
+		case resp3:
+			return append(b, "#f\r\n"...)
+		case bool(v):
+			return append(b, ":1\r\n"...)
+		default:
+			return append(b, ":0\r\n"...)
+		}
+	case BigNumber:
+		if resp3 {
+			return appendLine(b, '(', string(v))
+		}
+		return appendBulk(b, '$', string(v))
+	case nilArrayReply:
+		if resp3 {
+			return append(b, "_\r\n"...)
+		}
+		return append(b, "*-1\r\n"...)
+	default:
+		if resp3 {
+			return append(b, "_\r\n"...)
+		}
+		return append(b, "$-1\r\n"...)
+	}
+}
+
+func appendLine(b []byte, prefix byte, s string) []byte {
+	b = append(b, prefix)
+	b = append(b, s...)
+	return append(b, '\r', '\n')
+}
+
+func appendBulk(b []byte, prefix byte, s string) []byte {
+	b = appendLine(b, prefix, strconv.Itoa(len(s)))
+	b = append(b, s...)
+	return append(b, '\r', '\n')
+}
+
+func appendAggregate(b []byte, prefix byte, p Protocol, items []Reply) []byte {
+	b = appendLine(b, prefix, strconv.Itoa(len(items)))
+	for _, e := range items {
+		b = appendRESP(b, p, e)
+	}
+	return b
+}
+
+// simple strings and errors can't carry line breaks
+func stripNewlines(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
+
+// FormatDouble formats f the way redis does: integral values without exponent
+// and the shortest representation that round trips otherwise
+func FormatDouble(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "inf"
+	case math.IsInf(f, -1):
+		return "-inf"
+	case math.IsNaN(f):
+		return "nan"
+	case f == math.Trunc(f) && math.Abs(f) < 1e17:
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	default:
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	}
+}
+
+// text format mirrors the output of redis-cli
+// indent is the column at which nested items start
+func appendText(b []byte, r Reply, indent int) []byte {
+	switch v := r.(type) {
+	case SimpleString:
+		return append(b, v...)
+	case Error:
+		return append(append(b, "(error) "...), v...)
+	case Integer:
+		return append(append(b, "(integer) "...), strconv.FormatInt(int64(v), 10)...)
+	case BulkString:
+		return strconv.AppendQuote(b, string(v))
+	case Verbatim:
+		return append(b, v...)
+	case Double:
+		return append(append(b, "(double) "...), FormatDouble(float64(v))...)
+	case Boolean:
+		if v {
+			return append(b, "(true)"...)
+		}
+		return append(b, "(false)"...)
+	case BigNumber:
+		return append(append(b, "(big number) "...), v...)
+	case Array:
+		return appendTextItems(b, ')', v, indent)
+	case Push:
+		return appendTextItems(b, ')', v, indent)
+	case Set:
+		return appendTextItems(b, '~', v, indent)
+	case Map:
+		if len(v) == 0 {
+			return append(b, MssgEmptyArray...)
+		}
+
+		width := len(strconv.Itoa(len(v)))
+		for i, item := range v {
+			b = appendTextIndex(b, '#', i, width, indent)
+			b = appendText(b, item.Key, indent+width+2)
+			b = append(b, " => "...)
+			b = appendText(b, item.Value, indent+width+2)
+		}
+		return b
+	default:
+		return append(b, MssgNil...)
+	}
+}
+
+func appendTextItems(b []byte, sep byte, items []Reply, indent int) []byte {
+	if len(items) == 0 {
+		return append(b, MssgEmptyArray...)
+	}
+
+	width := len(strconv.Itoa(len(items)))
+	for i, e := range items {
+		b = appendTextIndex(b, sep, i, width, indent)
+		b = appendText(b, e, indent+width+2)
+	}
+	return b
+}
+
+// writes the "n) " like prefix of the ith item, items after the first start on a new line
+func appendTextIndex(b []byte, sep byte, i, width, indent int) []byte {
+	if i > 0 {
+		b = append(b, '\n')
+		b = append(b, strings.Repeat(" ", indent)...)
+	}
+	idx := strconv.Itoa(i + 1)
+	b = append(b, strings.Repeat(" ", width-len(idx))...)
+	b = append(b, idx...)
+	return append(b, sep, ' ')
+}
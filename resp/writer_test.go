@@ -0,0 +1,108 @@
+package resp
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestWriteReply(t *testing.T) {
+	testCases := []struct {
+		name     string
+		reply    Reply
+		expRESP2 string
+		expText  string
+	}{
+		{"simple string", SimpleString("OK"), "+OK\r\n", "OK\n"},
+		{"error", Error("ERR unknown command"), "-ERR unknown command\r\n", "(error) ERR unknown command\n"},
+		{"integer", Integer(-42), ":-42\r\n", "(integer) -42\n"},
+		{"bulk string", BulkString("bar"), "$3\r\nbar\r\n", "\"bar\"\n"},
+		{"binary bulk string", BulkString("a\r\n\x00"), "$4\r\na\r\n\x00\r\n", "\"a\\r\\n\\x00\"\n"},
+		{"nil", Nil, "$-1\r\n", "(nil)\n"},
+		{"nil array", NilArray, "*-1\r\n", "(nil)\n"},
+		{"verbatim", Verbatim("SET foo bar"), "$11\r\nSET foo bar\r\n", "SET foo bar\n"},
+		{"empty array", Array{}, "*0\r\n", "(empty array)\n"},
+		{"array", Array{SimpleString("OK"), BulkString("bar"), Nil}, "*3\r\n+OK\r\n$3\r\nbar\r\n$-1\r\n", "1) OK\n2) \"bar\"\n3) (nil)\n"},
+		{"nested array", Array{Integer(1), Array{BulkString("a"), BulkString("b")}}, "*2\r\n:1\r\n*2\r\n$1\r\na\r\n$1\r\nb\r\n", "1) (integer) 1\n2) 1) \"a\"\n   2) \"b\"\n"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			if err := NewWriter(&buf, RESP2).WriteReply(tc.reply); err != nil {
+				t.Fatalf("Unexpected error occured: %v", err)
+			}
+			if buf.String() != tc.expRESP2 {
+				t.Errorf("Expected RESP2 output %q but got %q", tc.expRESP2, buf.String())
+			}
+
+			buf.Reset()
+			if err := NewWriter(&buf, Text).WriteReply(tc.reply); err != nil {
+				t.Fatalf("Unexpected error occured: %v", err)
+			}
+			if buf.String() != tc.expText {
+				t.Errorf("Expected text output %q but got %q", tc.expText, buf.String())
+			}
+		})
+	}
+}
+
+func TestNewError(t *testing.T) {
+	testCases := []struct {
+		name   string
+		err    error
+		expOut Error
+	}{
+		{"generic error", errors.New("unknown command"), "ERR unknown command"},
+		{"error with own code", errors.New("EXECABORT Transaction discarded"), "EXECABORT Transaction discarded"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			out := NewError(tc.err)
+			if out != tc.expOut {
+				t.Errorf("Expected %q but got %q", tc.expOut, out)
+			}
+		})
+	}
+}
+
+func TestWriteReplyRESP3(t *testing.T) {
+	testCases := []struct {
+		name     string
+		reply    Reply
+		expRESP3 string
+		expRESP2 string
+		expText  string
+	}{
+		{"nil", Nil, "_\r\n", "$-1\r\n", "(nil)\n"},
+		{"nil array", NilArray, "_\r\n", "*-1\r\n", "(nil)\n"},
+		{"map", BulkStringMap("foo", "bar"), "%1\r\n$3\r\nfoo\r\n$3\r\nbar\r\n", "*2\r\n$3\r\nfoo\r\n$3\r\nbar\r\n", "1# \"foo\" => \"bar\"\n"},
+		{"set", Set{BulkString("a"), BulkString("b")}, "~2\r\n$1\r\na\r\n$1\r\nb\r\n", "*2\r\n$1\r\na\r\n$1\r\nb\r\n", "1~ \"a\"\n2~ \"b\"\n"},
+		{"double", Double(1.5), ",1.5\r\n", "$3\r\n1.5\r\n", "(double) 1.5\n"},
+		{"integral double", Double(100000000), ",100000000\r\n", "$9\r\n100000000\r\n", "(double) 100000000\n"},
+		{"infinite double", Double(math.Inf(-1)), ",-inf\r\n", "$4\r\n-inf\r\n", "(double) -inf\n"},
+		{"true", Boolean(true), "#t\r\n", ":1\r\n", "(true)\n"},
+		{"false", Boolean(false), "#f\r\n", ":0\r\n", "(false)\n"},
+		{"big number", BigNumber("1234567999999999999999"), "(1234567999999999999999\r\n", "$22\r\n1234567999999999999999\r\n", "(big number) 1234567999999999999999\n"},
+		{"verbatim", Verbatim("a b"), "=7\r\ntxt:a b\r\n", "$3\r\na b\r\n", "a b\n"},
+		{"push", Push{BulkString("message"), BulkString("ch")}, ">2\r\n$7\r\nmessage\r\n$2\r\nch\r\n", "*2\r\n$7\r\nmessage\r\n$2\r\nch\r\n", "1) \"message\"\n2) \"ch\"\n"},
+		{"map inside array", Array{BulkStringMap("a", "1")}, "*1\r\n%1\r\n$1\r\na\r\n$1\r\n1\r\n", "*1\r\n*2\r\n$1\r\na\r\n$1\r\n1\r\n", "1) 1# \"a\" => \"1\"\n"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, c := range []struct {
+				protocol Protocol
+				expOut   string
+			}{{RESP3, tc.expRESP3}, {RESP2, tc.expRESP2}, {Text, tc.expText}} {
+				out := string(AppendReply(nil, c.protocol, tc.reply))
+				if out != c.expOut {
+					t.Errorf("Expected output %q in protocol %d but got %q", c.expOut, c.protocol, out)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,751 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/aof"
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/db"
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/rdb"
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/resp"
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store"
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store/inMemoryStore"
+)
+
+const (
+	BGREWRITEAOF string = "BGREWRITEAOF"
+
+	MssgRewriteStarted string = "Background append only file rewriting started"
+)
+
+var (
+	ErrAppendFilenameIsPath = errors.New("appendfilename can't be a path, just a filename")
+	ErrAppendDirnameIsPath  = errors.New("appenddirname can't be a path, just a dirname")
+	ErrInvalidAppendFsync   = errors.New("argument(s) must be one of the following: always, everysec, no")
+	ErrRewriteInProgress    = errors.New("Background append only file rewriting already in progress")
+	ErrAOFOff               = errors.New("Background append only file rewriting needs appendonly turned on")
+	ErrAOFMisconf           = errors.New("MISCONF Errors writing to the AOF file")
+)
+
+// the writes are logged while appendonly is on, to files listed by a manifest in appenddirname
+// the log is made of a base holding the keys at some point, followed by incremental files with the writes done since
+type aofState struct {
+	mu       sync.Mutex
+	started  bool          // Start opened the files, CONFIG SET appendonly opens and closes them from then on
+	w        *aof.Writer   // last incremental file of the manifest, the writes go to it, nil unless appendonly
+	dir      string        // dir of the files, from dir and appenddirname once opened
+	prefix   string        // the names of the files start with it, from appendfilename once opened
+	manifest *aof.Manifest // files making up the log
+	size     int64         // size of the files of the manifest but w
+	dbIdx    int           // db of the last logged command, -1 if a SELECT must come first
+	writeErr error         // error of the last write or sync, nil if it went fine
+
+	rewriting    bool      // a new base is being written by BGREWRITEAOF
+	rewriteStart time.Time // start of the last rewrite
+	rewriteErr   error     // error of the last rewrite, nil if it went fine
+	rewrites     int64     // number of successful rewrites
+	baseSize     int64     // size of the files once the last rewrite was done, or once they were opened
+}
+
+// a write to log, along the db it was done to
+type aofEntry struct {
+	dbIdx int
+	args  []string
+}
+
+// returns the dir of the append only files, from the dir and appenddirname params
+func (s *Server) aofDir() string {
+	return filepath.Join(s.config.getDir(), s.config.getAppendDirname())
+}
+
+// returns the path of the single append only file written before the log was split into several files
+func (s *Server) legacyAOFPath() string {
+	return filepath.Join(s.config.getDir(), s.config.getAppendFilename())
+}
+
+func (a *aofState) manifestPath() string {
+	return filepath.Join(a.dir, aof.ManifestName(a.prefix))
+}
+
+// reports if the writes are being logged
+func (s *Server) aofOn() bool {
+	s.aof.mu.Lock()
+	defer s.aof.mu.Unlock()
+	return s.aof.w != nil
+}
+
+// logs the write c which replied with reply, if it went through
+func (s *Server) propagate(dbIdx int, c Command, reply resp.Reply) {
+	if s.aofOn() {
+		s.feedAOF(s.aofEntries(dbIdx, c, reply), false)
+	}
+}
+
+// appends the entries to the file, with a SELECT wherever the db changes
+// the entries of a tran are wrapped in MULTI and EXEC, so a tran cut by a crash isn't replayed in part
+func (s *Server) feedAOF(entries []aofEntry, tran bool) {
+	a := &s.aof
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.w == nil || len(entries) == 0 {
+		return
+	}
+
+	cmds := [][]string{}
+	if tran {
+		cmds = append(cmds, []string{MULTI})
+	}
+	for _, e := range entries {
+		if e.dbIdx != a.dbIdx {
+			cmds = append(cmds, []string{SELECT, strconv.Itoa(e.dbIdx)})
+			a.dbIdx = e.dbIdx
+		}
+		cmds = append(cmds, e.args)
+	}
+	if tran {
+		cmds = append(cmds, []string{EXEC})
+	}
+
+	a.setWriteErr(a.w.Append(cmds...))
+}
+
+// records the outcome of a write or a sync, called with mu held
+// the change is logged once rather than on every write, writes are refused while it failed
+func (a *aofState) setWriteErr(err error) {
+	switch {
+	case err != nil && a.writeErr == nil:
+		fmt.Printf("Error while writing the append only file, writes are refused until it can be written again: %v\n", err)
+	case err == nil && a.writeErr != nil:
+		fmt.Println("The append only file can be written again, writes are accepted from now on")
+	}
+	a.writeErr = err
+}
+
+// returns the error of the last write or sync of the append only file, nil if it went fine or appendonly is off
+func (s *Server) aofWriteErr() error {
+	s.aof.mu.Lock()
+	defer s.aof.mu.Unlock()
+	if s.aof.w == nil {
+		return nil
+	}
+	return s.aof.writeErr
+}
+
+// returns what to log for the write c which replied with reply, nothing if it failed or changed nothing
+// writes depending on the time or on chance are logged as the plain writes they turned into,
+// so replaying the file gives the same keys
+func (s *Server) aofEntries(dbIdx int, c Command, reply resp.Reply) []aofEntry {
+	// LOADCOMMANDS logs the commands it runs one by one, IMPORTRDB the keys it adds
+	if !writeCommands[c.name] || c.name == LOADCOMMANDS || c.name == IMPORTRDB {
+		return nil
+	}
+	if _, ok := reply.(resp.Error); ok {
+		return nil
+	}
+
+	args := append([]string{c.name}, c.args...)
+	switch c.name {
+	case SET:
+		args = s.setAOFCommand(dbIdx, args)
+	case EXPIRE, PEXPIRE, EXPIREAT, PEXPIREAT:
+		if reply != resp.Integer(1) {
+			return nil
+		}
+		// a time in the past deletes the key
+		args = []string{DEL, c.args[0]}
+		if at := s.getDb(dbIdx).ExpireTime(c.args[0]); at >= 0 {
+			args = []string{PEXPIREAT, c.args[0], strconv.FormatInt(at, 10)}
+		}
+	case SPOP:
+		members := replyStrings(reply)
+		if len(members) == 0 {
+			return nil
+		}
+		args = append([]string{SREM, c.args[0]}, members...)
+	case BLPOP, BRPOP, BZPOPMIN, BZPOPMAX:
+		// the key popped from comes first in the reply
+		popped := replyStrings(reply)
+		if len(popped) == 0 {
+			return nil
+		}
+		args = []string{strings.TrimPrefix(c.name, "B"), popped[0]}
+	case BLMOVE:
+		if reply == resp.Nil {
+			return nil
+		}
+		args = append([]string{LMOVE}, c.args[:4]...)
+	case XADD:
+		id, ok := reply.(resp.BulkString)
+		if !ok {
+			return nil
+		}
+		_, i, _ := parseXAddOptions(c.args)
+		args[i+1] = string(id)
+	case XREADGROUP:
+		if reply == resp.NilArray {
+			return nil
+		}
+		args = xreadgroupAOFCommand(c)
+	case XCLAIM, XAUTOCLAIM:
+		args = xclaimAOFCommand(c, reply)
+		if args == nil {
+			return nil
+		}
+	}
+	return []aofEntry{{dbIdx: dbIdx, args: args}}
+}
+
+// SET with a timeout is logged with the unix time in ms the key expires at
+func (s *Server) setAOFCommand(dbIdx int, args []string) []string {
+	key := args[1]
+	out := append([]string{}, args[:3]...)
+	for i := 3; i < len(args); i++ {
+		switch strings.ToUpper(args[i]) {
+		case "EX", "PX", "EXAT", "PXAT":
+			i++
+			switch at := s.getDb(dbIdx).ExpireTime(key); {
+			case at == db.TTLKeyNotFound:
+				// a time in the past deletes the key
+				return []string{DEL, key}
+			case at >= 0:
+				out = append(out, "PXAT", strconv.FormatInt(at, 10))
+			}
+		default:
+			out = append(out, args[i])
+		}
+	}
+	return out
+}
+
+// XREADGROUP is logged without BLOCK, the entries are read at once when replayed
+func xreadgroupAOFCommand(c Command) []string {
+	x, _ := parseXReadArgs(c, true)
+	args := []string{XREADGROUP, "GROUP", x.group, x.consumer}
+	if x.count > 0 {
+		args = append(args, "COUNT", strconv.Itoa(x.count))
+	}
+	if x.noAck {
+		args = append(args, "NOACK")
+	}
+	args = append(args, "STREAMS")
+	return append(append(args, x.keys...), x.ids...)
+}
+
+// the claims are logged as an XCLAIM of the entries claimed, whatever their idle time is when replayed
+// nil is returned if nothing was claimed
+func xclaimAOFCommand(c Command, reply resp.Reply) []string {
+	var claimed resp.Reply = reply
+	var opts []string
+	if c.name == XAUTOCLAIM {
+		if r, ok := reply.(resp.Array); ok && len(r) > 1 {
+			claimed = r[1]
+		}
+		for _, a := range c.args[5:] {
+			if strings.ToUpper(a) == "JUSTID" {
+				opts = append(opts, a)
+			}
+		}
+	} else {
+		// the options follow the ids
+		i := 4
+		for i < len(c.args) {
+			if _, err := parseStreamID(c.args[i], 0); err != nil {
+				break
+			}
+			i++
+		}
+		opts = c.args[i:]
+	}
+
+	ids := []string{}
+	items, _ := claimed.(resp.Array)
+	for _, item := range items {
+		// entries come as an id fields pair, unless JUSTID is given
+		if entry, ok := item.(resp.Array); ok && len(entry) > 0 {
+			item = entry[0]
+		}
+		if id, ok := item.(resp.BulkString); ok {
+			ids = append(ids, string(id))
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	args := append([]string{XCLAIM}, c.args[:3]...)
+	return append(append(append(args, "0"), ids...), opts...)
+}
+
+// returns the bulk strings of a reply, a single one or those of an array or a set
+func replyStrings(r resp.Reply) []string {
+	var items []resp.Reply
+	switch v := r.(type) {
+	case resp.BulkString:
+		return []string{string(v)}
+	case resp.Array:
+		items = v
+	case resp.Set:
+		items = v
+	}
+
+	out := []string{}
+	for _, item := range items {
+		if b, ok := item.(resp.BulkString); ok {
+			out = append(out, string(b))
+		}
+	}
+	return out
+}
+
+// reports if c waits for its keys when they're empty, such commands log their write once served
+func blocksOnKeys(c Command) bool {
+	switch c.name {
+	case BLPOP, BRPOP, BLMOVE, BZPOPMIN, BZPOPMAX:
+		return true
+	case XREADGROUP:
+		x, err := parseXReadArgs(c, true)
+		return err == nil && x.block
+	}
+	return false
+}
+
+// opens the files if appendonly is set, then syncs them every second for appendfsync everysec
+// and rewrites them once they grew by auto-aof-rewrite-percentage
+func (s *Server) startAOF() {
+	a := &s.aof
+	a.mu.Lock()
+	a.started = true
+	if s.config.getAppendOnly() {
+		if err := s.openAOFLocked(false); err != nil {
+			fmt.Printf("Error while opening the append only file, appendonly is turned off: %v\n", err)
+			s.config.Lock()
+			s.config.appendOnly = false
+			s.config.Unlock()
+		}
+	}
+	a.mu.Unlock()
+
+	s.startJob(func() {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.quit:
+				return
+			case now := <-ticker.C:
+				s.syncAOF()
+				s.rewriteAOFIfNeeded(now)
+			}
+		}
+	})
+}
+
+// flushes the writes logged during the last second to the disk, and retries them after a failed write whatever appendfsync is
+func (s *Server) syncAOF() {
+	a := &s.aof
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.w == nil || (a.w.Fsync() != aof.FsyncEverySec && a.writeErr == nil) {
+		return
+	}
+
+	a.setWriteErr(a.w.Sync())
+}
+
+// opens the files for the writes to come, called with mu held and no command running
+// the files left by an earlier run are appended to unless fresh is set, they were replayed by Load
+// otherwise the log starts over from a base holding the keys, the files of the previous log are removed once it's in place
+func (s *Server) openAOFLocked(fresh bool) error {
+	a := &s.aof
+	a.dir, a.prefix = s.aofDir(), s.config.getAppendFilename()
+	if err := os.MkdirAll(a.dir, 0o755); err != nil {
+		return err
+	}
+
+	m, err := aof.ReadManifest(a.manifestPath())
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		m = &aof.Manifest{}
+	case err != nil:
+		return err
+	case !fresh && len(m.Incrs) > 0:
+		last := m.Incrs[len(m.Incrs)-1]
+		w, err := aof.Open(filepath.Join(a.dir, last.Name), s.config.getAppendFsync())
+		if err != nil {
+			return err
+		}
+		a.manifest, a.size = m, filesSize(a.dir, m.Files()[:len(m.Files())-1])
+		a.w, a.dbIdx, a.baseSize = w, -1, a.size+w.Size()
+		return nil
+	}
+
+	baseSeq, incrSeq := m.NextSeqs()
+	base := aof.File{Name: aof.BaseName(a.prefix, baseSeq), Seq: baseSeq, Type: aof.TypeBase}
+	dbs, _ := s.snapshot()
+	if err := rdb.SaveFile(filepath.Join(a.dir, base.Name), dbs); err != nil {
+		return err
+	}
+	incr, w, err := s.openIncrLocked(incrSeq)
+	if err == nil {
+		a.manifest = m
+		err = s.switchManifestLocked(&aof.Manifest{Base: &base, Incrs: []aof.File{incr}}, w)
+	}
+	if err != nil {
+		if w != nil {
+			w.Close()
+			os.Remove(filepath.Join(a.dir, incr.Name))
+		}
+		os.Remove(filepath.Join(a.dir, base.Name))
+		return err
+	}
+	a.baseSize = a.size + w.Size()
+	return nil
+}
+
+// creates the incremental file with the given seq
+func (s *Server) openIncrLocked(seq int64) (aof.File, *aof.Writer, error) {
+	a := &s.aof
+	f := aof.File{Name: aof.IncrName(a.prefix, seq), Seq: seq, Type: aof.TypeIncr}
+	w, err := aof.Open(filepath.Join(a.dir, f.Name), s.config.getAppendFsync())
+	return f, w, err
+}
+
+// replaces the manifest by m, whose last incremental file is w, the writes go to w from now on
+// the files of the previous manifest which m doesn't list are removed once m is in place
+func (s *Server) switchManifestLocked(m *aof.Manifest, w *aof.Writer) error {
+	a := &s.aof
+	if err := aof.WriteManifest(a.manifestPath(), m); err != nil {
+		return err
+	}
+
+	if a.w != w {
+		s.closeAOFLocked()
+		a.w, a.dbIdx = w, -1
+	}
+	files := m.Files()
+	for _, f := range a.manifest.Files() {
+		if !slices.Contains(files, f) {
+			os.Remove(filepath.Join(a.dir, f.Name))
+		}
+	}
+	a.manifest, a.size = m, filesSize(a.dir, files[:len(files)-1])
+	return nil
+}
+
+// returns the total size of the files in dir, those which can't be read count as empty
+func filesSize(dir string, files []aof.File) int64 {
+	var size int64
+	for _, f := range files {
+		if info, err := os.Stat(filepath.Join(dir, f.Name)); err == nil {
+			size += info.Size()
+		}
+	}
+	return size
+}
+
+// stops logging the writes, the file is synced and closed, called with mu held
+func (s *Server) closeAOFLocked() {
+	a := &s.aof
+	if a.w == nil {
+		return
+	}
+	if err := a.w.Close(); err != nil {
+		fmt.Printf("Error while closing the append only file: %v\n", err)
+	}
+	a.w, a.writeErr = nil, nil
+}
+
+// turns the logging on or off, the files are opened or closed right away once the server started
+// it can't be turned back on before a rewrite started earlier is done
+func (s *Server) setAppendOnly(on bool) error {
+	a := &s.aof
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	switch {
+	case !a.started:
+	case on && a.w == nil && a.rewriting:
+		return ErrRewriteInProgress
+	case on && a.w == nil:
+		if err := s.openAOFLocked(true); err != nil {
+			return err
+		}
+	case !on:
+		s.closeAOFLocked()
+	}
+
+	s.config.Lock()
+	defer s.config.Unlock()
+	s.config.appendOnly = on
+	return nil
+}
+
+// changes how often the file is synced, starting with the next write
+func (s *Server) setAppendFsync(fsync aof.Fsync) {
+	a := &s.aof
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.w != nil {
+		a.w.SetFsync(fsync)
+	}
+
+	s.config.Lock()
+	defer s.config.Unlock()
+	s.config.appendFsync = fsync
+}
+
+// BGREWRITEAOF
+func (s *Server) bgrewriteaofAction() resp.Reply {
+	if err := s.rewriteAOF(); err != nil {
+		return resp.NewError(err)
+	}
+	return resp.SimpleString(MssgRewriteStarted)
+}
+
+// the writes from now on go to a new incremental file, while a base holding the keys up to now is written in the background
+// once it's done the manifest lists the base and the files started since, the older files are removed
+// called with no other command running, so the base and the new file split the writes at the same point
+func (s *Server) rewriteAOF() error {
+	s.init()
+	a := &s.aof
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	switch {
+	case a.w == nil:
+		return ErrAOFOff
+	case a.rewriting:
+		return ErrRewriteInProgress
+	}
+
+	baseSeq, incrSeq := a.manifest.NextSeqs()
+	incr, w, err := s.openIncrLocked(incrSeq)
+	if err != nil {
+		return err
+	}
+	m := &aof.Manifest{Base: a.manifest.Base, Incrs: append(slices.Clone(a.manifest.Incrs), incr)}
+	if err := s.switchManifestLocked(m, w); err != nil {
+		w.Close()
+		os.Remove(filepath.Join(a.dir, incr.Name))
+		return err
+	}
+
+	dir := a.dir
+	base := aof.File{Name: aof.BaseName(a.prefix, baseSeq), Seq: baseSeq, Type: aof.TypeBase}
+	dbs, _ := s.snapshot()
+	started := s.startJob(func() {
+		err := rdb.SaveFile(filepath.Join(dir, base.Name), dbs)
+
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		a.rewriting = false
+		if err == nil && a.w == nil {
+			// appendonly was turned off meanwhile, the files are left as they were
+			os.Remove(filepath.Join(dir, base.Name))
+			return
+		}
+		if err == nil {
+			err = s.rebaseLocked(base, incr.Seq)
+		}
+		if err != nil {
+			fmt.Printf("Background append only file rewriting error: %v\n", err)
+			os.Remove(filepath.Join(dir, base.Name))
+		}
+		a.rewriteErr = err
+	})
+	if !started {
+		return ErrShuttingDown
+	}
+	a.rewriting, a.rewriteStart = true, time.Now()
+	return nil
+}
+
+// makes base the base of the log, in place of the previous one and of the incremental files older than the one with seq from
+func (s *Server) rebaseLocked(base aof.File, from int64) error {
+	a := &s.aof
+	m := &aof.Manifest{Base: &base}
+	for _, f := range a.manifest.Incrs {
+		if f.Seq >= from {
+			m.Incrs = append(m.Incrs, f)
+		}
+	}
+	if err := s.switchManifestLocked(m, a.w); err != nil {
+		return err
+	}
+	a.rewrites++
+	a.baseSize = a.size + a.w.Size()
+	return nil
+}
+
+// starts a rewrite once the files grew by auto-aof-rewrite-percentage since the last one, and are at least auto-aof-rewrite-min-size
+// the sizes are checked along other commands, only the rewrite runs apart from them
+func (s *Server) rewriteAOFIfNeeded(now time.Time) {
+	if !s.aofRewriteNeeded(now) {
+		return
+	}
+	s.execute(true, func() {
+		if err := s.rewriteAOF(); err != nil && !errors.Is(err, ErrRewriteInProgress) && !errors.Is(err, ErrAOFOff) {
+			fmt.Printf("Background append only file rewriting error: %v\n", err)
+		}
+	})
+}
+
+// a failed rewrite is retried once bgsaveRetryDelay passed, rather than on every check
+func (s *Server) aofRewriteNeeded(now time.Time) bool {
+	percentage, minSize := s.config.getAOFRewritePercentage(), s.config.getAOFRewriteMinSize()
+	if percentage == 0 {
+		return false
+	}
+
+	a := &s.aof
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.w == nil || a.rewriting || (a.rewriteErr != nil && now.Sub(a.rewriteStart) < bgsaveRetryDelay) {
+		return false
+	}
+
+	size := a.size + a.w.Size()
+	return size >= minSize && (size-a.baseSize)*100 >= int64(percentage)*a.baseSize
+}
+
+// replays the base and the incremental files of the manifest to rebuild the dbs, SELECT switches included
+// the single file of earlier versions is replayed if there's no manifest yet, the log moves to appenddirname once opened
+// a tail cut by a crash is cut off the last file if aof-load-truncated allows it, otherwise loading fails
+func (s *Server) loadAOF() error {
+	s.dbMu.Lock()
+	if s.Db == nil {
+		s.Db = map[int]db.DbInterface{}
+	}
+	s.dbMu.Unlock()
+
+	dir, path := s.aofDir(), ""
+	m, err := aof.ReadManifest(filepath.Join(dir, aof.ManifestName(s.config.getAppendFilename())))
+	load := func(l aof.Loader) (int64, error) { return aof.LoadDir(dir, m, l) }
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		path = s.legacyAOFPath()
+		load = func(l aof.Loader) (int64, error) { return aof.LoadFile(path, l) }
+	case err != nil:
+		return err
+	case len(m.Incrs) > 0:
+		path = filepath.Join(dir, m.Incrs[len(m.Incrs)-1].Name)
+	}
+
+	// a logged command replying with an error didn't do what it did when it was logged, so loading fails
+	var replyErr error
+	cc := &ConnContext{replied: func(r resp.Reply) { replyErr = errorReply(r) }}
+	size, err := load(aof.Loader{
+		Restore: s.restore,
+		Apply: func(args []string) error {
+			if _, ok := commandArity[strings.ToUpper(args[0])]; !ok {
+				return fmt.Errorf("%v '%s'", ErrUnknownCommand, args[0])
+			}
+			replyErr = nil
+			s.handleRequest(args, io.Discard, cc)
+			if replyErr != nil {
+				return fmt.Errorf("'%s' failed: %v", args[0], replyErr)
+			}
+			return nil
+		},
+	})
+	if errors.Is(err, aof.ErrTruncated) {
+		if !s.config.getAOFLoadTruncated() {
+			return fmt.Errorf("%w after %d bytes of %s, set aof-load-truncated to yes to load it anyway", err, size, path)
+		}
+		if err = os.Truncate(path, size); err == nil {
+			fmt.Printf("The append only file %s ends with a truncated command, AOF loaded anyway because aof-load-truncated is enabled, the file was truncated to %d bytes\n", path, size)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if m == nil {
+		fmt.Printf("Loaded the append only file %s, the writes are logged to %s from now on\n", path, dir)
+	}
+
+	// the replayed writes aren't changes which need saving
+	dirty := s.dirty()
+	s.persistence.mu.Lock()
+	defer s.persistence.mu.Unlock()
+	s.persistence.dirtyAtSave = dirty
+	return nil
+}
+
+// returns the error of the reply, or the first of the replies of an EXEC, nil if there's none
+func errorReply(r resp.Reply) error {
+	switch v := r.(type) {
+	case resp.Error:
+		return errors.New(string(v))
+	case resp.Array:
+		for _, item := range v {
+			if err := errorReply(item); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// sets the keys of the dbs found in a snapshot, the dbs missing are created
+func (s *Server) restore(dbs map[int][]store.Entry) error {
+	s.dbMu.Lock()
+	defer s.dbMu.Unlock()
+	if s.Db == nil {
+		s.Db = map[int]db.DbInterface{}
+	}
+	for idx := range dbs {
+		if idx < DbRangeMin || idx > DbRangeMax {
+			return fmt.Errorf("%w: db index %d is out of range", rdb.ErrCorrupt, idx)
+		}
+	}
+
+	for idx, entries := range dbs {
+		if _, ok := s.Db[idx]; !ok {
+			s.Db[idx] = db.GetNewDB(inMemoryStore.NewInMemoryStore())
+		}
+		s.Db[idx].Restore(entries)
+	}
+	return nil
+}
+
+// lines of the persistence section of INFO about the append only files, their sizes are reported while appendonly is on
+func (s *Server) aofInfo() []string {
+	a := &s.aof
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	enabled, rewriting, rewriteStatus, status := 0, 0, "ok", "ok"
+	if a.w != nil {
+		enabled = 1
+	}
+	if a.rewriting {
+		rewriting = 1
+	}
+	if a.rewriteErr != nil {
+		rewriteStatus = "err"
+	}
+	if a.writeErr != nil {
+		status = "err"
+	}
+	lines := []string{
+		fmt.Sprintf("aof_enabled:%d", enabled),
+		fmt.Sprintf("aof_rewrite_in_progress:%d", rewriting),
+		fmt.Sprintf("aof_last_bgrewrite_status:%s", rewriteStatus),
+		fmt.Sprintf("aof_rewrites:%d", a.rewrites),
+		fmt.Sprintf("aof_last_write_status:%s", status),
+	}
+	if a.w != nil {
+		lines = append(lines,
+			fmt.Sprintf("aof_current_size:%d", a.size+a.w.Size()),
+			fmt.Sprintf("aof_base_size:%d", a.baseSize),
+		)
+	}
+	return lines
+}
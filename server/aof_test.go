@@ -0,0 +1,554 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/aof"
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/db"
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store"
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store/inMemoryStore"
+)
+
+// returns a started server logging its writes to the append only file in dir
+func getTestServerWithAOF(t *testing.T, dir string) *Server {
+	t.Helper()
+	s := getTestServerWithDir(t, dir)
+	if err := s.SetConfig("appendonly", "yes"); err != nil {
+		t.Fatalf("Failed to turn appendonly on: %v", err)
+	}
+	if err := s.Load(); err != nil {
+		t.Fatalf("Failed to load: %v", err)
+	}
+	s.startAOF()
+	return s
+}
+
+// returns the commands of the incremental append only files in dir, the base they start from is left out
+func readAOF(t *testing.T, dir string) []string {
+	t.Helper()
+	aofDir := filepath.Join(dir, defaultAppendDirname)
+	m, err := aof.ReadManifest(filepath.Join(aofDir, aof.ManifestName(defaultAppendFilename)))
+	if err != nil {
+		t.Fatalf("Failed to read the manifest: %v", err)
+	}
+
+	cmds := []string{}
+	_, err = aof.LoadDir(aofDir, &aof.Manifest{Incrs: m.Incrs}, aof.Loader{
+		Restore: func(map[int][]store.Entry) error { return nil },
+		Apply: func(args []string) error {
+			cmds = append(cmds, strings.Join(args, " "))
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to read the append only file: %v", err)
+	}
+	return cmds
+}
+
+// returns the keys of every db as the commands recreating them, so the keys of two servers can be compared
+func dumpDbs(t *testing.T, s *Server) []string {
+	t.Helper()
+	dbs, _ := s.snapshot()
+	out := []string{}
+	for idx, entries := range dbs {
+		for _, e := range entries {
+			out = append(out, fmt.Sprintf("%d %d %v", idx, e.ExpireAt, valueCommands(e.Key, e.Value)))
+		}
+	}
+	slices.Sort(out)
+	return out
+}
+
+func TestAOFCommands(t *testing.T) {
+	testCases := []struct {
+		name     string
+		inputArr []string
+		expCmds  []string
+	}{
+		{
+			name:     "writes",
+			inputArr: []string{"SET foo bar", "GET foo", "INCR foo", "RPUSH list a b", "DEL list"},
+			expCmds:  []string{"SELECT 0", "SET foo bar", "RPUSH list a b", "DEL list"},
+		},
+		{
+			name:     "SELECT",
+			inputArr: []string{"SET a 1", "SELECT 3", "SET b 2", "SET c 3", "SELECT 0", "SET d 4"},
+			expCmds:  []string{"SELECT 0", "SET a 1", "SELECT 3", "SET b 2", "SET c 3", "SELECT 0", "SET d 4"},
+		},
+		{
+			name:     "SET with timeout",
+			inputArr: []string{"SET foo bar EXAT 4102444800 GET", "SET old bar EXAT 1"},
+			expCmds:  []string{"SELECT 0", "SET foo bar PXAT 4102444800000 GET", "DEL old"},
+		},
+		{
+			name:     "EXPIRE",
+			inputArr: []string{"SET foo bar", "EXPIREAT foo 4102444800", "EXPIRE missing 10", "EXPIRE foo -1"},
+			expCmds:  []string{"SELECT 0", "SET foo bar", "PEXPIREAT foo 4102444800000", "DEL foo"},
+		},
+		{
+			name:     "SPOP",
+			inputArr: []string{"SADD set a", "SPOP set", "SPOP set"},
+			expCmds:  []string{"SELECT 0", "SADD set a", "SREM set a"},
+		},
+		{
+			name:     "blocking commands served at once",
+			inputArr: []string{"RPUSH list a b", "BRPOP list 0", "BLMOVE list other LEFT RIGHT 0", "ZADD z 1 m", "BZPOPMIN z 0"},
+			expCmds:  []string{"SELECT 0", "RPUSH list a b", "RPOP list", "LMOVE list other LEFT RIGHT", "ZADD z 1 m", "ZPOPMIN z"},
+		},
+		{
+			name:     "XREADGROUP",
+			inputArr: []string{"XADD st 1-1 f v", "XGROUP CREATE st g 0", "XREADGROUP GROUP g c COUNT 5 BLOCK 10 STREAMS st >"},
+			expCmds:  []string{"SELECT 0", "XADD st 1-1 f v", "XGROUP CREATE st g 0", "XREADGROUP GROUP g c COUNT 5 STREAMS st >"},
+		},
+		{
+			name:     "tran",
+			inputArr: []string{"MULTI", "SET a 1", "SELECT 2", "INCR b", "GET a", "EXEC"},
+			expCmds:  []string{"MULTI", "SELECT 0", "SET a 1", "SELECT 2", "INCR b", "EXEC"},
+		},
+		{
+			name:     "discarded tran",
+			inputArr: []string{"MULTI", "SET a 1", "DISCARD", "MULTI", "SET a 1", "NOPE", "EXEC"},
+			expCmds:  []string{},
+		},
+		{
+			name:     "LOADCOMMANDS logs the commands it runs",
+			inputArr: []string{"SET a 1", "RPUSH l x", "COMPACT TO dump.txt", "FLUSHDB", "LOADCOMMANDS dump.txt"},
+			expCmds:  []string{"SELECT 0", "SET a 1", "RPUSH l x", "FLUSHDB", "MULTI", "SET a 1", "RPUSH l x", "EXEC"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			dir := t.TempDir()
+			s := getTestServerWithAOF(t, dir)
+			defer s.Close()
+			cc := &ConnContext{}
+
+			for _, input := range tc.inputArr {
+				s.handleCommand(input, &buf, cc)
+			}
+
+			if cmds := readAOF(t, dir); !reflect.DeepEqual(cmds, tc.expCmds) {
+				t.Errorf("Expected %q but got %q", tc.expCmds, cmds)
+			}
+		})
+	}
+}
+
+func TestAOFBlockedClient(t *testing.T) {
+	var buf bytes.Buffer
+	dir := t.TempDir()
+	s := getTestServerWithAOF(t, dir)
+	defer s.Close()
+
+	out := blockClient(t, s, 2, "BLPOP list 0")
+	s.handleCommand("RPUSH list a b", &buf, &ConnContext{id: 1})
+	expectReply(t, out, "1) \"list\"\n2) \"a\"\n")
+
+	exp := []string{"SELECT 0", "RPUSH list a b", "LPOP list"}
+	if cmds := readAOF(t, dir); !reflect.DeepEqual(cmds, exp) {
+		t.Errorf("Expected %q but got %q", exp, cmds)
+	}
+}
+
+func TestAOFLoadCommandsFailed(t *testing.T) {
+	var buf bytes.Buffer
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "dump.txt"), []byte("SET a x\nSELECT 2\nSET b x\nINCR b\nSET d 1\n"), 0644); err != nil {
+		t.Fatalf("Failed to write the dump: %v", err)
+	}
+	s := getTestServerWithAOF(t, dir)
+	defer s.Close()
+	cc := &ConnContext{}
+
+	// the commands run before the failing one are neither kept nor logged
+	s.handleCommand("SET c 1", &buf, cc)
+	s.handleCommand("SELECT 1", &buf, cc)
+	s.handleCommand("LOADCOMMANDS dump.txt", &buf, cc)
+
+	exp := []string{"SELECT 0", "SET c 1"}
+	if cmds := readAOF(t, dir); !reflect.DeepEqual(cmds, exp) {
+		t.Errorf("Expected %q but got %q", exp, cmds)
+	}
+	if exp, got := []string{"0 0 [[SET c 1]]"}, dumpDbs(t, s); !reflect.DeepEqual(got, exp) {
+		t.Errorf("Expected %q but got %q", exp, got)
+	}
+}
+
+func TestAOFReplay(t *testing.T) {
+	var buf bytes.Buffer
+	dir := t.TempDir()
+
+	// data saved before the AOF is turned on is in the snapshot the file starts with
+	s := getTestServerWithDir(t, dir)
+	cc := &ConnContext{}
+	s.handleCommand("SET before 1", &buf, cc)
+	s.startAOF()
+	s.handleCommand("CONFIG SET appendonly yes", &buf, cc)
+
+	for _, input := range []string{
+		"SET foo bar EX 100", "RPUSH list a b c", "LPOP list", "SADD set a b c d", "SPOP set 2",
+		"SELECT 4", "HSET hash f v", "ZADD z 1 a 2 b", "XADD st * f v", "XADD st * f w", "XGROUP CREATE st g 0",
+		"MULTI", "INCR counter", "SELECT 0", "INCR counter", "EXEC", "SET gone 1", "SELECT 1", "FLUSHDB", "SET after 1",
+	} {
+		s.handleCommand(input, &buf, cc)
+	}
+	s.Close()
+
+	loaded := getTestServerWithAOF(t, dir)
+	if exp, got := dumpDbs(t, s), dumpDbs(t, loaded); !reflect.DeepEqual(got, exp) {
+		t.Errorf("Expected %q but got %q", exp, got)
+	}
+
+	// the file goes on where it was left
+	loaded.handleCommand("SELECT 4", &buf, cc)
+	loaded.handleCommand("SET more 1", &buf, cc)
+	loaded.Close()
+
+	again := getTestServerWithAOF(t, dir)
+	defer again.Close()
+	if exp, got := dumpDbs(t, loaded), dumpDbs(t, again); !reflect.DeepEqual(got, exp) {
+		t.Errorf("Expected %q but got %q", exp, got)
+	}
+}
+
+func TestAOFTruncated(t *testing.T) {
+	valid := aof.AppendCommand(aof.AppendCommand(nil, []string{"SELECT", "1"}), []string{"SET", "foo", "bar"})
+	tran := aof.AppendCommand(aof.AppendCommand(nil, []string{"MULTI"}), []string{"SET", "foo", "baz"})
+
+	testCases := []struct {
+		name       string
+		tail       []byte
+		loadAnyway string
+		inManifest bool
+		expErr     error
+	}{
+		{"truncated command", []byte("*3\r\n$3\r\nSET\r\n$3\r\nfo"), "yes", false, nil},
+		{"tran without EXEC", tran, "yes", false, nil},
+		{"refused", []byte("*3\r\n$3\r\nSET\r\n$3\r\nfo"), "no", false, aof.ErrTruncated},
+		{"truncated last incremental file", []byte("*3\r\n$3\r\nSET\r\n$3\r\nfo"), "yes", true, nil},
+		{"refused in the last incremental file", tran, "no", true, aof.ErrTruncated},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, defaultAppendFilename)
+			if tc.inManifest {
+				aofDir := filepath.Join(dir, defaultAppendDirname)
+				os.Mkdir(aofDir, 0o755)
+				os.WriteFile(filepath.Join(aofDir, "appendonly.aof.1.incr.aof"), aof.AppendCommand(nil, []string{"SET", "a", "1"}), 0o644)
+				aof.WriteManifest(filepath.Join(aofDir, aof.ManifestName(defaultAppendFilename)), &aof.Manifest{Incrs: []aof.File{
+					{Name: "appendonly.aof.1.incr.aof", Seq: 1, Type: aof.TypeIncr},
+					{Name: "appendonly.aof.2.incr.aof", Seq: 2, Type: aof.TypeIncr},
+				}})
+				path = filepath.Join(aofDir, "appendonly.aof.2.incr.aof")
+			}
+			os.WriteFile(path, append(bytes.Clone(valid), tc.tail...), 0o644)
+
+			s := getTestServerWithDir(t, dir)
+			s.SetConfig("appendonly", "yes")
+			s.SetConfig("aof-load-truncated", tc.loadAnyway)
+			err := s.Load()
+
+			if !errors.Is(err, tc.expErr) {
+				t.Fatalf("Expected error %v but got %v", tc.expErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if val, _ := s.getDb(1).Get("foo"); val != "bar" {
+				t.Errorf("Expected foo to be bar but got %q", val)
+			}
+			if out, _ := os.ReadFile(path); !bytes.Equal(out, valid) {
+				t.Errorf("Expected the file to be cut to %q but got %q", valid, out)
+			}
+		})
+	}
+}
+
+func TestAOFFailingCommand(t *testing.T) {
+	testCases := []struct {
+		name   string
+		cmds   [][]string
+		expErr string
+	}{
+		{"unknown command", [][]string{{"NOPE", "a"}}, "unknown command 'NOPE'"},
+		{"wrong number of args", [][]string{{"SET", "a"}}, "'SET' failed: ERR wrong number of arguments"},
+		{"wrong type", [][]string{{"SET", "a", "1"}, {"LPUSH", "a", "x"}}, "'LPUSH' failed: WRONGTYPE"},
+		{"failing in a tran", [][]string{{"SET", "a", "x"}, {"MULTI"}, {"SET", "b", "1"}, {"INCR", "a"}, {"EXEC"}}, "'EXEC' failed: ERR value is not an integer"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			data := []byte{}
+			for _, args := range tc.cmds {
+				data = aof.AppendCommand(data, args)
+			}
+			os.WriteFile(filepath.Join(dir, defaultAppendFilename), data, 0o644)
+
+			s := getTestServerWithDir(t, dir)
+			s.SetConfig("appendonly", "yes")
+			err := s.Load()
+			if !errors.Is(err, aof.ErrCorrupt) || !strings.Contains(err.Error(), tc.expErr) {
+				t.Errorf("Expected error %q but got %v", tc.expErr, err)
+			}
+		})
+	}
+}
+
+func waitForRewrite(t *testing.T, s *Server) {
+	t.Helper()
+	waitFor(t, func() bool {
+		s.aof.mu.Lock()
+		defer s.aof.mu.Unlock()
+		return !s.aof.rewriting
+	})
+}
+
+// returns the manifest in dir along with the names of the files of appenddirname
+func readManifest(t *testing.T, dir string) (*aof.Manifest, []string) {
+	t.Helper()
+	aofDir := filepath.Join(dir, defaultAppendDirname)
+	m, err := aof.ReadManifest(filepath.Join(aofDir, aof.ManifestName(defaultAppendFilename)))
+	if err != nil {
+		t.Fatalf("Failed to read the manifest: %v", err)
+	}
+
+	names := []string{}
+	entries, _ := os.ReadDir(aofDir)
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return m, names
+}
+
+func TestAOFRewrite(t *testing.T) {
+	var buf bytes.Buffer
+	dir := t.TempDir()
+	s := getTestServerWithAOF(t, dir)
+	cc := &ConnContext{}
+
+	for _, input := range []string{"SET a 1", "INCR a", "RPUSH l x y", "LPOP l", "SELECT 2", "SET b 1"} {
+		s.handleCommand(input, &buf, cc)
+	}
+	buf.Reset()
+	s.handleCommand("BGREWRITEAOF", &buf, cc)
+	if exp := MssgRewriteStarted + "\n"; buf.String() != exp {
+		t.Fatalf("Expected %q but got %q", exp, buf.String())
+	}
+
+	// the writes from now on go to the incremental file started along the base
+	s.handleCommand("SET c 1", &buf, cc)
+	waitForRewrite(t, s)
+
+	m, names := readManifest(t, dir)
+	exp := &aof.Manifest{
+		Base:  &aof.File{Name: "appendonly.aof.2.base.goredis", Seq: 2, Type: aof.TypeBase},
+		Incrs: []aof.File{{Name: "appendonly.aof.2.incr.aof", Seq: 2, Type: aof.TypeIncr}},
+	}
+	if !reflect.DeepEqual(m, exp) {
+		t.Errorf("Expected %+v but got %+v", exp, m)
+	}
+	if expNames := []string{"appendonly.aof.2.base.goredis", "appendonly.aof.2.incr.aof", "appendonly.aof.manifest"}; !reflect.DeepEqual(names, expNames) {
+		t.Errorf("Expected the files %q but got %q", expNames, names)
+	}
+	if cmds, exp := readAOF(t, dir), []string{"SELECT 2", "SET c 1"}; !reflect.DeepEqual(cmds, exp) {
+		t.Errorf("Expected %q but got %q", exp, cmds)
+	}
+
+	buf.Reset()
+	s.handleCommand("INFO persistence", &buf, cc)
+	for _, line := range []string{"aof_rewrite_in_progress:0\r\n", "aof_last_bgrewrite_status:ok\r\n", "aof_rewrites:1\r\n"} {
+		if !strings.Contains(buf.String(), line) {
+			t.Errorf("Expected the info to contain %q but got %q", line, buf.String())
+		}
+	}
+	s.Close()
+
+	loaded := getTestServerWithAOF(t, dir)
+	defer loaded.Close()
+	if exp, got := dumpDbs(t, s), dumpDbs(t, loaded); !reflect.DeepEqual(got, exp) {
+		t.Errorf("Expected %q but got %q", exp, got)
+	}
+}
+
+func TestAOFWriteError(t *testing.T) {
+	var buf bytes.Buffer
+	dir := t.TempDir()
+	s := getTestServerWithAOF(t, dir)
+	defer s.Close()
+	cc := &ConnContext{}
+
+	s.handleCommand("SET a 1", &buf, cc)
+	s.aof.mu.Lock()
+	s.aof.setWriteErr(errors.New("no space left on device"))
+	s.aof.mu.Unlock()
+
+	// writes are refused until the file can be written again, reads go on
+	buf.Reset()
+	for _, input := range []string{"SET a 2", "GET a", "MULTI", "INCR a", "EXEC"} {
+		s.handleCommand(input, &buf, cc)
+	}
+	misconf := "(error) " + ErrAOFMisconf.Error() + ": no space left on device\n"
+	if exp := misconf + "\"1\"\n" + MssgOK + "\n" + misconf; !strings.HasPrefix(buf.String(), exp) {
+		t.Errorf("Expected %q but got %q", exp, buf.String())
+	}
+
+	s.syncAOF()
+	buf.Reset()
+	s.handleCommand("SET a 3", &buf, cc)
+	if exp := MssgOK + "\n"; buf.String() != exp {
+		t.Errorf("Expected %q but got %q", exp, buf.String())
+	}
+	if exp, cmds := []string{"SELECT 0", "SET a 1", "SET a 3"}, readAOF(t, dir); !reflect.DeepEqual(cmds, exp) {
+		t.Errorf("Expected %q but got %q", exp, cmds)
+	}
+}
+
+func TestAOFRewriteErrors(t *testing.T) {
+	var buf bytes.Buffer
+	s := getTestServerWithDir(t, t.TempDir())
+	s.startAOF()
+	defer s.Close()
+
+	s.handleCommand("BGREWRITEAOF", &buf, &ConnContext{})
+	if exp := "(error) ERR " + ErrAOFOff.Error() + "\n"; buf.String() != exp {
+		t.Errorf("Expected %q but got %q", exp, buf.String())
+	}
+
+	// a rewrite started while another runs is refused
+	s.handleCommand("CONFIG SET appendonly yes", &buf, &ConnContext{})
+	s.aof.mu.Lock()
+	s.aof.rewriting = true
+	s.aof.mu.Unlock()
+	buf.Reset()
+	s.handleCommand("BGREWRITEAOF", &buf, &ConnContext{})
+	if exp := "(error) ERR " + ErrRewriteInProgress.Error() + "\n"; buf.String() != exp {
+		t.Errorf("Expected %q but got %q", exp, buf.String())
+	}
+}
+
+func TestAOFAutoRewrite(t *testing.T) {
+	var buf bytes.Buffer
+	dir := t.TempDir()
+	s := getTestServerWithAOF(t, dir)
+	defer s.Close()
+	s.SetConfig("auto-aof-rewrite-min-size", "100")
+	s.handleCommand("SET a 1", &buf, &ConnContext{})
+
+	now := time.Now()
+	if s.aofRewriteNeeded(now) {
+		t.Fatalf("Expected no rewrite under auto-aof-rewrite-min-size")
+	}
+
+	// the files have to double in size since they were opened
+	s.aof.mu.Lock()
+	base := s.aof.baseSize
+	s.aof.mu.Unlock()
+	for !s.aofRewriteNeeded(now) {
+		s.handleCommand("SET a 1", &buf, &ConnContext{})
+	}
+	s.aof.mu.Lock()
+	grown := s.aof.size + s.aof.w.Size()
+	s.aof.mu.Unlock()
+	if grown < 2*base || grown < 100 {
+		t.Errorf("Expected a rewrite once the files are twice the size %d but got one at %d", base, grown)
+	}
+
+	s.rewriteAOFIfNeeded(now)
+	waitForRewrite(t, s)
+	if m, _ := readManifest(t, dir); m.Base.Seq != 2 {
+		t.Errorf("Expected the base to be rewritten but got %+v", m.Base)
+	}
+	if s.aofRewriteNeeded(now) {
+		t.Errorf("Expected no rewrite right after one")
+	}
+
+	s.SetConfig("auto-aof-rewrite-percentage", "0")
+	s.SetConfig("auto-aof-rewrite-min-size", "0")
+	s.handleCommand("SET a 2", &buf, &ConnContext{})
+	if s.aofRewriteNeeded(now) {
+		t.Errorf("Expected no rewrite with auto-aof-rewrite-percentage 0")
+	}
+}
+
+func TestAOFLegacyFile(t *testing.T) {
+	var buf bytes.Buffer
+	dir := t.TempDir()
+	legacy := aof.AppendCommand(aof.AppendCommand(nil, []string{"SELECT", "1"}), []string{"SET", "foo", "bar"})
+	os.WriteFile(filepath.Join(dir, defaultAppendFilename), legacy, 0o644)
+
+	// the single file is loaded, then the log goes on in appenddirname from a base holding its keys
+	s := getTestServerWithAOF(t, dir)
+	if val, _ := s.getDb(1).Get("foo"); val != "bar" {
+		t.Errorf("Expected foo to be bar but got %q", val)
+	}
+	s.handleCommand("SET more 1", &buf, &ConnContext{})
+	s.Close()
+
+	if m, _ := readManifest(t, dir); m.Base == nil || len(m.Incrs) != 1 {
+		t.Errorf("Expected a base and an incremental file but got %+v", m)
+	}
+	loaded := getTestServerWithAOF(t, dir)
+	defer loaded.Close()
+	if exp, got := dumpDbs(t, s), dumpDbs(t, loaded); !reflect.DeepEqual(got, exp) {
+		t.Errorf("Expected %q but got %q", exp, got)
+	}
+}
+
+func TestAOFPreferredOverSnapshot(t *testing.T) {
+	var buf bytes.Buffer
+	dir := t.TempDir()
+
+	s := getTestServerWithDir(t, dir)
+	s.handleCommand("SET foo snapshot", &buf, &ConnContext{})
+	s.handleCommand("SAVE", &buf, &ConnContext{})
+
+	// without an AOF the snapshot is loaded, and the AOF starts with it
+	withAOF := getTestServerWithAOF(t, dir)
+	withAOF.handleCommand("SET foo aof", &buf, &ConnContext{})
+	withAOF.Close()
+
+	loaded := &Server{Db: map[int]db.DbInterface{0: db.GetNewDB(inMemoryStore.NewInMemoryStore())}}
+	loaded.SetConfig("dir", dir)
+	loaded.SetConfig("appendonly", "yes")
+	if err := loaded.Load(); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if val, _ := loaded.getDb(0).Get("foo"); val != "aof" {
+		t.Errorf("Expected foo to be aof but got %q", val)
+	}
+}
+
+func TestInfoAOF(t *testing.T) {
+	var buf bytes.Buffer
+	s := getTestServerWithAOF(t, t.TempDir())
+	defer s.Close()
+
+	s.handleCommand("INFO persistence", &buf, &ConnContext{})
+	for _, line := range []string{"aof_enabled:1\r\n", "aof_last_write_status:ok\r\n"} {
+		if !strings.Contains(buf.String(), line) {
+			t.Errorf("Expected the info to contain %q but got %q", line, buf.String())
+		}
+	}
+
+	buf.Reset()
+	s.handleCommand("CONFIG SET appendonly no", &buf, &ConnContext{})
+	s.handleCommand("INFO persistence", &buf, &ConnContext{})
+	if !strings.Contains(buf.String(), "aof_enabled:0\r\n") {
+		t.Errorf("Expected the info to contain %q but got %q", "aof_enabled:0\r\n", buf.String())
+	}
+}
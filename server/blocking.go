@@ -0,0 +1,383 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/db"
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/resp"
+)
+
+const (
+	BLPOP    string = "BLPOP"
+	BRPOP    string = "BRPOP"
+	BLMOVE   string = "BLMOVE"
+	BZPOPMIN string = "BZPOPMIN"
+	BZPOPMAX string = "BZPOPMAX"
+	CLIENT   string = "CLIENT"
+)
+
+var (
+	ErrTimeoutNotFloat   = errors.New("timeout is not a float or out of range")
+	ErrTimeoutNotInteger = errors.New("timeout is not an integer or out of range")
+	ErrTimeoutNegative   = errors.New("timeout is negative")
+	ErrUnblocked         = errors.New("UNBLOCKED client unblocked via CLIENT UNBLOCK")
+)
+
+// client parked until one of its keys gets new items
+type blockedClient struct {
+	id           int64
+	dbIdx        int
+	keys         []string
+	cmd          Command                   // logged to the AOF once served
+	try          func() (resp.Reply, bool) // serves the client if its keys allow it
+	timeoutReply resp.Reply                // sent when the timeout is hit
+	reply        chan resp.Reply           // gets the reply once the client is served, buffered
+}
+
+// clients blocked on every key of every db, in the order they blocked
+// the clients are served by whoever holds mu, so the items are handed out one waiter at a time
+type blockingState struct {
+	mu      sync.Mutex
+	waiting map[int]map[string][]*blockedClient
+	clients map[int64]*blockedClient // by client id, for CLIENT UNBLOCK
+}
+
+// tries to serve the command right away, or parks the client until it's served or the timeout is hit
+// a zero timeout waits forever, commands of a transaction never wait and time out at once
+// the write done by the command is logged to the AOF as it's served, EXEC logs it for a transaction
+func (s *Server) block(cc *ConnContext, c Command, keys []string, timeout time.Duration, timeoutReply resp.Reply, try func() (resp.Reply, bool)) resp.Reply {
+	s.init()
+	b := &s.blocking
+	d := s.getDb(cc.dbIdx)
+
+	b.mu.Lock()
+	// keys are marked before trying, a write landing in between reports them as ready
+	d.Block(keys...)
+
+	// clients blocked on the keys already are served first
+	s.serveBlockedLocked()
+	if r, ok := try(); ok {
+		d.Unblock(keys...)
+		if !cc.isMulti {
+			s.propagate(cc.dbIdx, c, r)
+		}
+		b.mu.Unlock()
+		return r
+	}
+	if cc.isMulti {
+		d.Unblock(keys...)
+		b.mu.Unlock()
+		return timeoutReply
+	}
+
+	bc := &blockedClient{id: cc.id, dbIdx: cc.dbIdx, keys: keys, cmd: c, try: try, timeoutReply: timeoutReply, reply: make(chan resp.Reply, 1)}
+	b.park(bc)
+	b.mu.Unlock()
+
+	// a parked client doesn't hold up the commands of other clients, one of which may serve it
+	// the executor hands the wait over to the conn of the client
+	if s.SingleThreaded {
+		cc.parked = func() resp.Reply { return s.waitUnparked(cc, bc, timeout) }
+		return nil
+	}
+	s.execMu.RUnlock()
+	defer s.execMu.RLock()
+	return s.waitUnparked(cc, bc, timeout)
+}
+
+// waits for the parked client to be served, or until the timeout is hit or the client hangs up
+func (s *Server) waitUnparked(cc *ConnContext, bc *blockedClient, timeout time.Duration) resp.Reply {
+	b := &s.blocking
+
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	var hangup <-chan struct{}
+	if cc.hangup != nil {
+		hangup = cc.hangup.watch()
+	}
+
+	select {
+	case r := <-bc.reply:
+		return r
+	case <-expired:
+	case <-hangup:
+	case <-s.quit:
+	}
+
+	// the client may have been served meanwhile
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if s.unpark(bc) {
+		return bc.timeoutReply
+	}
+	return <-bc.reply
+}
+
+// adds the client to the waiters of its keys, its keys are marked in the db by the caller
+func (b *blockingState) park(bc *blockedClient) {
+	if b.waiting == nil {
+		b.waiting = make(map[int]map[string][]*blockedClient)
+		b.clients = make(map[int64]*blockedClient)
+	}
+	if b.waiting[bc.dbIdx] == nil {
+		b.waiting[bc.dbIdx] = make(map[string][]*blockedClient)
+	}
+	for _, k := range bc.keys {
+		b.waiting[bc.dbIdx][k] = append(b.waiting[bc.dbIdx][k], bc)
+	}
+	b.clients[bc.id] = bc
+}
+
+// removes the client from the waiters of its keys
+// returns false if it wasn't blocked anymore
+func (s *Server) unpark(bc *blockedClient) bool {
+	b := &s.blocking
+	if b.clients[bc.id] != bc {
+		return false
+	}
+	delete(b.clients, bc.id)
+
+	waiting := b.waiting[bc.dbIdx]
+	for _, k := range bc.keys {
+		waiting[k] = slices.DeleteFunc(waiting[k], func(w *blockedClient) bool { return w == bc })
+		if len(waiting[k]) == 0 {
+			delete(waiting, k)
+		}
+	}
+	if len(waiting) == 0 {
+		delete(b.waiting, bc.dbIdx)
+	}
+	s.getDb(bc.dbIdx).Unblock(bc.keys...)
+	return true
+}
+
+// serves the clients blocked on the keys written by the last command
+func (s *Server) serveBlocked() {
+	b := &s.blocking
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.clients) > 0 {
+		s.serveBlockedLocked()
+	}
+}
+
+// clients blocked on a ready key are tried in the order they blocked
+// serving a client can make other keys ready, like BLMOVE does, so it goes on until no key is
+func (s *Server) serveBlockedLocked() {
+	b := &s.blocking
+	for served := true; served; {
+		served = false
+		for idx := range b.waiting {
+			for _, key := range s.getDb(idx).ReadyKeys() {
+				for _, bc := range slices.Clone(b.waiting[idx][key]) {
+					if r, ok := bc.try(); ok {
+						s.unpark(bc)
+						s.propagate(bc.dbIdx, bc.cmd, r)
+						bc.reply <- r
+						served = true
+					}
+				}
+			}
+		}
+	}
+}
+
+// CLIENT ID | UNBLOCK client-id [TIMEOUT | ERROR]
+func (s *Server) clientAction(cc *ConnContext, args []string) resp.Reply {
+	switch sub := strings.ToUpper(args[0]); {
+	case sub == "ID" && len(args) == 1:
+		return resp.Integer(cc.id)
+	case sub == "UNBLOCK" && (len(args) == 2 || len(args) == 3):
+		id, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return resp.NewError(db.ErrKeyNotInteger)
+		}
+		withErr := false
+		if len(args) == 3 {
+			switch strings.ToUpper(args[2]) {
+			case "TIMEOUT":
+			case "ERROR":
+				withErr = true
+			default:
+				return resp.NewError(errors.New("CLIENT UNBLOCK reason should be TIMEOUT or ERROR"))
+			}
+		}
+		return resp.Integer(s.unblockClient(id, withErr))
+	default:
+		return resp.NewError(fmt.Errorf("unknown subcommand or wrong number of arguments for '%s'. Try CLIENT HELP.", args[0]))
+	}
+}
+
+// unblocks the client as if its timeout was hit, or with an error
+// returns 1 if the client was blocked, 0 otherwise
+func (s *Server) unblockClient(id int64, withErr bool) int {
+	b := &s.blocking
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	bc, ok := b.clients[id]
+	if !ok {
+		return 0
+	}
+	s.unpark(bc)
+	if withErr {
+		bc.reply <- resp.NewError(ErrUnblocked)
+	} else {
+		bc.reply <- bc.timeoutReply
+	}
+	return 1
+}
+
+// BLPOP key [key ...] timeout
+// BRPOP takes the same args, replies with the key and the popped item, or with nil on timeout
+func (s *Server) blpopAction(cc *ConnContext, c Command) resp.Reply {
+	keys := c.args[:len(c.args)-1]
+	timeout, err := parseTimeout(c.args[len(c.args)-1], false)
+	if err != nil {
+		return resp.NewError(err)
+	}
+
+	end := db.ListHead
+	if c.name == BRPOP {
+		end = db.ListTail
+	}
+
+	d := s.getDb(cc.dbIdx)
+	return s.block(cc, c, keys, timeout, resp.NilArray, func() (resp.Reply, bool) {
+		for _, k := range keys {
+			out, err := d.Pop(k, end, 1)
+			if err != nil {
+				return resp.NewError(err), true
+			}
+			if len(out) > 0 {
+				return resp.BulkStrings([]string{k, out[0]}), true
+			}
+		}
+		return nil, false
+	})
+}
+
+// BLMOVE source destination LEFT | RIGHT LEFT | RIGHT timeout
+func (s *Server) blmoveAction(cc *ConnContext, c Command) resp.Reply {
+	args := c.args
+	from, ok := parseListEnd(args[2])
+	if !ok {
+		return resp.NewError(ErrSyntax)
+	}
+	to, ok := parseListEnd(args[3])
+	if !ok {
+		return resp.NewError(ErrSyntax)
+	}
+	timeout, err := parseTimeout(args[4], false)
+	if err != nil {
+		return resp.NewError(err)
+	}
+
+	d := s.getDb(cc.dbIdx)
+	return s.block(cc, c, args[:1], timeout, resp.Nil, func() (resp.Reply, bool) {
+		val, ok, err := d.LMove(args[0], args[1], from, to)
+		if err != nil {
+			return resp.NewError(err), true
+		}
+		return resp.BulkString(val), ok
+	})
+}
+
+// BZPOPMIN key [key ...] timeout
+// BZPOPMAX takes the same args, replies with the key, the member and its score, or with nil on timeout
+func (s *Server) bzpopAction(cc *ConnContext, c Command) resp.Reply {
+	keys := c.args[:len(c.args)-1]
+	timeout, err := parseTimeout(c.args[len(c.args)-1], false)
+	if err != nil {
+		return resp.NewError(err)
+	}
+
+	d := s.getDb(cc.dbIdx)
+	return s.block(cc, c, keys, timeout, resp.NilArray, func() (resp.Reply, bool) {
+		for _, k := range keys {
+			items, err := d.ZPop(k, 1, c.name == BZPOPMAX)
+			if err != nil {
+				return resp.NewError(err), true
+			}
+			if len(items) > 0 {
+				return resp.Array{resp.BulkString(k), resp.BulkString(items[0].Member), resp.Double(items[0].Score)}, true
+			}
+		}
+		return nil, false
+	})
+}
+
+// parses the timeout of a blocking command, given in seconds or in ms for the BLOCK option of streams
+func parseTimeout(arg string, ms bool) (time.Duration, error) {
+	if ms {
+		n, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			return 0, ErrTimeoutNotInteger
+		}
+		if n < 0 {
+			return 0, ErrTimeoutNegative
+		}
+		return time.Duration(min(n, math.MaxInt64/int64(time.Millisecond))) * time.Millisecond, nil
+	}
+
+	secs, err := strconv.ParseFloat(arg, 64)
+	if err != nil || math.IsNaN(secs) || math.IsInf(secs, 0) {
+		return 0, ErrTimeoutNotFloat
+	}
+	if secs < 0 {
+		return 0, ErrTimeoutNegative
+	}
+	return time.Duration(min(secs, float64(math.MaxInt64/int64(time.Second))) * float64(time.Second)), nil
+}
+
+// watches the conn of a blocked client, so the client is unblocked if it hangs up
+// the watcher only peeks, the commands sent meanwhile are read once it's done
+type hangupWatcher struct {
+	r      *resp.Reader
+	done   chan struct{} // closed once the conn has input or is gone, nil if not watching
+	hangup chan struct{} // closed once the conn is gone
+}
+
+func newHangupWatcher(r *resp.Reader) *hangupWatcher {
+	return &hangupWatcher{r: r, hangup: make(chan struct{})}
+}
+
+// starts watching the conn unless requests are buffered already
+// returns a channel closed if the client hangs up
+func (h *hangupWatcher) watch() <-chan struct{} {
+	if h.done == nil && h.r.Buffered() == 0 {
+		h.done = make(chan struct{})
+		go func() {
+			defer close(h.done)
+			if err := h.r.Peek(); err != nil {
+				close(h.hangup)
+			}
+		}()
+	}
+	return h.hangup
+}
+
+// reports if the conn is being watched, it can't be read meanwhile
+func (h *hangupWatcher) watching() bool {
+	return h.done != nil
+}
+
+// waits for the watch to end before the conn is read again
+func (h *hangupWatcher) wait() {
+	if h.done != nil {
+		<-h.done
+		h.done = nil
+	}
+}
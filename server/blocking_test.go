@@ -0,0 +1,229 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/db"
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store/inMemoryStore"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// runs the blocking command of the client in the background, once it's parked
+// returns the channel getting its reply
+func blockClient(t *testing.T, s *Server, id int64, input string) <-chan string {
+	t.Helper()
+	out := make(chan string, 1)
+	go func() {
+		var buf bytes.Buffer
+		s.handleCommand(input, &buf, &ConnContext{id: id})
+		out <- buf.String()
+	}()
+
+	waitFor(t, func() bool {
+		s.blocking.mu.Lock()
+		defer s.blocking.mu.Unlock()
+		_, ok := s.blocking.clients[id]
+		return ok
+	})
+	return out
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(2 * time.Second); !cond(); time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting")
+		}
+	}
+}
+
+func expectReply(t *testing.T, out <-chan string, exp string) {
+	t.Helper()
+	select {
+	case got := <-out:
+		if got != exp {
+			t.Errorf("Expected %q but got %q", exp, got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Errorf("Expected %q but the client is still blocked", exp)
+	}
+}
+
+func expectBlocked(t *testing.T, out <-chan string) {
+	t.Helper()
+	select {
+	case got := <-out:
+		t.Errorf("Expected the client to be blocked but got %q", got)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestBlockingCommands(t *testing.T) {
+	testCases := []struct {
+		name     string
+		inputArr []string
+		expOut   []string
+	}{
+		{
+			name:     "served right away",
+			inputArr: []string{"RPUSH a x y z", "BLPOP missing a 0", "BRPOP a 0", "BLMOVE a dst LEFT RIGHT 0", "ZADD z 1 m", "BZPOPMIN z 0", "XADD s 1-1 f v", "XREAD BLOCK 0 STREAMS s 0"},
+			expOut:   []string{"(integer) 3", "1) \"a\"\n2) \"x\"\n", "1) \"a\"\n2) \"z\"\n", "\"y\"", "(integer) 1", "1) \"z\"\n2) \"m\"\n3) (double) 1\n", "\"1-1\"", "1) 1) \"s\"\n"},
+		},
+		{
+			name:     "commands of a transaction never block",
+			inputArr: []string{"MULTI", "BLPOP a 0", "BLMOVE a b LEFT LEFT 0", "XREAD BLOCK 0 STREAMS s $", "EXEC"},
+			expOut:   []string{MssgOK, QUEUED, QUEUED, QUEUED, "1) (nil)\n2) (nil)\n3) (nil)\n"},
+		},
+		{
+			name:     "timeout is hit",
+			inputArr: []string{"BLPOP a 0.01", "BZPOPMAX z 0.01", "XREAD BLOCK 10 STREAMS s $"},
+			expOut:   []string{MssgNil, MssgNil, MssgNil},
+		},
+		{
+			name:     "bad args",
+			inputArr: []string{"BLPOP a x", "BLPOP a -1", "BLMOVE a b UP LEFT 0", "XREAD BLOCK 1.5 STREAMS s 0", "SET str v", "BLPOP str 0", "CLIENT NOPE", "CLIENT UNBLOCK 1 NOPE"},
+			expOut:   []string{ErrTimeoutNotFloat.Error(), ErrTimeoutNegative.Error(), ErrSyntax.Error(), ErrTimeoutNotInteger.Error(), MssgOK, db.ErrWrongType.Error(), "unknown subcommand or wrong number of arguments for 'NOPE'", "CLIENT UNBLOCK reason should be TIMEOUT or ERROR"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			s := GetTestServerWithDB()
+			cc := &ConnContext{}
+
+			for i, input := range tc.inputArr {
+				s.handleCommand(input, &buf, cc)
+
+				if !bytes.Contains(buf.Bytes(), []byte(tc.expOut[i])) {
+					t.Errorf("Expected output of %q to contain %q but got %q instead", input, tc.expOut[i], buf.String())
+				}
+				buf.Reset()
+			}
+		})
+	}
+}
+
+func TestBlockedClientsAreServed(t *testing.T) {
+	var buf bytes.Buffer
+	producer := &ConnContext{id: 100}
+
+	t.Run("clients are served in the order they blocked", func(t *testing.T) {
+		s := GetTestServerWithDB()
+		first := blockClient(t, s, 1, "BLPOP q 0")
+		second := blockClient(t, s, 2, "BRPOP other q 0")
+		third := blockClient(t, s, 3, "BLPOP q 0")
+
+		s.handleCommand("RPUSH q a b", &buf, producer)
+		expectReply(t, first, "1) \"q\"\n2) \"a\"\n")
+		expectReply(t, second, "1) \"q\"\n2) \"b\"\n")
+		expectBlocked(t, third)
+
+		s.handleCommand("RPUSH q c", &buf, producer)
+		expectReply(t, third, "1) \"q\"\n2) \"c\"\n")
+		s.handleCommand("LLEN q", &buf, producer)
+		if !bytes.HasSuffix(buf.Bytes(), []byte("(integer) 0\n")) {
+			t.Errorf("Expected every item to be popped but got %q", buf.String())
+		}
+	})
+
+	t.Run("writes made inside EXEC", func(t *testing.T) {
+		s := GetTestServerWithDB()
+		out := blockClient(t, s, 1, "BZPOPMAX z 0")
+
+		for _, input := range []string{"MULTI", "ZADD z 1 a 2 b", "ZREM z b", "EXEC"} {
+			s.handleCommand(input, &buf, producer)
+		}
+		expectReply(t, out, "1) \"z\"\n2) \"a\"\n3) (double) 1\n")
+	})
+
+	t.Run("BLMOVE serves the clients blocked on its destination", func(t *testing.T) {
+		s := GetTestServerWithDB()
+		mover := blockClient(t, s, 1, "BLMOVE src dst LEFT LEFT 0")
+		popper := blockClient(t, s, 2, "BLPOP dst 0")
+
+		s.handleCommand("LPUSH src x", &buf, producer)
+		expectReply(t, mover, "\"x\"\n")
+		expectReply(t, popper, "1) \"dst\"\n2) \"x\"\n")
+	})
+
+	t.Run("XREAD and XREADGROUP BLOCK", func(t *testing.T) {
+		s := GetTestServerWithDB()
+		s.handleCommand("XGROUP CREATE s g $ MKSTREAM", &buf, producer)
+		reader := blockClient(t, s, 1, "XREAD BLOCK 0 STREAMS s $")
+		consumer := blockClient(t, s, 2, "XREADGROUP GROUP g alice BLOCK 0 STREAMS s >")
+
+		s.handleCommand("XADD s 1-1 f v", &buf, producer)
+		exp := "1) 1) \"s\"\n   2) 1) 1) \"1-1\"\n         2) 1) \"f\"\n            2) \"v\"\n"
+		expectReply(t, reader, exp)
+		expectReply(t, consumer, exp)
+	})
+
+	t.Run("XREADGROUP gets an error once its group is destroyed", func(t *testing.T) {
+		s := GetTestServerWithDB()
+		s.handleCommand("XGROUP CREATE s g $ MKSTREAM", &buf, producer)
+		consumer := blockClient(t, s, 1, "XREADGROUP GROUP g alice BLOCK 0 STREAMS s >")
+
+		s.handleCommand("XGROUP DESTROY s g", &buf, producer)
+		expectReply(t, consumer, "(error) NOGROUP No such key 's' or consumer group 'g'\n")
+	})
+
+	t.Run("CLIENT UNBLOCK", func(t *testing.T) {
+		s := GetTestServerWithDB()
+		timedOut := blockClient(t, s, 1, "BLPOP q 0")
+		failed := blockClient(t, s, 2, "BLPOP q 0")
+
+		buf.Reset()
+		for _, input := range []string{"CLIENT UNBLOCK 1", "CLIENT UNBLOCK 2 ERROR", "CLIENT UNBLOCK 2"} {
+			s.handleCommand(input, &buf, producer)
+		}
+		if exp := "(integer) 1\n(integer) 1\n(integer) 0\n"; buf.String() != exp {
+			t.Errorf("Expected %q but got %q", exp, buf.String())
+		}
+		expectReply(t, timedOut, MssgNil+"\n")
+		expectReply(t, failed, "(error) "+ErrUnblocked.Error()+"\n")
+	})
+}
+
+func TestBlockedClientHangsUp(t *testing.T) {
+	ln := bufconn.Listen(1024 * 1024)
+	s := &Server{Db: map[int]db.DbInterface{0: db.GetNewDB(inMemoryStore.NewInMemoryStore())}, Listener: ln, TextMode: true}
+	go s.Start()
+	defer s.Close()
+
+	conn, err := ln.Dial()
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	fmt.Fprintln(conn, "BLPOP q 0")
+	waitFor(t, func() bool {
+		s.blocking.mu.Lock()
+		defer s.blocking.mu.Unlock()
+		return len(s.blocking.clients) == 1
+	})
+
+	// the item isn't handed to the client which is gone
+	conn.Close()
+	waitFor(t, func() bool {
+		s.blocking.mu.Lock()
+		defer s.blocking.mu.Unlock()
+		return len(s.blocking.clients) == 0
+	})
+
+	conn, err = ln.Dial()
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer conn.Close()
+	fmt.Fprintln(conn, "RPUSH q a")
+	fmt.Fprintln(conn, "LLEN q")
+	r := bufio.NewReader(conn)
+	r.ReadString('\n')
+	if line, _ := r.ReadString('\n'); line != "(integer) 1\n" {
+		t.Errorf("Expected the item to be left in the list but got %q", line)
+	}
+}
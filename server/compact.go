@@ -0,0 +1,382 @@
+package server
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/resp"
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store"
+)
+
+const LOADCOMMANDS string = "LOADCOMMANDS"
+
+var (
+	ErrLoadCommandsNotEmpty = errors.New("LOADCOMMANDS only loads into empty dbs")
+	ErrLoadCommandsRefused  = errors.New("LOADCOMMANDS only runs SELECT and the commands writing keys")
+	ErrFileOutsideDir       = errors.New("the file must be a relative path inside dir")
+)
+
+// dump of the keys of a db, ordered by key
+type dbDump struct {
+	idx     int
+	entries []store.Entry
+}
+
+// COMPACT [ALL] [TO file]
+// replies with the commands recreating the keys of the selected db, one per line, ordered by key and followed by their timeouts
+// ALL dumps every db with keys instead, each after a SELECT of it
+// TO writes the lines to the file, a relative path inside dir, and replies with the number of keys written
+func (s *Server) compactAction(cc *ConnContext, args []string) resp.Reply {
+	all, path := false, ""
+	for i := 0; i < len(args); i++ {
+		switch opt := strings.ToUpper(args[i]); {
+		case opt == "ALL":
+			all = true
+		case opt == "TO" && i+1 < len(args):
+			path = args[i+1]
+			i++
+		default:
+			return resp.NewError(ErrSyntax)
+		}
+	}
+
+	if path != "" {
+		file, err := s.filePath(path)
+		if err != nil {
+			return resp.NewError(err)
+		}
+		n, err := writeDumpFile(file, func(fn func(dbDump) error) error { return s.eachDump(cc.dbIdx, all, fn) }, all)
+		if err != nil {
+			return resp.NewError(fmt.Errorf("writing the dump: %v", err))
+		}
+		return resp.Integer(n)
+	}
+
+	dumps := s.dumpDbs(cc.dbIdx, all)
+	if len(dumps) == 0 || len(dumps[0].entries) == 0 {
+		return resp.Nil
+	}
+
+	// RESP3 clients get the key value pairs as a native map, by db with ALL
+	if cc.protocol == resp.RESP3 {
+		if !all {
+			return entriesMap(dumps[0].entries)
+		}
+		m := resp.Map{}
+		for _, d := range dumps {
+			m = append(m, resp.MapItem{Key: resp.Integer(d.idx), Value: entriesMap(d.entries)})
+		}
+		return m
+	}
+
+	var b strings.Builder
+	writeDump(&b, dumps, all)
+	return resp.Verbatim(strings.TrimSuffix(b.String(), "\n"))
+}
+
+// returns the keys of the db, or those of every db with keys in the order of their index
+func (s *Server) dumpDbs(dbIdx int, all bool) []dbDump {
+	dumps := []dbDump{}
+	s.eachDump(dbIdx, all, func(d dbDump) error {
+		dumps = append(dumps, d)
+		return nil
+	})
+	return dumps
+}
+
+// calls fn with the keys of the db, or with those of every db with keys in the order of their index
+// a db is copied only once fn is done with the one before, so a single db is held at a time
+// called with no other command running, so the dbs are seen at the same point
+func (s *Server) eachDump(dbIdx int, all bool, fn func(dbDump) error) error {
+	if !all {
+		return fn(dbDump{idx: dbIdx, entries: s.getDb(dbIdx).Snapshot()})
+	}
+
+	dbs := s.allDbs()
+	idxs := make([]int, 0, len(dbs))
+	for idx := range dbs {
+		idxs = append(idxs, idx)
+	}
+	slices.Sort(idxs)
+
+	for _, idx := range idxs {
+		if entries := dbs[idx].Snapshot(); len(entries) > 0 {
+			if err := fn(dbDump{idx: idx, entries: entries}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writes the commands recreating the keys, a line each, and returns the number of keys written
+func writeDump(w io.Writer, dumps []dbDump, withSelect bool) (int, error) {
+	n := 0
+	for _, d := range dumps {
+		written, err := writeDbDump(w, d, withSelect)
+		n += written
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// writes the commands recreating the keys of a db, after a SELECT of it with withSelect
+func writeDbDump(w io.Writer, d dbDump, withSelect bool) (int, error) {
+	if withSelect {
+		if _, err := fmt.Fprintln(w, resp.JoinInline([]string{SELECT, strconv.Itoa(d.idx)})); err != nil {
+			return 0, err
+		}
+	}
+	for i, e := range d.entries {
+		for _, args := range entryCommands(e) {
+			if _, err := fmt.Fprintln(w, resp.JoinInline(args)); err != nil {
+				return i, err
+			}
+		}
+	}
+	return len(d.entries), nil
+}
+
+// writes the dumps given by each to a temp file renamed to path once complete, so a failed write keeps the previous dump
+// each db is written as soon as it's dumped, rather than once every db is
+func writeDumpFile(path string, each func(func(dbDump) error) error, withSelect bool) (int, error) {
+	f, err := os.CreateTemp(filepath.Dir(path), "temp-*.txt")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(f.Name())
+
+	w, n := bufio.NewWriter(f), 0
+	err = each(func(d dbDump) error {
+		written, err := writeDbDump(w, d, withSelect)
+		n += written
+		return err
+	})
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if err != nil {
+		f.Close()
+		return 0, err
+	}
+	if err := f.Close(); err != nil {
+		return 0, err
+	}
+	return n, os.Rename(f.Name(), path)
+}
+
+// returns the keys as a map of their values
+func entriesMap(entries []store.Entry) resp.Map {
+	m := resp.Map{}
+	for _, e := range entries {
+		m = append(m, resp.MapItem{Key: resp.BulkString(e.Key), Value: valueReply(e.Value)})
+	}
+	return m
+}
+
+// returns the commands which recreate the key along with its timeout
+func entryCommands(e store.Entry) [][]string {
+	cmds := valueCommands(e.Key, e.Value)
+	if e.ExpireAt != 0 {
+		cmds = append(cmds, []string{PEXPIREAT, e.Key, strconv.FormatInt(e.ExpireAt, 10)})
+	}
+	return cmds
+}
+
+// returns the commands which recreate the value of the key
+func valueCommands(key string, val store.Value) [][]string {
+	switch v := val.(type) {
+	case *store.List:
+		return [][]string{append([]string{RPUSH, key}, v.Values()...)}
+	case store.Hash:
+		args := []string{HSET, key}
+		for _, f := range sortedKeys(v) {
+			args = append(args, f, v[f])
+		}
+		return [][]string{args}
+	case store.Set:
+		return [][]string{append([]string{SADD, key}, sortedMembers(v)...)}
+	case *store.ZSet:
+		args := []string{ZADD, key}
+		for _, item := range v.Items() {
+			args = append(args, resp.FormatDouble(item.Score), item.Member)
+		}
+		return [][]string{args}
+	case *store.Stream:
+		return streamCommands(key, v)
+	default:
+		return [][]string{{SET, key, fmt.Sprint(v)}}
+	}
+}
+
+// returns the commands which recreate the stream along with its groups
+// the last id is kept past the entries deleted from the end by adding an entry with it and deleting it
+// consumers are created by claiming an id which isn't pending, as there's no XGROUP CREATECONSUMER
+func streamCommands(key string, v *store.Stream) [][]string {
+	cmds := [][]string{}
+	entries := v.Entries()
+	for _, e := range entries {
+		cmds = append(cmds, append([]string{XADD, key, e.ID.String()}, e.Fields...))
+	}
+	switch {
+	case v.LastID == (store.StreamID{}) && len(v.Groups) == 0:
+		// an empty stream left by XGROUP CREATE MKSTREAM, only a group can create it
+		cmds = append(cmds, []string{XGROUP, "CREATE", key, "", "0", "MKSTREAM"}, []string{XGROUP, "DESTROY", key, ""})
+	case v.LastID != (store.StreamID{}) && (len(entries) == 0 || entries[len(entries)-1].ID != v.LastID):
+		id := v.LastID.String()
+		cmds = append(cmds, []string{XADD, key, id, "", ""}, []string{XDEL, key, id})
+	}
+
+	for _, name := range sortedKeys(v.Groups) {
+		g := v.Groups[name]
+		cmds = append(cmds, []string{XGROUP, "CREATE", key, name, g.LastID.String(), "MKSTREAM", "ENTRIESREAD", strconv.FormatInt(g.EntriesRead, 10)})
+
+		ids := make([]store.StreamID, 0, len(g.Pending))
+		for id := range g.Pending {
+			ids = append(ids, id)
+		}
+		slices.SortFunc(ids, func(a, b store.StreamID) int { return a.Compare(b) })
+
+		for _, id := range ids {
+			p := g.Pending[id]
+			cmds = append(cmds, []string{XCLAIM, key, name, p.Consumer, "0", id.String(),
+				"TIME", strconv.FormatInt(p.DeliveryTime, 10), "RETRYCOUNT", strconv.Itoa(p.DeliveryCount), "FORCE", "JUSTID"})
+		}
+		for _, consumer := range sortedKeys(g.Consumers) {
+			if len(g.Consumers[consumer].Pending) == 0 {
+				cmds = append(cmds, []string{XCLAIM, key, name, consumer, "0", "0-0", "JUSTID"})
+			}
+		}
+	}
+	return cmds
+}
+
+// command of a dump along with its line
+type dumpCommand struct {
+	line int
+	c    Command
+}
+
+// LOADCOMMANDS file
+// runs the commands of a dump written by COMPACT, the file is a relative path inside dir, and replies with the number of commands run
+// they start in the selected db, which is kept as the dump is loaded, and every db they write to must be empty
+// the commands are checked before any runs, a load stopped by one which fails empties the dbs again
+func (s *Server) loadcommandsAction(cc *ConnContext, path string) resp.Reply {
+	file, err := s.filePath(path)
+	if err != nil {
+		return resp.NewError(err)
+	}
+	cmds, idxs, err := s.readDumpFile(file, cc.dbIdx)
+	if err != nil {
+		return resp.NewError(err)
+	}
+	for _, idx := range idxs {
+		if d := s.getDb(idx); d != nil && d.DbSize() > 0 {
+			return resp.NewError(fmt.Errorf("%v, db %d has keys", ErrLoadCommandsNotEmpty, idx))
+		}
+	}
+
+	// the writes are logged once every command ran, as a tran, so a failed load leaves nothing in the file
+	rc := &ConnContext{dbIdx: cc.dbIdx, protocol: cc.protocol, id: cc.id}
+	logged, logging := []aofEntry{}, s.aofOn()
+	for _, dc := range cmds {
+		reply := s.takeAction(rc, dc.c)
+		if err, ok := reply.(resp.Error); ok {
+			s.flushDbs(idxs)
+			return resp.Error(fmt.Sprintf("%s at line %d", err, dc.line))
+		}
+		if logging {
+			logged = append(logged, s.aofEntries(rc.dbIdx, dc.c, reply)...)
+		}
+	}
+	s.feedAOF(logged, true)
+	return resp.Integer(len(cmds))
+}
+
+// empties the dbs a failed load wrote to, they were empty before it
+func (s *Server) flushDbs(idxs []int) {
+	for _, idx := range idxs {
+		if d := s.getDb(idx); d != nil {
+			d.Flush()
+		}
+	}
+}
+
+// reads the commands of a dump along with the dbs they write to, starting from dbIdx
+// blank lines are skipped
+func (s *Server) readDumpFile(path string, dbIdx int) ([]dumpCommand, []int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	cmds, idxs := []dumpCommand{}, []int{dbIdx}
+	r := bufio.NewReader(f)
+	for line := 1; ; line++ {
+		l, err := r.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, nil, err
+		}
+
+		if strings.TrimSpace(l) != "" {
+			c, err := s.dumpLineCommand(l)
+			if err != nil {
+				return nil, nil, fmt.Errorf("%v at line %d", err, line)
+			}
+			if c.name == SELECT {
+				idx, _ := strconv.Atoi(c.args[0])
+				idxs = append(idxs, idx)
+			}
+			cmds = append(cmds, dumpCommand{line: line, c: c})
+		}
+
+		if err == io.EOF {
+			return cmds, idxs, nil
+		}
+	}
+}
+
+// parses a line of a dump, which must be a SELECT or a write which doesn't block
+func (s *Server) dumpLineCommand(line string) (Command, error) {
+	args, err := resp.SplitInline([]byte(strings.TrimRight(line, "\r\n")))
+	if err != nil {
+		return Command{}, err
+	}
+	c, err := s.makeCommand(args, &ConnContext{})
+	if err != nil {
+		return Command{}, err
+	}
+
+	switch {
+	case c.name == SELECT:
+		idx, err := strconv.Atoi(c.args[0])
+		if err != nil || idx < DbRangeMin || idx > DbRangeMax {
+			return Command{}, ErrDBIndexOutOfRange
+		}
+	case !writeCommands[c.name] || blocksOnKeys(c) || c.name == LOADCOMMANDS || c.name == IMPORTRDB:
+		return Command{}, fmt.Errorf("%v, got '%s'", ErrLoadCommandsRefused, args[0])
+	}
+	return c, nil
+}
+
+// returns the path of a file read or written by a command, taken from dir
+// absolute paths and those leaving dir are refused, so clients only reach the files of the server
+func (s *Server) filePath(path string) (string, error) {
+	if !filepath.IsLocal(path) {
+		return "", ErrFileOutsideDir
+	}
+	return filepath.Join(s.config.getDir(), path), nil
+}
@@ -0,0 +1,242 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompactCommands(t *testing.T) {
+	at := time.Now().Add(time.Hour).UnixMilli()
+
+	testCases := []struct {
+		name     string
+		inputArr []string
+		expOut   []string
+	}{
+		{
+			name:     "keys ordered by key",
+			inputArr: []string{"SET b 2", "SET c 3", "SET a 1", "COMPACT"},
+			expOut:   []string{MssgOK, MssgOK, MssgOK, "SET a 1\nSET b 2\nSET c 3"},
+		},
+		{
+			name:     "args quoted when needed",
+			inputArr: []string{`SET "a key" "hello world"`, `SET empty ""`, `SET nl "x\ny"`, "COMPACT"},
+			expOut:   []string{MssgOK, MssgOK, MssgOK, `SET "a key" "hello world"` + "\n" + `SET empty ""` + "\n" + `SET nl "x\ny"`},
+		},
+		{
+			name:     "timeouts kept",
+			inputArr: []string{fmt.Sprintf("SET foo bar PXAT %d", at), "COMPACT"},
+			expOut:   []string{MssgOK, fmt.Sprintf("SET foo bar\nPEXPIREAT foo %d", at)},
+		},
+		{
+			name:     "selected db only",
+			inputArr: []string{"SET a 1", "SELECT 2", "SET b 2", "COMPACT"},
+			expOut:   []string{MssgOK, MssgOK, MssgOK, "SET b 2"},
+		},
+		{
+			name:     "ALL with a SELECT before each db",
+			inputArr: []string{"SET a 1", "SELECT 3", "SELECT 2", "SET b 2", "COMPACT ALL"},
+			expOut:   []string{MssgOK, MssgOK, MssgOK, MssgOK, "SELECT 0\nSET a 1\nSELECT 2\nSET b 2"},
+		},
+		{
+			name:     "ALL with no keys",
+			inputArr: []string{"SELECT 2", "COMPACT ALL"},
+			expOut:   []string{MssgOK, "(nil)"},
+		},
+		{
+			name:     "stream with its last entry deleted",
+			inputArr: []string{"XADD s 1-1 f a", "XADD s 1-2 f b", "XDEL s 1-2", "COMPACT"},
+			expOut:   []string{"\"1-1\"", "\"1-2\"", "(integer) 1", "XADD s 1-1 f a\nXADD s 1-2 \"\" \"\"\nXDEL s 1-2"},
+		},
+		{
+			name:     "stream with a group",
+			inputArr: []string{"XADD s 1-1 f a", "XGROUP CREATE s g 0", "XGROUP CREATE s idle $", "XREADGROUP GROUP idle c STREAMS s >", "COMPACT"},
+			expOut:   []string{"\"1-1\"", MssgOK, MssgOK, "(nil)", "XADD s 1-1 f a\nXGROUP CREATE s g 0-0 MKSTREAM ENTRIESREAD 0\nXGROUP CREATE s idle 1-1 MKSTREAM ENTRIESREAD 1\nXCLAIM s idle c 0 0-0 JUSTID"},
+		},
+		{
+			name:     "unknown option",
+			inputArr: []string{"SET a 1", "COMPACT TO"},
+			expOut:   []string{MssgOK, "(error) ERR syntax error"},
+		},
+		{
+			name:     "LOADCOMMANDS in a tran",
+			inputArr: []string{"MULTI", "LOADCOMMANDS dump.txt", "EXEC"},
+			expOut:   []string{MssgOK, "(error) ERR Command not allowed inside a transaction", "EXECABORT"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := GetTestServerWithDB()
+			cc := &ConnContext{}
+
+			for i, input := range tc.inputArr {
+				var buf bytes.Buffer
+				s.handleCommand(input, &buf, cc)
+
+				if !strings.Contains(buf.String(), tc.expOut[i]) {
+					t.Errorf("Expected %q but got %q", tc.expOut[i], buf.String())
+				}
+			}
+		})
+	}
+}
+
+func TestCompactToFile(t *testing.T) {
+	var buf bytes.Buffer
+	dir := t.TempDir()
+	s := getTestServerWithDir(t, dir)
+	cc := &ConnContext{}
+
+	at := time.Now().Add(time.Hour).UnixMilli()
+	for _, input := range []string{
+		`SET "a key" "x\r\ny"`, fmt.Sprintf("SET ttl 1 PXAT %d", at), "RPUSH list a b c", "SADD set a b", "HSET hash f v g w",
+		"SELECT 4", "ZADD z 1.5 a 2 b", "XADD s 1-1 f a", "XADD s 1-2 f b", "XADD s 1-3 f c", "XDEL s 1-3",
+		"XGROUP CREATE s g 0", "XREADGROUP GROUP g alice COUNT 1 STREAMS s >", "XGROUP CREATE s other $",
+		"XREADGROUP GROUP other bob STREAMS s >", "XGROUP CREATE empty g $ MKSTREAM", "SELECT 9", "SET last 1",
+	} {
+		s.handleCommand(input, &buf, cc)
+	}
+
+	buf.Reset()
+	s.handleCommand("COMPACT ALL TO dump.txt", &buf, cc)
+	if exp := "(integer) 9\n"; buf.String() != exp {
+		t.Fatalf("Expected %q but got %q", exp, buf.String())
+	}
+
+	// the file holds the same lines as the reply
+	buf.Reset()
+	s.handleCommand("COMPACT ALL", &buf, cc)
+	data, err := os.ReadFile(filepath.Join(dir, "dump.txt"))
+	if err != nil {
+		t.Fatalf("Failed to read the dump: %v", err)
+	}
+	if exp, got := buf.String(), string(data); got != exp {
+		t.Errorf("Expected %q but got %q", exp, got)
+	}
+
+	// loading the dump into an empty server gives the same keys
+	loaded := getTestServerWithDir(t, dir)
+	buf.Reset()
+	loaded.handleCommand("LOADCOMMANDS dump.txt", &buf, &ConnContext{})
+	if !strings.HasPrefix(buf.String(), "(integer) ") {
+		t.Fatalf("Expected the number of commands but got %q", buf.String())
+	}
+	if exp, got := dumpDbs(t, s), dumpDbs(t, loaded); !reflect.DeepEqual(got, exp) {
+		t.Errorf("Expected %q but got %q", exp, got)
+	}
+
+	// files outside dir are refused
+	for _, path := range []string{"../dump.txt", filepath.Join(dir, "abs.txt"), "sub/../../dump.txt"} {
+		buf.Reset()
+		s.handleCommand("COMPACT TO "+path, &buf, cc)
+		if exp := "(error) ERR " + ErrFileOutsideDir.Error() + "\n"; buf.String() != exp {
+			t.Errorf("Expected %q but got %q", exp, buf.String())
+		}
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dir), "dump.txt")); err == nil {
+		t.Errorf("Expected no dump written outside dir")
+	}
+
+	// nor can dir be moved by a client to reach them
+	buf.Reset()
+	s.handleCommand("CONFIG SET dir "+filepath.Dir(dir), &buf, cc)
+	s.handleCommand("COMPACT TO dump.txt", &buf, cc)
+	if exp := "(error) ERR CONFIG SET failed (possibly related to argument 'dir') - " + ErrProtectedConfig.Error() + "\n(integer) 1\n"; buf.String() != exp {
+		t.Errorf("Expected %q but got %q", exp, buf.String())
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dir), "dump.txt")); err == nil {
+		t.Errorf("Expected no dump written outside dir")
+	}
+}
+
+func TestLoadCommands(t *testing.T) {
+	testCases := []struct {
+		name     string
+		dump     string
+		inputArr []string
+		expOut   []string
+	}{
+		{
+			name:     "commands run in the selected db",
+			dump:     "SET a 1\n\nSELECT 3\nRPUSH l x y\n",
+			inputArr: []string{"SELECT 1", "LOADCOMMANDS dump.txt", "GET a", "SELECT 3", "LRANGE l 0 -1"},
+			expOut:   []string{MssgOK, "(integer) 3", "\"1\"", MssgOK, "1) \"x\"\n2) \"y\""},
+		},
+		{
+			name:     "db with keys",
+			dump:     "SELECT 2\nSET a 1\n",
+			inputArr: []string{"SELECT 2", "SET b 2", "SELECT 0", "LOADCOMMANDS dump.txt", "GET a"},
+			expOut:   []string{MssgOK, MssgOK, MssgOK, "(error) ERR LOADCOMMANDS only loads into empty dbs, db 2 has keys", "(nil)"},
+		},
+		{
+			name:     "read command",
+			dump:     "SET a 1\nGET a\n",
+			inputArr: []string{"LOADCOMMANDS dump.txt", "GET a"},
+			expOut:   []string{"(error) ERR LOADCOMMANDS only runs SELECT and the commands writing keys, got 'GET' at line 2", "(nil)"},
+		},
+		{
+			name:     "unknown command",
+			dump:     "NOPE a\n",
+			inputArr: []string{"LOADCOMMANDS dump.txt"},
+			expOut:   []string{"(error) ERR unknown command 'NOPE'"},
+		},
+		{
+			name:     "unbalanced quotes",
+			dump:     "SET a 1\nSET \"b 2\n",
+			inputArr: []string{"LOADCOMMANDS dump.txt"},
+			expOut:   []string{"at line 2"},
+		},
+		{
+			name:     "command failing halfway",
+			dump:     "SET a x\nINCR a\nSET b 1\n",
+			inputArr: []string{"LOADCOMMANDS dump.txt", "GET a", "GET b"},
+			expOut:   []string{"(error) ERR value is not an integer or out of range at line 2", "(nil)", "(nil)"},
+		},
+		{
+			name:     "command failing in another db",
+			dump:     "SET a 1\nSELECT 2\nSET b x\nLPUSH b y\nSET c 1\n",
+			inputArr: []string{"LOADCOMMANDS dump.txt", "GET a", "SELECT 2", "GET b"},
+			expOut:   []string{"(error) WRONGTYPE Operation against a key holding the wrong kind of value at line 4", "(nil)", MssgOK, "(nil)"},
+		},
+		{
+			name:     "missing file",
+			dump:     "",
+			inputArr: []string{"LOADCOMMANDS missing.txt"},
+			expOut:   []string{"no such file or directory"},
+		},
+		{
+			name:     "file outside dir",
+			dump:     "SET a 1\n",
+			inputArr: []string{"LOADCOMMANDS ../dump.txt", "LOADCOMMANDS /etc/passwd", "LOADCOMMANDS sub/../../dump.txt", "GET a"},
+			expOut: []string{"(error) ERR " + ErrFileOutsideDir.Error(), "(error) ERR " + ErrFileOutsideDir.Error(),
+				"(error) ERR " + ErrFileOutsideDir.Error(), "(nil)"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "dump.txt"), []byte(tc.dump), 0644); err != nil {
+				t.Fatalf("Failed to write the dump: %v", err)
+			}
+			s := getTestServerWithDir(t, dir)
+			cc := &ConnContext{}
+
+			for i, input := range tc.inputArr {
+				var buf bytes.Buffer
+				s.handleCommand(input, &buf, cc)
+
+				if !strings.Contains(buf.String(), tc.expOut[i]) {
+					t.Errorf("Expected %q but got %q", tc.expOut[i], buf.String())
+				}
+			}
+		})
+	}
+}
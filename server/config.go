@@ -0,0 +1,690 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/aof"
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/resp"
+)
+
+var (
+	ErrUnknownConfigParam = errors.New("Unknown option or number of arguments for CONFIG SET")
+	ErrImmutableConfig    = errors.New("can't set immutable config")
+	ErrProtectedConfig    = errors.New("can't set protected config")
+	ErrInvalidMemory      = errors.New("argument must be a memory value")
+	ErrConfigOutOfRange   = errors.New("argument must be between")
+	ErrNotInteger         = errors.New("argument couldn't be parsed into an integer")
+	ErrBufferLimitArgs    = errors.New("Wrong number of arguments in buffer limit configuration.")
+	ErrBufferLimitClass   = errors.New("Invalid client class specified in buffer limit configuration.")
+	ErrBufferLimitValue   = errors.New("Error in hard, soft or soft_seconds setting in buffer limit configuration.")
+	ErrDbFilenameIsPath   = errors.New("dbfilename can't be a path, just a filename")
+	ErrInvalidSaveParams  = errors.New("Invalid save parameters")
+	ErrNotYesNo           = errors.New("argument must be 'yes' or 'no'")
+)
+
+const (
+	minMaxBulkLen int64 = 1024 * 1024
+	defaultHz     int   = 10
+	minHz         int   = 1
+	maxHz         int   = 500
+
+	defaultDir        string = "."
+	defaultDbFilename string = "dump.goredis"
+
+	defaultAppendFilename string = "appendonly.aof"
+	defaultAppendDirname  string = "appendonlydir"
+
+	defaultAOFRewritePercentage int   = 100
+	defaultAOFRewriteMinSize    int64 = 64 * 1024 * 1024
+)
+
+// classes of clients with their own output buffer limits
+const (
+	normalClass  string = "normal"
+	replicaClass string = "slave"
+	pubsubClass  string = "pubsub"
+)
+
+// a BGSAVE is started once it's been seconds since the last save, with at least changes made meanwhile
+type saveRule struct {
+	seconds int64
+	changes uint64
+}
+
+var defaultSaveRules = []saveRule{{3600, 1}, {300, 100}, {60, 10000}}
+
+var outputBufferClasses = []string{normalClass, replicaClass, pubsubClass}
+
+// limits of the output buffer of a class of clients, 0 disables a limit
+// a client is dropped once its pending output is over the hard limit, or stays over the soft one for softSeconds
+type outputBufferLimit struct {
+	hard, soft  int64
+	softSeconds int64
+}
+
+var defaultOutputBufferLimits = map[string]outputBufferLimit{
+	normalClass:  {},
+	replicaClass: {hard: 256 * 1024 * 1024, soft: 64 * 1024 * 1024, softSeconds: 60},
+	pubsubClass:  {hard: 32 * 1024 * 1024, soft: 8 * 1024 * 1024, softSeconds: 60},
+}
+
+// reports whether pending bytes of output go over the limit
+// softSince keeps when the output went over the soft limit, it's reset once the output is back under it
+func (l outputBufferLimit) exceeded(pending int, softSince *time.Time) bool {
+	if l.hard > 0 && int64(pending) > l.hard {
+		return true
+	}
+	if l.soft == 0 || int64(pending) <= l.soft {
+		*softSince = time.Time{}
+		return false
+	}
+	if softSince.IsZero() {
+		*softSince = time.Now()
+		return false
+	}
+	return time.Since(*softSince) > time.Duration(l.softSeconds)*time.Second
+}
+
+// runtime tunables of the server, guarded since CONFIG SET can change them
+// while clients are being served, zero values stand for the redis defaults
+type config struct {
+	sync.RWMutex
+	maxBulkLen int64 // proto-max-bulk-len
+	hz         int   // how many times a second the background jobs run
+
+	outputBufferLimits map[string]outputBufferLimit // client-output-buffer-limit by class, defaults when missing
+	keyspaceEvents     keyspaceEvents               // notify-keyspace-events, none by default
+
+	dir        string // where the snapshot is saved
+	dbFilename string // name of the snapshot file
+
+	saveRules              []saveRule // the defaults when nil, no snapshots are saved by the server when empty
+	keepWritingOnBgsaveErr bool       // stop-writes-on-bgsave-error no
+
+	appendOnly         bool      // the writes are logged to the append only file
+	appendFilename     string    // names of the append only files start with it
+	appendDirname      string    // dir of the append only files, in dir
+	appendFsync        aof.Fsync // how often the append only file is synced
+	refuseTruncatedAOF bool      // aof-load-truncated no
+
+	aofRewritePercentage *int   // auto-aof-rewrite-percentage, the default when nil
+	aofRewriteMinSize    *int64 // auto-aof-rewrite-min-size, the default when nil
+}
+
+func (c *config) getMaxBulkLen() int64 {
+	c.RLock()
+	defer c.RUnlock()
+	if c.maxBulkLen == 0 {
+		return resp.DefaultMaxBulkLen
+	}
+	return c.maxBulkLen
+}
+
+func (c *config) getOutputBufferLimit(class string) outputBufferLimit {
+	c.RLock()
+	defer c.RUnlock()
+	if l, ok := c.outputBufferLimits[class]; ok {
+		return l
+	}
+	return defaultOutputBufferLimits[class]
+}
+
+func (c *config) getKeyspaceEvents() keyspaceEvents {
+	c.RLock()
+	defer c.RUnlock()
+	return c.keyspaceEvents
+}
+
+func (c *config) getDir() string {
+	c.RLock()
+	defer c.RUnlock()
+	if c.dir == "" {
+		return defaultDir
+	}
+	return c.dir
+}
+
+func (c *config) getDbFilename() string {
+	c.RLock()
+	defer c.RUnlock()
+	if c.dbFilename == "" {
+		return defaultDbFilename
+	}
+	return c.dbFilename
+}
+
+func (c *config) getSaveRules() []saveRule {
+	c.RLock()
+	defer c.RUnlock()
+	if c.saveRules == nil {
+		return defaultSaveRules
+	}
+	return c.saveRules
+}
+
+func (c *config) getStopWritesOnBgsaveError() bool {
+	c.RLock()
+	defer c.RUnlock()
+	return !c.keepWritingOnBgsaveErr
+}
+
+func (c *config) getAppendOnly() bool {
+	c.RLock()
+	defer c.RUnlock()
+	return c.appendOnly
+}
+
+func (c *config) getAppendFilename() string {
+	c.RLock()
+	defer c.RUnlock()
+	if c.appendFilename == "" {
+		return defaultAppendFilename
+	}
+	return c.appendFilename
+}
+
+func (c *config) getAppendDirname() string {
+	c.RLock()
+	defer c.RUnlock()
+	if c.appendDirname == "" {
+		return defaultAppendDirname
+	}
+	return c.appendDirname
+}
+
+func (c *config) getAppendFsync() aof.Fsync {
+	c.RLock()
+	defer c.RUnlock()
+	return c.appendFsync
+}
+
+func (c *config) getAOFLoadTruncated() bool {
+	c.RLock()
+	defer c.RUnlock()
+	return !c.refuseTruncatedAOF
+}
+
+// returns the growth of the append only files since the last rewrite, in percent of their size then, which starts a rewrite
+// 0 means they're never rewritten on their own
+func (c *config) getAOFRewritePercentage() int {
+	c.RLock()
+	defer c.RUnlock()
+	if c.aofRewritePercentage == nil {
+		return defaultAOFRewritePercentage
+	}
+	return *c.aofRewritePercentage
+}
+
+// returns the size the append only files must reach before they're rewritten on their own
+func (c *config) getAOFRewriteMinSize() int64 {
+	c.RLock()
+	defer c.RUnlock()
+	if c.aofRewriteMinSize == nil {
+		return defaultAOFRewriteMinSize
+	}
+	return *c.aofRewriteMinSize
+}
+
+// returns the time between two runs of the background jobs
+func (c *config) getHzPeriod() time.Duration {
+	return time.Second / time.Duration(c.getHz())
+}
+
+func (c *config) getHz() int {
+	c.RLock()
+	defer c.RUnlock()
+	if c.hz == 0 {
+		return defaultHz
+	}
+	return c.hz
+}
+
+// a server tunable exposed through CONFIG GET/SET
+type configParam struct {
+	get       func(s *Server) string
+	set       func(s *Server, val string) error // nil for params which can't be changed at runtime
+	protected bool                              // set at startup only, CONFIG SET refuses it
+}
+
+var configParams = map[string]configParam{
+	"databases": {
+		get: func(*Server) string { return strconv.Itoa(DbRangeMax - DbRangeMin + 1) },
+	},
+	"proto-max-bulk-len": {
+		get: func(s *Server) string { return strconv.FormatInt(s.config.getMaxBulkLen(), 10) },
+		set: func(s *Server, val string) error {
+			n, err := parseMemory(val)
+			if err != nil {
+				return err
+			}
+			if n < minMaxBulkLen {
+				return fmt.Errorf("%w %d and %d", ErrConfigOutOfRange, minMaxBulkLen, int64(math.MaxInt64))
+			}
+
+			s.config.Lock()
+			defer s.config.Unlock()
+			s.config.maxBulkLen = n
+			return nil
+		},
+	},
+
+	"hz": {
+		get: func(s *Server) string { return strconv.Itoa(s.config.getHz()) },
+		set: func(s *Server, val string) error {
+			n, err := strconv.Atoi(val)
+			if err != nil {
+				return ErrNotInteger
+			}
+
+			// out of range values are clamped, like redis does
+			s.config.Lock()
+			defer s.config.Unlock()
+			s.config.hz = min(max(n, minHz), maxHz)
+			return nil
+		},
+	},
+
+	// <class> <hard limit> <soft limit> <soft seconds> for any of the classes normal, slave (or replica) and pubsub
+	"client-output-buffer-limit": {
+		get: func(s *Server) string {
+			fields := []string{}
+			for _, class := range outputBufferClasses {
+				l := s.config.getOutputBufferLimit(class)
+				fields = append(fields, class, strconv.FormatInt(l.hard, 10), strconv.FormatInt(l.soft, 10), strconv.FormatInt(l.softSeconds, 10))
+			}
+			return strings.Join(fields, " ")
+		},
+		set: func(s *Server, val string) error {
+			fields := strings.Fields(val)
+			if len(fields)%4 != 0 {
+				return ErrBufferLimitArgs
+			}
+
+			limits := map[string]outputBufferLimit{}
+			for i := 0; i < len(fields); i += 4 {
+				class := strings.ToLower(fields[i])
+				if class == "replica" {
+					class = replicaClass
+				}
+				if !slices.Contains(outputBufferClasses, class) {
+					return ErrBufferLimitClass
+				}
+
+				hard, err := parseMemory(fields[i+1])
+				if err != nil {
+					return ErrBufferLimitValue
+				}
+				soft, err := parseMemory(fields[i+2])
+				if err != nil {
+					return ErrBufferLimitValue
+				}
+				secs, err := strconv.ParseInt(fields[i+3], 10, 64)
+				if err != nil || secs < 0 {
+					return ErrBufferLimitValue
+				}
+				limits[class] = outputBufferLimit{hard: hard, soft: soft, softSeconds: secs}
+			}
+
+			// classes left out keep their limits
+			s.config.Lock()
+			defer s.config.Unlock()
+			if s.config.outputBufferLimits == nil {
+				s.config.outputBufferLimits = make(map[string]outputBufferLimit)
+			}
+			for class, l := range limits {
+				s.config.outputBufferLimits[class] = l
+			}
+			return nil
+		},
+	},
+
+	// flags of the keyspace events to publish, eg. KEA for all of them, empty for none
+	"notify-keyspace-events": {
+		get: func(s *Server) string { return s.config.getKeyspaceEvents().String() },
+		set: func(s *Server, val string) error {
+			ev, err := parseKeyspaceEvents(val)
+			if err != nil {
+				return err
+			}
+
+			s.config.Lock()
+			defer s.config.Unlock()
+			s.config.keyspaceEvents = ev
+			return nil
+		},
+	},
+
+	// dir of the snapshot and the append only files, it must exist
+	// it's protected since the files of COMPACT, LOADCOMMANDS, IMPORTRDB and EXPORTRDB are kept inside it
+	"dir": {
+		protected: true,
+		get: func(s *Server) string {
+			dir, err := filepath.Abs(s.config.getDir())
+			if err != nil {
+				return s.config.getDir()
+			}
+			return dir
+		},
+		set: func(s *Server, val string) error {
+			info, err := os.Stat(val)
+			if err != nil {
+				return err
+			}
+			if !info.IsDir() {
+				return fmt.Errorf("%s is not a directory", val)
+			}
+
+			s.config.Lock()
+			defer s.config.Unlock()
+			s.config.dir = val
+			return nil
+		},
+	},
+
+	"dbfilename": {
+		get: func(s *Server) string { return s.config.getDbFilename() },
+		set: func(s *Server, val string) error {
+			if val == "" || filepath.Base(val) != val {
+				return ErrDbFilenameIsPath
+			}
+
+			s.config.Lock()
+			defer s.config.Unlock()
+			s.config.dbFilename = val
+			return nil
+		},
+	},
+
+	// <seconds> <changes> pairs, a BGSAVE is started once any of them is met, empty to save only on demand
+	"save": {
+		get: func(s *Server) string {
+			fields := []string{}
+			for _, r := range s.config.getSaveRules() {
+				fields = append(fields, strconv.FormatInt(r.seconds, 10), strconv.FormatUint(r.changes, 10))
+			}
+			return strings.Join(fields, " ")
+		},
+		set: func(s *Server, val string) error {
+			fields := strings.Fields(val)
+			if len(fields)%2 != 0 {
+				return ErrInvalidSaveParams
+			}
+
+			rules := []saveRule{}
+			for i := 0; i < len(fields); i += 2 {
+				secs, err := strconv.ParseInt(fields[i], 10, 64)
+				if err != nil || secs < 1 {
+					return ErrInvalidSaveParams
+				}
+				changes, err := strconv.ParseUint(fields[i+1], 10, 64)
+				if err != nil {
+					return ErrInvalidSaveParams
+				}
+				rules = append(rules, saveRule{seconds: secs, changes: changes})
+			}
+
+			s.config.Lock()
+			defer s.config.Unlock()
+			s.config.saveRules = rules
+			return nil
+		},
+	},
+
+	"stop-writes-on-bgsave-error": {
+		get: func(s *Server) string { return formatYesNo(s.config.getStopWritesOnBgsaveError()) },
+		set: func(s *Server, val string) error {
+			stop, err := parseYesNo(val)
+			if err != nil {
+				return err
+			}
+
+			s.config.Lock()
+			defer s.config.Unlock()
+			s.config.keepWritingOnBgsaveErr = !stop
+			return nil
+		},
+	},
+
+	// turning it on starts the log over from a base holding the keys, turning it off stops the logging
+	"appendonly": {
+		get: func(s *Server) string { return formatYesNo(s.config.getAppendOnly()) },
+		set: func(s *Server, val string) error {
+			on, err := parseYesNo(val)
+			if err != nil {
+				return err
+			}
+			return s.setAppendOnly(on)
+		},
+	},
+
+	// used the next time the append only files are opened
+	"appendfilename": {
+		get: func(s *Server) string { return s.config.getAppendFilename() },
+		set: func(s *Server, val string) error {
+			if val == "" || filepath.Base(val) != val {
+				return ErrAppendFilenameIsPath
+			}
+
+			s.config.Lock()
+			defer s.config.Unlock()
+			s.config.appendFilename = val
+			return nil
+		},
+	},
+
+	// used the next time the append only files are opened, it's created in dir if needed
+	"appenddirname": {
+		get: func(s *Server) string { return s.config.getAppendDirname() },
+		set: func(s *Server, val string) error {
+			if val == "" || filepath.Base(val) != val {
+				return ErrAppendDirnameIsPath
+			}
+
+			s.config.Lock()
+			defer s.config.Unlock()
+			s.config.appendDirname = val
+			return nil
+		},
+	},
+
+	// always, everysec or no
+	"appendfsync": {
+		get: func(s *Server) string { return s.config.getAppendFsync().String() },
+		set: func(s *Server, val string) error {
+			fsync, ok := aof.ParseFsync(strings.ToLower(val))
+			if !ok {
+				return ErrInvalidAppendFsync
+			}
+			s.setAppendFsync(fsync)
+			return nil
+		},
+	},
+
+	// a truncated append only file is loaded up to its last whole command, and cut there
+	"aof-load-truncated": {
+		get: func(s *Server) string { return formatYesNo(s.config.getAOFLoadTruncated()) },
+		set: func(s *Server, val string) error {
+			load, err := parseYesNo(val)
+			if err != nil {
+				return err
+			}
+
+			s.config.Lock()
+			defer s.config.Unlock()
+			s.config.refuseTruncatedAOF = !load
+			return nil
+		},
+	},
+
+	// 0 turns the rewrites started on their own off
+	"auto-aof-rewrite-percentage": {
+		get: func(s *Server) string { return strconv.Itoa(s.config.getAOFRewritePercentage()) },
+		set: func(s *Server, val string) error {
+			n, err := strconv.Atoi(val)
+			if err != nil {
+				return ErrNotInteger
+			}
+			if n < 0 || n > math.MaxInt32 {
+				return fmt.Errorf("%w 0 and %d", ErrConfigOutOfRange, math.MaxInt32)
+			}
+
+			s.config.Lock()
+			defer s.config.Unlock()
+			s.config.aofRewritePercentage = &n
+			return nil
+		},
+	},
+
+	"auto-aof-rewrite-min-size": {
+		get: func(s *Server) string { return strconv.FormatInt(s.config.getAOFRewriteMinSize(), 10) },
+		set: func(s *Server, val string) error {
+			n, err := parseMemory(val)
+			if err != nil {
+				return err
+			}
+
+			s.config.Lock()
+			defer s.config.Unlock()
+			s.config.aofRewriteMinSize = &n
+			return nil
+		},
+	},
+}
+
+// ConfigParams lists the names of all the params known to CONFIG GET
+func ConfigParams() []string {
+	names := make([]string, 0, len(configParams))
+	for name := range configParams {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// SetConfig changes a param like CONFIG SET does, used to apply the startup config
+func (s *Server) SetConfig(name, val string) error {
+	param, ok := configParams[strings.ToLower(name)]
+	if !ok {
+		return fmt.Errorf("%v - '%s'", ErrUnknownConfigParam, name)
+	}
+	if param.set == nil {
+		return ErrImmutableConfig
+	}
+	return param.set(s, val)
+}
+
+// parses memory values like redis does, eg. 1k is 1000 bytes while 1kb is 1024 bytes
+func parseMemory(val string) (int64, error) {
+	units := []struct {
+		suffix string
+		mul    int64
+	}{
+		{"kb", 1024}, {"mb", 1024 * 1024}, {"gb", 1024 * 1024 * 1024},
+		{"k", 1000}, {"m", 1000 * 1000}, {"g", 1000 * 1000 * 1000}, {"b", 1},
+	}
+
+	num, mul := strings.ToLower(val), int64(1)
+	for _, u := range units {
+		if strings.HasSuffix(num, u.suffix) {
+			num, mul = strings.TrimSuffix(num, u.suffix), u.mul
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(num, 10, 64)
+	if err != nil || n < 0 || n > math.MaxInt64/mul {
+		return 0, ErrInvalidMemory
+	}
+	return n * mul, nil
+}
+
+func parseYesNo(val string) (bool, error) {
+	switch strings.ToLower(val) {
+	case "yes":
+		return true, nil
+	case "no":
+		return false, nil
+	}
+	return false, ErrNotYesNo
+}
+
+func formatYesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+// CONFIG GET pattern [pattern ...]
+// CONFIG SET param value [param value ...]
+func (s *Server) configAction(args []string) resp.Reply {
+	switch sub := strings.ToUpper(args[0]); {
+	case sub == "GET" && len(args) > 1:
+		return s.configGet(args[1:])
+	case sub == "SET" && len(args) > 1 && len(args)%2 == 1:
+		return s.configSet(args[1:])
+	case sub == "GET" || sub == "SET":
+		return resp.NewError(fmt.Errorf("%v for 'config|%s' command", ErrWrongNumberOfArgs, strings.ToLower(sub)))
+	default:
+		return resp.NewError(fmt.Errorf("unknown subcommand '%s'. Try CONFIG HELP.", args[0]))
+	}
+}
+
+// replies with a map of every param matching any of the patterns
+func (s *Server) configGet(patterns []string) resp.Reply {
+	names := []string{}
+	for name := range configParams {
+		for _, p := range patterns {
+			if matchGlob(strings.ToLower(p), name) {
+				names = append(names, name)
+				break
+			}
+		}
+	}
+	slices.Sort(names)
+
+	m := resp.Map{}
+	for _, name := range names {
+		m = append(m, resp.MapItem{Key: resp.BulkString(name), Value: resp.BulkString(configParams[name].get(s))})
+	}
+	return m
+}
+
+// all the params are checked to be known, mutable and not protected before setting any of them
+func (s *Server) configSet(pairs []string) resp.Reply {
+	for i := 0; i < len(pairs); i += 2 {
+		param, ok := configParams[strings.ToLower(pairs[i])]
+		if !ok {
+			return resp.NewError(fmt.Errorf("%v - '%s'", ErrUnknownConfigParam, pairs[i]))
+		}
+		if param.set == nil {
+			return resp.NewError(fmt.Errorf("CONFIG SET failed (possibly related to argument '%s') - %v", pairs[i], ErrImmutableConfig))
+		}
+		if param.protected {
+			return resp.NewError(fmt.Errorf("CONFIG SET failed (possibly related to argument '%s') - %v", pairs[i], ErrProtectedConfig))
+		}
+	}
+
+	// the params are set in order, once one fails those set before it get their previous values back, like redis does
+	prev := make([]string, 0, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		param := configParams[strings.ToLower(pairs[i])]
+		prev = append(prev, param.get(s))
+		if err := param.set(s, pairs[i+1]); err != nil {
+			for j := len(prev) - 1; j >= 0; j-- {
+				configParams[strings.ToLower(pairs[2*j])].set(s, prev[j])
+			}
+			return resp.NewError(fmt.Errorf("CONFIG SET failed (possibly related to argument '%s') - %v", pairs[i], err))
+		}
+	}
+	return resp.SimpleString(MssgOK)
+}
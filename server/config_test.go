@@ -0,0 +1,185 @@
+package server
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestConfigCommand(t *testing.T) {
+	testCases := []struct {
+		name   string
+		input  string
+		expOut string
+	}{
+		{"CONFIG GET exact param", "CONFIG GET databases", "1# \"databases\" => \"16\"\n"},
+		{"CONFIG GET with pattern", "CONFIG GET data*", "1# \"databases\" => \"16\"\n"},
+		{"CONFIG GET with no match", "CONFIG GET nothing*", MssgEmptyArray},
+		{"CONFIG GET without pattern", "CONFIG GET", ErrWrongNumberOfArgs.Error()},
+		{"CONFIG SET immutable param", "CONFIG SET databases 4", "can't set immutable config"},
+		{"CONFIG SET unknown param", "CONFIG SET nothing 4", ErrUnknownConfigParam.Error()},
+		{"CONFIG SET proto-max-bulk-len", "CONFIG SET proto-max-bulk-len 2mb", MssgOK},
+		{"CONFIG SET proto-max-bulk-len too small", "CONFIG SET proto-max-bulk-len 1kb", ErrConfigOutOfRange.Error()},
+		{"CONFIG SET proto-max-bulk-len invalid", "CONFIG SET proto-max-bulk-len lots", ErrInvalidMemory.Error()},
+		{"CONFIG GET proto-max-bulk-len", "CONFIG GET proto-max-bulk-len", "\"536870912\""},
+		{"CONFIG GET hz", "CONFIG GET hz", "1# \"hz\" => \"10\"\n"},
+		{"CONFIG SET hz", "CONFIG SET hz 100", MssgOK},
+		{"CONFIG SET hz invalid", "CONFIG SET hz fast", ErrNotInteger.Error()},
+		{"CONFIG GET dbfilename", "CONFIG GET dbfilename", "1# \"dbfilename\" => \"dump.goredis\"\n"},
+		{"CONFIG SET dbfilename to a path", "CONFIG SET dbfilename dir/dump.rdb", ErrDbFilenameIsPath.Error()},
+		{"CONFIG SET dir", "CONFIG SET dir /", ErrProtectedConfig.Error()},
+		{"CONFIG GET save", "CONFIG GET save", "1# \"save\" => \"3600 1 300 100 60 10000\"\n"},
+		{"CONFIG SET save without changes", "CONFIG SET save 900", ErrInvalidSaveParams.Error()},
+		{"CONFIG GET stop-writes-on-bgsave-error", "CONFIG GET stop-writes-on-bgsave-error", "\"yes\""},
+		{"CONFIG SET stop-writes-on-bgsave-error", "CONFIG SET stop-writes-on-bgsave-error maybe", ErrNotYesNo.Error()},
+		{"CONFIG GET appendonly", "CONFIG GET appendonly", "1# \"appendonly\" => \"no\"\n"},
+		{"CONFIG GET appendfsync", "CONFIG GET appendfsync", "1# \"appendfsync\" => \"everysec\"\n"},
+		{"CONFIG SET appendfsync", "CONFIG SET appendfsync always", MssgOK},
+		{"CONFIG SET appendfsync invalid", "CONFIG SET appendfsync sometimes", ErrInvalidAppendFsync.Error()},
+		{"CONFIG SET appendfilename to a path", "CONFIG SET appendfilename dir/appendonly.aof", ErrAppendFilenameIsPath.Error()},
+		{"CONFIG GET aof-load-truncated", "CONFIG GET aof-load-truncated", "\"yes\""},
+		{"CONFIG SET appenddirname to a path", "CONFIG SET appenddirname dir/appendonlydir", ErrAppendDirnameIsPath.Error()},
+		{"CONFIG GET auto-aof-rewrite-percentage", "CONFIG GET auto-aof-rewrite-percentage", "\"100\""},
+		{"CONFIG SET auto-aof-rewrite-percentage negative", "CONFIG SET auto-aof-rewrite-percentage -1", ErrConfigOutOfRange.Error()},
+		{"CONFIG GET auto-aof-rewrite-min-size", "CONFIG GET auto-aof-rewrite-min-size", "\"67108864\""},
+		{"CONFIG SET auto-aof-rewrite-min-size", "CONFIG SET auto-aof-rewrite-min-size 1mb", MssgOK},
+		{"CONFIG unknown subcommand", "CONFIG FOO", "unknown subcommand 'FOO'"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			s := GetTestServer(&mockDB{}, nil)
+
+			s.handleRequest(strings.Fields(tc.input), &buf, &ConnContext{})
+
+			if !bytes.Contains(buf.Bytes(), []byte(tc.expOut)) {
+				t.Errorf("Expected output to contain %q but got %q instead", tc.expOut, buf.String())
+			}
+		})
+	}
+}
+
+func TestParseMemory(t *testing.T) {
+	testCases := []struct {
+		input   string
+		expOut  int64
+		isError bool
+	}{
+		{"1024", 1024, false},
+		{"1k", 1000, false},
+		{"1kb", 1024, false},
+		{"2mb", 2 * 1024 * 1024, false},
+		{"1GB", 1024 * 1024 * 1024, false},
+		{"abc", 0, true},
+		{"-1mb", 0, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			out, err := parseMemory(tc.input)
+			if tc.isError {
+				if err == nil {
+					t.Fatalf("Expected error but got none")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Unexpected error occured: %v", err)
+			}
+			if out != tc.expOut {
+				t.Errorf("Expected %d but got %d", tc.expOut, out)
+			}
+		})
+	}
+}
+
+func TestConfigHzClamped(t *testing.T) {
+	testCases := []struct {
+		val   string
+		expHz int
+	}{
+		{"0", minHz},
+		{"1000", maxHz},
+		{"50", 50},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.val, func(t *testing.T) {
+			s := GetTestServer(&mockDB{}, nil)
+
+			if err := s.SetConfig("hz", tc.val); err != nil {
+				t.Fatalf("Unexpected error occured: %v", err)
+			}
+			if hz := s.config.getHz(); hz != tc.expHz {
+				t.Errorf("Expected hz %d but got %d", tc.expHz, hz)
+			}
+		})
+	}
+}
+
+func TestConfigDirAtStartup(t *testing.T) {
+	testCases := []struct {
+		name   string
+		dir    string
+		expErr string
+	}{
+		{name: "existing dir", dir: t.TempDir()},
+		{name: "missing dir", dir: "/nonexistent/dir", expErr: "no such file or directory"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := GetTestServer(&mockDB{}, nil)
+
+			// the startup config sets dir, which CONFIG SET refuses
+			err := s.SetConfig("dir", tc.dir)
+			if tc.expErr == "" && err != nil {
+				t.Fatalf("Unexpected error occured: %v", err)
+			}
+			if tc.expErr != "" && (err == nil || !strings.Contains(err.Error(), tc.expErr)) {
+				t.Errorf("Expected error %q but got %v", tc.expErr, err)
+			}
+		})
+	}
+}
+
+func TestConfigSetAtomic(t *testing.T) {
+	testCases := []struct {
+		name     string
+		inputArr []string
+		expOut   []string
+	}{
+		{
+			name:     "all the params set",
+			inputArr: []string{"CONFIG SET hz 100 appendfsync always", "CONFIG GET hz", "CONFIG GET appendfsync"},
+			expOut:   []string{MssgOK, "\"100\"", "\"always\""},
+		},
+		{
+			name:     "params set before a failing one restored",
+			inputArr: []string{"CONFIG SET hz 100 appendfsync always save 900", "CONFIG GET hz", "CONFIG GET appendfsync", "CONFIG GET save"},
+			expOut:   []string{ErrInvalidSaveParams.Error(), "\"10\"", "\"everysec\"", "\"3600 1 300 100 60 10000\""},
+		},
+		{
+			name:     "same param set twice before a failing one",
+			inputArr: []string{"CONFIG SET hz 100 hz 200 hz fast", "CONFIG GET hz"},
+			expOut:   []string{ErrNotInteger.Error(), "\"10\""},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := GetTestServer(&mockDB{}, nil)
+
+			for i, input := range tc.inputArr {
+				var buf bytes.Buffer
+				s.handleRequest(strings.Fields(input), &buf, &ConnContext{})
+
+				if !bytes.Contains(buf.Bytes(), []byte(tc.expOut[i])) {
+					t.Errorf("Expected output to contain %q but got %q instead", tc.expOut[i], buf.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package server
+
+import "errors"
+
+var ErrShuttingDown = errors.New("server is shutting down")
+
+// runs fn apart from the commands of other clients, exclusive runs it alone like EXEC
+// in the single threaded mode fn is handed to the executor, which always runs it alone
+// false is returned if the server shut down before fn could run
+func (s *Server) execute(exclusive bool, fn func()) bool {
+	if !s.SingleThreaded {
+		if exclusive {
+			s.execMu.Lock()
+			defer s.execMu.Unlock()
+		} else {
+			s.execMu.RLock()
+			defer s.execMu.RUnlock()
+		}
+		fn()
+		return true
+	}
+
+	s.init()
+	done := make(chan struct{})
+	select {
+	case s.executor <- func() { fn(); close(done) }:
+	case <-s.quit:
+		return false
+	}
+	<-done
+	return true
+}
+
+// applies the commands of every client in the order they arrive, until the server shuts down
+func (s *Server) runExecutor() {
+	defer s.jobs.Done()
+
+	for {
+		select {
+		case job := <-s.executor:
+			job()
+		case <-s.quit:
+			return
+		}
+	}
+}
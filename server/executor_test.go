@@ -0,0 +1,135 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/db"
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store/inMemoryStore"
+)
+
+func getSingleThreadedServer() *Server {
+	return &Server{Db: map[int]db.DbInterface{0: db.GetNewDB(inMemoryStore.NewInMemoryStore())}, SingleThreaded: true}
+}
+
+func TestSingleThreadedMode(t *testing.T) {
+	var buf bytes.Buffer
+	producer := &ConnContext{id: 100}
+
+	t.Run("commands of many clients", func(t *testing.T) {
+		s := getSingleThreadedServer()
+		defer s.Close()
+
+		var wg sync.WaitGroup
+		for i := range 8 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				var buf bytes.Buffer
+				cc := &ConnContext{}
+				for _, input := range []string{"MULTI", fmt.Sprintf("SET key%d %d", i, i), "INCR counter", "EXEC", "INCR counter"} {
+					s.handleCommand(input, &buf, cc)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if out, _ := s.getDb(0).Get("counter"); out != "16" {
+			t.Errorf("Expected the counter to be 16 but got %q", out)
+		}
+		if out, _ := s.getDb(0).Get("key7"); out != "7" {
+			t.Errorf("Expected key7 to be 7 but got %q", out)
+		}
+	})
+
+	t.Run("other clients wait for a tran halfway", func(t *testing.T) {
+		pdb := &pausingDB{DbInterface: db.GetNewDB(inMemoryStore.NewInMemoryStore()), paused: make(chan struct{}, 1), release: make(chan struct{})}
+		s := &Server{Db: map[int]db.DbInterface{0: pdb}, SingleThreaded: true}
+		defer s.Close()
+
+		tran := make(chan string, 1)
+		go func() {
+			var buf bytes.Buffer
+			cc := &ConnContext{}
+			for _, input := range []string{"MULTI", "SET state dirty", "INCR pause", "SET state clean", "EXEC"} {
+				buf.Reset()
+				s.handleCommand(input, &buf, cc)
+			}
+			tran <- buf.String()
+		}()
+		<-pdb.paused
+
+		reader := make(chan string, 1)
+		go func() {
+			var buf bytes.Buffer
+			s.handleCommand("GET state", &buf, &ConnContext{})
+			reader <- buf.String()
+		}()
+		expectBlocked(t, reader)
+
+		close(pdb.release)
+		expectReply(t, tran, "1) OK\n2) (integer) 1\n3) OK\n")
+		expectReply(t, reader, "\"clean\"\n")
+	})
+
+	t.Run("a blocked client doesn't hold up the executor", func(t *testing.T) {
+		s := getSingleThreadedServer()
+		defer s.Close()
+
+		out := blockClient(t, s, 1, "BLPOP q 0")
+		s.handleCommand("PING", &buf, producer)
+		expectBlocked(t, out)
+
+		s.handleCommand("RPUSH q a", &buf, producer)
+		expectReply(t, out, "1) \"q\"\n2) \"a\"\n")
+	})
+
+	t.Run("a blocked client times out", func(t *testing.T) {
+		s := getSingleThreadedServer()
+		defer s.Close()
+
+		out := blockClient(t, s, 1, "BLPOP q 0.01")
+		expectReply(t, out, MssgNil+"\n")
+	})
+
+	t.Run("commands fail once the server is closed", func(t *testing.T) {
+		s := getSingleThreadedServer()
+		s.Close()
+
+		buf.Reset()
+		s.handleCommand("PING", &buf, producer)
+		if exp := "(error) ERR server is shutting down\n"; buf.String() != exp {
+			t.Errorf("Expected %q but got %q", exp, buf.String())
+		}
+	})
+}
+
+// compares the goroutine per conn mode with the single executor over a mix of reads and writes
+func BenchmarkExecutionModes(b *testing.B) {
+	inputs := [][]string{{"SET", "key", "val"}, {"GET", "key"}, {"INCR", "counter"}, {"LPUSH", "list", "item"}, {"LPOP", "list"}}
+
+	for _, mode := range []struct {
+		name           string
+		singleThreaded bool
+	}{
+		{"goroutine per conn", false},
+		{"single executor", true},
+	} {
+		b.Run(mode.name, func(b *testing.B) {
+			s := &Server{Db: map[int]db.DbInterface{0: db.GetNewDB(inMemoryStore.NewInMemoryStore())}, SingleThreaded: mode.singleThreaded}
+			defer s.Close()
+
+			b.SetParallelism(8)
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				cc := &ConnContext{}
+				for i := 0; pb.Next(); i++ {
+					s.handleRequest(inputs[i%len(inputs)], io.Discard, cc)
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/db"
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/resp"
+)
+
+const (
+	EXPIRE      string = "EXPIRE"
+	PEXPIRE     string = "PEXPIRE"
+	EXPIREAT    string = "EXPIREAT"
+	PEXPIREAT   string = "PEXPIREAT"
+	TTL         string = "TTL"
+	PTTL        string = "PTTL"
+	EXPIRETIME  string = "EXPIRETIME"
+	PEXPIRETIME string = "PEXPIRETIME"
+	PERSIST     string = "PERSIST"
+)
+
+const activeExpireCyclePercent = 25 // share of every period of the active expiry which can be spent expiring keys
+
+var (
+	ErrInvalidExpireTime      = errors.New("invalid expire time")
+	ErrExpireNXIncompatible   = errors.New("NX and XX, GT or LT options at the same time are not compatible")
+	ErrExpireGTLTIncompatible = errors.New("GT and LT options at the same time are not compatible")
+)
+
+// EXPIRE key seconds [NX | XX | GT | LT]
+// PEXPIRE takes ms, EXPIREAT and PEXPIREAT take a unix time in seconds and ms
+func (s *Server) expireAction(cc *ConnContext, c Command) resp.Reply {
+	key := c.args[0]
+	when, err := strconv.ParseInt(c.args[1], 10, 64)
+	if err != nil {
+		return resp.NewError(db.ErrKeyNotInteger)
+	}
+
+	cond, err := parseExpireCond(c.args[2:])
+	if err != nil {
+		return resp.NewError(err)
+	}
+
+	// seconds are turned into ms, relative times into unix times
+	errInvalid := fmt.Errorf("%v in '%s' command", ErrInvalidExpireTime, strings.ToLower(c.name))
+	if c.name == EXPIRE || c.name == EXPIREAT {
+		if when > math.MaxInt64/1000 || when < math.MinInt64/1000 {
+			return resp.NewError(errInvalid)
+		}
+		when *= 1000
+	}
+	if c.name == EXPIRE || c.name == PEXPIRE {
+		now := time.Now().UnixMilli()
+		if when > math.MaxInt64-now {
+			return resp.NewError(errInvalid)
+		}
+		when += now
+	}
+
+	if s.getDb(cc.dbIdx).Expire(key, when, cond) {
+		return resp.Integer(1)
+	}
+	return resp.Integer(0)
+}
+
+func parseExpireCond(args []string) (db.ExpireCond, error) {
+	var cond db.ExpireCond
+	for _, a := range args {
+		switch strings.ToUpper(a) {
+		case "NX":
+			cond |= db.ExpireNX
+		case "XX":
+			cond |= db.ExpireXX
+		case "GT":
+			cond |= db.ExpireGT
+		case "LT":
+			cond |= db.ExpireLT
+		default:
+			return db.ExpireAlways, fmt.Errorf("Unsupported option %s", a)
+		}
+	}
+
+	switch {
+	case cond&db.ExpireNX != 0 && cond != db.ExpireNX:
+		return db.ExpireAlways, ErrExpireNXIncompatible
+	case cond&db.ExpireGT != 0 && cond&db.ExpireLT != 0:
+		return db.ExpireAlways, ErrExpireGTLTIncompatible
+	}
+	return cond, nil
+}
+
+// TTL key, PTTL key
+// replies with the remaining time to live, -2 if the key doesn't exist and -1 if it has no timeout
+func (s *Server) ttlAction(cc *ConnContext, c Command) resp.Reply {
+	ttl := s.getDb(cc.dbIdx).TTL(c.args[0])
+	if ttl < 0 || c.name == PTTL {
+		return resp.Integer(ttl)
+	}
+	// rounded to the closest second, like redis does
+	return resp.Integer((ttl + 500) / 1000)
+}
+
+// EXPIRETIME key, PEXPIRETIME key
+// replies with the unix time at which the key expires, -2 if the key doesn't exist and -1 if it has no timeout
+func (s *Server) expireTimeAction(cc *ConnContext, c Command) resp.Reply {
+	at := s.getDb(cc.dbIdx).ExpireTime(c.args[0])
+	if at < 0 || c.name == PEXPIRETIME {
+		return resp.Integer(at)
+	}
+	return resp.Integer(at / 1000)
+}
+
+// PERSIST key
+func (s *Server) persistAction(cc *ConnContext, key string) resp.Reply {
+	if s.getDb(cc.dbIdx).Persist(key) {
+		return resp.Integer(1)
+	}
+	return resp.Integer(0)
+}
+
+// runs the active expiry cycle of the db hz times a second, until the server shuts down
+func (s *Server) startActiveExpiry(d db.DbInterface) {
+	s.startJob(func() {
+		timer := time.NewTimer(s.config.getHzPeriod())
+		defer timer.Stop()
+		for {
+			select {
+			case <-s.quit:
+				return
+			case <-timer.C:
+			}
+
+			// keys don't expire in the middle of an EXEC
+			period := s.config.getHzPeriod()
+			s.execute(false, func() {
+				d.ActiveExpireCycle(period * activeExpireCyclePercent / 100)
+			})
+			timer.Reset(period)
+		}
+	})
+}
@@ -0,0 +1,172 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/db"
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store/inMemoryStore"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// server backed by a real db, for commands whose behaviour depends on the stored data
+func GetTestServerWithDB() *Server {
+	return &Server{Db: map[int]db.DbInterface{0: db.GetNewDB(inMemoryStore.NewInMemoryStore())}}
+}
+
+func TestExpireCommands(t *testing.T) {
+	inFuture := time.Now().Add(time.Hour).Unix()
+
+	testCases := []struct {
+		name     string
+		inputArr []string
+		expOut   []string
+	}{
+		{
+			name:     "EXPIRE and TTL",
+			inputArr: []string{"SET foo bar", "EXPIRE foo 100", "TTL foo"},
+			expOut:   []string{MssgOK, "(integer) 1", "(integer) 100"},
+		},
+		{
+			name:     "PEXPIRE and PTTL",
+			inputArr: []string{"SET foo bar", "PEXPIRE foo 100000", "TTL foo"},
+			expOut:   []string{MssgOK, "(integer) 1", "(integer) 100"},
+		},
+		{
+			name:     "EXPIREAT and EXPIRETIME",
+			inputArr: []string{"SET foo bar", fmt.Sprintf("EXPIREAT foo %d", inFuture), "EXPIRETIME foo", "PEXPIRETIME foo"},
+			expOut:   []string{MssgOK, "(integer) 1", fmt.Sprintf("(integer) %d", inFuture), fmt.Sprintf("(integer) %d000", inFuture)},
+		},
+		{
+			name:     "PEXPIREAT in the past deletes the key",
+			inputArr: []string{"SET foo bar", "PEXPIREAT foo 1000", "GET foo", "TTL foo"},
+			expOut:   []string{MssgOK, "(integer) 1", MssgNil, "(integer) -2"},
+		},
+		{
+			name:     "EXPIRE on missing key",
+			inputArr: []string{"EXPIRE foo 100", "TTL foo"},
+			expOut:   []string{"(integer) 0", "(integer) -2"},
+		},
+		{
+			name:     "TTL on key without timeout",
+			inputArr: []string{"SET foo bar", "TTL foo", "PTTL foo", "EXPIRETIME foo", "PTTL bar"},
+			expOut:   []string{MssgOK, "(integer) -1", "(integer) -1", "(integer) -1", "(integer) -2"},
+		},
+		{
+			name:     "EXPIRE with NX and XX",
+			inputArr: []string{"SET foo bar", "EXPIRE foo 100 XX", "EXPIRE foo 100 NX", "EXPIRE foo 200 NX", "EXPIRE foo 200 XX", "TTL foo"},
+			expOut:   []string{MssgOK, "(integer) 0", "(integer) 1", "(integer) 0", "(integer) 1", "(integer) 200"},
+		},
+		{
+			name:     "EXPIRE with GT and LT",
+			inputArr: []string{"SET foo bar", "EXPIRE foo 100 GT", "EXPIRE foo 100 LT", "EXPIRE foo 50 GT", "EXPIRE foo 200 gt", "EXPIRE foo 300 XX LT", "TTL foo"},
+			expOut:   []string{MssgOK, "(integer) 0", "(integer) 1", "(integer) 0", "(integer) 1", "(integer) 0", "(integer) 200"},
+		},
+		{
+			name:     "EXPIRE with incompatible options",
+			inputArr: []string{"SET foo bar", "EXPIRE foo 100 NX XX", "EXPIRE foo 100 GT LT", "EXPIRE foo 100 YY"},
+			expOut:   []string{MssgOK, ErrExpireNXIncompatible.Error(), ErrExpireGTLTIncompatible.Error(), "Unsupported option YY"},
+		},
+		{
+			name:     "EXPIRE with invalid time",
+			inputArr: []string{"SET foo bar", "EXPIRE foo ten", "EXPIRE foo 9223372036854775807"},
+			expOut:   []string{MssgOK, db.ErrKeyNotInteger.Error(), "invalid expire time in 'expire' command"},
+		},
+		{
+			name:     "PERSIST",
+			inputArr: []string{"SET foo bar", "PERSIST foo", "EXPIRE foo 100", "PERSIST foo", "TTL foo"},
+			expOut:   []string{MssgOK, "(integer) 0", "(integer) 1", "(integer) 1", "(integer) -1"},
+		},
+		{
+			name:     "SET clears the timeout",
+			inputArr: []string{"SET foo bar", "EXPIRE foo 100", "SET foo baz", "TTL foo"},
+			expOut:   []string{MssgOK, "(integer) 1", MssgOK, "(integer) -1"},
+		},
+		{
+			name:     "SET with KEEPTTL retains the timeout",
+			inputArr: []string{"SET foo bar", "EXPIRE foo 100", "SET foo baz KEEPTTL", "TTL foo", "GET foo"},
+			expOut:   []string{MssgOK, "(integer) 1", MssgOK, "(integer) 100", "\"baz\""},
+		},
+		{
+			name:     "INCR retains the timeout",
+			inputArr: []string{"SET foo 1", "EXPIRE foo 100", "INCR foo", "TTL foo"},
+			expOut:   []string{MssgOK, "(integer) 1", "(integer) 2", "(integer) 100"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			s := GetTestServerWithDB()
+			cc := &ConnContext{}
+
+			for i, input := range tc.inputArr {
+				s.handleCommand(input, &buf, cc)
+
+				if !bytes.Contains(buf.Bytes(), []byte(tc.expOut[i])) {
+					t.Errorf("Expected output of %q to contain %q but got %q instead", input, tc.expOut[i], buf.String())
+				}
+				buf.Reset()
+			}
+		})
+	}
+
+	t.Run("key is gone once its timeout passes", func(t *testing.T) {
+		var buf bytes.Buffer
+		s := GetTestServerWithDB()
+		cc := &ConnContext{}
+
+		s.handleCommand("SET foo bar", &buf, cc)
+		s.handleCommand("PEXPIRE foo 20", &buf, cc)
+		time.Sleep(40 * time.Millisecond)
+		buf.Reset()
+
+		s.handleCommand("GET foo", &buf, cc)
+		if buf.String() != MssgNil+"\n" {
+			t.Errorf("Expected %q but got %q", MssgNil, buf.String())
+		}
+	})
+}
+
+func TestActiveExpiry(t *testing.T) {
+	store := inMemoryStore.NewInMemoryStore()
+	ln := bufconn.Listen(1024)
+	s := &Server{Db: map[int]db.DbInterface{0: db.GetNewDB(store)}, Listener: ln}
+	if err := s.SetConfig("hz", "100"); err != nil {
+		t.Fatalf("Unexpected error occured: %v", err)
+	}
+
+	var buf bytes.Buffer
+	cc := &ConnContext{}
+	for i := range 100 {
+		s.handleCommand(fmt.Sprintf("SET key%d val", i), &buf, cc)
+		s.handleCommand(fmt.Sprintf("PEXPIRE key%d 10", i), &buf, cc)
+	}
+	s.handleCommand("SET persistent val", &buf, cc)
+
+	done := make(chan struct{})
+	go func() {
+		s.Start()
+		close(done)
+	}()
+
+	// expired keys are removed without being accessed
+	deadline := time.Now().Add(2 * time.Second)
+	for len(store.GetAll()) > 1 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := len(store.GetAll()); n != 1 {
+		t.Errorf("Expected only the persistent key to be left but got %d keys", n)
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatalf("Unexpected error occured: %v", err)
+	}
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Errorf("Expected the server to stop after close")
+	}
+}
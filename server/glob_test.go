@@ -0,0 +1,40 @@
+package server
+
+import "testing"
+
+func TestMatchGlob(t *testing.T) {
+	testCases := []struct {
+		pattern string
+		str     string
+		expOut  bool
+	}{
+		{"*", "anything", true},
+		{"*", "", true},
+		{"foo", "foo", true},
+		{"foo", "foobar", false},
+		{"foo*", "foobar", true},
+		{"*bar", "foobar", true},
+		{"f*o*r", "foobar", true},
+		{"f*o*z", "foobar", false},
+		{"h?llo", "hello", true},
+		{"h?llo", "hllo", false},
+		{"h[ae]llo", "hallo", true},
+		{"h[ae]llo", "hillo", false},
+		{"h[^e]llo", "hallo", true},
+		{"h[^e]llo", "hello", false},
+		{"h[a-c]llo", "hbllo", true},
+		{"h[a-c]llo", "hdllo", false},
+		{"h\\*llo", "h*llo", true},
+		{"h\\*llo", "hello", false},
+		{"__keyspace@*__:*", "__keyspace@0__:foo", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.pattern+" "+tc.str, func(t *testing.T) {
+			out := matchGlob(tc.pattern, tc.str)
+			if out != tc.expOut {
+				t.Errorf("Expected %v for pattern %q and string %q but got %v", tc.expOut, tc.pattern, tc.str, out)
+			}
+		})
+	}
+}
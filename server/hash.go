@@ -0,0 +1,303 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/db"
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/resp"
+)
+
+const (
+	HSET         string = "HSET"
+	HSETNX       string = "HSETNX"
+	HGET         string = "HGET"
+	HMGET        string = "HMGET"
+	HDEL         string = "HDEL"
+	HLEN         string = "HLEN"
+	HEXISTS      string = "HEXISTS"
+	HGETALL      string = "HGETALL"
+	HKEYS        string = "HKEYS"
+	HVALS        string = "HVALS"
+	HINCRBY      string = "HINCRBY"
+	HINCRBYFLOAT string = "HINCRBYFLOAT"
+	HRANDFIELD   string = "HRANDFIELD"
+	HSCAN        string = "HSCAN"
+
+	defaultScanCount int = 10 // fields returned by a SCAN like command without COUNT
+)
+
+var (
+	ErrNotFloat      = errors.New("value is not a valid float")
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// HSET key field value [field value ...]
+func (s *Server) hsetAction(cc *ConnContext, c Command) resp.Reply {
+	if len(c.args)%2 == 0 {
+		return resp.NewError(fmt.Errorf("%v for '%s' command", ErrWrongNumberOfArgs, strings.ToLower(c.name)))
+	}
+
+	n, err := s.getDb(cc.dbIdx).HSet(c.args[0], c.args[1:]...)
+	if err != nil {
+		return resp.NewError(err)
+	}
+	return resp.Integer(n)
+}
+
+// HSETNX key field value
+func (s *Server) hsetnxAction(cc *ConnContext, args []string) resp.Reply {
+	ok, err := s.getDb(cc.dbIdx).HSetNX(args[0], args[1], args[2])
+	if err != nil {
+		return resp.NewError(err)
+	}
+	return boolReply(ok)
+}
+
+// HGET key field
+func (s *Server) hgetAction(cc *ConnContext, args []string) resp.Reply {
+	val, ok, err := s.getDb(cc.dbIdx).HGet(args[0], args[1])
+	switch {
+	case err != nil:
+		return resp.NewError(err)
+	case !ok:
+		return resp.Nil
+	default:
+		return resp.BulkString(val)
+	}
+}
+
+// HMGET key field [field ...]
+func (s *Server) hmgetAction(cc *ConnContext, args []string) resp.Reply {
+	vals, found, err := s.getDb(cc.dbIdx).HMGet(args[0], args[1:]...)
+	if err != nil {
+		return resp.NewError(err)
+	}
+
+	replies := resp.Array{}
+	for i, v := range vals {
+		if found[i] {
+			replies = append(replies, resp.BulkString(v))
+		} else {
+			replies = append(replies, resp.Nil)
+		}
+	}
+	return replies
+}
+
+// HDEL key field [field ...]
+func (s *Server) hdelAction(cc *ConnContext, args []string) resp.Reply {
+	n, err := s.getDb(cc.dbIdx).HDel(args[0], args[1:]...)
+	if err != nil {
+		return resp.NewError(err)
+	}
+	return resp.Integer(n)
+}
+
+// HLEN key
+func (s *Server) hlenAction(cc *ConnContext, key string) resp.Reply {
+	n, err := s.getDb(cc.dbIdx).HLen(key)
+	if err != nil {
+		return resp.NewError(err)
+	}
+	return resp.Integer(n)
+}
+
+// HEXISTS key field
+func (s *Server) hexistsAction(cc *ConnContext, args []string) resp.Reply {
+	ok, err := s.getDb(cc.dbIdx).HExists(args[0], args[1])
+	if err != nil {
+		return resp.NewError(err)
+	}
+	return boolReply(ok)
+}
+
+// HGETALL key, HKEYS key and HVALS key
+// fields are sorted so the replies are stable
+func (s *Server) hgetallAction(cc *ConnContext, c Command) resp.Reply {
+	h, err := s.getDb(cc.dbIdx).HGetAll(c.args[0])
+	if err != nil {
+		return resp.NewError(err)
+	}
+
+	fields := sortedKeys(h)
+	switch c.name {
+	case HKEYS:
+		return resp.BulkStrings(fields)
+	case HVALS:
+		vals := resp.Array{}
+		for _, f := range fields {
+			vals = append(vals, resp.BulkString(h[f]))
+		}
+		return vals
+	default:
+		m := resp.Map{}
+		for _, f := range fields {
+			m = append(m, resp.MapItem{Key: resp.BulkString(f), Value: resp.BulkString(h[f])})
+		}
+		return m
+	}
+}
+
+// HINCRBY key field increment
+func (s *Server) hincrbyAction(cc *ConnContext, args []string) resp.Reply {
+	incr, err := strconv.ParseInt(args[2], 10, 64)
+	if err != nil {
+		return resp.NewError(db.ErrKeyNotInteger)
+	}
+
+	n, err := s.getDb(cc.dbIdx).HIncrBy(args[0], args[1], incr)
+	if err != nil {
+		return resp.NewError(err)
+	}
+	return resp.Integer(n)
+}
+
+// HINCRBYFLOAT key field increment
+func (s *Server) hincrbyfloatAction(cc *ConnContext, args []string) resp.Reply {
+	incr, err := parseFloat(args[2])
+	if err != nil || math.IsInf(incr, 0) {
+		return resp.NewError(ErrNotFloat)
+	}
+
+	val, err := s.getDb(cc.dbIdx).HIncrByFloat(args[0], args[1], incr)
+	if err != nil {
+		return resp.NewError(err)
+	}
+	return resp.BulkString(val)
+}
+
+// HRANDFIELD key [count [WITHVALUES]]
+// replies with a single field without count, and an array of fields with it
+func (s *Server) hrandfieldAction(cc *ConnContext, args []string) resp.Reply {
+	if len(args) > 3 || (len(args) == 3 && strings.ToUpper(args[2]) != "WITHVALUES") {
+		return resp.NewError(ErrSyntax)
+	}
+
+	count, hasCount := 1, len(args) > 1
+	if hasCount {
+		n, err := strconv.Atoi(args[1])
+		if err != nil {
+			return resp.NewError(db.ErrKeyNotInteger)
+		}
+		count = n
+	}
+
+	fields, vals, err := s.getDb(cc.dbIdx).HRandField(args[0], count)
+	switch {
+	case err != nil:
+		return resp.NewError(err)
+	case !hasCount && len(fields) == 0:
+		return resp.Nil
+	case !hasCount:
+		return resp.BulkString(fields[0])
+	case len(args) < 3:
+		return resp.BulkStrings(fields)
+	}
+
+	// RESP3 clients get field value pairs, RESP2 ones a flat array
+	replies := resp.Array{}
+	for i, f := range fields {
+		if cc.protocol == resp.RESP3 {
+			replies = append(replies, resp.BulkStrings([]string{f, vals[i]}))
+		} else {
+			replies = append(replies, resp.BulkString(f), resp.BulkString(vals[i]))
+		}
+	}
+	return replies
+}
+
+// HSCAN key cursor [MATCH pattern] [COUNT count] [NOVALUES]
+// replies with the next cursor and the fields with their values
+func (s *Server) hscanAction(cc *ConnContext, args []string) resp.Reply {
+	cursor, opts, err := parseScanArgs(args[1:], true)
+	if err != nil {
+		return resp.NewError(err)
+	}
+
+	next, fields, vals, err := s.getDb(cc.dbIdx).HScan(args[0], cursor, opts.count)
+	if err != nil {
+		return resp.NewError(err)
+	}
+
+	items := resp.Array{}
+	for i, f := range fields {
+		if opts.match != "" && !matchGlob(opts.match, f) {
+			continue
+		}
+		items = append(items, resp.BulkString(f))
+		if !opts.noValues {
+			items = append(items, resp.BulkString(vals[i]))
+		}
+	}
+	return resp.Array{resp.BulkString(strconv.FormatUint(next, 10)), items}
+}
+
+// options shared by the SCAN like commands
+type scanOptions struct {
+	match    string // glob the returned items must match, empty for all
+	count    int
+	noValues bool // only for HSCAN
+}
+
+// parses the cursor and the options of a SCAN like command
+func parseScanArgs(args []string, allowNoValues bool) (uint64, scanOptions, error) {
+	opts := scanOptions{count: defaultScanCount}
+
+	cursor, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		return 0, opts, ErrInvalidCursor
+	}
+
+	for i := 1; i < len(args); i++ {
+		switch opt := strings.ToUpper(args[i]); {
+		case opt == "MATCH" && i+1 < len(args):
+			opts.match = args[i+1]
+			i++
+		case opt == "COUNT" && i+1 < len(args):
+			n, err := strconv.Atoi(args[i+1])
+			if err != nil {
+				return 0, opts, db.ErrKeyNotInteger
+			}
+			if n < 1 {
+				return 0, opts, ErrSyntax
+			}
+			opts.count = n
+			i++
+		case opt == "NOVALUES" && allowNoValues:
+			opts.noValues = true
+		default:
+			return 0, opts, ErrSyntax
+		}
+	}
+	return cursor, opts, nil
+}
+
+// parses a float arg the way redis does, spaces and NaN aren't allowed
+func parseFloat(arg string) (float64, error) {
+	f, err := strconv.ParseFloat(arg, 64)
+	if err != nil || math.IsNaN(f) || strings.TrimSpace(arg) != arg {
+		return 0, ErrNotFloat
+	}
+	return f, nil
+}
+
+func boolReply(b bool) resp.Reply {
+	if b {
+		return resp.Integer(1)
+	}
+	return resp.Integer(0)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,112 @@
+package server
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/db"
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/resp"
+)
+
+func TestHashCommands(t *testing.T) {
+	wrongType := db.ErrWrongType.Error()
+
+	testCases := []struct {
+		name     string
+		inputArr []string
+		expOut   []string
+	}{
+		{
+			name:     "HSET and HGET",
+			inputArr: []string{"HSET user name ann age 30", "HSET user name bob", "HGET user name", "HGET user city", "HGET missing name", "HSET user name"},
+			expOut:   []string{"(integer) 2", "(integer) 0", "\"bob\"", MssgNil, MssgNil, "wrong number of arguments for 'hset' command"},
+		},
+		{
+			name:     "HSETNX",
+			inputArr: []string{"HSETNX user name ann", "HSETNX user name bob", "HGET user name"},
+			expOut:   []string{"(integer) 1", "(integer) 0", "\"ann\""},
+		},
+		{
+			name:     "HMGET",
+			inputArr: []string{"HSET user name ann", "HMGET user name city", "HMGET missing name"},
+			expOut:   []string{"(integer) 1", "1) \"ann\"\n2) (nil)\n", "1) (nil)\n"},
+		},
+		{
+			name:     "HGETALL, HKEYS and HVALS",
+			inputArr: []string{"HSET user name ann age 30", "HGETALL user", "HKEYS user", "HVALS user", "HGETALL missing"},
+			expOut:   []string{"(integer) 2", "1# \"age\" => \"30\"\n2# \"name\" => \"ann\"\n", "1) \"age\"\n2) \"name\"\n", "1) \"30\"\n2) \"ann\"\n", MssgEmptyArray},
+		},
+		{
+			name:     "HDEL, HLEN and HEXISTS",
+			inputArr: []string{"HSET user name ann age 30", "HDEL user age city", "HLEN user", "HEXISTS user age", "HEXISTS user name", "HDEL user name", "TYPE user", "HLEN user"},
+			expOut:   []string{"(integer) 2", "(integer) 1", "(integer) 1", "(integer) 0", "(integer) 1", "(integer) 1", "none", "(integer) 0"},
+		},
+		{
+			name:     "HINCRBY",
+			inputArr: []string{"HINCRBY user visits 5", "HINCRBY user visits -2", "HINCRBY user visits one", "HSET user name ann", "HINCRBY user name 1", "HSET user big 9223372036854775807", "HINCRBY user big 1"},
+			expOut:   []string{"(integer) 5", "(integer) 3", db.ErrKeyNotInteger.Error(), "(integer) 1", db.ErrHashValueNotInteger.Error(), "(integer) 1", db.ErrIncrOverflow.Error()},
+		},
+		{
+			name:     "HINCRBYFLOAT",
+			inputArr: []string{"HSET user score 10.50", "HINCRBYFLOAT user score 0.1", "HINCRBYFLOAT user score -5", "HINCRBYFLOAT user score abc", "HINCRBYFLOAT user score inf", "HSET user name ann", "HINCRBYFLOAT user name 1"},
+			expOut:   []string{"(integer) 1", "\"10.6\"", "\"5.6\"", ErrNotFloat.Error(), ErrNotFloat.Error(), "(integer) 1", db.ErrHashValueNotFloat.Error()},
+		},
+		{
+			name:     "HRANDFIELD",
+			inputArr: []string{"HSET user name ann", "HRANDFIELD user", "HRANDFIELD user 2", "HRANDFIELD user -2", "HRANDFIELD user 1 WITHVALUES", "HRANDFIELD missing", "HRANDFIELD missing 1", "HRANDFIELD user 1 WITH"},
+			expOut:   []string{"(integer) 1", "\"name\"", "1) \"name\"\n", "1) \"name\"\n2) \"name\"\n", "1) \"name\"\n2) \"ann\"\n", MssgNil, MssgEmptyArray, ErrSyntax.Error()},
+		},
+		{
+			name:     "HSCAN",
+			inputArr: []string{"HSET user name ann", "HSCAN user 0", "HSCAN user 0 NOVALUES", "HSCAN user 0 MATCH n* COUNT 5", "HSCAN user 0 MATCH x*", "HSCAN missing 0", "HSCAN user abc", "HSCAN user 0 COUNT 0"},
+			expOut:   []string{"(integer) 1", "1) \"0\"\n2) 1) \"name\"\n   2) \"ann\"\n", "1) \"0\"\n2) 1) \"name\"\n", "1) \"0\"\n2) 1) \"name\"\n", "1) \"0\"\n2) (empty array)\n", "1) \"0\"\n2) (empty array)\n", ErrInvalidCursor.Error(), ErrSyntax.Error()},
+		},
+		{
+			name:     "WRONGTYPE",
+			inputArr: []string{"SET str bar", "HSET user name ann", "HSET str name ann", "HGET str name", "HGETALL str", "GET user", "LPUSH user a"},
+			expOut:   []string{MssgOK, "(integer) 1", wrongType, wrongType, wrongType, wrongType, wrongType},
+		},
+		{
+			name:     "COMPACT with a hash",
+			inputArr: []string{"HSET user name ann age 30", "COMPACT"},
+			expOut:   []string{"(integer) 2", "HSET user age 30 name ann"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			s := GetTestServerWithDB()
+			cc := &ConnContext{}
+
+			for i, input := range tc.inputArr {
+				s.handleCommand(input, &buf, cc)
+
+				if !bytes.Contains(buf.Bytes(), []byte(tc.expOut[i])) {
+					t.Errorf("Expected output of %q to contain %q but got %q instead", input, tc.expOut[i], buf.String())
+				}
+				buf.Reset()
+			}
+		})
+	}
+
+	t.Run("RESP3 replies", func(t *testing.T) {
+		var buf bytes.Buffer
+		s := GetTestServerWithDB()
+		cc := &ConnContext{protocol: resp.RESP3}
+
+		s.handleCommand("HSET user name ann", &buf, cc)
+		buf.Reset()
+
+		s.handleCommand("HGETALL user", &buf, cc)
+		if exp := "%1\r\n$4\r\nname\r\n$3\r\nann\r\n"; buf.String() != exp {
+			t.Errorf("Expected %q but got %q", exp, buf.String())
+		}
+		buf.Reset()
+
+		s.handleCommand("HRANDFIELD user 1 WITHVALUES", &buf, cc)
+		if exp := "*1\r\n*2\r\n$4\r\nname\r\n$3\r\nann\r\n"; buf.String() != exp {
+			t.Errorf("Expected %q but got %q", exp, buf.String())
+		}
+	})
+}
@@ -0,0 +1,49 @@
+package server
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/resp"
+)
+
+const INFO string = "INFO"
+
+// a section of INFO, with the func giving its field:value lines
+type infoSection struct {
+	name  string
+	title string
+	lines func(s *Server) []string
+}
+
+// sections in the order they're reported
+var infoSections = []infoSection{
+	{"persistence", "Persistence", (*Server).persistenceInfo},
+}
+
+// INFO [section [section ...]]
+// all, everything and default report every section, unknown sections are left out
+func (s *Server) infoAction(args []string) resp.Reply {
+	names := []string{}
+	for _, a := range args {
+		names = append(names, strings.ToLower(a))
+	}
+	all := len(names) == 0 || slices.ContainsFunc(names, func(n string) bool {
+		return n == "all" || n == "everything" || n == "default"
+	})
+
+	var b strings.Builder
+	for _, sec := range infoSections {
+		if !all && !slices.Contains(names, sec.name) {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString("\r\n")
+		}
+		b.WriteString("# " + sec.title + "\r\n")
+		for _, line := range sec.lines(s) {
+			b.WriteString(line + "\r\n")
+		}
+	}
+	return resp.Verbatim(b.String())
+}
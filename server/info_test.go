@@ -0,0 +1,36 @@
+package server
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestInfoCommand(t *testing.T) {
+	testCases := []struct {
+		name   string
+		input  string
+		expOut string
+	}{
+		{"every section", "INFO", "# Persistence\r\nloading:0\r\n"},
+		{"all", "INFO all", "# Persistence\r\n"},
+		{"section", "INFO PERSISTENCE", "# Persistence\r\n"},
+		{"unknown section", "INFO nothing", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			s := GetTestServerWithDB()
+
+			s.handleCommand(tc.input, &buf, &ConnContext{})
+
+			if !strings.HasPrefix(buf.String(), tc.expOut) {
+				t.Errorf("Expected output to start with %q but got %q instead", tc.expOut, buf.String())
+			}
+			if tc.expOut == "" && buf.String() != "\n" {
+				t.Errorf("Expected an empty reply but got %q", buf.String())
+			}
+		})
+	}
+}
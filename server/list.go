@@ -0,0 +1,285 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/db"
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/resp"
+)
+
+const (
+	LPUSH   string = "LPUSH"
+	RPUSH   string = "RPUSH"
+	LPOP    string = "LPOP"
+	RPOP    string = "RPOP"
+	LRANGE  string = "LRANGE"
+	LLEN    string = "LLEN"
+	LINDEX  string = "LINDEX"
+	LSET    string = "LSET"
+	LREM    string = "LREM"
+	LTRIM   string = "LTRIM"
+	LINSERT string = "LINSERT"
+	LPOS    string = "LPOS"
+	LMOVE   string = "LMOVE"
+)
+
+var (
+	ErrValueOutOfRange = errors.New("value is out of range, must be positive")
+	ErrLPosRankZero    = errors.New("RANK can't be zero: use 1 to start from the first match, 2 from the second ... or use negative to start from the end of the list")
+	ErrLPosNegCount    = errors.New("COUNT can't be negative")
+	ErrLPosNegMaxLen   = errors.New("MAXLEN can't be negative")
+)
+
+// LPUSH key element [element ...]
+// RPUSH pushes at the tail instead
+func (s *Server) pushAction(cc *ConnContext, c Command) resp.Reply {
+	end := db.ListHead
+	if c.name == RPUSH {
+		end = db.ListTail
+	}
+
+	n, err := s.getDb(cc.dbIdx).Push(c.args[0], end, c.args[1:]...)
+	if err != nil {
+		return resp.NewError(err)
+	}
+	return resp.Integer(n)
+}
+
+// LPOP key [count]
+// RPOP pops from the tail instead
+// replies with a single item without count, and an array of items with it
+func (s *Server) popAction(cc *ConnContext, c Command) resp.Reply {
+	if len(c.args) > 2 {
+		return resp.NewError(fmt.Errorf("%v for '%s' command", ErrWrongNumberOfArgs, strings.ToLower(c.name)))
+	}
+
+	end := db.ListHead
+	if c.name == RPOP {
+		end = db.ListTail
+	}
+
+	count, hasCount := 1, len(c.args) == 2
+	if hasCount {
+		n, err := strconv.Atoi(c.args[1])
+		if err != nil || n < 0 {
+			return resp.NewError(ErrValueOutOfRange)
+		}
+		count = n
+	}
+
+	out, err := s.getDb(cc.dbIdx).Pop(c.args[0], end, count)
+	switch {
+	case err != nil:
+		return resp.NewError(err)
+	case hasCount && out == nil:
+		return resp.NilArray
+	case hasCount:
+		return resp.BulkStrings(out)
+	case len(out) == 0:
+		return resp.Nil
+	default:
+		return resp.BulkString(out[0])
+	}
+}
+
+// LRANGE key start stop
+func (s *Server) lrangeAction(cc *ConnContext, args []string) resp.Reply {
+	ints, err := parseInts(args[1:])
+	if err != nil {
+		return resp.NewError(err)
+	}
+
+	out, err := s.getDb(cc.dbIdx).LRange(args[0], ints[0], ints[1])
+	if err != nil {
+		return resp.NewError(err)
+	}
+	return resp.BulkStrings(out)
+}
+
+// LLEN key
+func (s *Server) llenAction(cc *ConnContext, key string) resp.Reply {
+	n, err := s.getDb(cc.dbIdx).LLen(key)
+	if err != nil {
+		return resp.NewError(err)
+	}
+	return resp.Integer(n)
+}
+
+// LINDEX key index
+func (s *Server) lindexAction(cc *ConnContext, args []string) resp.Reply {
+	ints, err := parseInts(args[1:])
+	if err != nil {
+		return resp.NewError(err)
+	}
+
+	val, ok, err := s.getDb(cc.dbIdx).LIndex(args[0], ints[0])
+	switch {
+	case err != nil:
+		return resp.NewError(err)
+	case !ok:
+		return resp.Nil
+	default:
+		return resp.BulkString(val)
+	}
+}
+
+// LSET key index element
+func (s *Server) lsetAction(cc *ConnContext, args []string) resp.Reply {
+	ints, err := parseInts(args[1:2])
+	if err != nil {
+		return resp.NewError(err)
+	}
+
+	if err := s.getDb(cc.dbIdx).LSet(args[0], ints[0], args[2]); err != nil {
+		return resp.NewError(err)
+	}
+	return resp.SimpleString(MssgOK)
+}
+
+// LREM key count element
+func (s *Server) lremAction(cc *ConnContext, args []string) resp.Reply {
+	ints, err := parseInts(args[1:2])
+	if err != nil {
+		return resp.NewError(err)
+	}
+
+	n, err := s.getDb(cc.dbIdx).LRem(args[0], ints[0], args[2])
+	if err != nil {
+		return resp.NewError(err)
+	}
+	return resp.Integer(n)
+}
+
+// LTRIM key start stop
+func (s *Server) ltrimAction(cc *ConnContext, args []string) resp.Reply {
+	ints, err := parseInts(args[1:])
+	if err != nil {
+		return resp.NewError(err)
+	}
+
+	if err := s.getDb(cc.dbIdx).LTrim(args[0], ints[0], ints[1]); err != nil {
+		return resp.NewError(err)
+	}
+	return resp.SimpleString(MssgOK)
+}
+
+// LINSERT key BEFORE | AFTER pivot element
+func (s *Server) linsertAction(cc *ConnContext, args []string) resp.Reply {
+	var pos db.ListInsertPos
+	switch strings.ToUpper(args[1]) {
+	case "BEFORE":
+		pos = db.ListBefore
+	case "AFTER":
+		pos = db.ListAfter
+	default:
+		return resp.NewError(ErrSyntax)
+	}
+
+	n, err := s.getDb(cc.dbIdx).LInsert(args[0], pos, args[2], args[3])
+	if err != nil {
+		return resp.NewError(err)
+	}
+	return resp.Integer(n)
+}
+
+// LPOS key element [RANK rank] [COUNT num-matches] [MAXLEN len]
+// replies with the first index without COUNT, and an array of indexes with it
+func (s *Server) lposAction(cc *ConnContext, args []string) resp.Reply {
+	opts := db.LPosOptions{Rank: 1, Count: 1}
+	hasCount := false
+
+	for i := 2; i < len(args); i += 2 {
+		if i+1 == len(args) {
+			return resp.NewError(ErrSyntax)
+		}
+		n, err := strconv.Atoi(args[i+1])
+		if err != nil {
+			return resp.NewError(db.ErrKeyNotInteger)
+		}
+
+		switch strings.ToUpper(args[i]) {
+		case "RANK":
+			if n == 0 {
+				return resp.NewError(ErrLPosRankZero)
+			}
+			opts.Rank = n
+		case "COUNT":
+			if n < 0 {
+				return resp.NewError(ErrLPosNegCount)
+			}
+			opts.Count, hasCount = n, true
+		case "MAXLEN":
+			if n < 0 {
+				return resp.NewError(ErrLPosNegMaxLen)
+			}
+			opts.MaxLen = n
+		default:
+			return resp.NewError(ErrSyntax)
+		}
+	}
+
+	out, err := s.getDb(cc.dbIdx).LPos(args[0], args[1], opts)
+	switch {
+	case err != nil:
+		return resp.NewError(err)
+	case hasCount:
+		replies := resp.Array{}
+		for _, idx := range out {
+			replies = append(replies, resp.Integer(idx))
+		}
+		return replies
+	case len(out) == 0:
+		return resp.Nil
+	default:
+		return resp.Integer(out[0])
+	}
+}
+
+// LMOVE source destination LEFT | RIGHT LEFT | RIGHT
+func (s *Server) lmoveAction(cc *ConnContext, args []string) resp.Reply {
+	from, ok := parseListEnd(args[2])
+	if !ok {
+		return resp.NewError(ErrSyntax)
+	}
+	to, ok := parseListEnd(args[3])
+	if !ok {
+		return resp.NewError(ErrSyntax)
+	}
+
+	val, ok, err := s.getDb(cc.dbIdx).LMove(args[0], args[1], from, to)
+	switch {
+	case err != nil:
+		return resp.NewError(err)
+	case !ok:
+		return resp.Nil
+	default:
+		return resp.BulkString(val)
+	}
+}
+
+func parseListEnd(arg string) (db.ListEnd, bool) {
+	switch strings.ToUpper(arg) {
+	case "LEFT":
+		return db.ListHead, true
+	case "RIGHT":
+		return db.ListTail, true
+	default:
+		return 0, false
+	}
+}
+
+// parses integer args like indexes and counts
+func parseInts(args []string) ([]int, error) {
+	out := make([]int, 0, len(args))
+	for _, a := range args {
+		n, err := strconv.Atoi(a)
+		if err != nil {
+			return nil, db.ErrKeyNotInteger
+		}
+		out = append(out, n)
+	}
+	return out, nil
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/db"
+)
+
+func TestListCommands(t *testing.T) {
+	wrongType := db.ErrWrongType.Error()
+
+	testCases := []struct {
+		name     string
+		inputArr []string
+		expOut   []string
+	}{
+		{
+			name:     "LPUSH and RPUSH",
+			inputArr: []string{"LPUSH list b a", "RPUSH list c", "LRANGE list 0 -1", "LLEN list"},
+			expOut:   []string{"(integer) 2", "(integer) 3", "1) \"a\"\n2) \"b\"\n3) \"c\"\n", "(integer) 3"},
+		},
+		{
+			name:     "LPOP and RPOP",
+			inputArr: []string{"RPUSH list a b c d", "LPOP list", "RPOP list 2", "LPOP list 0", "LPOP list -1", "LPOP list 1 2"},
+			expOut:   []string{"(integer) 4", "\"a\"", "1) \"d\"\n2) \"c\"\n", MssgEmptyArray, ErrValueOutOfRange.Error(), "wrong number of arguments for 'lpop' command"},
+		},
+		{
+			name:     "pop on missing key",
+			inputArr: []string{"LPOP list", "RPOP list 1", "LLEN list", "LRANGE list 0 -1"},
+			expOut:   []string{MssgNil, MssgNil, "(integer) 0", MssgEmptyArray},
+		},
+		{
+			name:     "list is deleted once empty",
+			inputArr: []string{"RPUSH list a", "TYPE list", "LPOP list", "TYPE list"},
+			expOut:   []string{"(integer) 1", "list", "\"a\"", "none"},
+		},
+		{
+			name:     "LINDEX and LSET",
+			inputArr: []string{"RPUSH list a b c", "LINDEX list -1", "LINDEX list 5", "LSET list 1 x", "LINDEX list 1", "LSET list 5 x", "LSET missing 0 x", "LINDEX list one"},
+			expOut:   []string{"(integer) 3", "\"c\"", MssgNil, MssgOK, "\"x\"", "index out of range", "no such key", db.ErrKeyNotInteger.Error()},
+		},
+		{
+			name:     "LREM",
+			inputArr: []string{"RPUSH list a b a c a", "LREM list -2 a", "LRANGE list 0 -1", "LREM list 0 a", "LLEN list"},
+			expOut:   []string{"(integer) 5", "(integer) 2", "1) \"a\"\n2) \"b\"\n3) \"c\"\n", "(integer) 1", "(integer) 2"},
+		},
+		{
+			name:     "LTRIM",
+			inputArr: []string{"RPUSH list a b c d", "LTRIM list 1 -2", "LRANGE list 0 -1", "LTRIM list 5 10", "TYPE list"},
+			expOut:   []string{"(integer) 4", MssgOK, "1) \"b\"\n2) \"c\"\n", MssgOK, "none"},
+		},
+		{
+			name:     "LINSERT",
+			inputArr: []string{"RPUSH list a c", "LINSERT list BEFORE c b", "LINSERT list after c d", "LINSERT list BEFORE x y", "LINSERT list NEAR c d", "LRANGE list 0 -1"},
+			expOut:   []string{"(integer) 2", "(integer) 3", "(integer) 4", "(integer) -1", ErrSyntax.Error(), "1) \"a\"\n2) \"b\"\n3) \"c\"\n4) \"d\"\n"},
+		},
+		{
+			name:     "LPOS",
+			inputArr: []string{"RPUSH list a x b x c x", "LPOS list x", "LPOS list x RANK -1", "LPOS list x COUNT 0", "LPOS list x RANK 2 COUNT 1", "LPOS list y", "LPOS list y COUNT 1", "LPOS list x RANK 0", "LPOS list x COUNT -1", "LPOS list x MAXLEN -1"},
+			expOut:   []string{"(integer) 6", "(integer) 1", "(integer) 5", "1) (integer) 1\n2) (integer) 3\n3) (integer) 5\n", "1) (integer) 3\n", MssgNil, MssgEmptyArray, "RANK can't be zero", ErrLPosNegCount.Error(), ErrLPosNegMaxLen.Error()},
+		},
+		{
+			name:     "LMOVE",
+			inputArr: []string{"RPUSH src a b", "LMOVE src dst RIGHT LEFT", "LMOVE src dst left left", "LMOVE src dst LEFT LEFT", "LRANGE dst 0 -1", "LMOVE dst dst LEFT RIGHT", "LRANGE dst 0 -1", "LMOVE dst dst UP DOWN"},
+			expOut:   []string{"(integer) 2", "\"b\"", "\"a\"", MssgNil, "1) \"a\"\n2) \"b\"\n", "\"a\"", "1) \"b\"\n2) \"a\"\n", ErrSyntax.Error()},
+		},
+		{
+			name:     "WRONGTYPE",
+			inputArr: []string{"SET str bar", "RPUSH list a", "LPUSH str a", "LRANGE str 0 -1", "GET list", "INCR list", "SET list bar GET", "LMOVE list str LEFT LEFT", "LLEN list"},
+			expOut:   []string{MssgOK, "(integer) 1", wrongType, wrongType, wrongType, wrongType, wrongType, wrongType, "(integer) 1"},
+		},
+		{
+			name:     "SET overwrites a list",
+			inputArr: []string{"RPUSH list a", "SET list bar", "TYPE list", "GET list"},
+			expOut:   []string{"(integer) 1", MssgOK, "string", "\"bar\""},
+		},
+		{
+			name:     "COMPACT with a list",
+			inputArr: []string{"RPUSH list a b", "COMPACT"},
+			expOut:   []string{"(integer) 2", "RPUSH list a b"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			s := GetTestServerWithDB()
+			cc := &ConnContext{}
+
+			for i, input := range tc.inputArr {
+				s.handleCommand(input, &buf, cc)
+
+				if !bytes.Contains(buf.Bytes(), []byte(tc.expOut[i])) {
+					t.Errorf("Expected output of %q to contain %q but got %q instead", input, tc.expOut[i], buf.String())
+				}
+				buf.Reset()
+			}
+		})
+	}
+}
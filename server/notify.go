@@ -0,0 +1,113 @@
+package server
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/db"
+)
+
+var ErrInvalidKeyspaceEvents = errors.New("Invalid event class character. Use 'Ag$lshzxetKE'.")
+
+// char of notify-keyspace-events turning on a class of events
+type keyspaceEventFlag struct {
+	flag  byte
+	class db.EventClass
+}
+
+// flags in the order CONFIG GET reports them, A stands for all of them
+var keyspaceEventFlags = []keyspaceEventFlag{
+	{'g', db.EventGeneric},
+	{'$', db.EventString},
+	{'l', db.EventList},
+	{'s', db.EventSet},
+	{'h', db.EventHash},
+	{'z', db.EventZSet},
+	{'x', db.EventExpired},
+	{'e', db.EventEvicted},
+	{'t', db.EventStream},
+}
+
+// keyspace events to publish, as set by notify-keyspace-events
+// nothing is published unless some class is set along K or E
+type keyspaceEvents struct {
+	classes  db.EventClass
+	keyspace bool // K, published to __keyspace@<db>__:<key> with the event as message
+	keyevent bool // E, published to __keyevent@<db>__:<event> with the key as message
+}
+
+func parseKeyspaceEvents(val string) (keyspaceEvents, error) {
+	var ev keyspaceEvents
+	for i := range len(val) {
+		switch c := val[i]; c {
+		case 'A':
+			ev.classes |= db.EventAll
+		case 'K':
+			ev.keyspace = true
+		case 'E':
+			ev.keyevent = true
+		default:
+			j := slices.IndexFunc(keyspaceEventFlags, func(f keyspaceEventFlag) bool { return f.flag == c })
+			if j < 0 {
+				return keyspaceEvents{}, ErrInvalidKeyspaceEvents
+			}
+			ev.classes |= keyspaceEventFlags[j].class
+		}
+	}
+	return ev, nil
+}
+
+// formats the events the way CONFIG GET reports them, eg. AKE
+func (ev keyspaceEvents) String() string {
+	var b strings.Builder
+	if ev.classes == db.EventAll {
+		b.WriteByte('A')
+	} else {
+		for _, f := range keyspaceEventFlags {
+			if ev.classes&f.class != 0 {
+				b.WriteByte(f.flag)
+			}
+		}
+	}
+	if ev.keyspace {
+		b.WriteByte('K')
+	}
+	if ev.keyevent {
+		b.WriteByte('E')
+	}
+	return b.String()
+}
+
+// hooks the db up to the background jobs and the keyspace events, once
+// called with dbMu held, SELECT attaches dbs before Start while the AOF is replayed
+func (s *Server) attachDb(idx int, d db.DbInterface) {
+	if s.attached[idx] {
+		return
+	}
+	if s.attached == nil {
+		s.attached = make(map[int]bool)
+	}
+	s.attached[idx] = true
+
+	d.SetNotifier(s.keyspaceNotifier(idx))
+	s.startActiveExpiry(d)
+}
+
+// publishes the events of the db with the given index which notify-keyspace-events asks for
+func (s *Server) keyspaceNotifier(idx int) db.Notifier {
+	prefix := "@" + strconv.Itoa(idx) + "__:"
+	return func(class db.EventClass, event, key string) {
+		ev := s.config.getKeyspaceEvents()
+		if ev.classes&class == 0 {
+			return
+		}
+		if ev.keyspace {
+			s.publish("__keyspace"+prefix+key, event)
+		}
+		if ev.keyevent {
+			s.publish("__keyevent"+prefix+event, key)
+		}
+	}
+}
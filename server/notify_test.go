@@ -0,0 +1,106 @@
+package server
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestParseKeyspaceEvents(t *testing.T) {
+	testCases := []struct {
+		input  string
+		expOut string
+		expErr error
+	}{
+		{"", "", nil},
+		{"KEA", "AKE", nil},
+		{"El$", "$lE", nil},
+		{"g$lshzxetK", "AK", nil},
+		{"Kxg", "gxK", nil},
+		{"Kq", "", ErrInvalidKeyspaceEvents},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			ev, err := parseKeyspaceEvents(tc.input)
+			if err != tc.expErr {
+				t.Fatalf("Expected error %v but got %v", tc.expErr, err)
+			}
+			if out := ev.String(); out != tc.expOut {
+				t.Errorf("Expected %q but got %q", tc.expOut, out)
+			}
+		})
+	}
+}
+
+func TestKeyspaceNotifications(t *testing.T) {
+	var buf bytes.Buffer
+	s := GetTestServerWithDB()
+	defer s.Close()
+	s.Db[0].SetNotifier(s.keyspaceNotifier(0))
+
+	subscriber := &ConnContext{}
+	writer := &ConnContext{}
+	run := func(cc *ConnContext, input string) {
+		buf.Reset()
+		s.handleCommand(input, &buf, cc)
+	}
+	run(subscriber, "PSUBSCRIBE __key*@*__:*")
+	queued(subscriber)
+
+	testCases := []struct {
+		name   string
+		config string
+		inputs []string
+		expOut string
+	}{
+		{
+			name:   "nothing is published by default",
+			inputs: []string{"SET foo bar"},
+			expOut: "",
+		},
+		{
+			name:   "keyspace events",
+			config: "K$",
+			inputs: []string{"SET foo bar", "DEL foo"},
+			expOut: "1) \"pmessage\"\n2) \"__key*@*__:*\"\n3) \"__keyspace@0__:foo\"\n4) \"set\"\n",
+		},
+		{
+			name:   "keyevent events",
+			config: "Eg",
+			inputs: []string{"SET foo bar", "DEL foo"},
+			expOut: "1) \"pmessage\"\n2) \"__key*@*__:*\"\n3) \"__keyevent@0__:del\"\n4) \"foo\"\n",
+		},
+		{
+			name:   "both of them",
+			config: "KEl",
+			inputs: []string{"RPUSH list a"},
+			expOut: "1) \"pmessage\"\n2) \"__key*@*__:*\"\n3) \"__keyspace@0__:list\"\n4) \"rpush\"\n" +
+				"1) \"pmessage\"\n2) \"__key*@*__:*\"\n3) \"__keyevent@0__:rpush\"\n4) \"list\"\n",
+		},
+		{
+			name:   "events of a db created by SELECT",
+			config: "KA",
+			inputs: []string{"SELECT 3", "HSET h f v"},
+			expOut: "1) \"pmessage\"\n2) \"__key*@*__:*\"\n3) \"__keyspace@3__:h\"\n4) \"hset\"\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := s.SetConfig("notify-keyspace-events", tc.config); err != nil {
+				t.Fatalf("Unexpected error occured: %v", err)
+			}
+			for _, input := range tc.inputs {
+				run(writer, input)
+			}
+			if out := queued(subscriber); out != tc.expOut {
+				t.Errorf("Expected %q but got %q", tc.expOut, out)
+			}
+		})
+	}
+
+	run(writer, "CONFIG GET notify-keyspace-events")
+	if exp := "1# \"notify-keyspace-events\" => \"AK\"\n"; buf.String() != exp {
+		t.Errorf("Expected %q but got %q", exp, buf.String())
+	}
+}
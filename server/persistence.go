@@ -0,0 +1,279 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/rdb"
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/resp"
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store"
+)
+
+const (
+	SAVE     string = "SAVE"
+	BGSAVE   string = "BGSAVE"
+	LASTSAVE string = "LASTSAVE"
+
+	MssgBgsaveStarted string = "Background saving started"
+)
+
+var (
+	ErrBgsaveInProgress = errors.New("Background save already in progress")
+	ErrMisconf          = errors.New("MISCONF Redis is configured to save RDB snapshots, but it's currently unable to persist to disk. Commands that may modify the data set are disabled, because this instance is configured to report errors during writes if RDB snapshotting fails (stop-writes-on-bgsave-error option). Please check the Redis logs for details about the RDB error.")
+)
+
+const bgsaveRetryDelay = 5 * time.Second // a failed BGSAVE started by the save rules is retried after it
+
+type persistenceState struct {
+	mu          sync.Mutex
+	saving      bool   // a BGSAVE is running
+	lastSave    int64  // unix time in seconds of the last successful save, or of the startup
+	saves       int64  // number of successful saves
+	dirtyAtSave uint64 // changes of all the dbs when the last saved snapshot was taken
+
+	bgsaveErr      error         // error of the last BGSAVE, nil if it went fine, cleared by a successful SAVE
+	bgsaveStart    time.Time     // start of the last BGSAVE
+	bgsaveDuration time.Duration // time taken by the last BGSAVE which is done, -1 if none is
+}
+
+// returns the path of the snapshot file, from the dir and dbfilename params
+func (s *Server) snapshotPath() string {
+	return filepath.Join(s.config.getDir(), s.config.getDbFilename())
+}
+
+// copies the keys of every db along with the number of changes they include
+// called with no other command running, so the dbs are seen at the same point
+func (s *Server) snapshot() (map[int][]store.Entry, uint64) {
+	dbs, dirty := map[int][]store.Entry{}, uint64(0)
+	for idx, d := range s.allDbs() {
+		dbs[idx] = d.Snapshot()
+		dirty += d.Dirty()
+	}
+	return dbs, dirty
+}
+
+// returns the number of changes made to all the dbs so far
+func (s *Server) dirty() uint64 {
+	var dirty uint64
+	for _, d := range s.allDbs() {
+		dirty += d.Dirty()
+	}
+	return dirty
+}
+
+// Load restores the dbs from the append only file if appendonly is set and the file exists, or from the snapshot file
+// it's meant to be called before Start, the keys of the dbs given upfront are kept unless the files have them
+func (s *Server) Load() error {
+	if s.config.getAppendOnly() {
+		if err := s.loadAOF(); !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	dbs, err := rdb.LoadFile(s.snapshotPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return s.restore(dbs)
+}
+
+// SAVE
+// the snapshot is written while every other client waits
+func (s *Server) saveAction() resp.Reply {
+	p := &s.persistence
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.saving {
+		return resp.NewError(ErrBgsaveInProgress)
+	}
+
+	dbs, dirty := s.snapshot()
+	if err := rdb.SaveFile(s.snapshotPath(), dbs); err != nil {
+		return resp.NewError(err)
+	}
+	p.saved(dirty)
+	p.bgsaveErr = nil
+	return resp.SimpleString(MssgOK)
+}
+
+// BGSAVE
+func (s *Server) bgsaveAction() resp.Reply {
+	if err := s.bgsave(); err != nil {
+		return resp.NewError(err)
+	}
+	return resp.SimpleString(MssgBgsaveStarted)
+}
+
+// the dbs are copied while every other client waits, then the copy is written in the background
+// so the writes of other clients go on during the dump without changing what's saved
+// only the state of the save is guarded by mu, LASTSAVE and INFO don't wait for the copy
+func (s *Server) bgsave() error {
+	s.init()
+	p := &s.persistence
+	p.mu.Lock()
+	if p.saving {
+		p.mu.Unlock()
+		return ErrBgsaveInProgress
+	}
+	p.saving = true
+	p.bgsaveStart = time.Now()
+	p.mu.Unlock()
+
+	path := s.snapshotPath()
+	dbs, dirty := s.snapshot()
+	started := s.startJob(func() {
+		err := rdb.SaveFile(path, dbs)
+		if err != nil {
+			fmt.Printf("Background saving error: %v\n", err)
+		}
+
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.saving = false
+		p.bgsaveErr = err
+		p.bgsaveDuration = time.Since(p.bgsaveStart)
+		if err == nil {
+			p.saved(dirty)
+		}
+	})
+	if !started {
+		p.mu.Lock()
+		p.saving = false
+		p.mu.Unlock()
+		return ErrShuttingDown
+	}
+	return nil
+}
+
+// records a successful save of a snapshot which included dirty changes, called with mu held
+func (p *persistenceState) saved(dirty uint64) {
+	p.lastSave = time.Now().Unix()
+	p.saves++
+	p.dirtyAtSave = dirty
+}
+
+// LASTSAVE
+func (s *Server) lastsaveAction() resp.Reply {
+	s.init()
+	p := &s.persistence
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return resp.Integer(p.lastSave)
+}
+
+// checks the save rules hz times a second, until the server shuts down
+func (s *Server) startSaveRules() {
+	s.startJob(func() {
+		timer := time.NewTimer(s.config.getHzPeriod())
+		defer timer.Stop()
+		for {
+			select {
+			case <-s.quit:
+				return
+			case <-timer.C:
+			}
+
+			s.saveIfNeeded(time.Now())
+			timer.Reset(s.config.getHzPeriod())
+		}
+	})
+}
+
+// starts a BGSAVE once any of the save rules is met at now
+// the rules are checked along other commands, only the BGSAVE runs apart from them
+func (s *Server) saveIfNeeded(now time.Time) {
+	if !s.saveRuleMet(now) {
+		return
+	}
+	s.execute(true, func() {
+		if err := s.bgsave(); err != nil && !errors.Is(err, ErrBgsaveInProgress) {
+			fmt.Printf("Background saving error: %v\n", err)
+		}
+	})
+}
+
+// a rule is met once it's been at least its seconds since the last save, with at least its changes made meanwhile
+// a failed BGSAVE is retried once bgsaveRetryDelay passed, rather than on every check
+func (s *Server) saveRuleMet(now time.Time) bool {
+	rules := s.config.getSaveRules()
+	if len(rules) == 0 {
+		return false
+	}
+
+	s.init()
+	p := &s.persistence
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.saving || (p.bgsaveErr != nil && now.Sub(p.bgsaveStart) < bgsaveRetryDelay) {
+		return false
+	}
+
+	changes := s.dirty() - p.dirtyAtSave
+	elapsed := now.Unix() - p.lastSave
+	for _, r := range rules {
+		if changes >= r.changes && elapsed >= r.seconds {
+			return true
+		}
+	}
+	return false
+}
+
+// returns the error refusing the writes, nil if they go through
+// they're refused while the append only file can't be written, and once a BGSAVE failed if told so by stop-writes-on-bgsave-error,
+// so the changes which can't be saved don't pile up unnoticed
+func (s *Server) writesRefused() error {
+	if err := s.aofWriteErr(); err != nil {
+		return fmt.Errorf("%v: %v", ErrAOFMisconf, err)
+	}
+	if !s.config.getStopWritesOnBgsaveError() || len(s.config.getSaveRules()) == 0 {
+		return nil
+	}
+
+	p := &s.persistence
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.bgsaveErr != nil {
+		return ErrMisconf
+	}
+	return nil
+}
+
+// lines of the persistence section of INFO
+func (s *Server) persistenceInfo() []string {
+	s.init()
+	dirty := s.dirty()
+	p := &s.persistence
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	status, inProgress, current := "ok", 0, int64(-1)
+	if p.bgsaveErr != nil {
+		status = "err"
+	}
+	if p.saving {
+		inProgress, current = 1, int64(time.Since(p.bgsaveStart).Seconds())
+	}
+	last := int64(-1)
+	if p.bgsaveDuration >= 0 {
+		last = int64(p.bgsaveDuration.Seconds())
+	}
+
+	lines := []string{
+		"loading:0",
+		fmt.Sprintf("rdb_changes_since_last_save:%d", dirty-p.dirtyAtSave),
+		fmt.Sprintf("rdb_bgsave_in_progress:%d", inProgress),
+		fmt.Sprintf("rdb_last_save_time:%d", p.lastSave),
+		fmt.Sprintf("rdb_last_bgsave_status:%s", status),
+		fmt.Sprintf("rdb_last_bgsave_time_sec:%d", last),
+		fmt.Sprintf("rdb_current_bgsave_time_sec:%d", current),
+		fmt.Sprintf("rdb_saves:%d", p.saves),
+	}
+	return append(lines, s.aofInfo()...)
+}
@@ -0,0 +1,324 @@
+package server
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/db"
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store/inMemoryStore"
+)
+
+// returns a server saving its snapshots in a temp dir
+func getTestServerWithDir(t *testing.T, dir string) *Server {
+	t.Helper()
+	s := GetTestServerWithDB()
+	if err := s.SetConfig("dir", dir); err != nil {
+		t.Fatalf("Failed to set the dir: %v", err)
+	}
+	return s
+}
+
+func TestPersistenceCommands(t *testing.T) {
+	testCases := []struct {
+		name     string
+		inputArr []string
+		expOut   []string
+	}{
+		{
+			name:     "SAVE",
+			inputArr: []string{"SET foo bar", "SAVE"},
+			expOut:   []string{"OK\n", "OK\n"},
+		},
+		{
+			name:     "BGSAVE",
+			inputArr: []string{"SET foo bar", "BGSAVE"},
+			expOut:   []string{"OK\n", MssgBgsaveStarted + "\n"},
+		},
+		{
+			name:     "wrong number of args",
+			inputArr: []string{"SAVE now", "BGSAVE now", "LASTSAVE now"},
+			expOut:   []string{ErrWrongNumberOfArgs.Error(), ErrWrongNumberOfArgs.Error(), ErrWrongNumberOfArgs.Error()},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			s := getTestServerWithDir(t, t.TempDir())
+			defer s.Close()
+			cc := &ConnContext{}
+
+			for i, input := range tc.inputArr {
+				s.handleCommand(input, &buf, cc)
+
+				if !bytes.Contains(buf.Bytes(), []byte(tc.expOut[i])) {
+					t.Errorf("Expected output of %q to contain %q but got %q instead", input, tc.expOut[i], buf.String())
+				}
+				buf.Reset()
+			}
+		})
+	}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	var buf bytes.Buffer
+
+	t.Run("SAVE", func(t *testing.T) {
+		dir := t.TempDir()
+		s := getTestServerWithDir(t, dir)
+		cc := &ConnContext{}
+		for _, input := range []string{"SET foo bar", "RPUSH list a b", "SET ttl v EX 100", "SET gone v PX 1", "SELECT 5", "HSET hash f v", "SAVE"} {
+			s.handleCommand(input, &buf, cc)
+		}
+		time.Sleep(2 * time.Millisecond)
+
+		loaded := getTestServerWithDir(t, dir)
+		loaded.Db = map[int]db.DbInterface{}
+		if err := loaded.Load(); err != nil {
+			t.Fatalf("Expected no error but got %v", err)
+		}
+
+		buf.Reset()
+		cc = &ConnContext{}
+		for _, input := range []string{"GET foo", "LRANGE list 0 -1", "TTL ttl", "GET gone", "SELECT 5", "HGET hash f"} {
+			loaded.handleCommand(input, &buf, cc)
+		}
+		if exp := "\"bar\"\n1) \"a\"\n2) \"b\"\n(integer) 100\n(nil)\nOK\n\"v\"\n"; buf.String() != exp {
+			t.Errorf("Expected %q but got %q", exp, buf.String())
+		}
+	})
+
+	t.Run("BGSAVE saves the dbs as they were when it started", func(t *testing.T) {
+		dir := t.TempDir()
+		s := getTestServerWithDir(t, dir)
+		defer s.Close()
+		cc := &ConnContext{}
+
+		s.handleCommand("SET foo before", &buf, cc)
+		s.handleCommand("BGSAVE", &buf, cc)
+		s.handleCommand("SET foo after", &buf, cc)
+		waitFor(t, func() bool {
+			s.persistence.mu.Lock()
+			defer s.persistence.mu.Unlock()
+			return !s.persistence.saving
+		})
+
+		loaded := getTestServerWithDir(t, dir)
+		if err := loaded.Load(); err != nil {
+			t.Fatalf("Expected no error but got %v", err)
+		}
+		if out, _ := loaded.getDb(0).Get("foo"); out != "before" {
+			t.Errorf("Expected %q but got %q", "before", out)
+		}
+	})
+
+	t.Run("BGSAVE copies the dbs apart from other commands", func(t *testing.T) {
+		s := getTestServerWithDir(t, t.TempDir())
+		defer s.Close()
+
+		// another command is running, BGSAVE waits for it so every db is copied at the same point
+		s.execMu.RLock()
+		out := make(chan string, 1)
+		go func() {
+			var b bytes.Buffer
+			s.handleCommand("BGSAVE", &b, &ConnContext{})
+			out <- b.String()
+		}()
+		expectBlocked(t, out)
+		s.execMu.RUnlock()
+		expectReply(t, out, MssgBgsaveStarted+"\n")
+		waitForBgsave(t, s)
+	})
+
+	t.Run("BGSAVE while another one is running", func(t *testing.T) {
+		s := getTestServerWithDir(t, t.TempDir())
+		s.persistence.saving = true
+
+		buf.Reset()
+		for _, input := range []string{"BGSAVE", "SAVE"} {
+			s.handleCommand(input, &buf, &ConnContext{})
+		}
+		if exp := "(error) ERR " + ErrBgsaveInProgress.Error() + "\n"; buf.String() != exp+exp {
+			t.Errorf("Expected %q twice but got %q", exp, buf.String())
+		}
+	})
+
+	t.Run("LASTSAVE", func(t *testing.T) {
+		s := getTestServerWithDir(t, t.TempDir())
+		s.init()
+		s.persistence.lastSave = 100
+
+		buf.Reset()
+		s.handleCommand("LASTSAVE", &buf, &ConnContext{})
+		s.handleCommand("SAVE", &buf, &ConnContext{})
+		if exp := "(integer) 100\nOK\n"; buf.String() != exp {
+			t.Errorf("Expected %q but got %q", exp, buf.String())
+		}
+
+		buf.Reset()
+		s.handleCommand("LASTSAVE", &buf, &ConnContext{})
+		if last, _ := strconv.ParseInt(buf.String()[len("(integer) "):buf.Len()-1], 10, 64); time.Now().Unix()-last > 1 {
+			t.Errorf("Expected LASTSAVE to be now but got %q", buf.String())
+		}
+	})
+
+	t.Run("missing snapshot", func(t *testing.T) {
+		s := getTestServerWithDir(t, t.TempDir())
+		if err := s.Load(); err != nil {
+			t.Errorf("Expected no error but got %v", err)
+		}
+	})
+
+	t.Run("corrupt snapshot", func(t *testing.T) {
+		dir := t.TempDir()
+		os.WriteFile(filepath.Join(dir, "dump.goredis"), []byte("GOREDIS\x01garbage"), 0o644)
+
+		s := &Server{Db: map[int]db.DbInterface{0: db.GetNewDB(inMemoryStore.NewInMemoryStore())}}
+		s.SetConfig("dir", dir)
+		if err := s.Load(); err == nil {
+			t.Errorf("Expected an error but got none")
+		}
+	})
+}
+
+// waits for the running BGSAVE to be done
+func waitForBgsave(t *testing.T, s *Server) {
+	t.Helper()
+	waitFor(t, func() bool {
+		s.persistence.mu.Lock()
+		defer s.persistence.mu.Unlock()
+		return !s.persistence.saving
+	})
+}
+
+func TestSaveRules(t *testing.T) {
+	var buf bytes.Buffer
+	cc := &ConnContext{}
+
+	t.Run("BGSAVE once a rule is met", func(t *testing.T) {
+		dir := t.TempDir()
+		s := getTestServerWithDir(t, dir)
+		defer s.Close()
+		s.SetConfig("save", "10 2 100 1")
+		s.handleCommand("SET foo bar", &buf, cc)
+
+		// only one change for 10 secs, then a second one
+		s.saveIfNeeded(time.Now().Add(20 * time.Second))
+		if _, err := os.Stat(filepath.Join(dir, "dump.goredis")); err == nil {
+			t.Fatalf("Expected no snapshot before a rule is met")
+		}
+
+		s.handleCommand("SET foo baz", &buf, cc)
+		s.saveIfNeeded(time.Now().Add(20 * time.Second))
+		waitForBgsave(t, s)
+		if _, err := os.Stat(filepath.Join(dir, "dump.goredis")); err != nil {
+			t.Fatalf("Expected a snapshot but got %v", err)
+		}
+		if dirty := s.dirty() - s.persistence.dirtyAtSave; dirty != 0 {
+			t.Errorf("Expected no changes since the save but got %d", dirty)
+		}
+	})
+
+	t.Run("no rules", func(t *testing.T) {
+		dir := t.TempDir()
+		s := getTestServerWithDir(t, dir)
+		if err := s.SetConfig("save", ""); err != nil {
+			t.Fatalf("Expected no error but got %v", err)
+		}
+		s.handleCommand("SET foo bar", &buf, cc)
+
+		if s.saveRuleMet(time.Now().Add(time.Hour)) {
+			t.Errorf("Expected no rule to be met")
+		}
+	})
+
+	t.Run("writes are refused once BGSAVE failed", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "gone")
+		os.Mkdir(dir, 0o755)
+		s := getTestServerWithDir(t, dir)
+		defer s.Close()
+		os.Remove(dir)
+
+		buf.Reset()
+		s.handleCommand("BGSAVE", &buf, cc)
+		waitForBgsave(t, s)
+		for _, input := range []string{"SET foo bar", "GET foo", "MULTI", "INCR counter", "EXEC"} {
+			s.handleCommand(input, &buf, cc)
+		}
+		misconf := "(error) " + ErrMisconf.Error() + "\n"
+		exp := MssgBgsaveStarted + "\n" + misconf + "(nil)\nOK\n" + misconf + "(error) EXECABORT " + ErrTranAbortedDueToPrevError.Error() + "\n"
+		if buf.String() != exp {
+			t.Errorf("Expected %q but got %q", exp, buf.String())
+		}
+
+		// writes go on once told so
+		buf.Reset()
+		s.handleCommand("CONFIG SET stop-writes-on-bgsave-error no", &buf, cc)
+		s.handleCommand("SET foo bar", &buf, cc)
+		if exp := "OK\nOK\n"; buf.String() != exp {
+			t.Errorf("Expected %q but got %q", exp, buf.String())
+		}
+
+		// a failed BGSAVE isn't retried right away by the rules
+		s.SetConfig("save", "1 1")
+		if s.saveRuleMet(time.Now().Add(time.Second)) {
+			t.Errorf("Expected the failed BGSAVE not to be retried right away")
+		}
+		if !s.saveRuleMet(time.Now().Add(time.Second + bgsaveRetryDelay)) {
+			t.Errorf("Expected the failed BGSAVE to be retried after %v", bgsaveRetryDelay)
+		}
+	})
+
+	t.Run("a successful SAVE lets the writes go on", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "gone")
+		os.Mkdir(dir, 0o755)
+		s := getTestServerWithDir(t, dir)
+		defer s.Close()
+		os.Remove(dir)
+
+		s.handleCommand("BGSAVE", &buf, cc)
+		waitForBgsave(t, s)
+		os.Mkdir(dir, 0o755)
+
+		buf.Reset()
+		s.handleCommand("SAVE", &buf, cc)
+		s.handleCommand("SET foo bar", &buf, cc)
+		if exp := "OK\nOK\n"; buf.String() != exp {
+			t.Errorf("Expected %q but got %q", exp, buf.String())
+		}
+	})
+}
+
+func TestInfoPersistence(t *testing.T) {
+	var buf bytes.Buffer
+	s := getTestServerWithDir(t, t.TempDir())
+	defer s.Close()
+	cc := &ConnContext{}
+
+	info := func() string {
+		buf.Reset()
+		s.handleCommand("INFO persistence", &buf, cc)
+		return buf.String()
+	}
+
+	s.handleCommand("SET foo bar", &buf, cc)
+	s.handleCommand("RPUSH list a", &buf, cc)
+	for _, line := range []string{"# Persistence\r\n", "rdb_changes_since_last_save:2\r\n", "rdb_last_bgsave_status:ok\r\n", "rdb_last_bgsave_time_sec:-1\r\n", "rdb_saves:0\r\n"} {
+		if out := info(); !strings.Contains(out, line) {
+			t.Errorf("Expected the info to contain %q but got %q", line, out)
+		}
+	}
+
+	s.handleCommand("BGSAVE", &buf, cc)
+	waitForBgsave(t, s)
+	for _, line := range []string{"rdb_changes_since_last_save:0\r\n", "rdb_bgsave_in_progress:0\r\n", "rdb_last_bgsave_time_sec:0\r\n", "rdb_saves:1\r\n"} {
+		if out := info(); !strings.Contains(out, line) {
+			t.Errorf("Expected the info to contain %q but got %q", line, out)
+		}
+	}
+}
@@ -0,0 +1,334 @@
+package server
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/resp"
+)
+
+const (
+	PUBLISH      string = "PUBLISH"
+	SUBSCRIBE    string = "SUBSCRIBE"
+	UNSUBSCRIBE  string = "UNSUBSCRIBE"
+	PSUBSCRIBE   string = "PSUBSCRIBE"
+	PUNSUBSCRIBE string = "PUNSUBSCRIBE"
+	PUBSUB       string = "PUBSUB"
+)
+
+var ErrNotAllowedInMulti = errors.New("Command not allowed inside a transaction")
+
+// commands managing the subscriptions, the only ones a RESP2 client can send while subscribed along PING
+var subscriptionCommands = []string{SUBSCRIBE, UNSUBSCRIBE, PSUBSCRIBE, PUNSUBSCRIBE}
+
+// channels and patterns along with the clients subscribed to them
+type pubsubState struct {
+	mu       sync.RWMutex
+	channels map[string]map[*subscriber]struct{}
+	patterns map[string]map[*subscriber]struct{}
+}
+
+// output of a subscribed client
+// published messages are queued and written by the conn of the client, so a slow client never holds up the publishers
+// once a client subscribes, its replies are queued as well to keep them in order with the messages
+type subscriber struct {
+	channels map[string]struct{} // changed by the conn of the client only, under the mu of pubsubState
+	patterns map[string]struct{}
+
+	mu        sync.Mutex
+	protocol  resp.Protocol
+	queue     []byte    // encoded output not taken by the writer yet
+	pending   int       // bytes queued or being written
+	softSince time.Time // when pending went over the soft limit
+	closed    bool
+	wake      chan struct{} // signals the writer, buffered
+	conn      io.Closer     // dropped once the output buffer limit is hit, nil outside a conn
+}
+
+func newSubscriber(conn io.Closer) *subscriber {
+	return &subscriber{
+		channels: make(map[string]struct{}),
+		patterns: make(map[string]struct{}),
+		wake:     make(chan struct{}, 1),
+		conn:     conn,
+	}
+}
+
+// number of channels and patterns the client is subscribed to
+func (sub *subscriber) count() int {
+	return len(sub.channels) + len(sub.patterns)
+}
+
+// queues the reply for the writer, and drops the client if its output goes over the limit
+func (sub *subscriber) send(r resp.Reply, limit outputBufferLimit) {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+
+	if sub.closed {
+		return
+	}
+	n := len(sub.queue)
+	sub.queue = resp.AppendReply(sub.queue, sub.protocol, r)
+	sub.pending += len(sub.queue) - n
+
+	if limit.exceeded(sub.pending, &sub.softSince) {
+		fmt.Printf("Client closed for overcoming of output buffer limits: %d bytes pending\n", sub.pending)
+		sub.closeLocked()
+		if sub.conn != nil {
+			sub.conn.Close()
+		}
+		return
+	}
+
+	select {
+	case sub.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (sub *subscriber) setProtocol(p resp.Protocol) {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+	sub.protocol = p
+}
+
+// waits for queued output and takes it
+// returns false once the subscriber is closed
+func (sub *subscriber) take() ([]byte, bool) {
+	for {
+		sub.mu.Lock()
+		if sub.closed {
+			sub.mu.Unlock()
+			return nil, false
+		}
+		if len(sub.queue) > 0 {
+			b := sub.queue
+			sub.queue = nil
+			sub.mu.Unlock()
+			return b, true
+		}
+		sub.mu.Unlock()
+		<-sub.wake
+	}
+}
+
+// marks the output taken by the writer as written
+func (sub *subscriber) written(n int) {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+	sub.pending -= n
+}
+
+func (sub *subscriber) close() {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+	sub.closeLocked()
+}
+
+func (sub *subscriber) closeLocked() {
+	sub.closed = true
+	sub.queue = nil
+	select {
+	case sub.wake <- struct{}{}:
+	default:
+	}
+}
+
+// writes the output of the subscriber to the conn until it's closed
+func (sub *subscriber) pump(w *syncWriter) {
+	for {
+		b, ok := sub.take()
+		if !ok {
+			return
+		}
+		_, err := w.Write(b)
+		if err == nil {
+			err = w.Flush()
+		}
+		sub.written(len(b))
+		if err != nil {
+			sub.close()
+			return
+		}
+	}
+}
+
+// bufio.Writer shared by the conn loop and the writer of a subscriber
+type syncWriter struct {
+	mu sync.Mutex
+	w  *bufio.Writer
+}
+
+func (sw *syncWriter) Write(b []byte) (int, error) {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	return sw.w.Write(b)
+}
+
+func (sw *syncWriter) Flush() error {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	return sw.w.Flush()
+}
+
+// PUBLISH channel message
+// replies with the number of clients which got the message
+func (s *Server) publishAction(args []string) resp.Reply {
+	return resp.Integer(s.publish(args[0], args[1]))
+}
+
+// sends the message to the clients subscribed to the channel, then to those with a matching pattern
+func (s *Server) publish(channel, message string) int {
+	ps := &s.pubsub
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	limit := s.config.getOutputBufferLimit(pubsubClass)
+	n := 0
+	for sub := range ps.channels[channel] {
+		sub.send(resp.Push{resp.BulkString("message"), resp.BulkString(channel), resp.BulkString(message)}, limit)
+		n++
+	}
+	for pattern, subs := range ps.patterns {
+		if !matchGlob(pattern, channel) {
+			continue
+		}
+		for sub := range subs {
+			sub.send(resp.Push{resp.BulkString("pmessage"), resp.BulkString(pattern), resp.BulkString(channel), resp.BulkString(message)}, limit)
+			n++
+		}
+	}
+	return n
+}
+
+// SUBSCRIBE channel [channel ...]
+// PSUBSCRIBE takes patterns, every subscription is confirmed with the number of subscriptions of the client
+func (s *Server) subscribeAction(cc *ConnContext, c Command) resp.Reply {
+	if cc.sub == nil {
+		cc.sub = newSubscriber(cc.conn)
+		cc.sub.setProtocol(cc.protocol)
+	}
+
+	ps := &s.pubsub
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	if ps.channels == nil {
+		ps.channels = make(map[string]map[*subscriber]struct{})
+		ps.patterns = make(map[string]map[*subscriber]struct{})
+	}
+
+	kind, all, mine := "subscribe", ps.channels, cc.sub.channels
+	if c.name == PSUBSCRIBE {
+		kind, all, mine = "psubscribe", ps.patterns, cc.sub.patterns
+	}
+
+	// confirmations are queued along the messages, so none is sent before its confirmation
+	limit := s.config.getOutputBufferLimit(pubsubClass)
+	for _, name := range c.args {
+		if all[name] == nil {
+			all[name] = make(map[*subscriber]struct{})
+		}
+		all[name][cc.sub] = struct{}{}
+		mine[name] = struct{}{}
+		cc.sub.send(resp.Push{resp.BulkString(kind), resp.BulkString(name), resp.Integer(cc.sub.count())}, limit)
+	}
+	return nil
+}
+
+// UNSUBSCRIBE [channel [channel ...]]
+// PUNSUBSCRIBE takes patterns, no args unsubscribes from all of them
+func (s *Server) unsubscribeAction(cc *ConnContext, c Command) resp.Reply {
+	kind := "unsubscribe"
+	if c.name == PUNSUBSCRIBE {
+		kind = "punsubscribe"
+	}
+	if cc.sub == nil {
+		return resp.Push{resp.BulkString(kind), resp.Nil, resp.Integer(0)}
+	}
+
+	ps := &s.pubsub
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	all, mine := ps.channels, cc.sub.channels
+	if c.name == PUNSUBSCRIBE {
+		all, mine = ps.patterns, cc.sub.patterns
+	}
+
+	names := c.args
+	if len(names) == 0 {
+		names = sortedKeys(mine)
+	}
+	limit := s.config.getOutputBufferLimit(pubsubClass)
+	if len(names) == 0 {
+		cc.sub.send(resp.Push{resp.BulkString(kind), resp.Nil, resp.Integer(cc.sub.count())}, limit)
+	}
+	for _, name := range names {
+		unsubscribe(all, mine, name, cc.sub)
+		cc.sub.send(resp.Push{resp.BulkString(kind), resp.BulkString(name), resp.Integer(cc.sub.count())}, limit)
+	}
+	return nil
+}
+
+// removes the subscription of sub to name, the name is forgotten along with its last subscriber
+func unsubscribe(all map[string]map[*subscriber]struct{}, mine map[string]struct{}, name string, sub *subscriber) {
+	delete(mine, name)
+	delete(all[name], sub)
+	if len(all[name]) == 0 {
+		delete(all, name)
+	}
+}
+
+// drops every subscription of the client once its conn is closed
+func (s *Server) unsubscribeAll(cc *ConnContext) {
+	if cc.sub == nil {
+		return
+	}
+
+	ps := &s.pubsub
+	ps.mu.Lock()
+	for name := range cc.sub.channels {
+		unsubscribe(ps.channels, cc.sub.channels, name, cc.sub)
+	}
+	for name := range cc.sub.patterns {
+		unsubscribe(ps.patterns, cc.sub.patterns, name, cc.sub)
+	}
+	ps.mu.Unlock()
+
+	cc.sub.close()
+}
+
+// PUBSUB CHANNELS [pattern] | NUMSUB [channel ...] | NUMPAT
+func (s *Server) pubsubAction(args []string) resp.Reply {
+	ps := &s.pubsub
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	switch sub := strings.ToUpper(args[0]); {
+	case sub == "CHANNELS" && len(args) <= 2:
+		channels := []string{}
+		for name := range ps.channels {
+			if len(args) == 1 || matchGlob(args[1], name) {
+				channels = append(channels, name)
+			}
+		}
+		slices.Sort(channels)
+		return resp.BulkStrings(channels)
+	case sub == "NUMSUB":
+		m := resp.Map{}
+		for _, name := range args[1:] {
+			m = append(m, resp.MapItem{Key: resp.BulkString(name), Value: resp.Integer(len(ps.channels[name]))})
+		}
+		return m
+	case sub == "NUMPAT" && len(args) == 1:
+		return resp.Integer(len(ps.patterns))
+	default:
+		return resp.NewError(fmt.Errorf("unknown subcommand or wrong number of arguments for '%s'. Try PUBSUB HELP.", args[0]))
+	}
+}
@@ -0,0 +1,215 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/db"
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/resp"
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store/inMemoryStore"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// takes the output queued for the subscribed client
+func queued(cc *ConnContext) string {
+	cc.sub.mu.Lock()
+	defer cc.sub.mu.Unlock()
+	out := string(cc.sub.queue)
+	cc.sub.queue = nil
+	cc.sub.pending = 0
+	return out
+}
+
+func TestPubSubCommands(t *testing.T) {
+	var buf bytes.Buffer
+	s := GetTestServerWithDB()
+	publisher := &ConnContext{}
+	subscriber := &ConnContext{}
+	resp3 := &ConnContext{protocol: resp.RESP3}
+
+	run := func(cc *ConnContext, input string) string {
+		buf.Reset()
+		s.handleCommand(input, &buf, cc)
+		if cc.sub != nil {
+			return queued(cc)
+		}
+		return buf.String()
+	}
+
+	testCases := []struct {
+		cc     *ConnContext
+		input  string
+		expOut string
+	}{
+		{subscriber, "UNSUBSCRIBE", "1) \"unsubscribe\"\n2) (nil)\n3) (integer) 0\n"},
+		{subscriber, "SUBSCRIBE news chat", "1) \"subscribe\"\n2) \"news\"\n3) (integer) 1\n1) \"subscribe\"\n2) \"chat\"\n3) (integer) 2\n"},
+		{subscriber, "PSUBSCRIBE news.*", "1) \"psubscribe\"\n2) \"news.*\"\n3) (integer) 3\n"},
+		{resp3, "SUBSCRIBE news", ">3\r\n$9\r\nsubscribe\r\n$4\r\nnews\r\n:1\r\n"},
+		{publisher, "PUBLISH news hello", "(integer) 2"},
+		{subscriber, "PING", "1) \"pong\"\n2) \"\"\n"},
+		{resp3, "PING", "+PONG\r\n"},
+		{publisher, "PUBLISH news.sports goal", "(integer) 1"},
+		{publisher, "PUBLISH nobody hello", "(integer) 0"},
+		{publisher, "PUBSUB CHANNELS", "1) \"chat\"\n2) \"news\"\n"},
+		{publisher, "PUBSUB CHANNELS n*", "1) \"news\"\n"},
+		{publisher, "PUBSUB NUMSUB news chat nobody", "1# \"news\" => (integer) 2\n2# \"chat\" => (integer) 1\n3# \"nobody\" => (integer) 0\n"},
+		{publisher, "PUBSUB NUMPAT", "(integer) 1"},
+		{subscriber, "GET foo", "(error) ERR Can't execute 'get': only (P)SUBSCRIBE / (P)UNSUBSCRIBE / PING / DISCONNECT are allowed in this context\n"},
+		{resp3, "SET foo bar", "+OK\r\n"},
+		{subscriber, "UNSUBSCRIBE", "1) \"unsubscribe\"\n2) \"chat\"\n3) (integer) 2\n1) \"unsubscribe\"\n2) \"news\"\n3) (integer) 1\n"},
+		{subscriber, "PUNSUBSCRIBE news.*", "1) \"punsubscribe\"\n2) \"news.*\"\n3) (integer) 0\n"},
+		{subscriber, "GET foo", "\"bar\"\n"},
+		{publisher, "PUBSUB NUMPAT", "(integer) 0"},
+		{publisher, "PUBSUB NOPE", "unknown subcommand or wrong number of arguments for 'NOPE'. Try PUBSUB HELP."},
+	}
+
+	for _, tc := range testCases {
+		if out := run(tc.cc, tc.input); !strings.Contains(out, tc.expOut) {
+			t.Errorf("Expected output of %q to contain %q but got %q instead", tc.input, tc.expOut, out)
+		}
+	}
+
+	t.Run("messages reach the subscribers in order", func(t *testing.T) {
+		run(subscriber, "SUBSCRIBE news")
+		run(subscriber, "PSUBSCRIBE n*")
+		run(publisher, "PUBLISH news first")
+		run(publisher, "PUBLISH news second")
+
+		exp := "1) \"message\"\n2) \"news\"\n3) \"first\"\n" +
+			"1) \"pmessage\"\n2) \"n*\"\n3) \"news\"\n4) \"first\"\n" +
+			"1) \"message\"\n2) \"news\"\n3) \"second\"\n" +
+			"1) \"pmessage\"\n2) \"n*\"\n3) \"news\"\n4) \"second\"\n"
+		if out := queued(subscriber); out != exp {
+			t.Errorf("Expected %q but got %q", exp, out)
+		}
+	})
+
+	t.Run("subscriptions aren't allowed in a transaction", func(t *testing.T) {
+		cc := &ConnContext{}
+		for _, input := range []string{"MULTI", "SUBSCRIBE news"} {
+			buf.Reset()
+			s.handleCommand(input, &buf, cc)
+		}
+		if !strings.Contains(buf.String(), ErrNotAllowedInMulti.Error()) || !cc.isTranDiscarded {
+			t.Errorf("Expected the transaction to be discarded but got %q", buf.String())
+		}
+	})
+}
+
+type closeRecorder struct{ closed bool }
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestPubSubOutputBufferLimit(t *testing.T) {
+	var buf bytes.Buffer
+	s := GetTestServerWithDB()
+	if err := s.SetConfig("client-output-buffer-limit", "pubsub 100 0 0"); err != nil {
+		t.Fatalf("Unexpected error occured: %v", err)
+	}
+
+	conn := &closeRecorder{}
+	subscriber := &ConnContext{conn: conn}
+	s.handleCommand("SUBSCRIBE news", &buf, subscriber)
+
+	// the output isn't written since nothing pumps it
+	for range 3 {
+		s.handleCommand("PUBLISH news "+strings.Repeat("x", 40), &buf, &ConnContext{})
+	}
+	if !conn.closed || !subscriber.sub.closed {
+		t.Errorf("Expected the subscriber to be dropped once over the limit")
+	}
+	if out := queued(subscriber); out != "" {
+		t.Errorf("Expected the output to be dropped but got %q", out)
+	}
+
+	expConfig := "normal 0 0 0 slave 268435456 67108864 60 pubsub 100 0 0"
+	if out := configParams["client-output-buffer-limit"].get(s); out != expConfig {
+		t.Errorf("Expected %q but got %q", expConfig, out)
+	}
+}
+
+func TestPubSubOverConn(t *testing.T) {
+	ln := bufconn.Listen(1024)
+	s := &Server{Db: map[int]db.DbInterface{0: db.GetNewDB(inMemoryStore.NewInMemoryStore())}, Listener: ln, TextMode: true}
+	if err := s.SetConfig("client-output-buffer-limit", "pubsub 64kb 0 0"); err != nil {
+		t.Fatalf("Unexpected error occured: %v", err)
+	}
+	go s.Start()
+	defer s.Close()
+
+	dial := func() (*bufio.Reader, func(string)) {
+		conn, err := ln.Dial()
+		if err != nil {
+			t.Fatalf("Failed to dial: %v", err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		return bufio.NewReader(conn), func(input string) { fmt.Fprintln(conn, input) }
+	}
+	readLines := func(r *bufio.Reader, n int) string {
+		var out strings.Builder
+		for range n {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				t.Fatalf("Failed to read: %v", err)
+			}
+			out.WriteString(line)
+		}
+		return out.String()
+	}
+
+	t.Run("messages are delivered while the subscriber waits", func(t *testing.T) {
+		subR, subW := dial()
+		pubR, pubW := dial()
+
+		subW("SUBSCRIBE news")
+		readLines(subR, 3)
+		pubW("PUBLISH news hello")
+		if out := readLines(pubR, 1); out != "(integer) 1\n" {
+			t.Errorf("Expected %q but got %q", "(integer) 1\n", out)
+		}
+		if out := readLines(subR, 3); out != "1) \"message\"\n2) \"news\"\n3) \"hello\"\n" {
+			t.Errorf("Expected the message but got %q", out)
+		}
+	})
+
+	t.Run("a slow subscriber doesn't hold up the publisher and is dropped", func(t *testing.T) {
+		_, subW := dial()
+		pubR, pubW := dial()
+
+		subW("SUBSCRIBE slow")
+		waitFor(t, func() bool {
+			s.pubsub.mu.RLock()
+			defer s.pubsub.mu.RUnlock()
+			return len(s.pubsub.channels["slow"]) == 1
+		})
+
+		// the subscriber never reads, the publisher is served anyway
+		msg := strings.Repeat("x", 1024)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for range 200 {
+				pubW("PUBLISH slow " + msg)
+				readLines(pubR, 1)
+			}
+		}()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Expected the publisher not to be held up by the subscriber")
+		}
+
+		waitFor(t, func() bool {
+			s.pubsub.mu.RLock()
+			defer s.pubsub.mu.RUnlock()
+			return len(s.pubsub.channels["slow"]) == 0
+		})
+	})
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/redisrdb"
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/resp"
+)
+
+const (
+	IMPORTRDB string = "IMPORTRDB"
+	EXPORTRDB string = "EXPORTRDB"
+)
+
+// IMPORTRDB file
+// adds the keys of a dump written by redis-server to the dbs of the same index, the file is a relative path inside dir
+// keys which exist already are replaced, those whose timeout passed are skipped, replies with the number of keys added
+// the whole file is read before any key is added, so a corrupt one changes nothing
+func (s *Server) importrdbAction(path string) resp.Reply {
+	file, err := s.filePath(path)
+	if err != nil {
+		return resp.NewError(err)
+	}
+	dbs, err := redisrdb.LoadFile(file)
+	if err != nil {
+		return resp.NewError(err)
+	}
+
+	idxs := make([]int, 0, len(dbs))
+	for idx := range dbs {
+		if idx < DbRangeMin || idx > DbRangeMax {
+			return resp.NewError(fmt.Errorf("%v, the file has db %d", ErrDBIndexOutOfRange, idx))
+		}
+		idxs = append(idxs, idx)
+	}
+	slices.Sort(idxs)
+
+	// every key is logged as the commands recreating it, after a DEL since it may replace a key of another type
+	n, entries := 0, []aofEntry{}
+	for _, idx := range idxs {
+		added := s.createDb(idx).Import(dbs[idx])
+		n += len(added)
+		if s.aofOn() {
+			for _, e := range added {
+				entries = append(entries, aofEntry{dbIdx: idx, args: []string{DEL, e.Key}})
+				for _, args := range entryCommands(e) {
+					entries = append(entries, aofEntry{dbIdx: idx, args: args})
+				}
+			}
+		}
+	}
+	s.feedAOF(entries, true)
+	return resp.Integer(n)
+}
+
+// EXPORTRDB file
+// writes every db as a dump which redis-server can load, the file is a relative path inside dir, and replies with the number of keys written
+// the dump is written aside and renamed once complete, streams can't be written so a db holding one fails the export
+func (s *Server) exportrdbAction(path string) resp.Reply {
+	file, err := s.filePath(path)
+	if err != nil {
+		return resp.NewError(err)
+	}
+	dbs, _ := s.snapshot()
+	if err := redisrdb.SaveFile(file, dbs); err != nil {
+		return resp.NewError(fmt.Errorf("writing the rdb: %v", err))
+	}
+
+	n := 0
+	for _, entries := range dbs {
+		n += len(entries)
+	}
+	return resp.Integer(n)
+}
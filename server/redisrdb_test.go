@@ -0,0 +1,233 @@
+package server
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/redisrdb"
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store"
+)
+
+// writes a dump like those of redis-server to redis.rdb in dir
+func writeTestRdb(t *testing.T, dir string, dbs map[int][]store.Entry) {
+	t.Helper()
+	if err := redisrdb.SaveFile(filepath.Join(dir, "redis.rdb"), dbs); err != nil {
+		t.Fatalf("Failed to write the rdb: %v", err)
+	}
+}
+
+func TestImportRdb(t *testing.T) {
+	inHour := time.Now().Add(time.Hour).UnixMilli()
+	z := store.NewZSet()
+	z.Add("a", 1.5)
+	dbs := map[int][]store.Entry{
+		0: {
+			{Key: "str", Value: store.String("new")},
+			{Key: "list", Value: store.NewList("a", "b")},
+			{Key: "ttl", Value: store.String("soon"), ExpireAt: inHour},
+			{Key: "gone", Value: store.String("old"), ExpireAt: 1000},
+		},
+		3: {
+			{Key: "hash", Value: store.Hash{"f": "v"}},
+			{Key: "zset", Value: z},
+			{Key: "set", Value: store.Set{"m": {}}},
+		},
+	}
+
+	testCases := []struct {
+		name     string
+		dbs      map[int][]store.Entry
+		inputArr []string
+		expOut   []string
+	}{
+		{
+			name: "keys added to the dbs of the file",
+			dbs:  dbs,
+			inputArr: []string{"SET str old", "SET keep 1", "IMPORTRDB redis.rdb", "GET str", "GET keep", "LRANGE list 0 -1", "GET gone",
+				"SELECT 3", "HGET hash f", "ZSCORE zset a", "SISMEMBER set m"},
+			expOut: []string{MssgOK, MssgOK, "(integer) 6", "\"new\"", "\"1\"", "1) \"a\"\n2) \"b\"", "(nil)",
+				MssgOK, "\"v\"", "1.5", "(integer) 1"},
+		},
+		{
+			name:     "timeouts kept",
+			dbs:      dbs,
+			inputArr: []string{"IMPORTRDB redis.rdb", "TTL ttl", "TTL str"},
+			expOut:   []string{"(integer) 6", "(integer) 3", "(integer) -1"},
+		},
+		{
+			name:     "key of another type replaced",
+			dbs:      map[int][]store.Entry{0: {{Key: "k", Value: store.String("v")}}},
+			inputArr: []string{"RPUSH k a", "IMPORTRDB redis.rdb", "GET k"},
+			expOut:   []string{"(integer) 1", "(integer) 1", "\"v\""},
+		},
+		{
+			name:     "db out of range",
+			dbs:      map[int][]store.Entry{0: {{Key: "a", Value: store.String("1")}}, 16: {{Key: "b", Value: store.String("2")}}},
+			inputArr: []string{"IMPORTRDB redis.rdb", "GET a"},
+			expOut:   []string{"(error) ERR DB index is out of range, the file has db 16", "(nil)"},
+		},
+		{
+			name:     "missing file",
+			inputArr: []string{"IMPORTRDB missing.rdb"},
+			expOut:   []string{"no such file or directory"},
+		},
+		{
+			name:     "file outside dir",
+			dbs:      dbs,
+			inputArr: []string{"IMPORTRDB ../redis.rdb", "IMPORTRDB /etc/passwd", "GET str"},
+			expOut:   []string{"(error) ERR " + ErrFileOutsideDir.Error(), "(error) ERR " + ErrFileOutsideDir.Error(), "(nil)"},
+		},
+		{
+			name:     "in a tran",
+			dbs:      dbs,
+			inputArr: []string{"MULTI", "IMPORTRDB redis.rdb", "EXEC", "GET str"},
+			expOut:   []string{MssgOK, "(error) ERR Command not allowed inside a transaction", "EXECABORT", "(nil)"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tc.dbs != nil {
+				writeTestRdb(t, dir, tc.dbs)
+			}
+			s := getTestServerWithDir(t, dir)
+			cc := &ConnContext{}
+
+			for i, input := range tc.inputArr {
+				var buf bytes.Buffer
+				s.handleCommand(input, &buf, cc)
+
+				if !strings.Contains(buf.String(), tc.expOut[i]) {
+					t.Errorf("Expected %q but got %q", tc.expOut[i], buf.String())
+				}
+			}
+		})
+	}
+}
+
+func TestImportRdbNotRdb(t *testing.T) {
+	dir := t.TempDir()
+	s := getTestServerWithDir(t, dir)
+	var buf bytes.Buffer
+
+	// a snapshot of this server isn't a dump of redis
+	s.handleCommand("SET a 1", &buf, &ConnContext{})
+	s.handleCommand("SAVE", &buf, &ConnContext{})
+	buf.Reset()
+	s.handleCommand("IMPORTRDB dump.goredis", &buf, &ConnContext{})
+	if exp := "(error) ERR not a redis rdb file\n"; buf.String() != exp {
+		t.Errorf("Expected %q but got %q", exp, buf.String())
+	}
+}
+
+func TestImportRdbAOF(t *testing.T) {
+	var buf bytes.Buffer
+	dir := t.TempDir()
+	inHour := time.Now().Add(time.Hour).UnixMilli()
+	writeTestRdb(t, dir, map[int][]store.Entry{
+		0: {{Key: "str", Value: store.String("new"), ExpireAt: inHour}},
+		2: {{Key: "list", Value: store.NewList("a", "b")}},
+	})
+
+	s := getTestServerWithAOF(t, dir)
+	cc := &ConnContext{}
+	s.handleCommand("SET str old", &buf, cc)
+	s.handleCommand("IMPORTRDB redis.rdb", &buf, cc)
+	s.Close()
+
+	exp := []string{"SELECT 0", "SET str old", "MULTI", "DEL str", "SET str new", "PEXPIREAT str " + strconv.FormatInt(inHour, 10),
+		"SELECT 2", "DEL list", "RPUSH list a b", "EXEC"}
+	if got := readAOF(t, dir); !reflect.DeepEqual(got, exp) {
+		t.Errorf("Expected %q but got %q", exp, got)
+	}
+
+	loaded := getTestServerWithAOF(t, dir)
+	defer loaded.Close()
+	if exp, got := dumpDbs(t, s), dumpDbs(t, loaded); !reflect.DeepEqual(got, exp) {
+		t.Errorf("Expected %q but got %q", exp, got)
+	}
+}
+
+func TestExportRdb(t *testing.T) {
+	var buf bytes.Buffer
+	dir := t.TempDir()
+	s := getTestServerWithDir(t, dir)
+	cc := &ConnContext{}
+
+	at := time.Now().Add(time.Hour).UnixMilli()
+	for _, input := range []string{
+		"SET str hello", "SET n 42", "SET ttl v PXAT " + strconv.FormatInt(at, 10), "RPUSH list a b c", "SELECT 2",
+		"SADD set a b", "HSET hash f v", "ZADD z 1.5 a -2 b",
+	} {
+		s.handleCommand(input, &buf, cc)
+	}
+
+	buf.Reset()
+	s.handleCommand("EXPORTRDB out.rdb", &buf, cc)
+	if exp := "(integer) 7\n"; buf.String() != exp {
+		t.Fatalf("Expected %q but got %q", exp, buf.String())
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "out.rdb"))
+	if err != nil {
+		t.Fatalf("Failed to read the rdb: %v", err)
+	}
+	if !bytes.HasPrefix(data, []byte("REDIS0009")) {
+		t.Errorf("Expected a redis rdb but got %q", data)
+	}
+
+	// importing the dump into an empty server gives the same keys
+	loaded := getTestServerWithDir(t, dir)
+	buf.Reset()
+	loaded.handleCommand("IMPORTRDB out.rdb", &buf, &ConnContext{})
+	if exp := "(integer) 7\n"; buf.String() != exp {
+		t.Fatalf("Expected %q but got %q", exp, buf.String())
+	}
+	if exp, got := dumpDbs(t, s), dumpDbs(t, loaded); !reflect.DeepEqual(got, exp) {
+		t.Errorf("Expected %q but got %q", exp, got)
+	}
+
+	// streams can't be exported, the dump written before is kept
+	buf.Reset()
+	s.handleCommand("XADD s 1-1 f v", &buf, cc)
+	buf.Reset()
+	s.handleCommand("EXPORTRDB out.rdb", &buf, cc)
+	if exp := "unsupported value type, can't save the stream value of key \"s\""; !strings.Contains(buf.String(), exp) {
+		t.Errorf("Expected %q but got %q", exp, buf.String())
+	}
+	if after, _ := os.ReadFile(filepath.Join(dir, "out.rdb")); !bytes.Equal(after, data) {
+		t.Errorf("Expected the previous dump to be kept")
+	}
+
+	// files outside dir are refused
+	for _, path := range []string{"../out.rdb", filepath.Join(dir, "abs.rdb")} {
+		buf.Reset()
+		s.handleCommand("EXPORTRDB "+path, &buf, cc)
+		if exp := "(error) ERR " + ErrFileOutsideDir.Error() + "\n"; buf.String() != exp {
+			t.Errorf("Expected %q but got %q", exp, buf.String())
+		}
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dir), "out.rdb")); err == nil {
+		t.Errorf("Expected no dump written outside dir")
+	}
+
+	// nor can dir be moved by a client to reach them, the files are still those of dir
+	buf.Reset()
+	for _, input := range []string{"CONFIG SET dir " + filepath.Dir(dir), "DEL s", "EXPORTRDB out.rdb", "IMPORTRDB out.rdb"} {
+		s.handleCommand(input, &buf, cc)
+	}
+	exp := "(error) ERR CONFIG SET failed (possibly related to argument 'dir') - " + ErrProtectedConfig.Error() + "\n(integer) 1\n(integer) 7\n(integer) 7\n"
+	if buf.String() != exp {
+		t.Errorf("Expected %q but got %q", exp, buf.String())
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dir), "out.rdb")); err == nil {
+		t.Errorf("Expected no dump written outside dir")
+	}
+}
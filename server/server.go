@@ -1,15 +1,23 @@
 package server
 
 import (
+	"bufio"
 	"errors"
 	"fmt"
 	"io"
+	"maps"
+	"math"
 	"net"
-	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/justsushant/one2n-go-bootcamp/go-redis/db"
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/resp"
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store"
 	"github.com/justsushant/one2n-go-bootcamp/go-redis/store/inMemoryStore"
 )
 
@@ -20,8 +28,11 @@ var (
 	ErrDiscardWithoutMulti       = errors.New("discard without multi")
 	ErrTranAbortedDueToPrevError = errors.New("transaction discarded because of previous errors")
 	ErrMultiCommandNested        = errors.New("multi calls can not be nested")
-	ErrDBIndexOutOfRange         = errors.New("(error) ERR DB index is out of range")
+	ErrDBIndexOutOfRange         = errors.New("DB index is out of range")
 	ErrKeyNotFound               = errors.New("failed to find the key")
+	ErrNoProto                   = errors.New("NOPROTO unsupported protocol version")
+	ErrProtoVerNotInteger        = errors.New("Protocol version is not an integer or out of range")
+	ErrSyntax                    = errors.New("syntax error")
 )
 
 const (
@@ -39,49 +50,345 @@ const (
 	PONG           string = "PONG"
 	DISCONNECT     string = "DISCONNECT"
 	SELECT         string = "SELECT"
-	MssgEmptyArray string = "(empty array)"
+	HELLO          string = "HELLO"
+	CONFIG         string = "CONFIG"
+	TYPE           string = "TYPE"
+	MssgEmptyArray string = resp.MssgEmptyArray
 	MssgOK         string = "OK"
-	MssgNil        string = "(nil)"
+	MssgNil        string = resp.MssgNil
 	DbRangeMin     int    = 0
 	DbRangeMax     int    = 15
+	RedisVersion   string = "7.2.0" // version of redis whose behaviour is cloned, reported by HELLO
+
+	replyBufferSize int = 16 * 1024 // replies buffered before being written to the conn
 )
 
+// number of args each command takes including its name
+// negative arity means at least that many args
+var commandArity = map[string]int{
+	SELECT:     2,
+	PING:       1,
+	GET:        2,
+	SET:        -3,
+	DEL:        2,
+	INCR:       2,
+	INCRBY:     3,
+	MULTI:      1,
+	EXEC:       1,
+	DISCARD:    1,
+	COMPACT:    -1,
+	DISCONNECT: 1,
+	HELLO:      -1,
+	CONFIG:     -2,
+	TYPE:       2,
+	WATCH:      -2,
+	UNWATCH:    1,
+	FLUSHDB:    -1,
+	FLUSHALL:   -1,
+
+	EXPIRE:      -3,
+	PEXPIRE:     -3,
+	EXPIREAT:    -3,
+	PEXPIREAT:   -3,
+	TTL:         2,
+	PTTL:        2,
+	EXPIRETIME:  2,
+	PEXPIRETIME: 2,
+	PERSIST:     2,
+
+	LPUSH:   -3,
+	RPUSH:   -3,
+	LPOP:    -2,
+	RPOP:    -2,
+	LRANGE:  4,
+	LLEN:    2,
+	LINDEX:  3,
+	LSET:    4,
+	LREM:    4,
+	LTRIM:   4,
+	LINSERT: 5,
+	LPOS:    -3,
+	LMOVE:   5,
+
+	HSET:         -4,
+	HSETNX:       4,
+	HGET:         3,
+	HMGET:        -3,
+	HDEL:         -3,
+	HLEN:         2,
+	HEXISTS:      3,
+	HGETALL:      2,
+	HKEYS:        2,
+	HVALS:        2,
+	HINCRBY:      4,
+	HINCRBYFLOAT: 4,
+	HRANDFIELD:   -2,
+	HSCAN:        -3,
+
+	SADD:        -3,
+	SREM:        -3,
+	SMEMBERS:    2,
+	SISMEMBER:   3,
+	SMISMEMBER:  -3,
+	SCARD:       2,
+	SPOP:        -2,
+	SRANDMEMBER: -2,
+	SINTER:      -2,
+	SUNION:      -2,
+	SDIFF:       -2,
+	SINTERCARD:  -3,
+	SINTERSTORE: -3,
+	SUNIONSTORE: -3,
+	SDIFFSTORE:  -3,
+
+	ZADD:        -4,
+	ZREM:        -3,
+	ZSCORE:      3,
+	ZMSCORE:     -3,
+	ZINCRBY:     4,
+	ZCARD:       2,
+	ZCOUNT:      4,
+	ZRANK:       -3,
+	ZREVRANK:    -3,
+	ZRANGE:      -4,
+	ZRANGESTORE: -5,
+	ZPOPMIN:     -2,
+	ZPOPMAX:     -2,
+	ZUNIONSTORE: -4,
+	ZINTERSTORE: -4,
+
+	XADD:       -5,
+	XLEN:       2,
+	XRANGE:     -4,
+	XREVRANGE:  -4,
+	XDEL:       -3,
+	XTRIM:      -4,
+	XREAD:      -4,
+	XGROUP:     -2,
+	XREADGROUP: -7,
+	XACK:       -4,
+	XPENDING:   -3,
+	XCLAIM:     -6,
+	XAUTOCLAIM: -6,
+	XINFO:      -2,
+
+	BLPOP:    -3,
+	BRPOP:    -3,
+	BLMOVE:   6,
+	BZPOPMIN: -3,
+	BZPOPMAX: -3,
+	CLIENT:   -2,
+
+	PUBLISH:      3,
+	SUBSCRIBE:    -2,
+	UNSUBSCRIBE:  -1,
+	PSUBSCRIBE:   -2,
+	PUNSUBSCRIBE: -1,
+	PUBSUB:       -2,
+
+	SAVE:     1,
+	BGSAVE:   1,
+	LASTSAVE: 1,
+	INFO:     -1,
+
+	LOADCOMMANDS: 2,
+	BGREWRITEAOF: 1,
+
+	IMPORTRDB: 2,
+	EXPORTRDB: 2,
+}
+
+// commands run apart from those of other clients
+// a tran must not interleave with other commands, while a snapshot must see every db at the same point
+// CONFIG takes one as well when appendonly is turned on, and so do the dumps written and loaded by COMPACT and LOADCOMMANDS
+// BGREWRITEAOF switches to a new incremental file at the point its base is taken, IMPORTRDB and EXPORTRDB work on every db at once
+var exclusiveCommands = []string{EXEC, SAVE, BGSAVE, CONFIG, COMPACT, LOADCOMMANDS, BGREWRITEAOF, IMPORTRDB, EXPORTRDB}
+
+// commands which may change the keys
+var writeCommands = map[string]bool{
+	SET: true, DEL: true, INCR: true, INCRBY: true, FLUSHDB: true, FLUSHALL: true,
+	EXPIRE: true, PEXPIRE: true, EXPIREAT: true, PEXPIREAT: true, PERSIST: true,
+	LPUSH: true, RPUSH: true, LPOP: true, RPOP: true, LSET: true, LREM: true, LTRIM: true, LINSERT: true, LMOVE: true,
+	HSET: true, HSETNX: true, HDEL: true, HINCRBY: true, HINCRBYFLOAT: true,
+	SADD: true, SREM: true, SPOP: true, SINTERSTORE: true, SUNIONSTORE: true, SDIFFSTORE: true,
+	ZADD: true, ZREM: true, ZINCRBY: true, ZRANGESTORE: true, ZPOPMIN: true, ZPOPMAX: true, ZUNIONSTORE: true, ZINTERSTORE: true,
+	XADD: true, XDEL: true, XTRIM: true, XGROUP: true, XREADGROUP: true, XACK: true, XCLAIM: true, XAUTOCLAIM: true,
+	BLPOP: true, BRPOP: true, BLMOVE: true, BZPOPMIN: true, BZPOPMAX: true,
+	LOADCOMMANDS: true, IMPORTRDB: true,
+}
+
 type Command struct {
 	name string
-	key  string
-	val  string
+	args []string
 }
 
 func (c *Command) String() string {
-	return fmt.Sprintf("%s %s %s", c.name, c.key, c.val)
+	return strings.Join(append([]string{c.name}, c.args...), " ")
 }
 
 type ConnContext struct {
-	isMulti         bool      // to check if multi tran in progress
-	multiCommandArr []Command // to store commands of multi tran
-	isTranDiscarded bool      // to check if multi tran was discarded
-	dbIdx           int       // to store the db index
+	isMulti         bool           // to check if multi tran in progress
+	multiCommandArr []Command      // to store commands of multi tran
+	isTranDiscarded bool           // to check if multi tran was discarded
+	dbIdx           int            // to store the db index
+	protocol        resp.Protocol  // to store the reply protocol negotiated by HELLO
+	id              int64          // to identify the client
+	name            string         // to store the name set by HELLO SETNAME
+	isClosing       bool           // to close the conn once the pending replies are sent
+	hangup          *hangupWatcher // to unblock the client if it hangs up while blocked, nil outside a conn
+	conn            io.Closer      // to drop the client, nil outside a conn
+	sub             *subscriber    // to queue the replies along the published messages once subscribed
+	watched         []watchedKey   // to fail EXEC if any of the keys is touched
+
+	parked  func() resp.Reply  // to wait for the reply of a blocking command off the executor
+	replied func(r resp.Reply) // to hand the replies to the caller rather than writing them, used to replay the AOF
 }
 
 type Server struct {
-	Db       map[int]db.DbInterface
-	Listener net.Listener
+	Db           map[int]db.DbInterface // dbs by index, SELECT adds the missing ones so they're read through getDb
+	Listener     net.Listener
+	TextMode     bool // replies in the human readable format instead of RESP, handy for netcat users
+	lastClientID atomic.Int64
+	config       config
+	blocking     blockingState
+	pubsub       pubsubState
+	persistence  persistenceState
+	aof          aofState
+
+	// commands are applied by a single executor in the order they arrive, like redis does
+	// the conns only parse the requests and write the replies
+	SingleThreaded bool
+	executor       chan func() // commands handed to the executor, set up by init
+
+	// commands run holding the read lock, along those of other clients, unless SingleThreaded
+	// exclusiveCommands like EXEC hold the write lock, so the commands of a tran can't interleave with those of other clients
+	execMu sync.RWMutex
+	dbMu   sync.RWMutex // guards Db
+	jobsMu sync.Mutex   // guards the start of background jobs against Close waiting for them
+	closed bool         // no more background jobs are started once set, guarded by jobsMu
+
+	attached map[int]bool // dbs set up by attachDb, guarded by dbMu
+
+	initOnce  sync.Once
+	closeOnce sync.Once
+	quit      chan struct{}  // closed on shutdown to stop the background jobs
+	jobs      sync.WaitGroup // background jobs, like the active expiry of every db
 }
 
 // starts the server
 // entrypoint for the app
 func (s *Server) Start() {
+	s.init()
+
+	// the dbs given upfront are set up like the ones created by SELECT
+	s.dbMu.Lock()
+	for idx, d := range s.Db {
+		s.attachDb(idx, d)
+	}
+	s.dbMu.Unlock()
+	s.startAOF()
+	s.startSaveRules()
+
 	for {
 		conn, err := s.Listener.Accept()
 		if err != nil {
-			fmt.Fprintf(conn, "Error while accepting connection: %v", err)
+			// listener is closed on shutdown
+			select {
+			case <-s.quit:
+				return
+			default:
+			}
+			fmt.Printf("Error while accepting connection: %v\n", err)
+			continue
 		}
 
 		// launching new go routine for each connection
-		go s.handleConnection(conn, &ConnContext{})
+		go s.handleConnection(conn, &ConnContext{id: s.lastClientID.Add(1)})
 	}
 }
 
+// stops accepting connections and waits for the background jobs to stop
+func (s *Server) Close() error {
+	s.init()
+
+	var err error
+	s.closeOnce.Do(func() {
+		close(s.quit)
+		if s.Listener != nil {
+			err = s.Listener.Close()
+		}
+
+		s.jobsMu.Lock()
+		s.closed = true
+		s.jobsMu.Unlock()
+		s.jobs.Wait()
+
+		// the writes logged so far are synced
+		s.aof.mu.Lock()
+		s.closeAOFLocked()
+		s.aof.mu.Unlock()
+	})
+	return err
+}
+
+// returns the db with the given index, it exists once selected
+func (s *Server) getDb(idx int) db.DbInterface {
+	s.dbMu.RLock()
+	defer s.dbMu.RUnlock()
+	return s.Db[idx]
+}
+
+// returns the db with the given index, creating it if it's not there yet
+func (s *Server) createDb(idx int) db.DbInterface {
+	s.dbMu.Lock()
+	defer s.dbMu.Unlock()
+	if _, ok := s.Db[idx]; !ok {
+		s.Db[idx] = db.GetNewDB(inMemoryStore.NewInMemoryStore())
+		s.attachDb(idx, s.Db[idx])
+	}
+	return s.Db[idx]
+}
+
+// returns a copy of Db, which can be ranged over while SELECT adds dbs
+func (s *Server) allDbs() map[int]db.DbInterface {
+	s.dbMu.RLock()
+	defer s.dbMu.RUnlock()
+	return maps.Clone(s.Db)
+}
+
+// runs job in the background, Close waits for it to return
+// false is returned if the server is closed already, job isn't run then
+func (s *Server) startJob(job func()) bool {
+	s.init()
+	s.jobsMu.Lock()
+	defer s.jobsMu.Unlock()
+	if s.closed {
+		return false
+	}
+	s.jobs.Add(1)
+
+	go func() {
+		defer s.jobs.Done()
+		job()
+	}()
+	return true
+}
+
+// sets up the state needed by the background jobs
+// done lazily since the server is built as a struct literal
+func (s *Server) init() {
+	s.initOnce.Do(func() {
+		s.quit = make(chan struct{})
+		s.persistence.lastSave = time.Now().Unix()
+		s.persistence.bgsaveDuration = -1
+		if s.SingleThreaded {
+			s.executor = make(chan func())
+			s.jobs.Add(1)
+			go s.runExecutor()
+		}
+	})
+}
+
 // start method for cli application
 // func (s *Server) Start() {
 //     // infinite loop to accept commands until an exit command is issued
@@ -112,18 +419,52 @@ func (s *Server) Start() {
 func (s *Server) handleConnection(conn net.Conn, cc *ConnContext) {
 	defer conn.Close()
 
-	buf := make([]byte, 1024)
-	for {
-		n, err := conn.Read(buf)
+	// netcat users get the human readable replies, the requests are parsed the same way
+	if !s.TextMode {
+		cc.protocol = resp.RESP2
+	}
+
+	// replies are collected in w and flushed once every pipelined command read so far is served
+	r := resp.NewReader(conn)
+	w := &syncWriter{w: bufio.NewWriterSize(conn, replyBufferSize)}
+	defer w.Flush()
+	cc.hangup = newHangupWatcher(r)
+	cc.conn = conn
+	defer s.unsubscribeAll(cc)
+	defer s.unwatchAll(cc)
+	pumping := false
+
+	for !cc.isClosing {
+		// the conn of a client which was blocked may still be watched
+		cc.hangup.wait()
+
+		// picks up the changes done by CONFIG SET
+		r.MaxBulkLen = s.config.getMaxBulkLen()
+
+		args, err := r.ReadCommand()
 		if err != nil {
-			if err != io.EOF {
-				fmt.Fprintln(conn, "Error reading:", err.Error())
+			// client is sent the reason before closing the conn, like redis does
+			if errors.Is(err, resp.ErrProtocol) {
+				s.writeReply(w, cc, resp.NewError(err))
 			}
 			break
 		}
 
-		// starts acting on the command
-		s.handleCommand(string(buf[:n]), conn, cc)
+		s.handleRequest(args, w, cc)
+
+		// once subscribed, the output of the client is written as it's queued
+		if cc.sub != nil && !pumping {
+			go cc.sub.pump(w)
+			pumping = true
+		}
+
+		// more commands waiting in the read buffer are served before flushing
+		// the reply of a blocked command is sent right away, the conn is watched so the buffer can't be checked
+		if cc.hangup.watching() || r.Buffered() == 0 {
+			if err := w.Flush(); err != nil {
+				break
+			}
+		}
 	}
 }
 
@@ -132,21 +473,55 @@ func (s *Server) handleCommand(input string, out io.Writer, cc *ConnContext) {
 	// parse the input command
 	i, err := s.stringSplit(input)
 	if err != nil {
-		fmt.Fprintln(out, err)
+		s.writeReply(out, cc, resp.NewError(err))
 		return
 	}
 
+	s.handleRequest(i, out, cc)
+}
+
+// takes action on an already split command and writes the reply
+func (s *Server) handleRequest(i []string, out io.Writer, cc *ConnContext) {
 	// convert raw command into command type
 	c, err := s.makeCommand(i, cc)
 	if err != nil {
-		fmt.Fprintln(out, err)
+		s.writeReply(out, cc, resp.NewError(err))
 		return
 	}
 
-	// handling disconnect
+	// handling disconnect, conn is closed by the connection loop
 	if c.name == DISCONNECT {
-		if conn, ok := out.(net.Conn); ok {
-			conn.Close()
+		cc.isClosing = true
+		return
+	}
+
+	// RESP2 clients can only manage their subscriptions while subscribed, DISCONNECT was handled above
+	if cc.sub != nil && cc.sub.count() > 0 && cc.protocol != resp.RESP3 && c.name != PING && !slices.Contains(subscriptionCommands, c.name) {
+		s.writeReply(out, cc, resp.NewError(fmt.Errorf("Can't execute '%s': only (P)SUBSCRIBE / (P)UNSUBSCRIBE / PING / DISCONNECT are allowed in this context", strings.ToLower(c.name))))
+		return
+	}
+
+	// replies of the subscription commands are queued right away, so they can't be part of a tran
+	// neither can LOADCOMMANDS, which stops halfway through the dump if a command fails, nor IMPORTRDB which logs its keys itself
+	if cc.isMulti && (slices.Contains(subscriptionCommands, c.name) || c.name == LOADCOMMANDS || c.name == IMPORTRDB) {
+		cc.isTranDiscarded = true
+		s.writeReply(out, cc, resp.NewError(ErrNotAllowedInMulti))
+		return
+	}
+
+	// keys are watched before the tran starts, the tran goes on
+	if cc.isMulti && c.name == WATCH {
+		s.writeReply(out, cc, resp.NewError(ErrWatchInMulti))
+		return
+	}
+
+	// writes are refused while the snapshots or the append only file fail, a tran with such a write fails as a whole
+	if writeCommands[c.name] {
+		if err := s.writesRefused(); err != nil {
+			if cc.isMulti {
+				cc.isTranDiscarded = true
+			}
+			s.writeReply(out, cc, resp.NewError(err))
 			return
 		}
 	}
@@ -154,32 +529,71 @@ func (s *Server) handleCommand(input string, out io.Writer, cc *ConnContext) {
 	// only add commands to multi tran if isMulti is ON & if they aren't commands related to multi
 	if cc.isMulti && c.name != EXEC && c.name != DISCARD && c.name != MULTI {
 		cc.multiCommandArr = append(cc.multiCommandArr, c)
-		fmt.Fprintln(out, QUEUED)
+		s.writeReply(out, cc, resp.SimpleString(QUEUED))
 		return
 	}
 
+	// writes are logged in the order they're applied, so they run apart from each other while the AOF is on
+	// commands which block on keys can't, they log their write once served
+	exclusive := slices.Contains(exclusiveCommands, c.name) || (writeCommands[c.name] && !blocksOnKeys(c) && s.aofOn())
+
 	// take appropriate action
-	resp := s.takeAction(cc, c)
-	fmt.Fprintln(out, resp)
+	var reply resp.Reply
+	ran := s.execute(exclusive, func() {
+		reply = s.takeAction(cc, c)
+		if c.name != EXEC && !blocksOnKeys(c) {
+			s.propagate(cc.dbIdx, c, reply)
+		}
+
+		// clients blocked on the keys written by the command are served before the next one
+		s.serveBlocked()
+	})
+	if !ran {
+		reply = resp.NewError(ErrShuttingDown)
+	}
+
+	// a client parked by the executor waits here, so the commands of other clients go on meanwhile
+	if cc.parked != nil {
+		reply = cc.parked()
+		cc.parked = nil
+	}
+
+	// the reply is written once the lock is released, so a slow client doesn't hold up an EXEC
+	s.writeReply(out, cc, reply)
+}
+
+// encodes the reply in the protocol of the connection
+// replies of subscribed clients are queued along the messages, nil replies were queued by the command itself
+func (s *Server) writeReply(out io.Writer, cc *ConnContext, r resp.Reply) {
+	switch {
+	case r == nil:
+	case cc.replied != nil:
+		cc.replied(r)
+	case cc.sub != nil:
+		cc.sub.setProtocol(cc.protocol)
+		cc.sub.send(r, s.config.getOutputBufferLimit(pubsubClass))
+	default:
+		resp.NewWriter(out, cc.protocol).WriteReply(r)
+	}
 }
 
 // takes action based on the command name
-func (s *Server) takeAction(cc *ConnContext, c Command) string {
+func (s *Server) takeAction(cc *ConnContext, c Command) resp.Reply {
 	switch c.name {
 	case PING:
-		return s.pingAction()
+		return s.pingAction(cc)
 	case SELECT:
-		return s.selectAction(cc, c.val)
+		return s.selectAction(cc, c.args[0])
 	case SET:
-		return s.setAction(cc, c.key, c.val)
+		return s.setAction(cc, c.args)
 	case GET:
-		return s.getAction(cc, c.key)
+		return s.getAction(cc, c.args[0])
 	case DEL:
-		return s.delAction(cc, c.key)
+		return s.delAction(cc, c.args[0])
 	case INCR:
-		return s.incrAction(cc, c.key)
+		return s.incrAction(cc, c.args[0])
 	case INCRBY:
-		return s.incrbyAction(cc, c.key, c.val)
+		return s.incrbyAction(cc, c.args[0], c.args[1])
 	case MULTI:
 		return s.multiAction(cc)
 	case EXEC:
@@ -187,308 +601,526 @@ func (s *Server) takeAction(cc *ConnContext, c Command) string {
 	case DISCARD:
 		return s.discardAction(cc)
 	case COMPACT:
-		return s.compactAction(cc)
+		return s.compactAction(cc, c.args)
+	case LOADCOMMANDS:
+		return s.loadcommandsAction(cc, c.args[0])
+	case IMPORTRDB:
+		return s.importrdbAction(c.args[0])
+	case EXPORTRDB:
+		return s.exportrdbAction(c.args[0])
+	case HELLO:
+		return s.helloAction(cc, c.args)
+	case CONFIG:
+		return s.configAction(c.args)
+	case EXPIRE, PEXPIRE, EXPIREAT, PEXPIREAT:
+		return s.expireAction(cc, c)
+	case TTL, PTTL:
+		return s.ttlAction(cc, c)
+	case EXPIRETIME, PEXPIRETIME:
+		return s.expireTimeAction(cc, c)
+	case PERSIST:
+		return s.persistAction(cc, c.args[0])
+	case TYPE:
+		return resp.SimpleString(s.getDb(cc.dbIdx).Type(c.args[0]))
+	case WATCH:
+		return s.watchAction(cc, c.args)
+	case UNWATCH:
+		return s.unwatchAction(cc)
+	case FLUSHDB, FLUSHALL:
+		return s.flushAction(cc, c)
+	case LPUSH, RPUSH:
+		return s.pushAction(cc, c)
+	case LPOP, RPOP:
+		return s.popAction(cc, c)
+	case LRANGE:
+		return s.lrangeAction(cc, c.args)
+	case LLEN:
+		return s.llenAction(cc, c.args[0])
+	case LINDEX:
+		return s.lindexAction(cc, c.args)
+	case LSET:
+		return s.lsetAction(cc, c.args)
+	case LREM:
+		return s.lremAction(cc, c.args)
+	case LTRIM:
+		return s.ltrimAction(cc, c.args)
+	case LINSERT:
+		return s.linsertAction(cc, c.args)
+	case LPOS:
+		return s.lposAction(cc, c.args)
+	case LMOVE:
+		return s.lmoveAction(cc, c.args)
+	case HSET:
+		return s.hsetAction(cc, c)
+	case HSETNX:
+		return s.hsetnxAction(cc, c.args)
+	case HGET:
+		return s.hgetAction(cc, c.args)
+	case HMGET:
+		return s.hmgetAction(cc, c.args)
+	case HDEL:
+		return s.hdelAction(cc, c.args)
+	case HLEN:
+		return s.hlenAction(cc, c.args[0])
+	case HEXISTS:
+		return s.hexistsAction(cc, c.args)
+	case HGETALL, HKEYS, HVALS:
+		return s.hgetallAction(cc, c)
+	case HINCRBY:
+		return s.hincrbyAction(cc, c.args)
+	case HINCRBYFLOAT:
+		return s.hincrbyfloatAction(cc, c.args)
+	case HRANDFIELD:
+		return s.hrandfieldAction(cc, c.args)
+	case HSCAN:
+		return s.hscanAction(cc, c.args)
+	case SADD:
+		return s.saddAction(cc, c.args)
+	case SREM:
+		return s.sremAction(cc, c.args)
+	case SMEMBERS:
+		return s.smembersAction(cc, c.args[0])
+	case SISMEMBER, SMISMEMBER:
+		return s.sismemberAction(cc, c)
+	case SCARD:
+		return s.scardAction(cc, c.args[0])
+	case SPOP:
+		return s.spopAction(cc, c)
+	case SRANDMEMBER:
+		return s.srandmemberAction(cc, c)
+	case SINTER, SUNION, SDIFF:
+		return s.setAlgebraAction(cc, c)
+	case SINTERSTORE, SUNIONSTORE, SDIFFSTORE:
+		return s.setAlgebraStoreAction(cc, c)
+	case SINTERCARD:
+		return s.sintercardAction(cc, c.args)
+	case ZADD:
+		return s.zaddAction(cc, c.args)
+	case ZINCRBY:
+		return s.zincrbyAction(cc, c.args)
+	case ZREM:
+		return s.zremAction(cc, c.args)
+	case ZSCORE:
+		return s.zscoreAction(cc, c.args)
+	case ZMSCORE:
+		return s.zmscoreAction(cc, c.args)
+	case ZCARD:
+		return s.zcardAction(cc, c.args[0])
+	case ZCOUNT:
+		return s.zcountAction(cc, c.args)
+	case ZRANK, ZREVRANK:
+		return s.zrankAction(cc, c)
+	case ZRANGE:
+		return s.zrangeAction(cc, c.args)
+	case ZRANGESTORE:
+		return s.zrangestoreAction(cc, c.args)
+	case ZPOPMIN, ZPOPMAX:
+		return s.zpopAction(cc, c)
+	case ZUNIONSTORE, ZINTERSTORE:
+		return s.zsetAlgebraStoreAction(cc, c)
+	case XADD:
+		return s.xaddAction(cc, c)
+	case XLEN:
+		return s.xlenAction(cc, c.args[0])
+	case XRANGE, XREVRANGE:
+		return s.xrangeAction(cc, c)
+	case XDEL:
+		return s.xdelAction(cc, c.args)
+	case XTRIM:
+		return s.xtrimAction(cc, c.args)
+	case XREAD:
+		return s.xreadAction(cc, c)
+	case XGROUP:
+		return s.xgroupAction(cc, c.args)
+	case XREADGROUP:
+		return s.xreadgroupAction(cc, c)
+	case XACK:
+		return s.xackAction(cc, c.args)
+	case XPENDING:
+		return s.xpendingAction(cc, c.args)
+	case XCLAIM:
+		return s.xclaimAction(cc, c.args)
+	case XAUTOCLAIM:
+		return s.xautoclaimAction(cc, c.args)
+	case XINFO:
+		return s.xinfoAction(cc, c.args)
+	case BLPOP, BRPOP:
+		return s.blpopAction(cc, c)
+	case BLMOVE:
+		return s.blmoveAction(cc, c)
+	case BZPOPMIN, BZPOPMAX:
+		return s.bzpopAction(cc, c)
+	case CLIENT:
+		return s.clientAction(cc, c.args)
+	case PUBLISH:
+		return s.publishAction(c.args)
+	case SUBSCRIBE, PSUBSCRIBE:
+		return s.subscribeAction(cc, c)
+	case UNSUBSCRIBE, PUNSUBSCRIBE:
+		return s.unsubscribeAction(cc, c)
+	case PUBSUB:
+		return s.pubsubAction(c.args)
+	case SAVE:
+		return s.saveAction()
+	case BGSAVE:
+		return s.bgsaveAction()
+	case LASTSAVE:
+		return s.lastsaveAction()
+	case BGREWRITEAOF:
+		return s.bgrewriteaofAction()
+	case INFO:
+		return s.infoAction(c.args)
 	default:
-		return fmt.Errorf("(error) ERR %v", ErrUnknownCommand).Error()
+		return resp.NewError(ErrUnknownCommand)
 	}
 }
 
-func (s *Server) pingAction() string {
-	return PONG
+// subscribed RESP2 clients get the pong as a message
+func (s *Server) pingAction(cc *ConnContext) resp.Reply {
+	if cc.sub != nil && cc.sub.count() > 0 && cc.protocol != resp.RESP3 {
+		return resp.BulkStrings([]string{strings.ToLower(PONG), ""})
+	}
+	return resp.SimpleString(PONG)
 }
 
-func (s *Server) selectAction(cc *ConnContext, val string) string {
+// negotiates the reply protocol of the connection, and replies with the server details
+// HELLO [protover [AUTH username password] [SETNAME clientname]]
+func (s *Server) helloAction(cc *ConnContext, args []string) resp.Reply {
+	ver := int64(resp.RESP2)
+	if cc.protocol == resp.RESP3 {
+		ver = int64(resp.RESP3)
+	}
+
+	if len(args) > 0 {
+		v, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			return resp.NewError(ErrProtoVerNotInteger)
+		}
+		if v != int64(resp.RESP2) && v != int64(resp.RESP3) {
+			return resp.NewError(ErrNoProto)
+		}
+		ver = v
+	}
+
+	// options are validated before any of them is applied
+	var name string
+	var hasName bool
+	for i := 1; i < len(args); i++ {
+		switch opt := strings.ToUpper(args[i]); {
+		case opt == "AUTH" && i+2 < len(args):
+			// there are no users apart from the default one, which has no password
+			if args[i+1] != "default" {
+				return resp.Error("WRONGPASS invalid username-password pair or user is disabled.")
+			}
+			i += 2
+		case opt == "SETNAME" && i+1 < len(args):
+			name, hasName = args[i+1], true
+			i++
+		default:
+			return resp.Error(fmt.Sprintf("ERR Syntax error in HELLO option '%s'", args[i]))
+		}
+	}
+
+	if hasName {
+		cc.name = name
+	}
+	// text mode keeps its format whatever the client asks for
+	if cc.protocol != resp.Text {
+		cc.protocol = resp.Protocol(ver)
+	}
+
+	return resp.Map{
+		{Key: resp.BulkString("server"), Value: resp.BulkString("redis")},
+		{Key: resp.BulkString("version"), Value: resp.BulkString(RedisVersion)},
+		{Key: resp.BulkString("proto"), Value: resp.Integer(ver)},
+		{Key: resp.BulkString("id"), Value: resp.Integer(cc.id)},
+		{Key: resp.BulkString("mode"), Value: resp.BulkString("standalone")},
+		{Key: resp.BulkString("role"), Value: resp.BulkString("master")},
+		{Key: resp.BulkString("modules"), Value: resp.Array{}},
+	}
+}
+
+func (s *Server) selectAction(cc *ConnContext, val string) resp.Reply {
 	i, err := strconv.Atoi(val)
 	if err != nil {
-		return db.ErrKeyNotInteger.Error()
+		return resp.NewError(db.ErrKeyNotInteger)
 	}
 
 	// db index should be between 0 and 15
 	if i < DbRangeMin || i > DbRangeMax {
-		return ErrDBIndexOutOfRange.Error()
+		return resp.NewError(ErrDBIndexOutOfRange)
 	}
 
-	// checking for the particular db index
 	// create db if its not there and set the index
-	_, ok := s.Db[i]
-	if !ok {
-		s.Db[i] = db.GetNewDB(inMemoryStore.NewInMemoryStore())
-	}
+	s.createDb(i)
 	cc.dbIdx = i
 
-	return MssgOK
+	return resp.SimpleString(MssgOK)
+}
+
+// SET key value [NX | XX] [GET] [EX seconds | PX ms | EXAT unix-time-seconds | PXAT unix-time-ms | KEEPTTL]
+// replies with nil if the condition prevented the write, GET replies with the old value instead of OK
+func (s *Server) setAction(cc *ConnContext, args []string) resp.Reply {
+	key, val := args[0], args[1]
+
+	opts, err := parseSetOptions(args[2:])
+	if err != nil {
+		return resp.NewError(err)
+	}
+
+	old, existed, written, err := s.getDb(cc.dbIdx).SetWithOptions(key, val, opts)
+	switch {
+	case err != nil:
+		return resp.NewError(err)
+	case opts.Get && existed:
+		return resp.BulkString(old)
+	case opts.Get, !written:
+		return resp.Nil
+	default:
+		return resp.SimpleString(MssgOK)
+	}
 }
 
-func (s *Server) setAction(cc *ConnContext, key, val string) string {
-	s.Db[cc.dbIdx].Set(key, val)
-	return MssgOK
+// parses the options of SET, the timeout is turned into a unix time in ms
+func parseSetOptions(args []string) (db.SetOptions, error) {
+	var opts db.SetOptions
+	var hasExpire bool
+
+	for i := 0; i < len(args); i++ {
+		switch opt := strings.ToUpper(args[i]); opt {
+		case "NX", "XX":
+			cond := db.SetNX
+			if opt == "XX" {
+				cond = db.SetXX
+			}
+			if opts.Cond != db.SetAlways && opts.Cond != cond {
+				return opts, ErrSyntax
+			}
+			opts.Cond = cond
+		case "GET":
+			opts.Get = true
+		case "KEEPTTL":
+			if hasExpire {
+				return opts, ErrSyntax
+			}
+			opts.KeepTTL = true
+		case "EX", "PX", "EXAT", "PXAT":
+			if hasExpire || opts.KeepTTL || i+1 == len(args) {
+				return opts, ErrSyntax
+			}
+			i++
+
+			at, err := parseSetExpire(opt, args[i])
+			if err != nil {
+				return opts, err
+			}
+			opts.ExpireAt, hasExpire = at, true
+		default:
+			return opts, ErrSyntax
+		}
+	}
+
+	return opts, nil
 }
 
-func (s *Server) getAction(cc *ConnContext, key string) string {
-	val, err := s.Db[cc.dbIdx].Get(key)
+// turns the timeout given to SET into a unix time in ms
+func parseSetExpire(opt, val string) (int64, error) {
+	when, err := strconv.ParseInt(val, 10, 64)
 	if err != nil {
-		return db.ErrKeyNotFound.Error()
+		return 0, db.ErrKeyNotInteger
+	}
+
+	errInvalid := fmt.Errorf("%v in 'set' command", ErrInvalidExpireTime)
+	if when <= 0 {
+		return 0, errInvalid
+	}
+
+	if opt == "EX" || opt == "EXAT" {
+		if when > math.MaxInt64/1000 {
+			return 0, errInvalid
+		}
+		when *= 1000
 	}
-	return strconv.Quote(val)
+	if opt == "EX" || opt == "PX" {
+		now := time.Now().UnixMilli()
+		if when > math.MaxInt64-now {
+			return 0, errInvalid
+		}
+		when += now
+	}
+	return when, nil
 }
 
-func (s *Server) delAction(cc *ConnContext, key string) string {
-	val := s.Db[cc.dbIdx].Del(key)
-	return val
+func (s *Server) getAction(cc *ConnContext, key string) resp.Reply {
+	val, err := s.getDb(cc.dbIdx).Get(key)
+	if errors.Is(err, db.ErrWrongType) {
+		return resp.NewError(err)
+	}
+	if err != nil {
+		return resp.Nil
+	}
+	return resp.BulkString(val)
 }
 
-func (s *Server) incrAction(cc *ConnContext, key string) string {
-	val, err := s.Db[cc.dbIdx].Incr(key)
+func (s *Server) delAction(cc *ConnContext, key string) resp.Reply {
+	if s.getDb(cc.dbIdx).Del(key) {
+		return resp.Integer(1)
+	}
+	return resp.Integer(0)
+}
+
+func (s *Server) incrAction(cc *ConnContext, key string) resp.Reply {
+	val, err := s.getDb(cc.dbIdx).Incr(key)
 	if err != nil {
-		return fmt.Errorf("(error) ERR %v", err).Error()
+		return resp.NewError(err)
 	}
-	return val
+	return resp.Integer(val)
 }
 
-func (s *Server) incrbyAction(cc *ConnContext, key, val string) string {
-	val, err := s.Db[cc.dbIdx].Incrby(key, val)
+func (s *Server) incrbyAction(cc *ConnContext, key, val string) resp.Reply {
+	i, err := s.getDb(cc.dbIdx).Incrby(key, val)
 	if err != nil {
-		return fmt.Errorf("(error) ERR %v", err).Error()
+		return resp.NewError(err)
 	}
-	return val
+	return resp.Integer(i)
 }
 
-func (s *Server) multiAction(cc *ConnContext) string {
+func (s *Server) multiAction(cc *ConnContext) resp.Reply {
 	// if multi tran is already in progress
 	if cc.isMulti {
-		return fmt.Errorf("(error) ERR %v", ErrMultiCommandNested).Error()
+		return resp.NewError(ErrMultiCommandNested)
 	}
 
 	cc.isMulti = true
-	return MssgOK
+	return resp.SimpleString(MssgOK)
 }
 
-func (s *Server) execAction(cc *ConnContext) string {
+func (s *Server) execAction(cc *ConnContext) resp.Reply {
 	// can't exec without multi
 	if !cc.isMulti {
-		return fmt.Errorf("(error) ERR %v", ErrExecWithoutMulti).Error()
+		return resp.NewError(ErrExecWithoutMulti)
 	}
 
 	// if tran was discarded due to error
 	if cc.isTranDiscarded {
 		s.resetTran(cc)
-		return fmt.Errorf("(error) ERR %v", ErrTranAbortedDueToPrevError).Error()
+		return resp.Error(fmt.Sprintf("EXECABORT %v", ErrTranAbortedDueToPrevError))
 	}
 
-	// if no commands were given in a multi tran
-	if len(cc.multiCommandArr) == 0 {
+	// nothing is run if a watched key was touched
+	if s.watchedKeysTouched(cc) {
 		s.resetTran(cc)
-		return MssgEmptyArray
+		return resp.NilArray
 	}
 
-	// normal execution
-	var builder strings.Builder
-	lastCmdArrIdx := len(cc.multiCommandArr) - 1
-	for i, c := range cc.multiCommandArr {
-		builder.WriteString(fmt.Sprintf("%d) ", i+1))
-
-		// avoids the extra newline in final output for the last command in tran
-		if lastCmdArrIdx == i {
-			builder.WriteString(s.takeAction(cc, c))
-		} else {
-			builder.WriteString(fmt.Sprintln(s.takeAction(cc, c)))
+	// normal execution, one reply per queued command
+	// the writes are logged as a whole, so a tran cut by a crash isn't replayed in part
+	replies, logged, logging := resp.Array{}, []aofEntry{}, s.aofOn()
+	for _, c := range cc.multiCommandArr {
+		r := s.takeAction(cc, c)
+		if logging {
+			logged = append(logged, s.aofEntries(cc.dbIdx, c, r)...)
 		}
-
+		replies = append(replies, r)
 	}
+	s.feedAOF(logged, true)
 
 	s.resetTran(cc)
-	return builder.String()
+	return replies
 }
 
-func (s *Server) discardAction(cc *ConnContext) string {
+func (s *Server) discardAction(cc *ConnContext) resp.Reply {
 	// can't discard without multi
 	if !cc.isMulti {
-		return fmt.Errorf("(error) ERR %v", ErrDiscardWithoutMulti).Error()
+		return resp.NewError(ErrDiscardWithoutMulti)
 	}
 
 	s.resetTran(cc)
-	return MssgOK
+	return resp.SimpleString(MssgOK)
 }
 
+// ends the tran, the keys watched for it are forgotten
 func (s *Server) resetTran(cc *ConnContext) {
+	s.unwatchAll(cc)
 	cc.isMulti = false
 	cc.isTranDiscarded = false
 	cc.multiCommandArr = []Command{}
 }
 
-func (s *Server) compactAction(cc *ConnContext) string {
-	data := s.Db[cc.dbIdx].GetAll()
-	if len(data) == 0 {
-		return MssgNil
-	}
-
-	// string builder implementation
-	// var builder strings.Builder
-	// for k, v := range data {
-	// 	builder.WriteString(fmt.Sprintf("%s %s %s\n", SET, k, v))
-	// }
-	// return builder.String()
-
-	var dataArr []string
-	for k, v := range data {
-		dataArr = append(dataArr, fmt.Sprintf("%s %s %s", SET, k, v))
+// returns the value of a key as a reply
+func valueReply(val store.Value) resp.Reply {
+	switch v := val.(type) {
+	case store.String:
+		return resp.BulkString(v)
+	case *store.List:
+		return resp.BulkStrings(v.Values())
+	case store.Hash:
+		m := resp.Map{}
+		for _, f := range sortedKeys(v) {
+			m = append(m, resp.MapItem{Key: resp.BulkString(f), Value: resp.BulkString(v[f])})
+		}
+		return m
+	case store.Set:
+		return setReply(sortedMembers(v))
+	case *store.ZSet:
+		items := resp.Array{}
+		for _, item := range v.Items() {
+			items = append(items, resp.Array{resp.BulkString(item.Member), resp.Double(item.Score)})
+		}
+		return items
+	case *store.Stream:
+		return entriesReply(v.Range(store.StreamID{}, store.MaxStreamID, -1, false))
+	default:
+		return resp.Nil
 	}
-	return strings.Join(dataArr, "\n")
 }
 
+// splits an inline command into args, quoted args can hold any byte
 func (s *Server) stringSplit(input string) ([]string, error) {
-	trimmedInput := strings.TrimSpace(input)
-	isValid := s.isValidCommand(trimmedInput)
-	if !isValid {
-		return nil, ErrUnknownCommand
+	out, err := resp.SplitInline([]byte(input))
+	if err != nil {
+		return nil, err
 	}
 
-	// declaration of single quote, double quote and space character vars
-	var (
-		sq rune = '\''
-		dq rune = '"'
-		sp rune = ' '
-	)
-
-	out := []string{}
-	currentString := []rune{}
-	isInsideQuote := false
-
-	for _, v := range trimmedInput {
-		// if char is quote, flip the isInsideQuote flag
-		if v == sq || v == dq {
-			isInsideQuote = !isInsideQuote
-		}
-
-		// if char is space and not inside quote, append the string to out
-		if v == sp && !isInsideQuote {
-			out = append(out, string(currentString))
-			currentString = []rune{}
-			continue
-		}
-
-		// if char isn't quote, append the char to currentString
-		if v != sq && v != dq {
-			currentString = append(currentString, v)
-		}
-	}
-	// append the last string to out
-	if len(currentString) > 0 {
-		out = append(out, string(currentString))
+	if len(out) == 0 {
+		return nil, ErrUnknownCommand
 	}
-
 	return out, nil
 }
 
-func (s *Server) isValidCommand(command string) bool {
-	// regex pattern for valid command
-	var validCommandPattern = `^(?i)(?:"[A-Za-z0-9 ]+"|\b[A-Za-z0-9]+\b)(?:\s+"[^"]*"\s*|\s+\b[A-Za-z0-9]+\b\s*)*$`
-	re := regexp.MustCompile(validCommandPattern)
-	return re.MatchString(command)
-}
-
 // turns the raw command into Command type
 // returns error if command is unknown or invalid number of args
 func (s *Server) makeCommand(i []string, cc *ConnContext) (Command, error) {
-	switch {
-	case i[0] == "SELECT" || i[0] == "select":
-		if len(i) != 2 {
-			if cc.isMulti {
-				cc.isTranDiscarded = true
-			}
-			return Command{}, fmt.Errorf("(error) ERR %v for '%s' command", ErrWrongNumberOfArgs, i[0])
-		}
-		return Command{name: SELECT, val: i[1]}, nil
-	case i[0] == "PING" || i[0] == "ping":
-		if len(i) != 1 {
-			if cc.isMulti {
-				cc.isTranDiscarded = true
-			}
-			return Command{}, fmt.Errorf("(error) ERR %v for '%s' command", ErrWrongNumberOfArgs, i[0])
-		}
-		return Command{name: PING}, nil
-	case i[0] == "GET" || i[0] == "get":
-		if len(i) != 2 {
-			if cc.isMulti {
-				cc.isTranDiscarded = true
-			}
-			return Command{}, fmt.Errorf("(error) ERR %v for '%s' command", ErrWrongNumberOfArgs, i[0])
-		}
-		return Command{name: GET, key: i[1]}, nil
-	case i[0] == "SET" || i[0] == "set":
-		if len(i) != 3 {
-			if cc.isMulti {
-				cc.isTranDiscarded = true
-			}
-			return Command{}, fmt.Errorf("(error) ERR %v for '%s' command", ErrWrongNumberOfArgs, i[0])
-		}
-		return Command{name: SET, key: i[1], val: i[2]}, nil
-	case i[0] == "DEL" || i[0] == "del":
-		if len(i) != 2 {
-			if cc.isMulti {
-				cc.isTranDiscarded = true
-			}
-			return Command{}, fmt.Errorf("(error) ERR %v for '%s' command", ErrWrongNumberOfArgs, i[0])
-		}
-		return Command{name: DEL, key: i[1]}, nil
-	case i[0] == "INCR" || i[0] == "incr":
-		if len(i) != 2 {
-			if cc.isMulti {
-				cc.isTranDiscarded = true
-			}
-			return Command{}, fmt.Errorf("(error) ERR %v for '%s' command", ErrWrongNumberOfArgs, i[0])
-		}
-		return Command{name: INCR, key: i[1]}, nil
-	case i[0] == "INCRBY" || i[0] == "incrby":
-		if len(i) != 3 {
-			if cc.isMulti {
-				cc.isTranDiscarded = true
-			}
-			return Command{}, fmt.Errorf("(error) ERR %v for '%s' command", ErrWrongNumberOfArgs, i[0])
-		}
-		return Command{name: INCRBY, key: i[1], val: i[2]}, nil
-	case i[0] == "MULTI" || i[0] == "multi":
-		if len(i) != 1 {
-			if cc.isMulti {
-				cc.isTranDiscarded = true
-			}
-			return Command{}, fmt.Errorf("(error) ERR %v for '%s' command", ErrWrongNumberOfArgs, i[0])
+	name := strings.ToUpper(i[0])
+	arity, ok := commandArity[name]
+	if !ok {
+		if cc.isMulti {
+			cc.isTranDiscarded = true
 		}
-		return Command{name: MULTI}, nil
-	case i[0] == "EXEC" || i[0] == "exec":
-		if len(i) != 1 {
+		return Command{}, fmt.Errorf("%v '%s', with args beginning with: %s", ErrUnknownCommand, i[0], quoteArgs(i[1:]))
+	}
+
+	if (arity > 0 && len(i) != arity) || (arity < 0 && len(i) < -arity) {
+		// exec with wrong args aborts the tran right away
+		if name == EXEC {
 			s.resetTran(cc)
-			return Command{}, fmt.Errorf("(error) EXECABORT Transaction discarded because of: %v for '%s' command", ErrWrongNumberOfArgs, i[0])
+			return Command{}, fmt.Errorf("EXECABORT Transaction discarded because of: %v for '%s' command", ErrWrongNumberOfArgs, strings.ToLower(i[0]))
 		}
-		return Command{name: EXEC}, nil
-	case i[0] == "DISCARD" || i[0] == "discard":
-		if len(i) != 1 {
-			if cc.isMulti {
-				cc.isTranDiscarded = true
-			}
-			return Command{}, fmt.Errorf("(error) ERR %v for '%s' command", ErrWrongNumberOfArgs, i[0])
-		}
-		return Command{name: DISCARD}, nil
-	case i[0] == "COMPACT" || i[0] == "compact":
-		if len(i) != 1 {
-			if cc.isMulti {
-				cc.isTranDiscarded = true
-			}
-			return Command{}, fmt.Errorf("(error) ERR %v for '%s' command", ErrWrongNumberOfArgs, i[0])
-		}
-		return Command{name: COMPACT}, nil
-	case i[0] == "DISCONNECT" || i[0] == "disconnect":
-		if len(i) != 1 {
-			if cc.isMulti {
-				cc.isTranDiscarded = true
-			}
-			return Command{}, fmt.Errorf("(error) ERR %v for '%s' command", ErrWrongNumberOfArgs, i[0])
-		}
-		return Command{name: DISCONNECT}, nil
-	default:
+
 		if cc.isMulti {
 			cc.isTranDiscarded = true
 		}
-		return Command{}, fmt.Errorf("(error) ERR %v '%s', with args beginning with: ", ErrUnknownCommand, i[0])
+		return Command{}, fmt.Errorf("%v for '%s' command", ErrWrongNumberOfArgs, strings.ToLower(i[0]))
 	}
+
+	return Command{name: name, args: i[1:]}, nil
+}
+
+// quotes the args like redis does in the unknown command error
+func quoteArgs(args []string) string {
+	var builder strings.Builder
+	for _, a := range args {
+		builder.WriteString(fmt.Sprintf("'%s' ", a))
+	}
+	return builder.String()
 }
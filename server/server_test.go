@@ -1,20 +1,29 @@
 package server
 
 import (
+	"bufio"
 	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"net"
 	"slices"
 	"strconv"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/justsushant/one2n-go-bootcamp/go-redis/db"
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/resp"
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store"
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store/inMemoryStore"
 	"google.golang.org/grpc/test/bufconn"
 )
 
+// commands which aren't mocked are left to the embedded interface, calling them panics
 type mockDB struct {
+	db.DbInterface
 	key string
 	val string
 }
@@ -32,67 +41,109 @@ func (m *mockDB) Set(key, val string) {
 	m.val = val
 }
 
-func (m *mockDB) Del(key string) string {
+// timeouts aren't mocked
+func (m *mockDB) SetWithOptions(key, val string, opts db.SetOptions) (string, bool, bool, error) {
+	old, existed := m.val, m.key == key
+	if !existed {
+		old = ""
+	}
+	if (opts.Cond == db.SetNX && existed) || (opts.Cond == db.SetXX && !existed) {
+		return old, existed, false, nil
+	}
+
+	m.Set(key, val)
+	return old, existed, true, nil
+}
+
+func (m *mockDB) Del(key string) bool {
 	if m.key == key {
 		m.key = ""
 		m.val = ""
-		return "(integer) 1"
+		return true
 	} else {
-		return "(integer) 0"
+		return false
 	}
 }
 
-func (m *mockDB) Incr(key string) (string, error) {
+func (m *mockDB) Incr(key string) (int, error) {
 	if m.key == key {
 		i, err := strconv.Atoi(m.val)
 		if err != nil {
-			return "", db.ErrKeyNotInteger
+			return 0, db.ErrKeyNotInteger
 		}
 		i += 1
 		m.val = strconv.Itoa(i)
-		return "(integer) " + strconv.Itoa(i), nil
+		return i, nil
 	} else {
 		m.key = key
 		m.val = "1"
-		return "(integer) 1", nil
+		return 1, nil
 	}
 }
 
-func (m *mockDB) Incrby(key, num string) (string, error) {
+func (m *mockDB) Incrby(key, num string) (int, error) {
 	i2, err := strconv.Atoi(num)
 	if err != nil {
-		return "", db.ErrKeyNotInteger
+		return 0, db.ErrKeyNotInteger
 	}
 
 	if m.key == key {
 		i, err := strconv.Atoi(m.val)
 		if err != nil {
-			return "", db.ErrKeyNotInteger
+			return 0, db.ErrKeyNotInteger
 		}
 		incrByVal := i + i2
 		m.val += strconv.Itoa(incrByVal)
-		return "(integer) " + strconv.Itoa(incrByVal), nil
+		return incrByVal, nil
 	} else {
 		m.key = key
 		m.val = num
-		return "(integer) " + num, nil
+		return i2, nil
 	}
 }
 
-func (m *mockDB) GetAll() map[string]string {
+func (m *mockDB) GetAll() map[string]store.Value {
 	if m.key == "one" {
-		return map[string]string{"foo": "bar"}
+		return map[string]store.Value{"foo": store.String("bar")}
 	} else if m.key == "multiple" {
-		return map[string]string{"foo": "bar", "counter": "13"}
+		return map[string]store.Value{"foo": store.String("bar"), "counter": store.String("13")}
 	} else {
-		return map[string]string{}
+		return map[string]store.Value{}
 	}
 }
 
+// the keys of GetAll ordered by key, nothing expires in the mock
+func (m *mockDB) Snapshot() []store.Entry {
+	entries := []store.Entry{}
+	data := m.GetAll()
+	for _, k := range sortedKeys(data) {
+		entries = append(entries, store.Entry{Key: k, Value: data[k]})
+	}
+	return entries
+}
+
+func (m *mockDB) DbSize() int {
+	return len(m.GetAll())
+}
+
+// nothing expires in the mock
+func (m *mockDB) ActiveExpireCycle(budget time.Duration) int {
+	return 0
+}
+
+// the mock sends no keyspace events
+func (m *mockDB) SetNotifier(n db.Notifier) {}
+
+// changes aren't counted by the mock, so the save rules are never met
+func (m *mockDB) Dirty() uint64 {
+	return 0
+}
+
 func GetTestServer(md *mockDB, ln net.Listener) *Server {
 	return &Server{
 		Db:       map[int]db.DbInterface{0: md},
 		Listener: ln,
+		TextMode: true,
 	}
 }
 
@@ -111,13 +162,13 @@ func TestHandleCommandWithNonMultiCommands(t *testing.T) {
 		{"PING command", []commandData{{"", "", PING, PONG}}},
 		{"SET command", []commandData{{"", "", "SET foo bar", MssgOK}}},
 		{"SET command with invalid number of arguments (1)", []commandData{{"", "", "SET foo", ErrWrongNumberOfArgs.Error()}}},
-		{"SET command with invalid number of arguments (3)", []commandData{{"", "", "SET foo bar extra", ErrWrongNumberOfArgs.Error()}}},
+		{"SET command with invalid option", []commandData{{"", "", "SET foo bar extra", ErrSyntax.Error()}}},
 		{"GET command with valid key", []commandData{{"foo", "bar", "GET foo", strconv.Quote("bar")}}},
 		{"GET command with invalid key", []commandData{{"", "", "GET foo", db.ErrKeyNotFound.Error()}}},
 		{"GET command with invalid number of args (2)", []commandData{{"", "", "GET foo bar", ErrWrongNumberOfArgs.Error()}}},
-		{"GET command with deleted key", []commandData{{"foo", "bar", "DEL foo", db.DeleteSuccessMessage}, {"", "", "GET foo", db.ErrKeyNotFound.Error()}}},
-		{"DEL command with valid key", []commandData{{"foo", "bar", "DEL foo", db.DeleteSuccessMessage}}},
-		{"DEL command with invalid key", []commandData{{"", "", "DEL foo", db.DeleteFailedMessage}}},
+		{"GET command with deleted key", []commandData{{"foo", "bar", "DEL foo", "(integer) 1"}, {"", "", "GET foo", db.ErrKeyNotFound.Error()}}},
+		{"DEL command with valid key", []commandData{{"foo", "bar", "DEL foo", "(integer) 1"}}},
+		{"DEL command with invalid key", []commandData{{"", "", "DEL foo", "(integer) 0"}}},
 		{"DEL command with invalid number of args (2)", []commandData{{"", "", "DEL foo bar", ErrWrongNumberOfArgs.Error()}}},
 		{"INCR command with valid key", []commandData{{"foo", "4", "INCR foo", "(integer) 5"}}},
 		{"INCR command with invalid key", []commandData{{"", "", "INCR foo", "(integer) 1"}}},
@@ -139,7 +190,7 @@ func TestHandleCommandWithNonMultiCommands(t *testing.T) {
 		{"SELECT command with invalid type of argument (string)", []commandData{{"", "", "SELECT foo", db.ErrKeyNotInteger.Error()}}},
 		{"SELECT command with invalid range of argument (not in 0-15)", []commandData{{"", "", "SELECT 24", ErrDBIndexOutOfRange.Error()}}},
 		{"Invalid command", []commandData{{"", "", "gibberish foo bar", ErrUnknownCommand.Error()}}},
-		{"COMPACT command with two key-val pair", []commandData{{"multiple", "", "COMPACT", "SET foo bar\nSET counter 13\n"}}},
+		{"COMPACT command with two key-val pair", []commandData{{"multiple", "", "COMPACT", "SET counter 13\nSET foo bar\n"}}},
 	}
 
 	for _, tc := range testCases {
@@ -458,9 +509,13 @@ func TestStringSplit(t *testing.T) {
 		{"command with key in quotes", "SET \"foo in quotes\" bar", []string{"SET", "foo in quotes", "bar"}, false, nil},
 		{"command with both key and value in quotes", "SET \"foo in quotes\" \"bar in quotes\"", []string{"SET", "foo in quotes", "bar in quotes"}, false, nil},
 		{"command with everything in quotes", "\"SET\" \"foo in quotes\" \"bar in quotes\"", []string{"SET", "foo in quotes", "bar in quotes"}, false, nil},
-		{"invalid command with quotes in between", "SET foo bar\"in\"quotes", nil, true, ErrUnknownCommand},
-		{"invalid command with unbalanced quotes", "SET \"foo in quotes \"bar in quotes\"", nil, true, ErrUnknownCommand},
-		{"invalid command with starting in quotes", "\"SET \"foo in quotes \"bar in quotes\"", nil, true, ErrUnknownCommand},
+		{"command with json value in single quotes", `SET foo '{"a": [1, 2]}'`, []string{"SET", "foo", `{"a": [1, 2]}`}, false, nil},
+		{"command with escaped binary value", `SET "k\x00ey" "line1\nline2\x00\"end\""`, []string{"SET", "k\x00ey", "line1\nline2\x00\"end\""}, false, nil},
+		{"command with non alphanumeric chars", "SET user:1 a-b_c.d@e", []string{"SET", "user:1", "a-b_c.d@e"}, false, nil},
+		{"empty command", "   ", nil, true, ErrUnknownCommand},
+		{"invalid command with quotes in between", "SET foo bar\"in\"quotes", nil, true, resp.ErrProtocol},
+		{"invalid command with unbalanced quotes", "SET \"foo in quotes \"bar in quotes\"", nil, true, resp.ErrProtocol},
+		{"invalid command with starting in quotes", "\"SET \"foo in quotes \"bar in quotes\"", nil, true, resp.ErrProtocol},
 	}
 
 	for _, tc := range testCases {
@@ -485,3 +540,579 @@ func TestStringSplit(t *testing.T) {
 		})
 	}
 }
+
+func TestServerRESP(t *testing.T) {
+	testCases := []struct {
+		name   string
+		input  string
+		expOut string
+	}{
+		{"PING as array", "*1\r\n$4\r\nPING\r\n", "+PONG\r\n"},
+		{"PING as inline command", "PING\r\n", "+PONG\r\n"},
+		{"SET with value containing spaces", "*3\r\n$3\r\nSET\r\n$3\r\nfoo\r\n$7\r\nbar baz\r\n", "+OK\r\n"},
+		{"GET existing key", "*2\r\n$3\r\nGET\r\n$3\r\nfoo\r\n", "$7\r\nbar baz\r\n"},
+		{"GET missing key", "*2\r\n$3\r\nget\r\n$7\r\nmissing\r\n", "$-1\r\n"},
+		{"INCR", "*2\r\n$4\r\nINCR\r\n$7\r\ncounter\r\n", ":1\r\n"},
+		{"INCRBY", "*3\r\n$6\r\nincrby\r\n$7\r\ncounter\r\n$2\r\n10\r\n", ":11\r\n"},
+		{"INCR on non integer value", "*2\r\n$4\r\nINCR\r\n$3\r\nfoo\r\n", "-ERR value is not an integer or out of range\r\n"},
+		{"DEL", "*2\r\n$3\r\nDEL\r\n$3\r\nfoo\r\n", ":1\r\n"},
+		{"wrong number of arguments", "*1\r\n$3\r\nGET\r\n", "-ERR wrong number of arguments for 'get' command\r\n"},
+		{"unknown command", "*2\r\n$4\r\nNOPE\r\n$1\r\na\r\n", "-ERR unknown command 'NOPE', with args beginning with: 'a' \r\n"},
+		{"MULTI", "MULTI\r\n", "+OK\r\n"},
+		{"queued command", "SET foo 1\r\n", "+QUEUED\r\n"},
+		{"EXEC", "EXEC\r\n", "*1\r\n+OK\r\n"},
+	}
+
+	// starting the server with a real db
+	ln := bufconn.Listen(1024 * 1024)
+	s := &Server{
+		Db:       map[int]db.DbInterface{0: db.GetNewDB(inMemoryStore.NewInMemoryStore())},
+		Listener: ln,
+	}
+	go s.Start()
+
+	conn, err := ln.Dial()
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer conn.Close()
+
+	buf := make([]byte, 1024)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fmt.Fprint(conn, tc.input)
+
+			n, err := conn.Read(buf)
+			if err != nil {
+				t.Fatalf("Error while reading from connection: %v", err)
+			}
+
+			if string(buf[:n]) != tc.expOut {
+				t.Errorf("Expected %q but got %q instead", tc.expOut, string(buf[:n]))
+			}
+		})
+	}
+
+	t.Run("protocol error closes the connection", func(t *testing.T) {
+		fmt.Fprint(conn, "*1\r\n:1\r\n")
+
+		n, err := conn.Read(buf)
+		if err != nil {
+			t.Fatalf("Error while reading from connection: %v", err)
+		}
+		expOut := "-ERR Protocol error: expected '$', got ':'\r\n"
+		if string(buf[:n]) != expOut {
+			t.Errorf("Expected %q but got %q instead", expOut, string(buf[:n]))
+		}
+
+		if _, err := conn.Read(buf); err == nil {
+			t.Errorf("Expected connection to be closed after protocol error")
+		}
+	})
+}
+
+func TestHelloCommand(t *testing.T) {
+	testCases := []struct {
+		name   string
+		input  []string
+		expOut []string
+	}{
+		{
+			name:   "HELLO without version keeps RESP2",
+			input:  []string{"HELLO\r\n", "GET missing\r\n"},
+			expOut: []string{"*14\r\n$6\r\nserver\r\n$5\r\nredis\r\n", "$-1\r\n"},
+		},
+		{
+			name:   "HELLO 3 switches to RESP3",
+			input:  []string{"HELLO 3\r\n", "GET missing\r\n", "CONFIG GET databases\r\n"},
+			expOut: []string{"%7\r\n$6\r\nserver\r\n$5\r\nredis\r\n", "_\r\n", "%1\r\n$9\r\ndatabases\r\n$2\r\n16\r\n"},
+		},
+		{
+			name:   "HELLO 2 switches back to RESP2",
+			input:  []string{"HELLO 3\r\n", "HELLO 2\r\n", "GET missing\r\n"},
+			expOut: []string{"%7\r\n", "*14\r\n", "$-1\r\n"},
+		},
+		{
+			name:   "HELLO with unsupported version",
+			input:  []string{"HELLO 4\r\n", "GET missing\r\n"},
+			expOut: []string{"-NOPROTO unsupported protocol version\r\n", "$-1\r\n"},
+		},
+		{
+			name:   "HELLO with invalid version",
+			input:  []string{"HELLO three\r\n"},
+			expOut: []string{"-ERR Protocol version is not an integer or out of range\r\n"},
+		},
+		{
+			name:   "HELLO with SETNAME and AUTH",
+			input:  []string{"HELLO 3 AUTH default secret SETNAME worker\r\n"},
+			expOut: []string{"$5\r\nproto\r\n:3\r\n"},
+		},
+		{
+			name:   "HELLO with invalid option",
+			input:  []string{"HELLO 3 SETNAME\r\n", "GET missing\r\n"},
+			expOut: []string{"-ERR Syntax error in HELLO option 'SETNAME'\r\n", "$-1\r\n"},
+		},
+		{
+			name:   "COMPACT replies with a map under RESP3",
+			input:  []string{"HELLO 3\r\n", "SET foo bar\r\n", "COMPACT\r\n"},
+			expOut: []string{"%7\r\n", "+OK\r\n", "%1\r\n$3\r\nfoo\r\n$3\r\nbar\r\n"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// starting the server with a real db
+			ln := bufconn.Listen(1024 * 1024)
+			s := &Server{
+				Db:       map[int]db.DbInterface{0: db.GetNewDB(inMemoryStore.NewInMemoryStore())},
+				Listener: ln,
+			}
+			go s.Start()
+
+			conn, err := ln.Dial()
+			if err != nil {
+				t.Fatalf("Failed to dial: %v", err)
+			}
+			defer conn.Close()
+
+			buf := make([]byte, 1024)
+			for i, input := range tc.input {
+				fmt.Fprint(conn, input)
+
+				n, err := conn.Read(buf)
+				if err != nil {
+					t.Fatalf("Error while reading from connection: %v", err)
+				}
+
+				if !bytes.Contains(buf[:n], []byte(tc.expOut[i])) {
+					t.Errorf("Expected output to contain %q but got %q instead", tc.expOut[i], string(buf[:n]))
+				}
+			}
+		})
+	}
+}
+
+func TestServerBinarySafeValues(t *testing.T) {
+	// starting the server with a real db
+	ln := bufconn.Listen(1024 * 1024)
+	s := &Server{
+		Db:       map[int]db.DbInterface{0: db.GetNewDB(inMemoryStore.NewInMemoryStore())},
+		Listener: ln,
+	}
+	go s.Start()
+
+	conn, err := ln.Dial()
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+
+	testCases := []struct {
+		name string
+		key  string
+		val  string
+	}{
+		{"value with newlines, quotes and NUL bytes", "bin", "{\"a\":\"b\"}\r\n'quoted'\x00\xff"},
+		{"key with NUL bytes and spaces", "k e\x00y", "val"},
+		{"value larger than a single read", "big", strings.Repeat("0123456789", 100*1024)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// request is written in small chunks to spread it over many reads
+			req := fmt.Sprintf("*3\r\n$3\r\nSET\r\n$%d\r\n%s\r\n$%d\r\n%s\r\n", len(tc.key), tc.key, len(tc.val), tc.val)
+			go func() {
+				for i := 0; i < len(req); i += 1000 {
+					conn.Write([]byte(req[i:min(i+1000, len(req))]))
+				}
+			}()
+
+			line, err := r.ReadString('\n')
+			if err != nil {
+				t.Fatalf("Error while reading from connection: %v", err)
+			}
+			if line != "+OK\r\n" {
+				t.Fatalf("Expected %q but got %q", "+OK\r\n", line)
+			}
+
+			fmt.Fprintf(conn, "*2\r\n$3\r\nGET\r\n$%d\r\n%s\r\n", len(tc.key), tc.key)
+			expOut := fmt.Sprintf("$%d\r\n%s\r\n", len(tc.val), tc.val)
+			out := make([]byte, len(expOut))
+			if _, err := io.ReadFull(r, out); err != nil {
+				t.Fatalf("Error while reading from connection: %v", err)
+			}
+			if string(out) != expOut {
+				t.Errorf("Expected the value %q back but got %q", expOut[:min(len(expOut), 50)], string(out[:min(len(out), 50)]))
+			}
+		})
+	}
+
+	t.Run("bulk string longer than proto-max-bulk-len", func(t *testing.T) {
+		fmt.Fprint(conn, "CONFIG SET proto-max-bulk-len 1mb\r\n")
+		line, err := r.ReadString('\n')
+		if err != nil || line != "+OK\r\n" {
+			t.Fatalf("Expected %q but got %q (%v)", "+OK\r\n", line, err)
+		}
+
+		fmt.Fprintf(conn, "*3\r\n$3\r\nSET\r\n$3\r\nfoo\r\n$%d\r\n", 1024*1024+1)
+		line, err = r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Error while reading from connection: %v", err)
+		}
+		expOut := "-ERR Protocol error: invalid bulk length\r\n"
+		if line != expOut {
+			t.Errorf("Expected %q but got %q", expOut, line)
+		}
+	})
+}
+
+func TestServerTextMode(t *testing.T) {
+	// starting the server with a real db in text mode
+	ln := bufconn.Listen(1024 * 1024)
+	s := &Server{
+		Db:       map[int]db.DbInterface{0: db.GetNewDB(inMemoryStore.NewInMemoryStore())},
+		Listener: ln,
+		TextMode: true,
+	}
+	go s.Start()
+
+	conn, err := ln.Dial()
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+
+	testCases := []struct {
+		input  string
+		expOut string
+	}{
+		{`SET msg "hello \"world\"\n"`, "OK\n"},
+		{"GET msg", "\"hello \\\"world\\\"\\n\"\n"},
+		{"SET json '{\"id\": 1}'", "OK\n"},
+		{"GET json", "\"{\\\"id\\\": 1}\"\n"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			fmt.Fprintln(conn, tc.input)
+
+			line, err := r.ReadString('\n')
+			if err != nil {
+				t.Fatalf("Error while reading from connection: %v", err)
+			}
+			if line != tc.expOut {
+				t.Errorf("Expected %q but got %q", tc.expOut, line)
+			}
+		})
+	}
+}
+
+// conn serving the given input and recording every write made to it
+type recordingConn struct {
+	net.Conn
+	in     io.Reader
+	out    bytes.Buffer
+	writes int
+}
+
+func (c *recordingConn) Read(b []byte) (int, error) {
+	return c.in.Read(b)
+}
+
+func (c *recordingConn) Write(b []byte) (int, error) {
+	c.writes++
+	return c.out.Write(b)
+}
+
+func (c *recordingConn) Close() error {
+	return nil
+}
+
+func TestPipelining(t *testing.T) {
+	testCases := []struct {
+		name      string
+		input     string
+		expOut    string
+		expWrites int
+	}{
+		{
+			name:      "inline commands in a single write",
+			input:     "SET a 1\r\nSET b 2\r\nGET a\r\n",
+			expOut:    "+OK\r\n+OK\r\n$1\r\n1\r\n",
+			expWrites: 1,
+		},
+		{
+			name:      "RESP commands in a single write",
+			input:     "*3\r\n$3\r\nSET\r\n$1\r\na\r\n$1\r\n1\r\n*2\r\n$4\r\nINCR\r\n$1\r\na\r\n*2\r\n$3\r\nGET\r\n$1\r\na\r\n",
+			expOut:    "+OK\r\n:2\r\n$1\r\n2\r\n",
+			expWrites: 1,
+		},
+		{
+			name:      "transaction in a single write",
+			input:     "MULTI\r\nSET a 1\r\nINCR a\r\nEXEC\r\nGET a\r\n",
+			expOut:    "+OK\r\n+QUEUED\r\n+QUEUED\r\n*2\r\n+OK\r\n:2\r\n$1\r\n2\r\n",
+			expWrites: 1,
+		},
+		{
+			name:      "replies sent before a protocol error",
+			input:     "SET a 1\r\nSET b \"2\r\n",
+			expOut:    "+OK\r\n-ERR Protocol error: unbalanced quotes in request\r\n",
+			expWrites: 1,
+		},
+		{
+			name:      "commands after DISCONNECT are not served",
+			input:     "SET a 1\r\nDISCONNECT\r\nGET a\r\n",
+			expOut:    "+OK\r\n",
+			expWrites: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := &Server{Db: map[int]db.DbInterface{0: db.GetNewDB(inMemoryStore.NewInMemoryStore())}}
+			conn := &recordingConn{in: strings.NewReader(tc.input)}
+
+			s.handleConnection(conn, &ConnContext{})
+
+			if conn.out.String() != tc.expOut {
+				t.Errorf("Expected %q but got %q", tc.expOut, conn.out.String())
+			}
+			if conn.writes != tc.expWrites {
+				t.Errorf("Expected replies to be flushed in %d writes but got %d", tc.expWrites, conn.writes)
+			}
+		})
+	}
+
+	t.Run("bulk load in a single round trip", func(t *testing.T) {
+		var req, expOut strings.Builder
+		for i := range 50000 {
+			fmt.Fprintf(&req, "SET key%d %d\r\n", i, i)
+			expOut.WriteString("+OK\r\n")
+		}
+		req.WriteString("GET key49999\r\n")
+		expOut.WriteString("$5\r\n49999\r\n")
+
+		s := &Server{Db: map[int]db.DbInterface{0: db.GetNewDB(inMemoryStore.NewInMemoryStore())}}
+		conn := &recordingConn{in: strings.NewReader(req.String())}
+
+		s.handleConnection(conn, &ConnContext{})
+
+		if conn.out.String() != expOut.String() {
+			t.Errorf("Expected every SET to be acknowledged in order followed by the value of the last key")
+		}
+	})
+}
+
+func TestSetCommand(t *testing.T) {
+	inFuture := time.Now().Add(time.Hour)
+
+	testCases := []struct {
+		name     string
+		inputArr []string
+		expOut   []string
+	}{
+		{
+			name:     "NX",
+			inputArr: []string{"SET foo bar NX", "SET foo baz NX", "GET foo"},
+			expOut:   []string{MssgOK, MssgNil, "\"bar\""},
+		},
+		{
+			name:     "XX",
+			inputArr: []string{"SET foo bar XX", "GET foo", "SET foo bar", "SET foo baz xx", "GET foo"},
+			expOut:   []string{MssgNil, MssgNil, MssgOK, MssgOK, "\"baz\""},
+		},
+		{
+			name:     "GET",
+			inputArr: []string{"SET foo bar GET", "SET foo baz GET", "GET foo"},
+			expOut:   []string{MssgNil, "\"bar\"", "\"baz\""},
+		},
+		{
+			name:     "NX with GET replies with the old value",
+			inputArr: []string{"SET foo bar", "SET foo baz NX GET", "GET foo"},
+			expOut:   []string{MssgOK, "\"bar\"", "\"bar\""},
+		},
+		{
+			name:     "lock idiom",
+			inputArr: []string{"SET lock token NX PX 30000", "SET lock other NX PX 30000", "TTL lock"},
+			expOut:   []string{MssgOK, MssgNil, "(integer) 30"},
+		},
+		{
+			name:     "EX",
+			inputArr: []string{"SET foo bar EX 100", "TTL foo"},
+			expOut:   []string{MssgOK, "(integer) 100"},
+		},
+		{
+			name:     "EXAT and PXAT",
+			inputArr: []string{fmt.Sprintf("SET foo bar EXAT %d", inFuture.Unix()), "EXPIRETIME foo", fmt.Sprintf("SET foo bar PXAT %d", inFuture.UnixMilli()), "PEXPIRETIME foo"},
+			expOut:   []string{MssgOK, fmt.Sprintf("(integer) %d", inFuture.Unix()), MssgOK, fmt.Sprintf("(integer) %d", inFuture.UnixMilli())},
+		},
+		{
+			name:     "PXAT in the past",
+			inputArr: []string{"SET foo bar PXAT 1000", "GET foo"},
+			expOut:   []string{MssgOK, MssgNil},
+		},
+		{
+			name:     "KEEPTTL",
+			inputArr: []string{"SET foo bar EX 100", "SET foo baz KEEPTTL", "TTL foo", "SET foo bar", "TTL foo"},
+			expOut:   []string{MssgOK, MssgOK, "(integer) 100", MssgOK, "(integer) -1"},
+		},
+		{
+			name:     "incompatible options",
+			inputArr: []string{"SET foo bar NX XX", "SET foo bar EX 10 PX 100", "SET foo bar EX 10 KEEPTTL", "SET foo bar KEEPTTL PXAT 100", "SET foo bar EX", "SET foo bar NOPE", "GET foo"},
+			expOut:   []string{ErrSyntax.Error(), ErrSyntax.Error(), ErrSyntax.Error(), ErrSyntax.Error(), ErrSyntax.Error(), ErrSyntax.Error(), MssgNil},
+		},
+		{
+			name:     "invalid timeout",
+			inputArr: []string{"SET foo bar EX ten", "SET foo bar EX 0", "SET foo bar PX -1", "SET foo bar EX 9223372036854775807"},
+			expOut:   []string{db.ErrKeyNotInteger.Error(), "invalid expire time in 'set' command", "invalid expire time in 'set' command", "invalid expire time in 'set' command"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			s := GetTestServerWithDB()
+			cc := &ConnContext{}
+
+			for i, input := range tc.inputArr {
+				s.handleCommand(input, &buf, cc)
+
+				if !bytes.Contains(buf.Bytes(), []byte(tc.expOut[i])) {
+					t.Errorf("Expected output of %q to contain %q but got %q instead", input, tc.expOut[i], buf.String())
+				}
+				buf.Reset()
+			}
+		})
+	}
+}
+
+// db pausing in INCR of the key "pause", to catch a tran halfway
+type pausingDB struct {
+	db.DbInterface
+	paused  chan struct{} // gets a value once INCR is reached
+	release chan struct{} // closed to let INCR go on
+}
+
+func (p *pausingDB) Incr(key string) (int, error) {
+	if key == "pause" {
+		p.paused <- struct{}{}
+		<-p.release
+	}
+	return p.DbInterface.Incr(key)
+}
+
+func TestTransactionIsolation(t *testing.T) {
+	const rounds = 200
+
+	t.Run("other clients wait for a tran halfway", func(t *testing.T) {
+		pdb := &pausingDB{DbInterface: db.GetNewDB(inMemoryStore.NewInMemoryStore()), paused: make(chan struct{}, 1), release: make(chan struct{})}
+		s := &Server{Db: map[int]db.DbInterface{0: pdb}}
+
+		run := func(cc *ConnContext, inputs ...string) <-chan string {
+			out := make(chan string, 1)
+			go func() {
+				var buf bytes.Buffer
+				for _, input := range inputs {
+					buf.Reset()
+					s.handleCommand(input, &buf, cc)
+				}
+				out <- buf.String()
+			}()
+			return out
+		}
+
+		tran := run(&ConnContext{}, "MULTI", "SET state dirty", "INCR pause", "SET state clean", "GET state", "EXEC")
+		<-pdb.paused
+		writer := run(&ConnContext{}, "SET state other")
+		reader := run(&ConnContext{}, "GET state")
+
+		select {
+		case out := <-writer:
+			t.Fatalf("Expected the writer to wait for the tran but got %q", out)
+		case out := <-reader:
+			t.Fatalf("Expected the reader to wait for the tran but got %q", out)
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		close(pdb.release)
+		if exp := "1) OK\n2) (integer) 1\n3) OK\n4) \"clean\"\n"; <-tran != exp {
+			t.Errorf("Expected the tran to run alone")
+		}
+		if out := <-reader; out != "\"clean\"\n" && out != "\"other\"\n" {
+			t.Errorf("Expected the reader to see the state once the tran is done but got %q", out)
+		}
+		<-writer
+	})
+
+	// runs the commands of every client in its own goroutine, each client goes through its commands rounds times
+	runClients := func(s *Server, clients [][]string, check func(input, out string)) {
+		var wg sync.WaitGroup
+		for _, inputs := range clients {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				cc := &ConnContext{}
+				var buf bytes.Buffer
+				for range rounds {
+					for _, input := range inputs {
+						buf.Reset()
+						s.handleCommand(input, &buf, cc)
+						check(input, buf.String())
+					}
+				}
+			}()
+		}
+		wg.Wait()
+	}
+
+	t.Run("writes of other clients don't land in the middle of a tran", func(t *testing.T) {
+		s := GetTestServerWithDB()
+		tran := []string{"MULTI", "SET counter 0"}
+		expOut := "1) OK\n"
+		for i := range 8 {
+			tran = append(tran, "INCR counter")
+			expOut += fmt.Sprintf("%d) (integer) %d\n", i+2, i+1)
+		}
+		tran = append(tran, "EXEC")
+
+		var mu sync.Mutex
+		failures := 0
+		runClients(s, [][]string{tran, tran, {"INCR counter"}, {"SET counter 100"}}, func(input, out string) {
+			if input == "EXEC" && out != expOut {
+				mu.Lock()
+				failures++
+				mu.Unlock()
+			}
+		})
+		if failures > 0 {
+			t.Errorf("Expected every EXEC to count from 1 to 8 but %d of them didn't", failures)
+		}
+	})
+
+	t.Run("trans run one after another", func(t *testing.T) {
+		s := GetTestServerWithDB()
+		trans := [][]string{}
+		for _, id := range []string{"a", "b", "c"} {
+			tran := []string{"MULTI"}
+			for range 10 {
+				tran = append(tran, "RPUSH list "+id)
+			}
+			trans = append(trans, append(tran, "EXEC"))
+		}
+		runClients(s, trans, func(input, out string) {})
+
+		items, _ := s.Db[0].LRange("list", 0, -1)
+		if len(items) != 3*10*rounds {
+			t.Fatalf("Expected %d items but got %d", 3*10*rounds, len(items))
+		}
+		for i := 0; i < len(items); i += 10 {
+			for _, item := range items[i : i+10] {
+				if item != items[i] {
+					t.Fatalf("Expected the items pushed by a tran to be next to each other but got %v at %d", items[i:i+10], i)
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,231 @@
+package server
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/db"
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/resp"
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store"
+)
+
+const (
+	SADD        string = "SADD"
+	SREM        string = "SREM"
+	SMEMBERS    string = "SMEMBERS"
+	SISMEMBER   string = "SISMEMBER"
+	SMISMEMBER  string = "SMISMEMBER"
+	SCARD       string = "SCARD"
+	SPOP        string = "SPOP"
+	SRANDMEMBER string = "SRANDMEMBER"
+	SINTER      string = "SINTER"
+	SUNION      string = "SUNION"
+	SDIFF       string = "SDIFF"
+	SINTERCARD  string = "SINTERCARD"
+	SINTERSTORE string = "SINTERSTORE"
+	SUNIONSTORE string = "SUNIONSTORE"
+	SDIFFSTORE  string = "SDIFFSTORE"
+)
+
+var (
+	ErrNumKeysNotPositive = errors.New("numkeys should be greater than 0")
+	ErrNumKeysTooMany     = errors.New("Number of keys can't be greater than number of args")
+	ErrLimitNegative      = errors.New("LIMIT can't be negative")
+)
+
+// operation behind each of the set algebra commands
+var setOps = map[string]db.SetOp{
+	SINTER:      db.SetOpInter,
+	SUNION:      db.SetOpUnion,
+	SDIFF:       db.SetOpDiff,
+	SINTERSTORE: db.SetOpInter,
+	SUNIONSTORE: db.SetOpUnion,
+	SDIFFSTORE:  db.SetOpDiff,
+}
+
+// SADD key member [member ...]
+func (s *Server) saddAction(cc *ConnContext, args []string) resp.Reply {
+	n, err := s.getDb(cc.dbIdx).SAdd(args[0], args[1:]...)
+	if err != nil {
+		return resp.NewError(err)
+	}
+	return resp.Integer(n)
+}
+
+// SREM key member [member ...]
+func (s *Server) sremAction(cc *ConnContext, args []string) resp.Reply {
+	n, err := s.getDb(cc.dbIdx).SRem(args[0], args[1:]...)
+	if err != nil {
+		return resp.NewError(err)
+	}
+	return resp.Integer(n)
+}
+
+// SMEMBERS key
+// members are sorted so the replies are stable
+func (s *Server) smembersAction(cc *ConnContext, key string) resp.Reply {
+	members, err := s.getDb(cc.dbIdx).SMembers(key)
+	if err != nil {
+		return resp.NewError(err)
+	}
+	return setReply(members)
+}
+
+// SISMEMBER key member and SMISMEMBER key member [member ...]
+func (s *Server) sismemberAction(cc *ConnContext, c Command) resp.Reply {
+	found, err := s.getDb(cc.dbIdx).SMIsMember(c.args[0], c.args[1:]...)
+	if err != nil {
+		return resp.NewError(err)
+	}
+
+	if c.name == SISMEMBER {
+		return boolReply(found[0])
+	}
+	replies := resp.Array{}
+	for _, ok := range found {
+		replies = append(replies, boolReply(ok))
+	}
+	return replies
+}
+
+// SCARD key
+func (s *Server) scardAction(cc *ConnContext, key string) resp.Reply {
+	n, err := s.getDb(cc.dbIdx).SCard(key)
+	if err != nil {
+		return resp.NewError(err)
+	}
+	return resp.Integer(n)
+}
+
+// SPOP key [count]
+// replies with a single member without count, and a set of members with it
+func (s *Server) spopAction(cc *ConnContext, c Command) resp.Reply {
+	if len(c.args) > 2 {
+		return resp.NewError(ErrSyntax)
+	}
+
+	count, hasCount := 1, len(c.args) == 2
+	if hasCount {
+		n, err := strconv.Atoi(c.args[1])
+		if err != nil || n < 0 {
+			return resp.NewError(ErrValueOutOfRange)
+		}
+		count = n
+	}
+
+	members, err := s.getDb(cc.dbIdx).SPop(c.args[0], count)
+	switch {
+	case err != nil:
+		return resp.NewError(err)
+	case hasCount:
+		return setReply(members)
+	case len(members) == 0:
+		return resp.Nil
+	default:
+		return resp.BulkString(members[0])
+	}
+}
+
+// SRANDMEMBER key [count]
+// replies with a single member without count, and an array of members with it
+func (s *Server) srandmemberAction(cc *ConnContext, c Command) resp.Reply {
+	if len(c.args) > 2 {
+		return resp.NewError(ErrSyntax)
+	}
+
+	count, hasCount := 1, len(c.args) == 2
+	if hasCount {
+		n, err := strconv.Atoi(c.args[1])
+		if err != nil {
+			return resp.NewError(db.ErrKeyNotInteger)
+		}
+		count = n
+	}
+
+	members, err := s.getDb(cc.dbIdx).SRandMember(c.args[0], count)
+	switch {
+	case err != nil:
+		return resp.NewError(err)
+	case hasCount:
+		return resp.BulkStrings(members)
+	case len(members) == 0:
+		return resp.Nil
+	default:
+		return resp.BulkString(members[0])
+	}
+}
+
+// SINTER key [key ...], and the same for SUNION and SDIFF
+func (s *Server) setAlgebraAction(cc *ConnContext, c Command) resp.Reply {
+	members, err := s.getDb(cc.dbIdx).SetAlgebra(setOps[c.name], c.args...)
+	if err != nil {
+		return resp.NewError(err)
+	}
+	return setReply(members)
+}
+
+// SINTERSTORE destination key [key ...], and the same for SUNIONSTORE and SDIFFSTORE
+func (s *Server) setAlgebraStoreAction(cc *ConnContext, c Command) resp.Reply {
+	n, err := s.getDb(cc.dbIdx).SetAlgebraStore(setOps[c.name], c.args[0], c.args[1:]...)
+	if err != nil {
+		return resp.NewError(err)
+	}
+	return resp.Integer(n)
+}
+
+// SINTERCARD numkeys key [key ...] [LIMIT limit]
+func (s *Server) sintercardAction(cc *ConnContext, args []string) resp.Reply {
+	numKeys, err := strconv.Atoi(args[0])
+	if err != nil {
+		return resp.NewError(db.ErrKeyNotInteger)
+	}
+	if numKeys <= 0 {
+		return resp.NewError(ErrNumKeysNotPositive)
+	}
+	if numKeys > len(args)-1 {
+		return resp.NewError(ErrNumKeysTooMany)
+	}
+
+	keys, opts := args[1:1+numKeys], args[1+numKeys:]
+	limit := 0
+	for i := 0; i < len(opts); i++ {
+		if strings.ToUpper(opts[i]) != "LIMIT" || i+1 == len(opts) {
+			return resp.NewError(ErrSyntax)
+		}
+		n, err := strconv.Atoi(opts[i+1])
+		if err != nil {
+			return resp.NewError(db.ErrKeyNotInteger)
+		}
+		if n < 0 {
+			return resp.NewError(ErrLimitNegative)
+		}
+		limit = n
+		i++
+	}
+
+	n, err := s.getDb(cc.dbIdx).SInterCard(limit, keys...)
+	if err != nil {
+		return resp.NewError(err)
+	}
+	return resp.Integer(n)
+}
+
+// members are sent as a set to RESP3 clients, and as an array otherwise
+func setReply(members []string) resp.Reply {
+	replies := resp.Set{}
+	for _, m := range members {
+		replies = append(replies, resp.BulkString(m))
+	}
+	return replies
+}
+
+func sortedMembers(s store.Set) []string {
+	members := make([]string, 0, len(s))
+	for m := range s {
+		members = append(members, m)
+	}
+	slices.Sort(members)
+	return members
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/db"
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/resp"
+)
+
+func TestSetCommands(t *testing.T) {
+	wrongType := db.ErrWrongType.Error()
+
+	testCases := []struct {
+		name     string
+		inputArr []string
+		expOut   []string
+	}{
+		{
+			name:     "SADD, SREM and SMEMBERS",
+			inputArr: []string{"SADD tags go redis go", "SADD tags db", "SMEMBERS tags", "SREM tags go rust", "SCARD tags", "SMEMBERS missing"},
+			expOut:   []string{"(integer) 2", "(integer) 1", "1~ \"db\"\n2~ \"go\"\n3~ \"redis\"\n", "(integer) 1", "(integer) 2", MssgEmptyArray},
+		},
+		{
+			name:     "SISMEMBER and SMISMEMBER",
+			inputArr: []string{"SADD tags go", "SISMEMBER tags go", "SISMEMBER tags db", "SMISMEMBER tags db go", "SISMEMBER missing go"},
+			expOut:   []string{"(integer) 1", "(integer) 1", "(integer) 0", "1) (integer) 0\n2) (integer) 1\n", "(integer) 0"},
+		},
+		{
+			name:     "SPOP",
+			inputArr: []string{"SADD tags go", "SPOP tags", "TYPE tags", "SPOP tags", "SPOP tags 1", "SPOP tags -1"},
+			expOut:   []string{"(integer) 1", "\"go\"", "none", MssgNil, MssgEmptyArray, ErrValueOutOfRange.Error()},
+		},
+		{
+			name:     "SRANDMEMBER",
+			inputArr: []string{"SADD tags go", "SRANDMEMBER tags", "SRANDMEMBER tags -2", "SRANDMEMBER missing", "SRANDMEMBER missing 2", "SCARD tags"},
+			expOut:   []string{"(integer) 1", "\"go\"", "1) \"go\"\n2) \"go\"\n", MssgNil, MssgEmptyArray, "(integer) 1"},
+		},
+		{
+			name:     "SINTER, SUNION and SDIFF",
+			inputArr: []string{"SADD a 1 2 3", "SADD b 2 3 4", "SINTER a b", "SUNION a b", "SDIFF a b", "SINTER a missing"},
+			expOut:   []string{"(integer) 3", "(integer) 3", "1~ \"2\"\n2~ \"3\"\n", "1~ \"1\"\n2~ \"2\"\n3~ \"3\"\n4~ \"4\"\n", "1~ \"1\"\n", MssgEmptyArray},
+		},
+		{
+			name:     "STORE variants",
+			inputArr: []string{"SADD a 1 2 3", "SADD b 2 3 4", "SET dst bar", "SINTERSTORE dst a b", "SMEMBERS dst", "SUNIONSTORE dst a b", "SDIFFSTORE dst a a", "TYPE dst"},
+			expOut:   []string{"(integer) 3", "(integer) 3", MssgOK, "(integer) 2", "1~ \"2\"\n2~ \"3\"\n", "(integer) 4", "(integer) 0", "none"},
+		},
+		{
+			name:     "SINTERCARD",
+			inputArr: []string{"SADD a 1 2 3", "SADD b 2 3 4", "SINTERCARD 2 a b", "SINTERCARD 2 a b LIMIT 1", "SINTERCARD 0 a", "SINTERCARD 3 a b", "SINTERCARD 2 a b LIMIT -1", "SINTERCARD 2 a b NOPE 1"},
+			expOut:   []string{"(integer) 3", "(integer) 3", "(integer) 2", "(integer) 1", ErrNumKeysNotPositive.Error(), ErrNumKeysTooMany.Error(), ErrLimitNegative.Error(), ErrSyntax.Error()},
+		},
+		{
+			name:     "WRONGTYPE",
+			inputArr: []string{"SET str bar", "SADD tags go", "SADD str go", "SMEMBERS str", "SUNION tags str", "GET tags", "HGET tags go"},
+			expOut:   []string{MssgOK, "(integer) 1", wrongType, wrongType, wrongType, wrongType, wrongType},
+		},
+		{
+			name:     "COMPACT with a set",
+			inputArr: []string{"SADD tags redis go", "COMPACT"},
+			expOut:   []string{"(integer) 2", "SADD tags go redis"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			s := GetTestServerWithDB()
+			cc := &ConnContext{}
+
+			for i, input := range tc.inputArr {
+				s.handleCommand(input, &buf, cc)
+
+				if !bytes.Contains(buf.Bytes(), []byte(tc.expOut[i])) {
+					t.Errorf("Expected output of %q to contain %q but got %q instead", input, tc.expOut[i], buf.String())
+				}
+				buf.Reset()
+			}
+		})
+	}
+
+	t.Run("RESP3 replies", func(t *testing.T) {
+		var buf bytes.Buffer
+		s := GetTestServerWithDB()
+		cc := &ConnContext{protocol: resp.RESP3}
+
+		s.handleCommand("SADD tags go", &buf, cc)
+		buf.Reset()
+
+		s.handleCommand("SMEMBERS tags", &buf, cc)
+		if exp := "~1\r\n$2\r\ngo\r\n"; buf.String() != exp {
+			t.Errorf("Expected %q but got %q", exp, buf.String())
+		}
+	})
+}
@@ -0,0 +1,427 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/db"
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/resp"
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store"
+)
+
+const (
+	XADD      string = "XADD"
+	XLEN      string = "XLEN"
+	XRANGE    string = "XRANGE"
+	XREVRANGE string = "XREVRANGE"
+	XDEL      string = "XDEL"
+	XTRIM     string = "XTRIM"
+	XREAD     string = "XREAD"
+)
+
+var (
+	ErrInvalidStreamID    = errors.New("Invalid stream ID specified as stream command argument")
+	ErrInvalidStartID     = errors.New("invalid start ID for the interval")
+	ErrInvalidEndID       = errors.New("invalid end ID for the interval")
+	ErrTrimLimitNoApprox  = errors.New("syntax error, LIMIT cannot be used without the special ~ option")
+	ErrTrimMaxLenNegative = errors.New("The MAXLEN argument must be >= 0.")
+	ErrTrimLimitNegative  = errors.New("The LIMIT argument must be >= 0.")
+)
+
+// XADD key [NOMKSTREAM] [MAXLEN | MINID [= | ~] threshold [LIMIT count]] * | id field value [field value ...]
+// replies with the id of the new entry, or nil if NOMKSTREAM is given and the stream is missing
+func (s *Server) xaddAction(cc *ConnContext, c Command) resp.Reply {
+	opts, i, err := parseXAddOptions(c.args)
+	if err != nil {
+		return resp.NewError(err)
+	}
+	if i >= len(c.args) || len(c.args[i+1:]) == 0 || len(c.args[i+1:])%2 != 0 {
+		return resp.NewError(fmt.Errorf("%v for '%s' command", ErrWrongNumberOfArgs, strings.ToLower(c.name)))
+	}
+
+	id, err := parseXAddID(c.args[i])
+	if err != nil {
+		return resp.NewError(err)
+	}
+
+	newID, ok, err := s.getDb(cc.dbIdx).XAdd(c.args[0], id, c.args[i+1:], opts)
+	switch {
+	case err != nil:
+		return resp.NewError(err)
+	case !ok:
+		return resp.Nil
+	default:
+		return resp.BulkString(newID.String())
+	}
+}
+
+// parses the options of XADD following the key, returns the index of the id which comes after them
+func parseXAddOptions(args []string) (db.XAddOptions, int, error) {
+	var opts db.XAddOptions
+	i := 1
+	for ; i < len(args); i++ {
+		switch strings.ToUpper(args[i]) {
+		case "NOMKSTREAM":
+			opts.NoMkStream = true
+		case "MAXLEN", "MINID":
+			trim, next, err := parseStreamTrim(args, i)
+			if err != nil {
+				return opts, i, err
+			}
+			opts.Trim, i = trim, next-1
+		default:
+			return opts, i, nil
+		}
+	}
+	return opts, i, nil
+}
+
+// XLEN key
+func (s *Server) xlenAction(cc *ConnContext, key string) resp.Reply {
+	n, err := s.getDb(cc.dbIdx).XLen(key)
+	if err != nil {
+		return resp.NewError(err)
+	}
+	return resp.Integer(n)
+}
+
+// XRANGE key start end [COUNT count]
+// XREVRANGE takes end before start and replies from end down to start
+func (s *Server) xrangeAction(cc *ConnContext, c Command) resp.Reply {
+	rev := c.name == XREVRANGE
+	startArg, endArg := c.args[1], c.args[2]
+	if rev {
+		startArg, endArg = endArg, startArg
+	}
+
+	start, err := parseRangeID(startArg, false)
+	if err != nil {
+		return resp.NewError(err)
+	}
+	end, err := parseRangeID(endArg, true)
+	if err != nil {
+		return resp.NewError(err)
+	}
+
+	count := -1
+	switch {
+	case len(c.args) == 5 && strings.ToUpper(c.args[3]) == "COUNT":
+		n, err := strconv.Atoi(c.args[4])
+		if err != nil {
+			return resp.NewError(db.ErrKeyNotInteger)
+		}
+		count = max(n, 0)
+	case len(c.args) != 3:
+		return resp.NewError(ErrSyntax)
+	}
+
+	entries, err := s.getDb(cc.dbIdx).XRange(c.args[0], start, end, count, rev)
+	if err != nil {
+		return resp.NewError(err)
+	}
+	return entriesReply(entries)
+}
+
+// XDEL key id [id ...]
+func (s *Server) xdelAction(cc *ConnContext, args []string) resp.Reply {
+	ids, err := parseStreamIDs(args[1:])
+	if err != nil {
+		return resp.NewError(err)
+	}
+
+	n, err := s.getDb(cc.dbIdx).XDel(args[0], ids...)
+	if err != nil {
+		return resp.NewError(err)
+	}
+	return resp.Integer(n)
+}
+
+// XTRIM key MAXLEN | MINID [= | ~] threshold [LIMIT count]
+func (s *Server) xtrimAction(cc *ConnContext, args []string) resp.Reply {
+	opt := strings.ToUpper(args[1])
+	if opt != "MAXLEN" && opt != "MINID" {
+		return resp.NewError(ErrSyntax)
+	}
+
+	trim, next, err := parseStreamTrim(args, 1)
+	if err != nil {
+		return resp.NewError(err)
+	}
+	if next != len(args) {
+		return resp.NewError(ErrSyntax)
+	}
+
+	n, err := s.getDb(cc.dbIdx).XTrim(args[0], trim)
+	if err != nil {
+		return resp.NewError(err)
+	}
+	return resp.Integer(n)
+}
+
+// XREAD [COUNT count] [BLOCK ms] STREAMS key [key ...] id [id ...]
+// replies with the entries after the id of each stream, $ standing for the last entry,
+// or with nil if there are none once the BLOCK timeout is hit
+func (s *Server) xreadAction(cc *ConnContext, c Command) resp.Reply {
+	x, err := parseXReadArgs(c, false)
+	if err != nil {
+		return resp.NewError(err)
+	}
+
+	after := make([]store.StreamID, len(x.keys))
+	for i, arg := range x.ids {
+		if arg == "$" {
+			after[i], err = s.getDb(cc.dbIdx).XLastID(x.keys[i])
+		} else {
+			after[i], err = parseStreamID(arg, 0)
+		}
+		if err != nil {
+			return resp.NewError(err)
+		}
+	}
+
+	d := s.getDb(cc.dbIdx)
+	read := func() (resp.Reply, bool) {
+		reads, err := d.XRead(x.keys, after, x.count)
+		if err != nil {
+			return resp.NewError(err), true
+		}
+		return streamReadsReply(cc, reads), len(reads) > 0
+	}
+
+	if !x.block {
+		r, _ := read()
+		return r
+	}
+	return s.block(cc, c, x.keys, x.timeout, resp.NilArray, read)
+}
+
+// args of XREAD and XREADGROUP
+type xreadArgs struct {
+	group, consumer string // only for XREADGROUP
+	count           int
+	block           bool          // waits for entries up to timeout
+	timeout         time.Duration // 0 waits forever
+	noAck           bool          // only for XREADGROUP
+	keys, ids       []string
+}
+
+// parses the args of XREAD, or of XREADGROUP with group set
+func parseXReadArgs(c Command, group bool) (xreadArgs, error) {
+	var x xreadArgs
+	args := c.args
+	for i := 0; i < len(args); i++ {
+		switch opt := strings.ToUpper(args[i]); {
+		case opt == "GROUP" && group && i+2 < len(args):
+			x.group, x.consumer = args[i+1], args[i+2]
+			i += 2
+		case opt == "COUNT" && i+1 < len(args):
+			n, err := strconv.Atoi(args[i+1])
+			if err != nil {
+				return x, db.ErrKeyNotInteger
+			}
+			x.count = max(n, 0)
+			i++
+		case opt == "BLOCK" && i+1 < len(args):
+			timeout, err := parseTimeout(args[i+1], true)
+			if err != nil {
+				return x, err
+			}
+			x.block, x.timeout = true, timeout
+			i++
+		case opt == "NOACK" && group:
+			x.noAck = true
+		case opt == "STREAMS" && i+1 < len(args):
+			streams := args[i+1:]
+			if len(streams)%2 != 0 {
+				return x, fmt.Errorf("Unbalanced '%s' list of streams: for each stream key an ID or '$' must be specified.", strings.ToLower(c.name))
+			}
+			x.keys, x.ids = streams[:len(streams)/2], streams[len(streams)/2:]
+			i = len(args)
+		default:
+			return x, ErrSyntax
+		}
+	}
+
+	if x.keys == nil || (group && x.group == "") {
+		return x, ErrSyntax
+	}
+	return x, nil
+}
+
+// parses MAXLEN | MINID [= | ~] threshold [LIMIT count] starting at args[i]
+// returns the trim and the index of the arg following it
+func parseStreamTrim(args []string, i int) (db.StreamTrim, int, error) {
+	var trim db.StreamTrim
+	strategy := strings.ToUpper(args[i])
+	i++
+
+	approx := false
+	if i < len(args) && (args[i] == "=" || args[i] == "~") {
+		approx = args[i] == "~"
+		i++
+	}
+	if i >= len(args) {
+		return trim, i, ErrSyntax
+	}
+
+	if strategy == "MAXLEN" {
+		n, err := strconv.Atoi(args[i])
+		if err != nil {
+			return trim, i, db.ErrKeyNotInteger
+		}
+		if n < 0 {
+			return trim, i, ErrTrimMaxLenNegative
+		}
+		trim.Strategy, trim.MaxLen = db.TrimMaxLen, n
+	} else {
+		id, err := parseStreamID(args[i], 0)
+		if err != nil {
+			return trim, i, err
+		}
+		trim.Strategy, trim.MinID = db.TrimMinID, id
+	}
+	i++
+
+	if i+1 < len(args) && strings.ToUpper(args[i]) == "LIMIT" {
+		n, err := strconv.Atoi(args[i+1])
+		if err != nil {
+			return trim, i, db.ErrKeyNotInteger
+		}
+		if n < 0 {
+			return trim, i, ErrTrimLimitNegative
+		}
+		if !approx {
+			return trim, i, ErrTrimLimitNoApprox
+		}
+		trim.Limit = n
+		i += 2
+	}
+	return trim, i, nil
+}
+
+// parses the id given to XADD: *, ms-* or an explicit id
+func parseXAddID(arg string) (db.XAddID, error) {
+	if arg == "*" {
+		return db.XAddID{AutoMs: true}, nil
+	}
+	if ms, ok := strings.CutSuffix(arg, "-*"); ok {
+		n, err := strconv.ParseUint(ms, 10, 64)
+		if err != nil {
+			return db.XAddID{}, ErrInvalidStreamID
+		}
+		return db.XAddID{ID: store.StreamID{Ms: n}, AutoSeq: true}, nil
+	}
+
+	id, err := parseStreamID(arg, 0)
+	return db.XAddID{ID: id}, err
+}
+
+// parses an id like 1526919030474-55, or 1526919030474 which gets missingSeq as its seq
+func parseStreamID(arg string, missingSeq uint64) (store.StreamID, error) {
+	msPart, seqPart, hasSeq := strings.Cut(arg, "-")
+
+	ms, err := strconv.ParseUint(msPart, 10, 64)
+	if err != nil {
+		return store.StreamID{}, ErrInvalidStreamID
+	}
+
+	seq := missingSeq
+	if hasSeq {
+		if seq, err = strconv.ParseUint(seqPart, 10, 64); err != nil {
+			return store.StreamID{}, ErrInvalidStreamID
+		}
+	}
+	return store.StreamID{Ms: ms, Seq: seq}, nil
+}
+
+func parseStreamIDs(args []string) ([]store.StreamID, error) {
+	ids := make([]store.StreamID, 0, len(args))
+	for _, arg := range args {
+		id, err := parseStreamID(arg, 0)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// parses a bound of XRANGE and friends: - and + for the smallest and the largest id,
+// an id, or an id prefixed with ( to leave it out
+// an id without seq spans the whole ms, so end bounds get the largest seq
+func parseRangeID(arg string, end bool) (store.StreamID, error) {
+	switch arg {
+	case "-":
+		return store.StreamID{}, nil
+	case "+":
+		return store.MaxStreamID, nil
+	}
+
+	exclusive := strings.HasPrefix(arg, "(")
+	if exclusive {
+		arg = arg[1:]
+	}
+
+	missingSeq := uint64(0)
+	if end {
+		missingSeq = math.MaxUint64
+	}
+	id, err := parseStreamID(arg, missingSeq)
+	if err != nil || !exclusive {
+		return id, err
+	}
+
+	if end {
+		if id, ok := id.Prev(); ok {
+			return id, nil
+		}
+		return id, ErrInvalidEndID
+	}
+	if id, ok := id.Next(); ok {
+		return id, nil
+	}
+	return id, ErrInvalidStartID
+}
+
+// replies with an entry as its id and its field value pairs
+// an entry without fields was deleted, and gets nil instead
+func entryReply(e store.StreamEntry) resp.Reply {
+	if e.Fields == nil {
+		return resp.Array{resp.BulkString(e.ID.String()), resp.NilArray}
+	}
+	return resp.Array{resp.BulkString(e.ID.String()), resp.BulkStrings(e.Fields)}
+}
+
+func entriesReply(entries []store.StreamEntry) resp.Reply {
+	replies := resp.Array{}
+	for _, e := range entries {
+		replies = append(replies, entryReply(e))
+	}
+	return replies
+}
+
+// replies with the entries read from each stream, or with nil if nothing was read
+// RESP3 clients get a map of streams, RESP2 ones an array of key entries pairs
+func streamReadsReply(cc *ConnContext, reads []db.StreamRead) resp.Reply {
+	if len(reads) == 0 {
+		return resp.NilArray
+	}
+
+	if cc.protocol == resp.RESP3 {
+		m := resp.Map{}
+		for _, r := range reads {
+			m = append(m, resp.MapItem{Key: resp.BulkString(r.Key), Value: entriesReply(r.Entries)})
+		}
+		return m
+	}
+
+	replies := resp.Array{}
+	for _, r := range reads {
+		replies = append(replies, resp.Array{resp.BulkString(r.Key), entriesReply(r.Entries)})
+	}
+	return replies
+}
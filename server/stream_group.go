@@ -0,0 +1,437 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/db"
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/resp"
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store"
+)
+
+const (
+	XGROUP     string = "XGROUP"
+	XREADGROUP string = "XREADGROUP"
+	XACK       string = "XACK"
+	XPENDING   string = "XPENDING"
+	XCLAIM     string = "XCLAIM"
+	XAUTOCLAIM string = "XAUTOCLAIM"
+	XINFO      string = "XINFO"
+
+	defaultAutoClaimCount int = 100 // entries claimed by XAUTOCLAIM without COUNT
+)
+
+var (
+	ErrXReadGroupLastID  = errors.New("The $ ID is meaningless in the context of XREADGROUP: you want to read the history of this consumer by specifying a proper ID, or use the > ID to get new messages. The $ ID would just return an empty result set.")
+	ErrInvalidMinIdle    = errors.New("Invalid min-idle-time argument")
+	ErrAutoClaimCount    = errors.New("COUNT must be > 0")
+	ErrEntriesReadFormat = errors.New("value for ENTRIESREAD must be positive or -1")
+)
+
+// XGROUP CREATE key group id | $ [MKSTREAM] [ENTRIESREAD entries-read]
+// XGROUP SETID key group id | $ [ENTRIESREAD entries-read]
+// XGROUP DESTROY key group
+func (s *Server) xgroupAction(cc *ConnContext, args []string) resp.Reply {
+	sub := strings.ToUpper(args[0])
+	switch {
+	case (sub == "CREATE" || sub == "SETID") && len(args) >= 4:
+		opts, err := parseXGroupOptions(args[3:], sub == "CREATE")
+		if err != nil {
+			return resp.NewError(err)
+		}
+
+		if sub == "CREATE" {
+			err = s.getDb(cc.dbIdx).XGroupCreate(args[1], args[2], opts)
+		} else {
+			err = s.getDb(cc.dbIdx).XGroupSetID(args[1], args[2], opts)
+		}
+		if err != nil {
+			return resp.NewError(err)
+		}
+		return resp.SimpleString(MssgOK)
+	case sub == "DESTROY" && len(args) == 3:
+		ok, err := s.getDb(cc.dbIdx).XGroupDestroy(args[1], args[2])
+		if err != nil {
+			return resp.NewError(err)
+		}
+		return boolReply(ok)
+	default:
+		return resp.NewError(fmt.Errorf("unknown subcommand '%s'. Try XGROUP HELP.", args[0]))
+	}
+}
+
+// parses id | $ [MKSTREAM] [ENTRIESREAD entries-read], MKSTREAM is only allowed for CREATE
+func parseXGroupOptions(args []string, create bool) (db.XGroupOptions, error) {
+	var opts db.XGroupOptions
+	if args[0] == "$" {
+		opts.LastEntry = true
+	} else {
+		id, err := parseStreamID(args[0], 0)
+		if err != nil {
+			return opts, err
+		}
+		opts.ID = id
+	}
+
+	for i := 1; i < len(args); i++ {
+		switch opt := strings.ToUpper(args[i]); {
+		case opt == "MKSTREAM" && create:
+			opts.MkStream = true
+		case opt == "ENTRIESREAD" && i+1 < len(args):
+			n, err := strconv.ParseInt(args[i+1], 10, 64)
+			if err != nil {
+				return opts, db.ErrKeyNotInteger
+			}
+			if n < -1 {
+				return opts, ErrEntriesReadFormat
+			}
+			opts.EntriesRead, opts.HasEntriesRead = n, true
+			i++
+		default:
+			return opts, ErrSyntax
+		}
+	}
+	return opts, nil
+}
+
+// XREADGROUP GROUP group consumer [COUNT count] [BLOCK ms] [NOACK] STREAMS key [key ...] id [id ...]
+// > reads the entries never delivered to the group, any other id the history of the consumer
+func (s *Server) xreadgroupAction(cc *ConnContext, c Command) resp.Reply {
+	x, err := parseXReadArgs(c, true)
+	if err != nil {
+		return resp.NewError(err)
+	}
+
+	ids := make([]db.XReadGroupID, len(x.ids))
+	for i, arg := range x.ids {
+		switch arg {
+		case ">":
+			ids[i].New = true
+		case "$":
+			return resp.NewError(ErrXReadGroupLastID)
+		default:
+			if ids[i].ID, err = parseStreamID(arg, 0); err != nil {
+				return resp.NewError(err)
+			}
+		}
+	}
+
+	// the history of the consumer is read right away, only new entries are waited for
+	d := s.getDb(cc.dbIdx)
+	read := func() (resp.Reply, bool) {
+		reads, err := d.XReadGroup(x.group, x.consumer, x.keys, ids, x.count, x.noAck)
+		if err != nil {
+			return resp.NewError(err), true
+		}
+		return streamReadsReply(cc, reads), len(reads) > 0 || !slices.ContainsFunc(ids, func(id db.XReadGroupID) bool { return id.New })
+	}
+
+	if !x.block {
+		r, _ := read()
+		return r
+	}
+	return s.block(cc, c, x.keys, x.timeout, resp.NilArray, read)
+}
+
+// XACK key group id [id ...]
+func (s *Server) xackAction(cc *ConnContext, args []string) resp.Reply {
+	ids, err := parseStreamIDs(args[2:])
+	if err != nil {
+		return resp.NewError(err)
+	}
+
+	n, err := s.getDb(cc.dbIdx).XAck(args[0], args[1], ids...)
+	if err != nil {
+		return resp.NewError(err)
+	}
+	return resp.Integer(n)
+}
+
+// XPENDING key group [[IDLE min-idle-time] start end count [consumer]]
+// replies with a summary of the pending entries without a range
+func (s *Server) xpendingAction(cc *ConnContext, args []string) resp.Reply {
+	key, group := args[0], args[1]
+	if len(args) == 2 {
+		return s.xpendingSummary(cc, key, group)
+	}
+
+	q := db.PendingQuery{}
+	rest := args[2:]
+	if strings.ToUpper(rest[0]) == "IDLE" && len(rest) > 1 {
+		n, err := strconv.ParseInt(rest[1], 10, 64)
+		if err != nil {
+			return resp.NewError(db.ErrKeyNotInteger)
+		}
+		q.MinIdle = n
+		rest = rest[2:]
+	}
+	if len(rest) < 3 || len(rest) > 4 {
+		return resp.NewError(ErrSyntax)
+	}
+
+	var err error
+	if q.Start, err = parseRangeID(rest[0], false); err != nil {
+		return resp.NewError(err)
+	}
+	if q.End, err = parseRangeID(rest[1], true); err != nil {
+		return resp.NewError(err)
+	}
+	if q.Count, err = strconv.Atoi(rest[2]); err != nil {
+		return resp.NewError(db.ErrKeyNotInteger)
+	}
+	q.Count = max(q.Count, 0)
+	if len(rest) == 4 {
+		q.Consumer = rest[3]
+	}
+
+	pending, err := s.getDb(cc.dbIdx).XPending(key, group, q)
+	if err != nil {
+		return resp.NewError(err)
+	}
+
+	replies := resp.Array{}
+	for _, p := range pending {
+		replies = append(replies, resp.Array{
+			resp.BulkString(p.ID.String()),
+			resp.BulkString(p.Consumer),
+			resp.Integer(p.Idle),
+			resp.Integer(p.DeliveryCount),
+		})
+	}
+	return replies
+}
+
+// replies with the number of pending entries, the smallest and largest pending ids
+// and the number of pending entries of each consumer
+func (s *Server) xpendingSummary(cc *ConnContext, key, group string) resp.Reply {
+	summary, err := s.getDb(cc.dbIdx).XPendingSummary(key, group)
+	if err != nil {
+		return resp.NewError(err)
+	}
+	if summary.Count == 0 {
+		return resp.Array{resp.Integer(0), resp.Nil, resp.Nil, resp.NilArray}
+	}
+
+	consumers := resp.Array{}
+	for _, c := range summary.Consumers {
+		consumers = append(consumers, resp.BulkStrings([]string{c.Name, strconv.Itoa(c.Count)}))
+	}
+	return resp.Array{
+		resp.Integer(summary.Count),
+		resp.BulkString(summary.Min.String()),
+		resp.BulkString(summary.Max.String()),
+		consumers,
+	}
+}
+
+// XCLAIM key group consumer min-idle-time id [id ...] [IDLE ms] [TIME unix-time-milliseconds]
+// [RETRYCOUNT count] [FORCE] [JUSTID] [LASTID lastid]
+// replies with the claimed entries, or just their ids with JUSTID
+func (s *Server) xclaimAction(cc *ConnContext, args []string) resp.Reply {
+	minIdle, err := strconv.ParseInt(args[3], 10, 64)
+	if err != nil {
+		return resp.NewError(fmt.Errorf("%v for XCLAIM", ErrInvalidMinIdle))
+	}
+	opts := db.XClaimOptions{Idle: -1, Time: -1, RetryCount: -1, MinIdle: max(minIdle, 0)}
+
+	// ids come first, the options start at the first arg which isn't one
+	i := 4
+	var ids []store.StreamID
+	for ; i < len(args); i++ {
+		id, err := parseStreamID(args[i], 0)
+		if err != nil {
+			break
+		}
+		ids = append(ids, id)
+	}
+
+	for ; i < len(args); i++ {
+		opt := strings.ToUpper(args[i])
+		hasValue := opt == "LASTID" || opt == "IDLE" || opt == "TIME" || opt == "RETRYCOUNT"
+		switch {
+		case opt == "FORCE":
+			opts.Force = true
+		case opt == "JUSTID":
+			opts.JustID = true
+		case hasValue && i+1 == len(args):
+			return resp.NewError(ErrSyntax)
+		case opt == "LASTID":
+			id, err := parseStreamID(args[i+1], 0)
+			if err != nil {
+				return resp.NewError(err)
+			}
+			opts.LastID, opts.HasLastID = id, true
+			i++
+		case opt == "IDLE" || opt == "TIME" || opt == "RETRYCOUNT":
+			n, err := strconv.ParseInt(args[i+1], 10, 64)
+			if err != nil {
+				return resp.NewError(db.ErrKeyNotInteger)
+			}
+			n = max(n, 0)
+			switch opt {
+			case "IDLE":
+				opts.Idle = n
+			case "TIME":
+				opts.Time = n
+			default:
+				opts.RetryCount = int(n)
+			}
+			i++
+		default:
+			return resp.NewError(fmt.Errorf("Unrecognized XCLAIM option '%s'", args[i]))
+		}
+	}
+
+	entries, err := s.getDb(cc.dbIdx).XClaim(args[0], args[1], args[2], ids, opts)
+	if err != nil {
+		return resp.NewError(err)
+	}
+	if opts.JustID {
+		return idsReply(entries)
+	}
+	return entriesReply(entries)
+}
+
+// XAUTOCLAIM key group consumer min-idle-time start [COUNT count] [JUSTID]
+// replies with the id to continue from, the claimed entries
+// and the ids of the pending entries which were deleted from the stream
+func (s *Server) xautoclaimAction(cc *ConnContext, args []string) resp.Reply {
+	minIdle, err := strconv.ParseInt(args[3], 10, 64)
+	if err != nil {
+		return resp.NewError(fmt.Errorf("%v for XAUTOCLAIM", ErrInvalidMinIdle))
+	}
+	start, err := parseRangeID(args[4], false)
+	if err != nil {
+		return resp.NewError(err)
+	}
+
+	count, justID := defaultAutoClaimCount, false
+	for i := 5; i < len(args); i++ {
+		switch opt := strings.ToUpper(args[i]); {
+		case opt == "JUSTID":
+			justID = true
+		case opt == "COUNT" && i+1 < len(args):
+			n, err := strconv.Atoi(args[i+1])
+			if err != nil {
+				return resp.NewError(db.ErrKeyNotInteger)
+			}
+			if n < 1 {
+				return resp.NewError(ErrAutoClaimCount)
+			}
+			count = n
+			i++
+		default:
+			return resp.NewError(ErrSyntax)
+		}
+	}
+
+	next, claimed, deleted, err := s.getDb(cc.dbIdx).XAutoClaim(args[0], args[1], args[2], max(minIdle, 0), start, count, justID)
+	if err != nil {
+		return resp.NewError(err)
+	}
+
+	deletedIDs := resp.Array{}
+	for _, id := range deleted {
+		deletedIDs = append(deletedIDs, resp.BulkString(id.String()))
+	}
+	if justID {
+		return resp.Array{resp.BulkString(next.String()), idsReply(claimed), deletedIDs}
+	}
+	return resp.Array{resp.BulkString(next.String()), entriesReply(claimed), deletedIDs}
+}
+
+// XINFO STREAM key
+// XINFO GROUPS key
+// XINFO CONSUMERS key group
+func (s *Server) xinfoAction(cc *ConnContext, args []string) resp.Reply {
+	sub := strings.ToUpper(args[0])
+	switch {
+	case sub == "STREAM" && len(args) == 2:
+		return s.xinfoStream(cc, args[1])
+	case sub == "GROUPS" && len(args) == 2:
+		return s.xinfoGroups(cc, args[1])
+	case sub == "CONSUMERS" && len(args) == 3:
+		return s.xinfoConsumers(cc, args[1], args[2])
+	default:
+		return resp.NewError(fmt.Errorf("unknown subcommand '%s'. Try XINFO HELP.", args[0]))
+	}
+}
+
+func (s *Server) xinfoStream(cc *ConnContext, key string) resp.Reply {
+	info, err := s.getDb(cc.dbIdx).XInfoStream(key)
+	if err != nil {
+		return resp.NewError(err)
+	}
+
+	var first, last resp.Reply = resp.Nil, resp.Nil
+	if info.First != nil {
+		first, last = entryReply(*info.First), entryReply(*info.Last)
+	}
+	return resp.Map{
+		{Key: resp.BulkString("length"), Value: resp.Integer(info.Length)},
+		{Key: resp.BulkString("last-generated-id"), Value: resp.BulkString(info.LastID.String())},
+		{Key: resp.BulkString("max-deleted-entry-id"), Value: resp.BulkString(info.MaxDeletedID.String())},
+		{Key: resp.BulkString("entries-added"), Value: resp.Integer(info.EntriesAdded)},
+		{Key: resp.BulkString("recorded-first-entry-id"), Value: resp.BulkString(info.RecordedFirstID.String())},
+		{Key: resp.BulkString("groups"), Value: resp.Integer(info.Groups)},
+		{Key: resp.BulkString("first-entry"), Value: first},
+		{Key: resp.BulkString("last-entry"), Value: last},
+	}
+}
+
+func (s *Server) xinfoGroups(cc *ConnContext, key string) resp.Reply {
+	groups, err := s.getDb(cc.dbIdx).XInfoGroups(key)
+	if err != nil {
+		return resp.NewError(err)
+	}
+
+	replies := resp.Array{}
+	for _, g := range groups {
+		replies = append(replies, resp.Map{
+			{Key: resp.BulkString("name"), Value: resp.BulkString(g.Name)},
+			{Key: resp.BulkString("consumers"), Value: resp.Integer(g.Consumers)},
+			{Key: resp.BulkString("pending"), Value: resp.Integer(g.Pending)},
+			{Key: resp.BulkString("last-delivered-id"), Value: resp.BulkString(g.LastID.String())},
+			{Key: resp.BulkString("entries-read"), Value: unknownIfNegative(g.EntriesRead)},
+			{Key: resp.BulkString("lag"), Value: unknownIfNegative(g.Lag)},
+		})
+	}
+	return replies
+}
+
+func (s *Server) xinfoConsumers(cc *ConnContext, key, group string) resp.Reply {
+	consumers, err := s.getDb(cc.dbIdx).XInfoConsumers(key, group)
+	if err != nil {
+		return resp.NewError(err)
+	}
+
+	replies := resp.Array{}
+	for _, c := range consumers {
+		replies = append(replies, resp.Map{
+			{Key: resp.BulkString("name"), Value: resp.BulkString(c.Name)},
+			{Key: resp.BulkString("pending"), Value: resp.Integer(c.Pending)},
+			{Key: resp.BulkString("idle"), Value: resp.Integer(c.Idle)},
+			{Key: resp.BulkString("inactive"), Value: resp.Integer(c.Inactive)},
+		})
+	}
+	return replies
+}
+
+// replies with nil for the -1 standing for an unknown count
+func unknownIfNegative(n int64) resp.Reply {
+	if n < 0 {
+		return resp.Nil
+	}
+	return resp.Integer(n)
+}
+
+func idsReply(entries []store.StreamEntry) resp.Reply {
+	replies := resp.Array{}
+	for _, e := range entries {
+		replies = append(replies, resp.BulkString(e.ID.String()))
+	}
+	return replies
+}
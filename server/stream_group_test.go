@@ -0,0 +1,100 @@
+package server
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/db"
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/resp"
+)
+
+func TestStreamGroupCommands(t *testing.T) {
+	fill := []string{"XADD s 1-1 f a", "XADD s 1-2 f b", "XGROUP CREATE s g 0"}
+	fillOut := []string{"\"1-1\"", "\"1-2\"", MssgOK}
+
+	testCases := []struct {
+		name     string
+		inputArr []string
+		expOut   []string
+	}{
+		{
+			name:     "XGROUP",
+			inputArr: []string{"XGROUP CREATE s g $", "XGROUP CREATE missing g $", "XGROUP CREATE new g $ MKSTREAM", "TYPE new", "XGROUP SETID s g 1-1 ENTRIESREAD -2", "XGROUP DESTROY s g", "XGROUP DESTROY s g", "XGROUP NOPE s g"},
+			expOut:   []string{db.ErrBusyGroup.Error(), db.ErrXGroupNoKey.Error(), MssgOK, "stream", ErrEntriesReadFormat.Error(), "(integer) 1", "(integer) 0", "unknown subcommand 'NOPE'. Try XGROUP HELP."},
+		},
+		{
+			name:     "XREADGROUP and XACK",
+			inputArr: []string{"XREADGROUP GROUP g alice COUNT 1 STREAMS s >", "XREADGROUP GROUP g bob STREAMS s >", "XREADGROUP GROUP g bob STREAMS s >", "XREADGROUP GROUP g alice STREAMS s 0", "XACK s g 1-1 1-2", "XREADGROUP GROUP g alice STREAMS s 0", "XREADGROUP GROUP g alice STREAMS s $", "XREADGROUP GROUP nope alice STREAMS s >"},
+			expOut:   []string{"1) 1) \"s\"\n   2) 1) 1) \"1-1\"\n         2) 1) \"f\"\n            2) \"a\"\n", "2) 1) 1) \"1-2\"", MssgNil, "2) 1) 1) \"1-1\"", "(integer) 2", "1) 1) \"s\"\n   2) (empty array)\n", ErrXReadGroupLastID.Error(), "NOGROUP No such key 's' or consumer group 'nope'"},
+		},
+		{
+			name:     "XREADGROUP shows deleted entries of the history without fields",
+			inputArr: []string{"XREADGROUP GROUP g alice STREAMS s >", "XDEL s 1-1", "XREADGROUP GROUP g alice STREAMS s 0"},
+			expOut:   []string{"1) 1) \"s\"", "(integer) 1", "2) 1) 1) \"1-1\"\n         2) (nil)\n"},
+		},
+		{
+			name:     "XPENDING",
+			inputArr: []string{"XPENDING s g", "XREADGROUP GROUP g alice STREAMS s >", "XPENDING s g", "XPENDING s g - + 10 alice", "XPENDING s g IDLE 100000 - + 10", "XPENDING s g - + 10 bob"},
+			expOut:   []string{"1) (integer) 0\n2) (nil)\n3) (nil)\n4) (nil)\n", "1) 1) \"s\"", "1) (integer) 2\n2) \"1-1\"\n3) \"1-2\"\n4) 1) 1) \"alice\"\n      2) \"2\"\n", "1) 1) \"1-1\"\n   2) \"alice\"\n", MssgEmptyArray, MssgEmptyArray},
+		},
+		{
+			name:     "XCLAIM",
+			inputArr: []string{"XREADGROUP GROUP g alice STREAMS s >", "XCLAIM s g bob 100000 1-1", "XCLAIM s g bob 0 1-1 9-9 JUSTID", "XPENDING s g - + 10 bob", "XCLAIM s g bob x 1-1", "XCLAIM s g bob 0 1-1 NOPE"},
+			expOut:   []string{"1) 1) \"s\"", MssgEmptyArray, "1) \"1-1\"\n", "1) 1) \"1-1\"\n   2) \"bob\"\n", "Invalid min-idle-time argument for XCLAIM", "Unrecognized XCLAIM option 'NOPE'"},
+		},
+		{
+			name:     "XAUTOCLAIM",
+			inputArr: []string{"XREADGROUP GROUP g alice STREAMS s >", "XDEL s 1-2", "XAUTOCLAIM s g bob 0 0 COUNT 1", "XAUTOCLAIM s g bob 0 1-2 JUSTID", "XAUTOCLAIM s g bob 0 0 COUNT 0"},
+			expOut:   []string{"1) 1) \"s\"", "(integer) 1", "1) \"1-2\"\n2) 1) 1) \"1-1\"\n      2) 1) \"f\"\n         2) \"a\"\n3) (empty array)\n", "1) \"0-0\"\n2) (empty array)\n3) 1) \"1-2\"\n", ErrAutoClaimCount.Error()},
+		},
+		{
+			name:     "XINFO",
+			inputArr: []string{"XREADGROUP GROUP g alice COUNT 1 STREAMS s >", "XINFO STREAM s", "XINFO GROUPS s", "XINFO CONSUMERS s g", "XINFO STREAM missing", "XINFO NOPE"},
+			expOut:   []string{"1) 1) \"s\"", "1# \"length\" => (integer) 2", "5# \"entries-read\" => (integer) 1\n   6# \"lag\" => (integer) 1\n", "1# \"name\" => \"alice\"\n   2# \"pending\" => (integer) 1", db.ErrNoSuchKey.Error(), "unknown subcommand 'NOPE'. Try XINFO HELP."},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			s := GetTestServerWithDB()
+			cc := &ConnContext{}
+
+			inputArr := append(append([]string{}, fill...), tc.inputArr...)
+			expOut := append(append([]string{}, fillOut...), tc.expOut...)
+			for i, input := range inputArr {
+				s.handleCommand(input, &buf, cc)
+
+				if !bytes.Contains(buf.Bytes(), []byte(expOut[i])) {
+					t.Errorf("Expected output of %q to contain %q but got %q instead", input, expOut[i], buf.String())
+				}
+				buf.Reset()
+			}
+		})
+	}
+
+	t.Run("RESP2 and RESP3 replies", func(t *testing.T) {
+		var buf bytes.Buffer
+		s := GetTestServerWithDB()
+
+		testCases := []struct {
+			protocol resp.Protocol
+			input    string
+			exp      string
+		}{
+			{resp.RESP2, "XINFO CONSUMERS s g", "*1\r\n*8\r\n$4\r\nname\r\n$5\r\nalice\r\n"},
+			{resp.RESP3, "XINFO CONSUMERS s g", "*1\r\n%4\r\n$4\r\nname\r\n$5\r\nalice\r\n"},
+		}
+
+		for _, input := range append(fill, "XREADGROUP GROUP g alice STREAMS s >") {
+			s.handleCommand(input, &buf, &ConnContext{})
+		}
+		for _, tc := range testCases {
+			buf.Reset()
+			s.handleCommand(tc.input, &buf, &ConnContext{protocol: tc.protocol})
+			if !bytes.HasPrefix(buf.Bytes(), []byte(tc.exp)) {
+				t.Errorf("Expected %q to start with %q over protocol %d but got %q", tc.input, tc.exp, tc.protocol, buf.String())
+			}
+		}
+	})
+}
@@ -0,0 +1,102 @@
+package server
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/db"
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/resp"
+)
+
+func TestStreamCommands(t *testing.T) {
+	wrongType := db.ErrWrongType.Error()
+
+	testCases := []struct {
+		name     string
+		inputArr []string
+		expOut   []string
+	}{
+		{
+			name:     "XADD and XLEN",
+			inputArr: []string{"XADD s 1-1 f a", "XADD s 1-* f b", "XADD s 1 f c", "XADD s 0-5 f d", "XLEN s", "XLEN missing"},
+			expOut:   []string{"\"1-1\"", "\"1-2\"", db.ErrStreamIDTooSmall.Error(), db.ErrStreamIDTooSmall.Error(), "(integer) 2", "(integer) 0"},
+		},
+		{
+			name:     "XADD bad args",
+			inputArr: []string{"XADD s 1-1 f", "XADD s x f a", "XADD s 0-0 f a", "XADD s NOMKSTREAM * f a", "TYPE s"},
+			expOut:   []string{"wrong number of arguments for 'xadd' command", ErrInvalidStreamID.Error(), db.ErrStreamIDZero.Error(), MssgNil, "none"},
+		},
+		{
+			name:     "XADD with trimming",
+			inputArr: []string{"XADD s 1-1 f a", "XADD s 1-2 f b", "XADD s MAXLEN 2 1-3 f c", "XRANGE s - +", "XADD s MAXLEN 1 LIMIT 1 1-4 f d", "XADD s MAXLEN -1 1-4 f d"},
+			expOut:   []string{"\"1-1\"", "\"1-2\"", "\"1-3\"", "1) 1) \"1-2\"\n   2) 1) \"f\"\n      2) \"b\"\n2) 1) \"1-3\"", ErrTrimLimitNoApprox.Error(), ErrTrimMaxLenNegative.Error()},
+		},
+		{
+			name:     "XRANGE and XREVRANGE",
+			inputArr: []string{"XADD s 1-1 f a", "XADD s 2-1 f b", "XADD s 3-1 f c", "XRANGE s 2 +", "XRANGE s (1-1 3-0", "XREVRANGE s + - COUNT 1", "XRANGE s 5 +", "XRANGE s x +", "XRANGE s (18446744073709551615-18446744073709551615 +"},
+			expOut:   []string{"\"1-1\"", "\"2-1\"", "\"3-1\"", "1) 1) \"2-1\"\n   2) 1) \"f\"\n      2) \"b\"\n2) 1) \"3-1\"", "1) 1) \"2-1\"\n   2) 1) \"f\"\n      2) \"b\"\n", "1) 1) \"3-1\"\n   2) 1) \"f\"\n      2) \"c\"\n", MssgEmptyArray, ErrInvalidStreamID.Error(), ErrInvalidStartID.Error()},
+		},
+		{
+			name:     "XDEL and XTRIM keep the empty stream",
+			inputArr: []string{"XADD s 1-1 f a", "XADD s 1-2 f b", "XDEL s 1-1 9-9", "XTRIM s MINID 2", "XLEN s", "TYPE s", "XADD s 1-2 f c"},
+			expOut:   []string{"\"1-1\"", "\"1-2\"", "(integer) 1", "(integer) 1", "(integer) 0", "stream", db.ErrStreamIDTooSmall.Error()},
+		},
+		{
+			name:     "XREAD",
+			inputArr: []string{"XADD s 1-1 f a", "XADD s 1-2 f b", "XREAD COUNT 1 STREAMS s 0", "XREAD STREAMS s $", "XREAD STREAMS s missing 1-1", "XREAD STREAMS s missing 1-2 0"},
+			expOut:   []string{"\"1-1\"", "\"1-2\"", "1) 1) \"s\"\n   2) 1) 1) \"1-1\"\n         2) 1) \"f\"\n            2) \"a\"\n", MssgNil, "Unbalanced 'xread' list of streams", MssgNil},
+		},
+		{
+			name:     "WRONGTYPE",
+			inputArr: []string{"SET str bar", "XADD str * f a", "XRANGE str - +", "XREAD STREAMS str 0", "XADD s 1-1 f a", "GET s"},
+			expOut:   []string{MssgOK, wrongType, wrongType, wrongType, "\"1-1\"", wrongType},
+		},
+		{
+			name:     "COMPACT with a stream",
+			inputArr: []string{"XADD s 1-1 f a", "XADD s 1-2 f b g c", "COMPACT"},
+			expOut:   []string{"\"1-1\"", "\"1-2\"", "XADD s 1-1 f a\nXADD s 1-2 f b g c"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			s := GetTestServerWithDB()
+			cc := &ConnContext{}
+
+			for i, input := range tc.inputArr {
+				s.handleCommand(input, &buf, cc)
+
+				if !bytes.Contains(buf.Bytes(), []byte(tc.expOut[i])) {
+					t.Errorf("Expected output of %q to contain %q but got %q instead", input, tc.expOut[i], buf.String())
+				}
+				buf.Reset()
+			}
+		})
+	}
+
+	t.Run("RESP2 and RESP3 replies", func(t *testing.T) {
+		var buf bytes.Buffer
+		s := GetTestServerWithDB()
+
+		testCases := []struct {
+			protocol resp.Protocol
+			input    string
+			exp      string
+		}{
+			{resp.RESP2, "XREAD STREAMS s 0", "*1\r\n*2\r\n$1\r\ns\r\n*1\r\n*2\r\n$3\r\n1-1\r\n*2\r\n$1\r\nf\r\n$1\r\na\r\n"},
+			{resp.RESP3, "XREAD STREAMS s 0", "%1\r\n$1\r\ns\r\n*1\r\n*2\r\n$3\r\n1-1\r\n*2\r\n$1\r\nf\r\n$1\r\na\r\n"},
+			{resp.RESP2, "XREAD STREAMS s $", "*-1\r\n"},
+			{resp.RESP3, "XREAD STREAMS s $", "_\r\n"},
+		}
+
+		s.handleCommand("XADD s 1-1 f a", &buf, &ConnContext{})
+		for _, tc := range testCases {
+			buf.Reset()
+			s.handleCommand(tc.input, &buf, &ConnContext{protocol: tc.protocol})
+			if buf.String() != tc.exp {
+				t.Errorf("Expected %q for %q over protocol %d but got %q", tc.exp, tc.input, tc.protocol, buf.String())
+			}
+		}
+	})
+}
@@ -0,0 +1,135 @@
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/db"
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store/inMemoryStore"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// reads a RESP2 reply, aggregates are read whole but only their header is returned
+func readRESP(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if len(line) == 0 {
+		return "", fmt.Errorf("empty reply")
+	}
+
+	n, _ := strconv.Atoi(line[1:])
+	switch line[0] {
+	case '$':
+		if n < 0 {
+			return line, nil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return "", err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		for range n {
+			if _, err := readRESP(r); err != nil {
+				return "", err
+			}
+		}
+	}
+	return line, nil
+}
+
+// many clients hammer the same dbs, run it with -race to catch unguarded state
+func TestStress(t *testing.T) {
+	t.Run("goroutine per conn", func(t *testing.T) { stress(t, false) })
+	t.Run("single executor", func(t *testing.T) { stress(t, true) })
+}
+
+func stress(t *testing.T, singleThreaded bool) {
+	const (
+		clients = 12
+		rounds  = 150
+		dbs     = 4
+	)
+
+	ln := bufconn.Listen(1024 * 1024)
+	s := &Server{Db: map[int]db.DbInterface{0: db.GetNewDB(inMemoryStore.NewInMemoryStore())}, Listener: ln, SingleThreaded: singleThreaded}
+	go s.Start()
+	defer s.Close()
+
+	var incrs [dbs]atomic.Int64
+	var wg sync.WaitGroup
+	for id := range clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			conn, err := ln.Dial()
+			if err != nil {
+				t.Errorf("Failed to dial: %v", err)
+				return
+			}
+			defer conn.Close()
+			r := bufio.NewReader(conn)
+
+			send := func(input string) string {
+				fmt.Fprintln(conn, input)
+				out, err := readRESP(r)
+				if err != nil {
+					t.Errorf("Failed to read the reply of %q: %v", input, err)
+				}
+				if strings.HasPrefix(out, "-") {
+					t.Errorf("Expected %q to succeed but got %q", input, out)
+				}
+				return out
+			}
+
+			for i := range rounds {
+				idx := (id + i) % dbs
+				own := fmt.Sprintf("client%d", id)
+				val := strconv.Itoa(i)
+
+				send("SELECT " + strconv.Itoa(idx))
+				send("SET " + own + " " + val)
+				send("SET shared " + val)
+				send("INCR counter")
+				incrs[idx].Add(1)
+				send("HSET hash " + own + " " + val)
+				send("RPUSH list " + val)
+				if out := send("GET " + own); out != val {
+					t.Errorf("Expected %q but got %q", val, out)
+				}
+				switch i % 5 {
+				case 0:
+					send("COMPACT")
+				case 1:
+					send("DEL shared")
+				case 2:
+					send("LPOP list")
+				case 3:
+					send("MULTI")
+					send("INCR counter")
+					send("DEL " + own)
+					send("EXEC")
+					incrs[idx].Add(1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	// no INCR was lost on the way
+	for idx := range dbs {
+		out, err := s.getDb(idx).Get("counter")
+		if err != nil || out != strconv.FormatInt(incrs[idx].Load(), 10) {
+			t.Errorf("Expected the counter of db %d to be %d but got %q", idx, incrs[idx].Load(), out)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package server
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/resp"
+)
+
+const (
+	WATCH    string = "WATCH"
+	UNWATCH  string = "UNWATCH"
+	FLUSHDB  string = "FLUSHDB"
+	FLUSHALL string = "FLUSHALL"
+)
+
+var ErrWatchInMulti = errors.New("WATCH inside MULTI is not allowed")
+
+// key watched by a client along with its version at WATCH time
+type watchedKey struct {
+	dbIdx   int
+	key     string
+	version uint64
+}
+
+// WATCH key [key ...]
+// EXEC fails if any of the keys is touched before it, watching a key twice keeps its first version
+func (s *Server) watchAction(cc *ConnContext, keys []string) resp.Reply {
+	for _, key := range keys {
+		if cc.watches(cc.dbIdx, key) {
+			continue
+		}
+		version := s.getDb(cc.dbIdx).Watch(key)
+		cc.watched = append(cc.watched, watchedKey{dbIdx: cc.dbIdx, key: key, version: version})
+	}
+	return resp.SimpleString(MssgOK)
+}
+
+// UNWATCH
+func (s *Server) unwatchAction(cc *ConnContext) resp.Reply {
+	s.unwatchAll(cc)
+	return resp.SimpleString(MssgOK)
+}
+
+func (cc *ConnContext) watches(dbIdx int, key string) bool {
+	for _, w := range cc.watched {
+		if w.dbIdx == dbIdx && w.key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// forgets the keys watched by the client, done once the tran ends or the conn is closed
+func (s *Server) unwatchAll(cc *ConnContext) {
+	for _, w := range cc.watched {
+		s.getDb(w.dbIdx).Unwatch(w.key)
+	}
+	cc.watched = nil
+}
+
+// reports whether any key watched by the client was touched since WATCH
+func (s *Server) watchedKeysTouched(cc *ConnContext) bool {
+	for _, w := range cc.watched {
+		if s.getDb(w.dbIdx).Touched(w.key, w.version) {
+			return true
+		}
+	}
+	return false
+}
+
+// FLUSHDB [ASYNC | SYNC]
+// FLUSHALL takes the same args, and flushes every db instead of the selected one
+// both modes flush right away
+func (s *Server) flushAction(cc *ConnContext, c Command) resp.Reply {
+	if len(c.args) > 1 {
+		return resp.NewError(ErrSyntax)
+	}
+	if len(c.args) == 1 {
+		if mode := strings.ToUpper(c.args[0]); mode != "ASYNC" && mode != "SYNC" {
+			return resp.NewError(ErrSyntax)
+		}
+	}
+
+	if c.name == FLUSHDB {
+		s.getDb(cc.dbIdx).Flush()
+		return resp.SimpleString(MssgOK)
+	}
+	for _, d := range s.allDbs() {
+		d.Flush()
+	}
+	return resp.SimpleString(MssgOK)
+}
@@ -0,0 +1,137 @@
+package server
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWatchCommands(t *testing.T) {
+	// steps are run by the client they start with, a for the watcher and b for another client
+	testCases := []struct {
+		name   string
+		steps  []string
+		expOut string
+	}{
+		{
+			name:   "untouched keys",
+			steps:  []string{"a WATCH foo bar", "b SET other 1", "a MULTI", "a SET foo 2", "a EXEC"},
+			expOut: "1) OK\n",
+		},
+		{
+			name:   "key written by another client",
+			steps:  []string{"a WATCH foo", "b SET foo 1", "a MULTI", "a SET foo 2", "a EXEC"},
+			expOut: MssgNil + "\n",
+		},
+		{
+			name:   "key written by the client itself",
+			steps:  []string{"a WATCH foo", "a INCR foo", "a MULTI", "a INCR foo", "a EXEC"},
+			expOut: MssgNil + "\n",
+		},
+		{
+			name:   "key deleted",
+			steps:  []string{"b SET foo 1", "a WATCH foo", "b DEL foo", "a MULTI", "a GET foo", "a EXEC"},
+			expOut: MssgNil + "\n",
+		},
+		{
+			name:   "key flushed",
+			steps:  []string{"b SET foo 1", "a WATCH foo", "b FLUSHALL", "a MULTI", "a GET foo", "a EXEC"},
+			expOut: MssgNil + "\n",
+		},
+		{
+			name:   "key of another db",
+			steps:  []string{"a WATCH foo", "b SELECT 1", "b SET foo 1", "a MULTI", "a GET foo", "a EXEC"},
+			expOut: "1) (nil)\n",
+		},
+		{
+			name:   "watched in another db",
+			steps:  []string{"a SELECT 2", "a WATCH foo", "a SELECT 0", "b SELECT 2", "b SET foo 1", "a MULTI", "a GET foo", "a EXEC"},
+			expOut: MssgNil + "\n",
+		},
+		{
+			name:   "UNWATCH",
+			steps:  []string{"a WATCH foo", "a UNWATCH", "b SET foo 1", "a MULTI", "a GET foo", "a EXEC"},
+			expOut: "1) \"1\"\n",
+		},
+		{
+			name:   "keys are forgotten once the tran ends",
+			steps:  []string{"a WATCH foo", "b SET foo 1", "a MULTI", "a EXEC", "a MULTI", "a GET foo", "a EXEC"},
+			expOut: "1) \"1\"\n",
+		},
+		{
+			name:   "keys are forgotten once the tran is discarded",
+			steps:  []string{"a WATCH foo", "a MULTI", "a DISCARD", "b SET foo 1", "a MULTI", "a GET foo", "a EXEC"},
+			expOut: "1) \"1\"\n",
+		},
+		{
+			name:   "WATCH inside MULTI",
+			steps:  []string{"a MULTI", "a WATCH foo", "a SET foo 1", "a EXEC"},
+			expOut: "1) OK\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := GetTestServerWithDB()
+			defer s.Close()
+			clients := map[string]*ConnContext{"a": {}, "b": {}}
+
+			var buf bytes.Buffer
+			for _, step := range tc.steps {
+				client, input, _ := strings.Cut(step, " ")
+				buf.Reset()
+				s.handleCommand(input, &buf, clients[client])
+			}
+
+			if buf.String() != tc.expOut {
+				t.Errorf("Expected %q but got %q", tc.expOut, buf.String())
+			}
+		})
+	}
+
+	t.Run("WATCH inside MULTI is refused", func(t *testing.T) {
+		s := GetTestServerWithDB()
+		cc := &ConnContext{}
+		var buf bytes.Buffer
+		s.handleCommand("MULTI", &buf, cc)
+		buf.Reset()
+		s.handleCommand("WATCH foo", &buf, cc)
+		if !strings.Contains(buf.String(), ErrWatchInMulti.Error()) {
+			t.Errorf("Expected %q but got %q", ErrWatchInMulti.Error(), buf.String())
+		}
+	})
+
+	t.Run("key expired", func(t *testing.T) {
+		s := GetTestServerWithDB()
+		cc := &ConnContext{}
+		var buf bytes.Buffer
+		for _, input := range []string{"SET foo 1 PX 20", "WATCH foo", "MULTI", "GET foo"} {
+			s.handleCommand(input, &buf, cc)
+		}
+		time.Sleep(30 * time.Millisecond)
+
+		buf.Reset()
+		s.handleCommand("EXEC", &buf, cc)
+		if buf.String() != MssgNil+"\n" {
+			t.Errorf("Expected %q but got %q", MssgNil+"\n", buf.String())
+		}
+	})
+
+	t.Run("FLUSHDB", func(t *testing.T) {
+		s := GetTestServerWithDB()
+		defer s.Close()
+		cc := &ConnContext{}
+		var buf bytes.Buffer
+		for _, input := range []string{"SET foo 1", "SELECT 1", "SET bar 1", "FLUSHDB ASYNC", "SELECT 0", "FLUSHDB NOW"} {
+			buf.Reset()
+			s.handleCommand(input, &buf, cc)
+		}
+		if !strings.Contains(buf.String(), ErrSyntax.Error()) {
+			t.Errorf("Expected %q but got %q", ErrSyntax.Error(), buf.String())
+		}
+		if s.Db[0].Type("foo") != "string" || s.Db[1].Type("bar") != "none" {
+			t.Errorf("Expected only db 1 to be flushed")
+		}
+	})
+}
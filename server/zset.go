@@ -0,0 +1,460 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/db"
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/resp"
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store"
+)
+
+const (
+	ZADD        string = "ZADD"
+	ZREM        string = "ZREM"
+	ZSCORE      string = "ZSCORE"
+	ZMSCORE     string = "ZMSCORE"
+	ZINCRBY     string = "ZINCRBY"
+	ZCARD       string = "ZCARD"
+	ZCOUNT      string = "ZCOUNT"
+	ZRANK       string = "ZRANK"
+	ZREVRANK    string = "ZREVRANK"
+	ZRANGE      string = "ZRANGE"
+	ZRANGESTORE string = "ZRANGESTORE"
+	ZPOPMIN     string = "ZPOPMIN"
+	ZPOPMAX     string = "ZPOPMAX"
+	ZUNIONSTORE string = "ZUNIONSTORE"
+	ZINTERSTORE string = "ZINTERSTORE"
+)
+
+var (
+	ErrZAddNXAndXX       = errors.New("XX and NX options at the same time are not compatible")
+	ErrZAddGTLTAndNX     = errors.New("GT, LT, and/or NX options at the same time are not compatible")
+	ErrZAddIncrPair      = errors.New("INCR option supports a single increment-element pair")
+	ErrMinMaxNotFloat    = errors.New("min or max is not a float")
+	ErrMinMaxNotLex      = errors.New("min or max not valid string range item")
+	ErrWeightNotFloat    = errors.New("weight value is not a float")
+	ErrZRangeLimit       = errors.New("syntax error, LIMIT is only supported in combination with either BYSCORE or BYLEX")
+	ErrZRangeWithScores  = errors.New("syntax error, WITHSCORES not supported in combination with BYLEX")
+	ErrZStoreNoInputKeys = errors.New("at least 1 input key is needed")
+)
+
+// operation behind each of the sorted set algebra commands
+var zsetOps = map[string]db.SetOp{
+	ZUNIONSTORE: db.SetOpUnion,
+	ZINTERSTORE: db.SetOpInter,
+}
+
+// ZADD key [NX | XX] [GT | LT] [CH] [INCR] score member [score member ...]
+// with INCR it replies like ZINCRBY, or with nil if the options didn't allow the update
+func (s *Server) zaddAction(cc *ConnContext, args []string) resp.Reply {
+	var opts db.ZAddOptions
+	nx, xx, incr := false, false, false
+
+	i := 1
+options:
+	for ; i < len(args); i++ {
+		switch strings.ToUpper(args[i]) {
+		case "NX":
+			nx = true
+		case "XX":
+			xx = true
+		case "GT":
+			opts.GT = true
+		case "LT":
+			opts.LT = true
+		case "CH":
+			opts.CH = true
+		case "INCR":
+			incr = true
+		default:
+			break options
+		}
+	}
+
+	pairs := args[i:]
+	switch {
+	case len(pairs) == 0 || len(pairs)%2 != 0:
+		return resp.NewError(ErrSyntax)
+	case nx && xx:
+		return resp.NewError(ErrZAddNXAndXX)
+	case (opts.GT && opts.LT) || (nx && (opts.GT || opts.LT)):
+		return resp.NewError(ErrZAddGTLTAndNX)
+	case incr && len(pairs) > 2:
+		return resp.NewError(ErrZAddIncrPair)
+	}
+
+	if nx {
+		opts.Cond = db.SetNX
+	} else if xx {
+		opts.Cond = db.SetXX
+	}
+
+	items := make([]store.ZItem, 0, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		score, err := parseFloat(pairs[i])
+		if err != nil {
+			return resp.NewError(err)
+		}
+		items = append(items, store.ZItem{Member: pairs[i+1], Score: score})
+	}
+
+	if incr {
+		score, ok, err := s.getDb(cc.dbIdx).ZIncrBy(args[0], opts, items[0].Member, items[0].Score)
+		switch {
+		case err != nil:
+			return resp.NewError(err)
+		case !ok:
+			return resp.Nil
+		default:
+			return resp.Double(score)
+		}
+	}
+
+	n, err := s.getDb(cc.dbIdx).ZAdd(args[0], opts, items...)
+	if err != nil {
+		return resp.NewError(err)
+	}
+	return resp.Integer(n)
+}
+
+// ZINCRBY key increment member
+func (s *Server) zincrbyAction(cc *ConnContext, args []string) resp.Reply {
+	incr, err := parseFloat(args[1])
+	if err != nil {
+		return resp.NewError(err)
+	}
+
+	score, _, err := s.getDb(cc.dbIdx).ZIncrBy(args[0], db.ZAddOptions{}, args[2], incr)
+	if err != nil {
+		return resp.NewError(err)
+	}
+	return resp.Double(score)
+}
+
+// ZREM key member [member ...]
+func (s *Server) zremAction(cc *ConnContext, args []string) resp.Reply {
+	n, err := s.getDb(cc.dbIdx).ZRem(args[0], args[1:]...)
+	if err != nil {
+		return resp.NewError(err)
+	}
+	return resp.Integer(n)
+}
+
+// ZSCORE key member
+func (s *Server) zscoreAction(cc *ConnContext, args []string) resp.Reply {
+	score, ok, err := s.getDb(cc.dbIdx).ZScore(args[0], args[1])
+	switch {
+	case err != nil:
+		return resp.NewError(err)
+	case !ok:
+		return resp.Nil
+	default:
+		return resp.Double(score)
+	}
+}
+
+// ZMSCORE key member [member ...]
+func (s *Server) zmscoreAction(cc *ConnContext, args []string) resp.Reply {
+	scores, found, err := s.getDb(cc.dbIdx).ZMScore(args[0], args[1:]...)
+	if err != nil {
+		return resp.NewError(err)
+	}
+
+	replies := resp.Array{}
+	for i, score := range scores {
+		if found[i] {
+			replies = append(replies, resp.Double(score))
+		} else {
+			replies = append(replies, resp.Nil)
+		}
+	}
+	return replies
+}
+
+// ZCARD key
+func (s *Server) zcardAction(cc *ConnContext, key string) resp.Reply {
+	n, err := s.getDb(cc.dbIdx).ZCard(key)
+	if err != nil {
+		return resp.NewError(err)
+	}
+	return resp.Integer(n)
+}
+
+// ZCOUNT key min max
+func (s *Server) zcountAction(cc *ConnContext, args []string) resp.Reply {
+	r, err := parseScoreRange(args[1], args[2])
+	if err != nil {
+		return resp.NewError(err)
+	}
+
+	n, err := s.getDb(cc.dbIdx).ZCount(args[0], r)
+	if err != nil {
+		return resp.NewError(err)
+	}
+	return resp.Integer(n)
+}
+
+// ZRANK key member [WITHSCORE]
+// ZREVRANK ranks from the highest score down instead
+func (s *Server) zrankAction(cc *ConnContext, c Command) resp.Reply {
+	if len(c.args) > 3 || (len(c.args) == 3 && strings.ToUpper(c.args[2]) != "WITHSCORE") {
+		return resp.NewError(ErrSyntax)
+	}
+	withScore := len(c.args) == 3
+
+	rank, score, ok, err := s.getDb(cc.dbIdx).ZRank(c.args[0], c.args[1], c.name == ZREVRANK)
+	switch {
+	case err != nil:
+		return resp.NewError(err)
+	case !ok && withScore:
+		return resp.NilArray
+	case !ok:
+		return resp.Nil
+	case withScore:
+		return resp.Array{resp.Integer(rank), resp.Double(score)}
+	default:
+		return resp.Integer(rank)
+	}
+}
+
+// ZRANGE key start stop [BYSCORE | BYLEX] [REV] [LIMIT offset count] [WITHSCORES]
+func (s *Server) zrangeAction(cc *ConnContext, args []string) resp.Reply {
+	q, withScores, err := parseZRangeQuery(args[1:], true)
+	if err != nil {
+		return resp.NewError(err)
+	}
+
+	items, err := s.getDb(cc.dbIdx).ZRange(args[0], q)
+	if err != nil {
+		return resp.NewError(err)
+	}
+	return zitemsReply(cc, items, withScores)
+}
+
+// ZRANGESTORE dst src min max [BYSCORE | BYLEX] [REV] [LIMIT offset count]
+func (s *Server) zrangestoreAction(cc *ConnContext, args []string) resp.Reply {
+	q, _, err := parseZRangeQuery(args[2:], false)
+	if err != nil {
+		return resp.NewError(err)
+	}
+
+	n, err := s.getDb(cc.dbIdx).ZRangeStore(args[0], args[1], q)
+	if err != nil {
+		return resp.NewError(err)
+	}
+	return resp.Integer(n)
+}
+
+// ZPOPMIN key [count]
+// ZPOPMAX pops the highest scores instead
+// replies with a flat member and score without count, and with the items with it
+func (s *Server) zpopAction(cc *ConnContext, c Command) resp.Reply {
+	if len(c.args) > 2 {
+		return resp.NewError(ErrSyntax)
+	}
+
+	count, hasCount := 1, len(c.args) == 2
+	if hasCount {
+		n, err := strconv.Atoi(c.args[1])
+		if err != nil || n < 0 {
+			return resp.NewError(ErrValueOutOfRange)
+		}
+		count = n
+	}
+
+	items, err := s.getDb(cc.dbIdx).ZPop(c.args[0], count, c.name == ZPOPMAX)
+	switch {
+	case err != nil:
+		return resp.NewError(err)
+	case hasCount:
+		return zitemsReply(cc, items, true)
+	case len(items) == 0:
+		return resp.Array{}
+	default:
+		return resp.Array{resp.BulkString(items[0].Member), resp.Double(items[0].Score)}
+	}
+}
+
+// ZUNIONSTORE destination numkeys key [key ...] [WEIGHTS weight [weight ...]] [AGGREGATE SUM | MIN | MAX]
+// ZINTERSTORE takes the same args
+func (s *Server) zsetAlgebraStoreAction(cc *ConnContext, c Command) resp.Reply {
+	numKeys, err := strconv.Atoi(c.args[1])
+	if err != nil {
+		return resp.NewError(db.ErrKeyNotInteger)
+	}
+	if numKeys < 1 {
+		return resp.NewError(fmt.Errorf("%v for '%s' command", ErrZStoreNoInputKeys, strings.ToLower(c.name)))
+	}
+	if numKeys > len(c.args)-2 {
+		return resp.NewError(ErrSyntax)
+	}
+
+	keys, opts := c.args[2:2+numKeys], c.args[2+numKeys:]
+	var weights []float64
+	agg := db.ZAggSum
+	for i := 0; i < len(opts); i++ {
+		switch opt := strings.ToUpper(opts[i]); {
+		case opt == "WEIGHTS" && i+numKeys < len(opts):
+			weights = make([]float64, numKeys)
+			for j := range weights {
+				w, err := parseFloat(opts[i+1+j])
+				if err != nil {
+					return resp.NewError(ErrWeightNotFloat)
+				}
+				weights[j] = w
+			}
+			i += numKeys
+		case opt == "AGGREGATE" && i+1 < len(opts):
+			switch strings.ToUpper(opts[i+1]) {
+			case "SUM":
+				agg = db.ZAggSum
+			case "MIN":
+				agg = db.ZAggMin
+			case "MAX":
+				agg = db.ZAggMax
+			default:
+				return resp.NewError(ErrSyntax)
+			}
+			i++
+		default:
+			return resp.NewError(ErrSyntax)
+		}
+	}
+
+	n, err := s.getDb(cc.dbIdx).ZSetAlgebraStore(zsetOps[c.name], c.args[0], keys, weights, agg)
+	if err != nil {
+		return resp.NewError(err)
+	}
+	return resp.Integer(n)
+}
+
+// parses the start, stop and options of ZRANGE and ZRANGESTORE
+// returns the query and whether WITHSCORES was given
+func parseZRangeQuery(args []string, allowWithScores bool) (db.ZRangeQuery, bool, error) {
+	q := db.ZRangeQuery{Count: -1}
+	withScores, hasLimit := false, false
+
+	for i := 2; i < len(args); i++ {
+		switch opt := strings.ToUpper(args[i]); {
+		case opt == "BYSCORE":
+			q.By = db.ZRangeByScore
+		case opt == "BYLEX":
+			q.By = db.ZRangeByLex
+		case opt == "REV":
+			q.Rev = true
+		case opt == "WITHSCORES" && allowWithScores:
+			withScores = true
+		case opt == "LIMIT" && i+2 < len(args):
+			ints, err := parseInts(args[i+1 : i+3])
+			if err != nil {
+				return q, false, err
+			}
+			q.Offset, q.Count, hasLimit = ints[0], ints[1], true
+			i += 2
+		default:
+			return q, false, ErrSyntax
+		}
+	}
+
+	if hasLimit && q.By == db.ZRangeByRank {
+		return q, false, ErrZRangeLimit
+	}
+	if withScores && q.By == db.ZRangeByLex {
+		return q, false, ErrZRangeWithScores
+	}
+
+	// the bounds of a reversed score or lex range come highest first
+	min, max := args[0], args[1]
+	if q.Rev && q.By != db.ZRangeByRank {
+		min, max = max, min
+	}
+
+	var err error
+	switch q.By {
+	case db.ZRangeByScore:
+		q.Score, err = parseScoreRange(min, max)
+	case db.ZRangeByLex:
+		q.Lex, err = parseLexRange(min, max)
+	default:
+		var ints []int
+		ints, err = parseInts(args[:2])
+		if err == nil {
+			q.Start, q.Stop = ints[0], ints[1]
+		}
+	}
+	return q, withScores, err
+}
+
+// parses score bounds like 1.5, (1.5 for an exclusive one, -inf and +inf
+func parseScoreRange(min, max string) (store.ScoreRange, error) {
+	var r store.ScoreRange
+	var err error
+	if r.Min, r.MinEx, err = parseScoreBound(min); err != nil {
+		return r, err
+	}
+	if r.Max, r.MaxEx, err = parseScoreBound(max); err != nil {
+		return r, err
+	}
+	return r, nil
+}
+
+func parseScoreBound(arg string) (float64, bool, error) {
+	exclusive := strings.HasPrefix(arg, "(")
+	if exclusive {
+		arg = arg[1:]
+	}
+
+	f, err := parseFloat(arg)
+	if err != nil {
+		return 0, false, ErrMinMaxNotFloat
+	}
+	return f, exclusive, nil
+}
+
+// parses lex bounds like [a, (a for an exclusive one, - and +
+func parseLexRange(min, max string) (store.LexRange, error) {
+	var r store.LexRange
+	var err error
+	if r.Min, err = parseLexBound(min); err != nil {
+		return r, err
+	}
+	if r.Max, err = parseLexBound(max); err != nil {
+		return r, err
+	}
+	return r, nil
+}
+
+func parseLexBound(arg string) (store.LexBound, error) {
+	switch {
+	case arg == "-":
+		return store.LexBound{Inf: -1}, nil
+	case arg == "+":
+		return store.LexBound{Inf: 1}, nil
+	case strings.HasPrefix(arg, "["):
+		return store.LexBound{Value: arg[1:]}, nil
+	case strings.HasPrefix(arg, "("):
+		return store.LexBound{Value: arg[1:], Exclusive: true}, nil
+	default:
+		return store.LexBound{}, ErrMinMaxNotLex
+	}
+}
+
+// replies with the members, or with the members and their scores
+// RESP3 clients get member score pairs, RESP2 ones a flat array
+func zitemsReply(cc *ConnContext, items []store.ZItem, withScores bool) resp.Reply {
+	replies := resp.Array{}
+	for _, item := range items {
+		switch {
+		case !withScores:
+			replies = append(replies, resp.BulkString(item.Member))
+		case cc.protocol == resp.RESP3:
+			replies = append(replies, resp.Array{resp.BulkString(item.Member), resp.Double(item.Score)})
+		default:
+			replies = append(replies, resp.BulkString(item.Member), resp.Double(item.Score))
+		}
+	}
+	return replies
+}
@@ -0,0 +1,147 @@
+package server
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/db"
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/resp"
+)
+
+func TestSortedSetCommands(t *testing.T) {
+	wrongType := db.ErrWrongType.Error()
+
+	testCases := []struct {
+		name     string
+		inputArr []string
+		expOut   []string
+	}{
+		{
+			name:     "ZADD, ZSCORE and ZCARD",
+			inputArr: []string{"ZADD z 1 a 2 b", "ZADD z 3 a 0 c", "ZSCORE z a", "ZSCORE z missing", "ZCARD z", "ZMSCORE z a missing", "ZADD z one a"},
+			expOut:   []string{"(integer) 2", "(integer) 1", "(double) 3", MssgNil, "(integer) 3", "1) (double) 3\n2) (nil)\n", ErrNotFloat.Error()},
+		},
+		{
+			name:     "ZADD options",
+			inputArr: []string{"ZADD z 1 a", "ZADD z NX 5 a 2 b", "ZADD z XX CH 5 a 3 c", "ZADD z GT CH 4 a 6 b", "ZADD z LT 1 a", "ZRANGE z 0 -1 WITHSCORES"},
+			expOut:   []string{"(integer) 1", "(integer) 1", "(integer) 1", "(integer) 1", "(integer) 0", "1) \"a\"\n2) (double) 1\n3) \"b\"\n4) (double) 6\n"},
+		},
+		{
+			name:     "ZADD INCR",
+			inputArr: []string{"ZADD z INCR 2 a", "ZADD z INCR 1.5 a", "ZADD z NX INCR 1 a", "ZADD z INCR 1 a 2 b"},
+			expOut:   []string{"(double) 2", "(double) 3.5", MssgNil, ErrZAddIncrPair.Error()},
+		},
+		{
+			name:     "ZADD bad options",
+			inputArr: []string{"ZADD z NX XX 1 a", "ZADD z NX GT 1 a", "ZADD z GT LT 1 a", "ZADD z 1 a 2", "ZADD z NX XX"},
+			expOut:   []string{ErrZAddNXAndXX.Error(), ErrZAddGTLTAndNX.Error(), ErrZAddGTLTAndNX.Error(), ErrSyntax.Error(), ErrSyntax.Error()},
+		},
+		{
+			name:     "ZINCRBY",
+			inputArr: []string{"ZINCRBY z 2 a", "ZINCRBY z -0.5 a", "ZINCRBY z +inf a", "ZINCRBY z -inf a", "ZINCRBY z x a"},
+			expOut:   []string{"(double) 2", "(double) 1.5", "(double) inf", db.ErrScoreNaN.Error(), ErrNotFloat.Error()},
+		},
+		{
+			name:     "ZREM deletes the key once empty",
+			inputArr: []string{"ZADD z 1 a 2 b", "ZREM z a missing", "ZREM z b", "TYPE z"},
+			expOut:   []string{"(integer) 2", "(integer) 1", "(integer) 1", "none"},
+		},
+		{
+			name:     "ZRANK and ZREVRANK",
+			inputArr: []string{"ZADD z 1 a 2 b 3 c", "ZRANK z a", "ZREVRANK z a", "ZRANK z b WITHSCORE", "ZRANK z missing", "ZRANK z a WITHSCORES"},
+			expOut:   []string{"(integer) 3", "(integer) 0", "(integer) 2", "1) (integer) 1\n2) (double) 2\n", MssgNil, ErrSyntax.Error()},
+		},
+		{
+			name:     "ZCOUNT",
+			inputArr: []string{"ZADD z 1 a 2 b 3 c", "ZCOUNT z 1 2", "ZCOUNT z (1 +inf", "ZCOUNT z -inf (1", "ZCOUNT z a b"},
+			expOut:   []string{"(integer) 3", "(integer) 2", "(integer) 2", "(integer) 0", ErrMinMaxNotFloat.Error()},
+		},
+		{
+			name:     "ZRANGE by rank",
+			inputArr: []string{"ZADD z 1 a 2 b 3 c", "ZRANGE z 0 1", "ZRANGE z 0 0 REV", "ZRANGE z 5 10", "ZRANGE z 0 -1 LIMIT 0 1"},
+			expOut:   []string{"(integer) 3", "1) \"a\"\n2) \"b\"\n", "1) \"c\"\n", MssgEmptyArray, ErrZRangeLimit.Error()},
+		},
+		{
+			name:     "ZRANGE BYSCORE",
+			inputArr: []string{"ZADD z 1 a 2 b 3 c 4 d", "ZRANGE z (1 3 BYSCORE", "ZRANGE z +inf -inf BYSCORE REV LIMIT 1 2", "ZRANGE z 1 x BYSCORE"},
+			expOut:   []string{"(integer) 4", "1) \"b\"\n2) \"c\"\n", "1) \"c\"\n2) \"b\"\n", ErrMinMaxNotFloat.Error()},
+		},
+		{
+			name:     "ZRANGE BYLEX",
+			inputArr: []string{"ZADD z 0 a 0 b 0 c 0 d", "ZRANGE z [b (d BYLEX", "ZRANGE z + - BYLEX REV LIMIT 0 2", "ZRANGE z a c BYLEX", "ZRANGE z - + BYLEX WITHSCORES"},
+			expOut:   []string{"(integer) 4", "1) \"b\"\n2) \"c\"\n", "1) \"d\"\n2) \"c\"\n", ErrMinMaxNotLex.Error(), ErrZRangeWithScores.Error()},
+		},
+		{
+			name:     "ZRANGESTORE",
+			inputArr: []string{"ZADD z 1 a 2 b 3 c", "SET dst bar EX 100", "ZRANGESTORE dst z 2 +inf BYSCORE", "ZRANGE dst 0 -1 WITHSCORES", "TTL dst", "ZRANGESTORE dst z 5 10", "TYPE dst"},
+			expOut:   []string{"(integer) 3", MssgOK, "(integer) 2", "1) \"b\"\n2) (double) 2\n3) \"c\"\n4) (double) 3\n", "(integer) -1", "(integer) 0", "none"},
+		},
+		{
+			name:     "ZPOPMIN and ZPOPMAX",
+			inputArr: []string{"ZADD z 1 a 2 b 3 c", "ZPOPMIN z", "ZPOPMAX z 5", "ZPOPMIN z", "ZPOPMIN z -1"},
+			expOut:   []string{"(integer) 3", "1) \"a\"\n2) (double) 1\n", "1) \"c\"\n2) (double) 3\n3) \"b\"\n4) (double) 2\n", MssgEmptyArray, ErrValueOutOfRange.Error()},
+		},
+		{
+			name:     "ZUNIONSTORE and ZINTERSTORE",
+			inputArr: []string{"ZADD a 1 x 2 y", "ZADD b 10 y 20 z", "ZUNIONSTORE out 2 a b", "ZRANGE out 0 -1 WITHSCORES", "ZINTERSTORE out 2 a b WEIGHTS 2 1 AGGREGATE MAX", "ZRANGE out 0 -1 WITHSCORES"},
+			expOut:   []string{"(integer) 2", "(integer) 2", "(integer) 3", "1) \"x\"\n2) (double) 1\n3) \"y\"\n4) (double) 12\n5) \"z\"\n6) (double) 20\n", "(integer) 1", "1) \"y\"\n2) (double) 10\n"},
+		},
+		{
+			name:     "ZUNIONSTORE bad args",
+			inputArr: []string{"ZUNIONSTORE out 0 a", "ZUNIONSTORE out 3 a b", "ZUNIONSTORE out 1 a WEIGHTS x", "ZUNIONSTORE out 1 a AGGREGATE AVG", "ZUNIONSTORE out x a"},
+			expOut:   []string{"at least 1 input key is needed for 'zunionstore' command", ErrSyntax.Error(), ErrWeightNotFloat.Error(), ErrSyntax.Error(), db.ErrKeyNotInteger.Error()},
+		},
+		{
+			name:     "WRONGTYPE",
+			inputArr: []string{"SET str bar", "ZADD str 1 a", "ZSCORE str a", "ZRANGE str 0 -1", "ZUNIONSTORE out 1 str", "ZADD z 1 a", "GET z", "TYPE z"},
+			expOut:   []string{MssgOK, wrongType, wrongType, wrongType, wrongType, "(integer) 1", wrongType, "zset"},
+		},
+		{
+			name:     "COMPACT with a sorted set",
+			inputArr: []string{"ZADD z 2 b 1.5 a", "COMPACT"},
+			expOut:   []string{"(integer) 2", "ZADD z 1.5 a 2 b"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			s := GetTestServerWithDB()
+			cc := &ConnContext{}
+
+			for i, input := range tc.inputArr {
+				s.handleCommand(input, &buf, cc)
+
+				if !bytes.Contains(buf.Bytes(), []byte(tc.expOut[i])) {
+					t.Errorf("Expected output of %q to contain %q but got %q instead", input, tc.expOut[i], buf.String())
+				}
+				buf.Reset()
+			}
+		})
+	}
+
+	t.Run("RESP2 and RESP3 replies", func(t *testing.T) {
+		var buf bytes.Buffer
+		s := GetTestServerWithDB()
+
+		testCases := []struct {
+			protocol resp.Protocol
+			input    string
+			exp      string
+		}{
+			{resp.RESP2, "ZSCORE z a", "$3\r\n1.5\r\n"},
+			{resp.RESP3, "ZSCORE z a", ",1.5\r\n"},
+			{resp.RESP2, "ZRANGE z 0 0 WITHSCORES", "*2\r\n$1\r\na\r\n$3\r\n1.5\r\n"},
+			{resp.RESP3, "ZRANGE z 0 0 WITHSCORES", "*1\r\n*2\r\n$1\r\na\r\n,1.5\r\n"},
+		}
+
+		s.handleCommand("ZADD z 1.5 a 2 b", &buf, &ConnContext{})
+		for _, tc := range testCases {
+			buf.Reset()
+			s.handleCommand(tc.input, &buf, &ConnContext{protocol: tc.protocol})
+			if buf.String() != tc.exp {
+				t.Errorf("Expected %q for %q over protocol %d but got %q", tc.exp, tc.input, tc.protocol, buf.String())
+			}
+		}
+	})
+}
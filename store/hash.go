@@ -0,0 +1,14 @@
+package store
+
+import "maps"
+
+// Hash is the value of HSET and friends, it maps fields to values
+type Hash map[string]string
+
+func (Hash) Type() string {
+	return "hash"
+}
+
+func (h Hash) Clone() Value {
+	return maps.Clone(h)
+}
@@ -1,41 +1,86 @@
 package inMemoryStore
 
 import (
+	"maps"
 	"sync"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store"
 )
 
 type InMemoryStore struct {
-	data map[string]string
+	data    map[string]store.Value
+	expires map[string]int64 // timeouts of the keys which have one
 	sync.RWMutex
 }
 
-func (i *InMemoryStore) GetAll() map[string]string {
+// returns a copy of the data, so it can be ranged over while the store is written
+func (i *InMemoryStore) GetAll() map[string]store.Value {
 	i.RLock()
 	defer i.RUnlock()
-	return i.data
+	return maps.Clone(i.data)
 }
 
-func (i *InMemoryStore) Set(key, value string) {
+func (i *InMemoryStore) Set(key string, value store.Value) {
 	i.Lock()
 	defer i.Unlock()
 	i.data[key] = value
 }
 
-func (i *InMemoryStore) Get(key string) (string, bool) {
+func (i *InMemoryStore) Get(key string) (store.Value, bool) {
 	i.RLock()
 	defer i.RUnlock()
 	proxy, ok := i.data[key]
-    return proxy, ok
+	return proxy, ok
 }
 
+// deleting a key drops its timeout as well
 func (i *InMemoryStore) Del(key string) {
+	i.Lock()
+	defer i.Unlock()
+	delete(i.data, key)
+	delete(i.expires, key)
+}
+
+func (i *InMemoryStore) GetExpiry(key string) (int64, bool) {
 	i.RLock()
 	defer i.RUnlock()
-	delete(i.data, key)
+	at, ok := i.expires[key]
+	return at, ok
+}
+
+func (i *InMemoryStore) SetExpiry(key string, at int64) {
+	i.Lock()
+	defer i.Unlock()
+	i.expires[key] = at
+}
+
+// returns true if the key had a timeout
+func (i *InMemoryStore) DelExpiry(key string) bool {
+	i.Lock()
+	defer i.Unlock()
+	_, ok := i.expires[key]
+	delete(i.expires, key)
+	return ok
+}
+
+// map iteration starts at a random position, which is good enough for sampling
+func (i *InMemoryStore) SampleExpiringKeys(n int) []string {
+	i.RLock()
+	defer i.RUnlock()
+
+	keys := make([]string, 0, min(n, len(i.expires)))
+	for k := range i.expires {
+		if len(keys) == n {
+			break
+		}
+		keys = append(keys, k)
+	}
+	return keys
 }
 
 func NewInMemoryStore() *InMemoryStore {
 	return &InMemoryStore{
-		data: make(map[string]string),
+		data:    make(map[string]store.Value),
+		expires: make(map[string]int64),
 	}
 }
@@ -2,11 +2,13 @@ package inMemoryStore
 
 import (
 	"testing"
+
+	"github.com/justsushant/one2n-go-bootcamp/go-redis/store"
 )
 
 func TestInMemoryStore(t *testing.T) {
 	key := "foo"
-	val := "bar"
+	val := store.String("bar")
 
 	t.Run("set op", func(t *testing.T) {
 		var dummyStore = NewInMemoryStore()
@@ -56,9 +58,9 @@ func TestInMemoryStore(t *testing.T) {
 
 	t.Run("get all op", func(t *testing.T) {
 		var dummyStore = NewInMemoryStore()
-		dummyStore.data["key1"] = "val1"
-		dummyStore.data["key2"] = "val2"
-		dummyStore.data["key3"] = "val3"
+		dummyStore.data["key1"] = store.String("val1")
+		dummyStore.data["key2"] = store.String("val2")
+		dummyStore.data["key3"] = store.String("val3")
 
 		result := dummyStore.GetAll()
 
@@ -66,7 +68,7 @@ func TestInMemoryStore(t *testing.T) {
 		if !ok {
 			t.Fatalf("Expected to find the key %s with value %s but didn't got any", "key1", "val1")
 		}
-		if v1 != "val1" {
+		if v1 != store.String("val1") {
 			t.Errorf("Expected the value %s for the key %s but found %s", val, key, v1)
 		}
 
@@ -74,7 +76,7 @@ func TestInMemoryStore(t *testing.T) {
 		if !ok {
 			t.Fatalf("Expected to find the key %s with value %s but didn't got any", "key1", "val1")
 		}
-		if v2 != "val1" {
+		if v2 != store.String("val1") {
 			t.Errorf("Expected the value %s for the key %s but found %s", val, key, v1)
 		}
 
@@ -82,9 +84,85 @@ func TestInMemoryStore(t *testing.T) {
 		if !ok {
 			t.Fatalf("Expected to find the key %s with value %s but didn't got any", "key1", "val1")
 		}
-		if v3 != "val1" {
+		if v3 != store.String("val1") {
 			t.Errorf("Expected the value %s for the key %s but found %s", val, key, v1)
 		}
 	})
 
+}
+func TestInMemoryStoreExpiry(t *testing.T) {
+	key := "foo"
+	val := store.String("bar")
+
+	t.Run("set and get expiry", func(t *testing.T) {
+		var dummyStore = NewInMemoryStore()
+		dummyStore.Set(key, val)
+		dummyStore.SetExpiry(key, 1000)
+
+		at, ok := dummyStore.GetExpiry(key)
+		if !ok || at != 1000 {
+			t.Errorf("Expected the expiry %d for the key %s but found %d", 1000, key, at)
+		}
+	})
+
+	t.Run("get expiry of key without one", func(t *testing.T) {
+		var dummyStore = NewInMemoryStore()
+		dummyStore.Set(key, val)
+
+		if at, ok := dummyStore.GetExpiry(key); ok {
+			t.Errorf("Didn't expect an expiry for the key %s but found %d", key, at)
+		}
+	})
+
+	t.Run("del expiry", func(t *testing.T) {
+		var dummyStore = NewInMemoryStore()
+		dummyStore.Set(key, val)
+		dummyStore.SetExpiry(key, 1000)
+
+		if !dummyStore.DelExpiry(key) {
+			t.Errorf("Expected the expiry of the key %s to be deleted", key)
+		}
+		if _, ok := dummyStore.expires[key]; ok {
+			t.Errorf("Didn't expect an expiry for the key %s after deleting it", key)
+		}
+		if dummyStore.DelExpiry(key) {
+			t.Errorf("Didn't expect an expiry to delete for the key %s", key)
+		}
+	})
+
+	t.Run("del op drops the expiry", func(t *testing.T) {
+		var dummyStore = NewInMemoryStore()
+		dummyStore.Set(key, val)
+		dummyStore.SetExpiry(key, 1000)
+
+		dummyStore.Del(key)
+		if _, ok := dummyStore.expires[key]; ok {
+			t.Errorf("Didn't expect an expiry for the deleted key %s", key)
+		}
+	})
+
+	t.Run("set op retains the expiry", func(t *testing.T) {
+		var dummyStore = NewInMemoryStore()
+		dummyStore.Set(key, val)
+		dummyStore.SetExpiry(key, 1000)
+
+		dummyStore.Set(key, store.String("baz"))
+		if at, ok := dummyStore.expires[key]; !ok || at != 1000 {
+			t.Errorf("Expected the expiry %d to be retained for the key %s", 1000, key)
+		}
+	})
+	t.Run("sample keys with expiry", func(t *testing.T) {
+		var dummyStore = NewInMemoryStore()
+		dummyStore.Set(key, val)
+		dummyStore.Set("baz", val)
+		dummyStore.SetExpiry(key, 1000)
+
+		sample := dummyStore.SampleExpiringKeys(20)
+		if len(sample) != 1 || sample[0] != key {
+			t.Errorf("Expected the sample %v but got %v", []string{key}, sample)
+		}
+		if sample := dummyStore.SampleExpiringKeys(0); len(sample) != 0 {
+			t.Errorf("Expected an empty sample but got %v", sample)
+		}
+	})
 }
\ No newline at end of file
@@ -0,0 +1,116 @@
+package store
+
+const minListCap = 8
+
+// List is the value of LPUSH and friends
+// it's a ring buffer, so pushing and popping at either end as well as indexing are O(1)
+type List struct {
+	items []string
+	head  int // position of the first item in items
+	size  int
+}
+
+// returns a list holding items in order
+func NewList(items ...string) *List {
+	l := &List{items: make([]string, max(len(items), minListCap))}
+	copy(l.items, items)
+	l.size = len(items)
+	return l
+}
+
+func (*List) Type() string {
+	return "list"
+}
+
+func (l *List) Clone() Value {
+	return NewList(l.Values()...)
+}
+
+func (l *List) Len() int {
+	return l.size
+}
+
+// returns the ith item, i must be in [0, Len)
+func (l *List) Index(i int) string {
+	return l.items[l.pos(i)]
+}
+
+// replaces the ith item, i must be in [0, Len)
+func (l *List) SetIndex(i int, val string) {
+	l.items[l.pos(i)] = val
+}
+
+func (l *List) PushFront(val string) {
+	l.grow()
+	l.head = (l.head - 1 + len(l.items)) % len(l.items)
+	l.items[l.head] = val
+	l.size++
+}
+
+func (l *List) PushBack(val string) {
+	l.grow()
+	l.items[l.pos(l.size)] = val
+	l.size++
+}
+
+// removes and returns the first item, the list must not be empty
+func (l *List) PopFront() string {
+	val := l.items[l.head]
+	l.items[l.head] = "" // lets the string be collected
+	l.head = (l.head + 1) % len(l.items)
+	l.size--
+	return val
+}
+
+// removes and returns the last item, the list must not be empty
+func (l *List) PopBack() string {
+	p := l.pos(l.size - 1)
+	val := l.items[p]
+	l.items[p] = ""
+	l.size--
+	return val
+}
+
+// inserts val before the ith item, i must be in [0, Len]
+func (l *List) Insert(i int, val string) {
+	l.PushBack(val)
+	for j := l.size - 1; j > i; j-- {
+		l.SetIndex(j, l.Index(j-1))
+	}
+	l.SetIndex(i, val)
+}
+
+// returns a copy of the items in [start, stop], both must be in [0, Len)
+func (l *List) Range(start, stop int) []string {
+	out := make([]string, 0, stop-start+1)
+	for i := start; i <= stop; i++ {
+		out = append(out, l.Index(i))
+	}
+	return out
+}
+
+// returns a copy of all the items
+func (l *List) Values() []string {
+	if l.size == 0 {
+		return []string{}
+	}
+	return l.Range(0, l.size-1)
+}
+
+func (l *List) pos(i int) int {
+	return (l.head + i) % len(l.items)
+}
+
+// doubles the buffer once it's full
+func (l *List) grow() {
+	if l.size < len(l.items) {
+		return
+	}
+
+	items := make([]string, max(len(l.items)*2, minListCap))
+	for i := 0; i < l.size; i++ {
+		items[i] = l.Index(i)
+	}
+	l.items = items
+	l.head = 0
+}
@@ -0,0 +1,82 @@
+package store
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestList(t *testing.T) {
+	t.Run("push and pop at both ends", func(t *testing.T) {
+		l := NewList()
+		for _, v := range []string{"c", "b", "a"} {
+			l.PushFront(v)
+		}
+		for _, v := range []string{"d", "e"} {
+			l.PushBack(v)
+		}
+
+		if out := l.Values(); !slices.Equal(out, []string{"a", "b", "c", "d", "e"}) {
+			t.Fatalf("Expected %v but got %v", []string{"a", "b", "c", "d", "e"}, out)
+		}
+		if v := l.PopFront(); v != "a" {
+			t.Errorf("Expected %q but got %q", "a", v)
+		}
+		if v := l.PopBack(); v != "e" {
+			t.Errorf("Expected %q but got %q", "e", v)
+		}
+		if l.Len() != 3 {
+			t.Errorf("Expected length %d but got %d", 3, l.Len())
+		}
+	})
+
+	t.Run("grows past its capacity when wrapped around", func(t *testing.T) {
+		l := NewList()
+		var exp []string
+		for i := range 100 {
+			v := string(rune('a' + i%26))
+			if i%2 == 0 {
+				l.PushFront(v)
+				exp = append([]string{v}, exp...)
+			} else {
+				l.PushBack(v)
+				exp = append(exp, v)
+			}
+		}
+
+		if out := l.Values(); !slices.Equal(out, exp) {
+			t.Errorf("Expected %v but got %v", exp, out)
+		}
+	})
+
+	t.Run("index and set index", func(t *testing.T) {
+		l := NewList("a", "b", "c")
+		l.PushFront("z")
+		l.SetIndex(2, "x")
+
+		if v := l.Index(2); v != "x" {
+			t.Errorf("Expected %q but got %q", "x", v)
+		}
+		if out := l.Range(1, 3); !slices.Equal(out, []string{"a", "x", "c"}) {
+			t.Errorf("Expected %v but got %v", []string{"a", "x", "c"}, out)
+		}
+	})
+
+	t.Run("insert", func(t *testing.T) {
+		l := NewList("a", "c")
+		l.Insert(1, "b")
+		l.Insert(0, "start")
+		l.Insert(l.Len(), "end")
+
+		exp := []string{"start", "a", "b", "c", "end"}
+		if out := l.Values(); !slices.Equal(out, exp) {
+			t.Errorf("Expected %v but got %v", exp, out)
+		}
+	})
+
+	t.Run("empty list", func(t *testing.T) {
+		l := NewList()
+		if out := l.Values(); len(out) != 0 {
+			t.Errorf("Expected no items but got %v", out)
+		}
+	})
+}
@@ -0,0 +1,14 @@
+package store
+
+import "maps"
+
+// Set is the value of SADD and friends, an unordered collection of unique members
+type Set map[string]struct{}
+
+func (Set) Type() string {
+	return "set"
+}
+
+func (s Set) Clone() Value {
+	return maps.Clone(s)
+}
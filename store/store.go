@@ -1,8 +1,22 @@
 package store
 
 type Store interface {
-    GetAll() map[string]string
-	Get(key string) (string, bool)
-    Set(key, value string)
-    Del(key string)
+	GetAll() map[string]Value
+	Get(key string) (Value, bool)
+	Set(key string, value Value)
+	Del(key string)
+
+	// timeouts are unix times in milliseconds, a key without one lives forever
+	GetExpiry(key string) (int64, bool)
+	SetExpiry(key string, at int64)
+	DelExpiry(key string) bool
+	// returns up to n random keys which have a timeout
+	SampleExpiringKeys(n int) []string
+}
+
+// Entry is a key along with its value and its timeout, 0 if it has none
+type Entry struct {
+	Key      string
+	Value    Value
+	ExpireAt int64
 }
\ No newline at end of file
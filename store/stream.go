@@ -0,0 +1,209 @@
+package store
+
+import (
+	"fmt"
+	"math"
+	"slices"
+)
+
+// StreamID identifies an entry of a stream, the ms part is usually the time at which it was added
+// and seq tells apart the entries added within the same ms
+type StreamID struct {
+	Ms, Seq uint64
+}
+
+// the largest id, which no entry can be added after
+var MaxStreamID = StreamID{Ms: math.MaxUint64, Seq: math.MaxUint64}
+
+func (id StreamID) String() string {
+	return fmt.Sprintf("%d-%d", id.Ms, id.Seq)
+}
+
+// returns -1, 0 or 1 as id sorts before, along with or after other
+func (id StreamID) Compare(other StreamID) int {
+	switch {
+	case id.Ms != other.Ms:
+		if id.Ms < other.Ms {
+			return -1
+		}
+		return 1
+	case id.Seq != other.Seq:
+		if id.Seq < other.Seq {
+			return -1
+		}
+		return 1
+	default:
+		return 0
+	}
+}
+
+func (id StreamID) IsZero() bool {
+	return id == StreamID{}
+}
+
+// returns the id right after this one, false if there is none
+func (id StreamID) Next() (StreamID, bool) {
+	switch {
+	case id.Seq < math.MaxUint64:
+		return StreamID{Ms: id.Ms, Seq: id.Seq + 1}, true
+	case id.Ms < math.MaxUint64:
+		return StreamID{Ms: id.Ms + 1}, true
+	default:
+		return id, false
+	}
+}
+
+// returns the id right before this one, false if there is none
+func (id StreamID) Prev() (StreamID, bool) {
+	switch {
+	case id.Seq > 0:
+		return StreamID{Ms: id.Ms, Seq: id.Seq - 1}, true
+	case id.Ms > 0:
+		return StreamID{Ms: id.Ms - 1, Seq: math.MaxUint64}, true
+	default:
+		return id, false
+	}
+}
+
+// StreamEntry is an entry of a stream, its fields are kept as field value pairs in order
+type StreamEntry struct {
+	ID     StreamID
+	Fields []string
+}
+
+// Stream is the value of XADD and friends, an append only log of entries ordered by id
+// the entries are kept in a slice, so ranges are found with a binary search
+type Stream struct {
+	entries      []StreamEntry
+	LastID       StreamID // id of the last entry ever added, even if it was deleted since
+	MaxDeletedID StreamID // largest id deleted by XDEL
+	EntriesAdded uint64   // number of entries ever added
+	Groups       map[string]*ConsumerGroup
+}
+
+func NewStream() *Stream {
+	return &Stream{Groups: map[string]*ConsumerGroup{}}
+}
+
+func (*Stream) Type() string {
+	return "stream"
+}
+
+// the fields of the entries are never changed once added, so they're shared with the copy
+func (s *Stream) Clone() Value {
+	c := *s
+	c.entries = slices.Clone(s.entries)
+	c.Groups = make(map[string]*ConsumerGroup, len(s.Groups))
+	for name, g := range s.Groups {
+		c.Groups[name] = g.clone()
+	}
+	return &c
+}
+
+func (s *Stream) Len() int {
+	return len(s.entries)
+}
+
+// appends the entry, its id must be greater than LastID
+func (s *Stream) Add(id StreamID, fields []string) {
+	s.entries = append(s.entries, StreamEntry{ID: id, Fields: fields})
+	s.LastID = id
+	s.EntriesAdded++
+}
+
+// returns all the entries in order
+func (s *Stream) Entries() []StreamEntry {
+	return slices.Clone(s.entries)
+}
+
+// returns the entry with the id
+func (s *Stream) Get(id StreamID) (StreamEntry, bool) {
+	i, ok := s.search(id)
+	if !ok {
+		return StreamEntry{}, false
+	}
+	return s.entries[i], true
+}
+
+// returns the first and the last entry, false if the stream is empty
+func (s *Stream) Ends() (StreamEntry, StreamEntry, bool) {
+	if len(s.entries) == 0 {
+		return StreamEntry{}, StreamEntry{}, false
+	}
+	return s.entries[0], s.entries[len(s.entries)-1], true
+}
+
+// returns the entries with an id in [start, end], at most count of them unless count is negative
+// rev returns them from end down to start
+func (s *Stream) Range(start, end StreamID, count int, rev bool) []StreamEntry {
+	from, _ := s.search(start)
+	to, found := s.search(end)
+	if found {
+		to++
+	}
+
+	out := []StreamEntry{}
+	if from >= to {
+		return out
+	}
+	if count < 0 || count > to-from {
+		count = to - from
+	}
+
+	if rev {
+		for i := to - 1; i >= to-count; i-- {
+			out = append(out, s.entries[i])
+		}
+		return out
+	}
+	return append(out, s.entries[from:from+count]...)
+}
+
+// deletes the entries with the ids, returns the number of deleted entries
+func (s *Stream) Delete(ids ...StreamID) int {
+	n := 0
+	for _, id := range ids {
+		i, ok := s.search(id)
+		if !ok {
+			continue
+		}
+
+		s.entries = slices.Delete(s.entries, i, i+1)
+		if id.Compare(s.MaxDeletedID) > 0 {
+			s.MaxDeletedID = id
+		}
+		n++
+	}
+	return n
+}
+
+// evicts the oldest entries until at most maxLen are left
+// limit caps the number of evicted entries unless it's 0
+// returns the number of evicted entries
+func (s *Stream) TrimMaxLen(maxLen, limit int) int {
+	return s.trim(max(len(s.entries)-maxLen, 0), limit)
+}
+
+// evicts the entries with an id less than minID, limit works like for TrimMaxLen
+func (s *Stream) TrimMinID(minID StreamID, limit int) int {
+	i, _ := s.search(minID)
+	return s.trim(i, limit)
+}
+
+func (s *Stream) trim(n, limit int) int {
+	if limit > 0 {
+		n = min(n, limit)
+	}
+
+	// clears the evicted entries, so they don't linger in the backing array
+	clear(s.entries[:n])
+	s.entries = s.entries[n:]
+	return n
+}
+
+// returns the index of the first entry with an id not less than id, and whether it has the id
+func (s *Stream) search(id StreamID) (int, bool) {
+	return slices.BinarySearchFunc(s.entries, id, func(e StreamEntry, id StreamID) int {
+		return e.ID.Compare(id)
+	})
+}
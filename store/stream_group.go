@@ -0,0 +1,127 @@
+package store
+
+import (
+	"maps"
+	"slices"
+)
+
+// ConsumerGroup tracks what a stream delivered to a group of consumers
+// every entry is delivered to one consumer of the group, and stays pending until it's acknowledged
+type ConsumerGroup struct {
+	LastID      StreamID // id of the last entry delivered to the group
+	EntriesRead int64    // number of entries delivered to the group so far, -1 if it's unknown
+	Pending     map[StreamID]*PendingEntry
+	Consumers   map[string]*Consumer
+}
+
+// PendingEntry is an entry delivered to a consumer but not acknowledged yet
+type PendingEntry struct {
+	Consumer      string
+	DeliveryTime  int64 // unix time in ms of the last delivery
+	DeliveryCount int
+}
+
+// Consumer is a member of a consumer group
+type Consumer struct {
+	SeenTime   int64 // unix time in ms of the last attempted interaction
+	ActiveTime int64 // unix time in ms of the last successful interaction, -1 if there was none
+	Pending    map[StreamID]struct{}
+}
+
+func (g *ConsumerGroup) clone() *ConsumerGroup {
+	c := *g
+	c.Pending = make(map[StreamID]*PendingEntry, len(g.Pending))
+	for id, p := range g.Pending {
+		pc := *p
+		c.Pending[id] = &pc
+	}
+	c.Consumers = make(map[string]*Consumer, len(g.Consumers))
+	for name, consumer := range g.Consumers {
+		cc := *consumer
+		cc.Pending = maps.Clone(consumer.Pending)
+		c.Consumers[name] = &cc
+	}
+	return &c
+}
+
+func NewConsumerGroup(lastID StreamID, entriesRead int64) *ConsumerGroup {
+	return &ConsumerGroup{
+		LastID:      lastID,
+		EntriesRead: entriesRead,
+		Pending:     map[StreamID]*PendingEntry{},
+		Consumers:   map[string]*Consumer{},
+	}
+}
+
+// returns the consumer, creating it if needed, and marks it as seen at now
+func (g *ConsumerGroup) Consumer(name string, now int64) *Consumer {
+	c, ok := g.Consumers[name]
+	if !ok {
+		c = &Consumer{ActiveTime: -1, Pending: map[StreamID]struct{}{}}
+		g.Consumers[name] = c
+	}
+	c.SeenTime = now
+	return c
+}
+
+// delivers the entry to the consumer at now, taking it from its former consumer if it was pending
+// the consumer must exist
+func (g *ConsumerGroup) Deliver(id StreamID, consumer string, now int64) {
+	p := g.Claim(id, consumer, now)
+	p.DeliveryCount++
+}
+
+// gives the entry to the consumer and sets its delivery time, without counting a delivery
+// the entry is added to the pending entries if needed, the consumer must exist
+func (g *ConsumerGroup) Claim(id StreamID, consumer string, deliveryTime int64) *PendingEntry {
+	p, ok := g.Pending[id]
+	if !ok {
+		p = &PendingEntry{}
+		g.Pending[id] = p
+	} else if c, ok := g.Consumers[p.Consumer]; ok {
+		delete(c.Pending, id)
+	}
+
+	p.Consumer = consumer
+	p.DeliveryTime = deliveryTime
+	g.Consumers[consumer].Pending[id] = struct{}{}
+	return p
+}
+
+// acknowledges the entry, removing it from the pending entries
+// returns false if it wasn't pending
+func (g *ConsumerGroup) Ack(id StreamID) bool {
+	p, ok := g.Pending[id]
+	if !ok {
+		return false
+	}
+
+	if c, ok := g.Consumers[p.Consumer]; ok {
+		delete(c.Pending, id)
+	}
+	delete(g.Pending, id)
+	return true
+}
+
+// returns the ids of the pending entries in order
+func (g *ConsumerGroup) PendingIDs() []StreamID {
+	ids := make([]StreamID, 0, len(g.Pending))
+	for id := range g.Pending {
+		ids = append(ids, id)
+	}
+	return sortIDs(ids)
+}
+
+// returns the ids of the entries pending for the consumer in order
+func (c *Consumer) PendingIDs() []StreamID {
+	ids := make([]StreamID, 0, len(c.Pending))
+	for id := range c.Pending {
+		ids = append(ids, id)
+	}
+	return sortIDs(ids)
+}
+
+func sortIDs(ids []StreamID) []StreamID {
+	slices.SortFunc(ids, StreamID.Compare)
+	return ids
+}
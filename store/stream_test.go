@@ -0,0 +1,136 @@
+package store
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestStreamID(t *testing.T) {
+	testCases := []struct {
+		name     string
+		id       StreamID
+		expNext  StreamID
+		expPrev  StreamID
+		nextOk   bool
+		prevOk   bool
+		expShown string
+	}{
+		{"plain", StreamID{5, 1}, StreamID{5, 2}, StreamID{5, 0}, true, true, "5-1"},
+		{"seq wraps around", StreamID{5, math.MaxUint64}, StreamID{6, 0}, StreamID{5, math.MaxUint64 - 1}, true, true, "5-18446744073709551615"},
+		{"zero", StreamID{}, StreamID{0, 1}, StreamID{}, true, false, "0-0"},
+		{"max", MaxStreamID, MaxStreamID, StreamID{math.MaxUint64, math.MaxUint64 - 1}, false, true, "18446744073709551615-18446744073709551615"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if next, ok := tc.id.Next(); next != tc.expNext || ok != tc.nextOk {
+				t.Errorf("Expected next %v, %v but got %v, %v", tc.expNext, tc.nextOk, next, ok)
+			}
+			if prev, ok := tc.id.Prev(); prev != tc.expPrev || ok != tc.prevOk {
+				t.Errorf("Expected prev %v, %v but got %v, %v", tc.expPrev, tc.prevOk, prev, ok)
+			}
+			if tc.id.String() != tc.expShown {
+				t.Errorf("Expected %q but got %q", tc.expShown, tc.id.String())
+			}
+		})
+	}
+}
+
+func TestStream(t *testing.T) {
+	// stream with the entries 1-0 to 5-0
+	newStream := func() *Stream {
+		s := NewStream()
+		for ms := range 5 {
+			s.Add(StreamID{Ms: uint64(ms + 1)}, []string{"n", string(rune('a' + ms))})
+		}
+		return s
+	}
+
+	t.Run("range", func(t *testing.T) {
+		s := newStream()
+
+		testCases := []struct {
+			name       string
+			start, end StreamID
+			count      int
+			rev        bool
+			exp        []uint64
+		}{
+			{"everything", StreamID{}, MaxStreamID, -1, false, []uint64{1, 2, 3, 4, 5}},
+			{"bounds are inclusive", StreamID{2, 0}, StreamID{4, 0}, -1, false, []uint64{2, 3, 4}},
+			{"bounds between entries", StreamID{2, 1}, StreamID{3, 5}, -1, false, []uint64{3}},
+			{"count", StreamID{}, MaxStreamID, 2, false, []uint64{1, 2}},
+			{"reversed with count", StreamID{}, MaxStreamID, 2, true, []uint64{5, 4}},
+			{"empty", StreamID{4, 0}, StreamID{2, 0}, -1, false, []uint64{}},
+		}
+
+		for _, tc := range testCases {
+			out := []uint64{}
+			for _, e := range s.Range(tc.start, tc.end, tc.count, tc.rev) {
+				out = append(out, e.ID.Ms)
+			}
+			if !slices.Equal(out, tc.exp) {
+				t.Errorf("%s: Expected %v but got %v", tc.name, tc.exp, out)
+			}
+		}
+	})
+
+	t.Run("delete keeps the last id and records the max deleted one", func(t *testing.T) {
+		s := newStream()
+
+		if n := s.Delete(StreamID{5, 0}, StreamID{2, 0}, StreamID{9, 0}); n != 2 {
+			t.Errorf("Expected %d deleted entries but got %d", 2, n)
+		}
+		if _, ok := s.Get(StreamID{2, 0}); ok {
+			t.Errorf("Expected 2-0 to be deleted")
+		}
+		if s.Len() != 3 || s.LastID != (StreamID{5, 0}) || s.MaxDeletedID != (StreamID{5, 0}) {
+			t.Errorf("Expected length 3, last id 5-0 and max deleted id 5-0 but got %d, %v and %v", s.Len(), s.LastID, s.MaxDeletedID)
+		}
+		if s.EntriesAdded != 5 {
+			t.Errorf("Expected %d entries added but got %d", 5, s.EntriesAdded)
+		}
+	})
+
+	t.Run("trim", func(t *testing.T) {
+		s := newStream()
+
+		if n := s.TrimMaxLen(3, 0); n != 2 {
+			t.Errorf("Expected %d evicted entries but got %d", 2, n)
+		}
+		if n := s.TrimMinID(StreamID{5, 0}, 1); n != 1 {
+			t.Errorf("Expected the limit to cap the evicted entries to %d but got %d", 1, n)
+		}
+		if first, _, _ := s.Ends(); first.ID != (StreamID{4, 0}) {
+			t.Errorf("Expected the first entry to be 4-0 but got %v", first.ID)
+		}
+	})
+}
+
+func TestConsumerGroup(t *testing.T) {
+	g := NewConsumerGroup(StreamID{}, 0)
+	g.Consumer("alice", 10)
+	g.Consumer("bob", 10)
+
+	g.Deliver(StreamID{1, 0}, "alice", 10)
+	g.Deliver(StreamID{2, 0}, "alice", 10)
+	g.Deliver(StreamID{1, 0}, "bob", 20)
+
+	if p := g.Pending[StreamID{1, 0}]; p.Consumer != "bob" || p.DeliveryCount != 2 || p.DeliveryTime != 20 {
+		t.Errorf("Expected 1-0 to be delivered twice, last to bob at 20 but got %+v", *p)
+	}
+	if ids := g.Consumers["alice"].PendingIDs(); !slices.Equal(ids, []StreamID{{2, 0}}) {
+		t.Errorf("Expected alice to have 2-0 pending but got %v", ids)
+	}
+
+	if !g.Ack(StreamID{1, 0}) || g.Ack(StreamID{1, 0}) {
+		t.Errorf("Expected 1-0 to be acknowledged once")
+	}
+	if ids := g.PendingIDs(); !slices.Equal(ids, []StreamID{{2, 0}}) {
+		t.Errorf("Expected 2-0 to be pending but got %v", ids)
+	}
+	if len(g.Consumers["bob"].Pending) != 0 {
+		t.Errorf("Expected bob to have nothing pending")
+	}
+}
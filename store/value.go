@@ -0,0 +1,20 @@
+package store
+
+// Value is anything which can be stored under a key
+type Value interface {
+	// name of the type, as reported by the TYPE command
+	Type() string
+	// returns a copy which can be read while the original is written
+	Clone() Value
+}
+
+// String is the value set by SET and friends
+type String string
+
+func (String) Type() string {
+	return "string"
+}
+
+func (s String) Clone() Value {
+	return s
+}
@@ -0,0 +1,214 @@
+package store
+
+// ZItem is a member of a sorted set along with its score
+type ZItem struct {
+	Member string
+	Score  float64
+}
+
+// ZSet is the value of ZADD and friends, its members are ordered by score, then by member
+// a map gives the score of a member in O(1) while a skiplist keeps the order,
+// so ranks and ranges are found in O(log n)
+type ZSet struct {
+	dict map[string]float64
+	zsl  *skiplist
+}
+
+// ScoreRange is an interval of scores, each bound is inclusive unless told otherwise
+type ScoreRange struct {
+	Min, Max     float64
+	MinEx, MaxEx bool
+}
+
+// LexRange is an interval of members, meant for sets where all members share the score
+type LexRange struct {
+	Min, Max LexBound
+}
+
+// LexBound is a bound of a LexRange
+type LexBound struct {
+	Value     string
+	Exclusive bool
+	Inf       int // -1 for a bound below every member, 1 for one above every member
+}
+
+func NewZSet() *ZSet {
+	return &ZSet{dict: map[string]float64{}, zsl: newSkiplist()}
+}
+
+func (*ZSet) Type() string {
+	return "zset"
+}
+
+func (z *ZSet) Clone() Value {
+	c := NewZSet()
+	for _, item := range z.Items() {
+		c.Add(item.Member, item.Score)
+	}
+	return c
+}
+
+func (z *ZSet) Len() int {
+	return len(z.dict)
+}
+
+func (z *ZSet) Score(member string) (float64, bool) {
+	score, ok := z.dict[member]
+	return score, ok
+}
+
+// adds the member or updates its score, returns true if it was added
+func (z *ZSet) Add(member string, score float64) bool {
+	cur, ok := z.dict[member]
+	if ok {
+		if cur == score {
+			return false
+		}
+		z.zsl.delete(cur, member)
+	}
+
+	z.zsl.insert(score, member)
+	z.dict[member] = score
+	return !ok
+}
+
+// removes the member, returns false if it wasn't there
+func (z *ZSet) Remove(member string) bool {
+	score, ok := z.dict[member]
+	if !ok {
+		return false
+	}
+
+	z.zsl.delete(score, member)
+	delete(z.dict, member)
+	return true
+}
+
+// returns the 0 based rank of the member, the member with the lowest score has rank 0
+func (z *ZSet) Rank(member string) (int, bool) {
+	score, ok := z.dict[member]
+	if !ok {
+		return 0, false
+	}
+	return z.zsl.rank(score, member) - 1, true
+}
+
+// returns the items from rank start to stop, both in [0, Len) with start <= stop
+// rev ranks the items from the highest score down
+func (z *ZSet) Range(start, stop int, rev bool) []ZItem {
+	items := make([]ZItem, 0, stop-start+1)
+
+	x := z.zsl.byRank(start + 1)
+	if rev {
+		x = z.zsl.byRank(z.Len() - start)
+	}
+	for i := start; i <= stop && x != nil; i++ {
+		items = append(items, x.ZItem)
+		x = x.next(rev)
+	}
+	return items
+}
+
+// returns every item in order
+func (z *ZSet) Items() []ZItem {
+	if z.Len() == 0 {
+		return []ZItem{}
+	}
+	return z.Range(0, z.Len()-1, false)
+}
+
+// returns the items with a score in r, skipping the first offset ones
+// count limits the number of items, a negative count returns all of them
+// rev walks the range from the highest score down
+func (z *ZSet) RangeByScore(r ScoreRange, rev bool, offset, count int) []ZItem {
+	aboveMin := func(n *skiplistNode) bool { return r.aboveMin(n.Score) }
+	belowMax := func(n *skiplistNode) bool { return r.belowMax(n.Score) }
+	return z.rangeBy(aboveMin, belowMax, rev, offset, count)
+}
+
+// same as RangeByScore, for members in r
+func (z *ZSet) RangeByLex(r LexRange, rev bool, offset, count int) []ZItem {
+	aboveMin := func(n *skiplistNode) bool { return r.aboveMin(n.Member) }
+	belowMax := func(n *skiplistNode) bool { return r.belowMax(n.Member) }
+	return z.rangeBy(aboveMin, belowMax, rev, offset, count)
+}
+
+// returns the number of items with a score in r
+func (z *ZSet) CountByScore(r ScoreRange) int {
+	first := z.zsl.first(func(n *skiplistNode) bool { return !r.aboveMin(n.Score) })
+	if first == nil || !r.belowMax(first.Score) {
+		return 0
+	}
+
+	last := z.zsl.last(func(n *skiplistNode) bool { return r.belowMax(n.Score) })
+	return z.zsl.rank(last.Score, last.Member) - z.zsl.rank(first.Score, first.Member) + 1
+}
+
+// the offset is skipped using ranks, so it doesn't cost a walk over the skipped items
+func (z *ZSet) rangeBy(aboveMin, belowMax func(*skiplistNode) bool, rev bool, offset, count int) []ZItem {
+	var x *skiplistNode
+	if rev {
+		x = z.zsl.last(belowMax)
+	} else {
+		x = z.zsl.first(func(n *skiplistNode) bool { return !aboveMin(n) })
+	}
+
+	if x != nil && offset > 0 {
+		rank := z.zsl.rank(x.Score, x.Member)
+		if rev {
+			x = z.zsl.byRank(rank - offset)
+		} else {
+			x = z.zsl.byRank(rank + offset)
+		}
+	}
+
+	items := []ZItem{}
+	for ; x != nil && count != 0 && aboveMin(x) && belowMax(x); count-- {
+		items = append(items, x.ZItem)
+		x = x.next(rev)
+	}
+	return items
+}
+
+func (n *skiplistNode) next(rev bool) *skiplistNode {
+	if rev {
+		return n.backward
+	}
+	return n.level[0].forward
+}
+
+func (r ScoreRange) aboveMin(score float64) bool {
+	if r.MinEx {
+		return score > r.Min
+	}
+	return score >= r.Min
+}
+
+func (r ScoreRange) belowMax(score float64) bool {
+	if r.MaxEx {
+		return score < r.Max
+	}
+	return score <= r.Max
+}
+
+func (r LexRange) aboveMin(member string) bool {
+	switch {
+	case r.Min.Inf != 0:
+		return r.Min.Inf < 0
+	case r.Min.Exclusive:
+		return member > r.Min.Value
+	default:
+		return member >= r.Min.Value
+	}
+}
+
+func (r LexRange) belowMax(member string) bool {
+	switch {
+	case r.Max.Inf != 0:
+		return r.Max.Inf > 0
+	case r.Max.Exclusive:
+		return member < r.Max.Value
+	default:
+		return member <= r.Max.Value
+	}
+}
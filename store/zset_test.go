@@ -0,0 +1,166 @@
+package store
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func TestZSet(t *testing.T) {
+	t.Run("orders by score then member", func(t *testing.T) {
+		z := NewZSet()
+		z.Add("b", 1)
+		z.Add("a", 1)
+		z.Add("c", 0.5)
+		z.Add("d", -2)
+
+		exp := []ZItem{{"d", -2}, {"c", 0.5}, {"a", 1}, {"b", 1}}
+		if out := z.Items(); !slices.Equal(out, exp) {
+			t.Errorf("Expected %v but got %v", exp, out)
+		}
+	})
+
+	t.Run("add updates the score", func(t *testing.T) {
+		z := NewZSet()
+		if !z.Add("a", 1) {
+			t.Errorf("Expected the first add to add the member")
+		}
+		if z.Add("a", 3) {
+			t.Errorf("Expected the second add to only update the score")
+		}
+		z.Add("b", 2)
+
+		if score, _ := z.Score("a"); score != 3 {
+			t.Errorf("Expected score %v but got %v", 3, score)
+		}
+		if rank, _ := z.Rank("a"); rank != 1 {
+			t.Errorf("Expected rank %d but got %d", 1, rank)
+		}
+		if z.Len() != 2 {
+			t.Errorf("Expected length %d but got %d", 2, z.Len())
+		}
+	})
+
+	t.Run("ranks stay right through random inserts and removes", func(t *testing.T) {
+		z := NewZSet()
+		members := map[string]float64{}
+		for i := range 2000 {
+			m := fmt.Sprint(rand.IntN(500))
+			if i%3 == 0 {
+				z.Remove(m)
+				delete(members, m)
+			} else {
+				score := float64(rand.IntN(50))
+				z.Add(m, score)
+				members[m] = score
+			}
+		}
+
+		exp := []ZItem{}
+		for m, score := range members {
+			exp = append(exp, ZItem{m, score})
+		}
+		slices.SortFunc(exp, func(a, b ZItem) int {
+			if a.Score != b.Score {
+				if a.Score < b.Score {
+					return -1
+				}
+				return 1
+			}
+			if a.Member < b.Member {
+				return -1
+			}
+			return 1
+		})
+
+		if out := z.Items(); !slices.Equal(out, exp) {
+			t.Fatalf("Expected %d items in order but got %v", len(exp), out)
+		}
+		for i, item := range exp {
+			if rank, ok := z.Rank(item.Member); !ok || rank != i {
+				t.Fatalf("Expected rank %d for %q but got %d", i, item.Member, rank)
+			}
+		}
+		if n := len(z.Range(0, len(exp)-1, true)); n != len(exp) {
+			t.Errorf("Expected %d items in reverse but got %d", len(exp), n)
+		}
+	})
+
+	t.Run("range by rank", func(t *testing.T) {
+		z := zsetOf("a", "b", "c", "d")
+
+		if out := z.Range(1, 2, false); !slices.Equal(out, []ZItem{{"b", 1}, {"c", 2}}) {
+			t.Errorf("Expected b and c but got %v", out)
+		}
+		if out := z.Range(0, 1, true); !slices.Equal(out, []ZItem{{"d", 3}, {"c", 2}}) {
+			t.Errorf("Expected d and c but got %v", out)
+		}
+	})
+
+	t.Run("range and count by score", func(t *testing.T) {
+		z := zsetOf("a", "b", "c", "d", "e")
+
+		testCases := []struct {
+			name          string
+			r             ScoreRange
+			rev           bool
+			offset, count int
+			exp           []string
+		}{
+			{"inclusive", ScoreRange{Min: 1, Max: 3}, false, 0, -1, []string{"b", "c", "d"}},
+			{"exclusive", ScoreRange{Min: 1, Max: 3, MinEx: true, MaxEx: true}, false, 0, -1, []string{"c"}},
+			{"reversed", ScoreRange{Min: 1, Max: 3}, true, 0, -1, []string{"d", "c", "b"}},
+			{"limited", ScoreRange{Min: 0, Max: 4}, false, 1, 2, []string{"b", "c"}},
+			{"limited and reversed", ScoreRange{Min: 0, Max: 4}, true, 1, 2, []string{"d", "c"}},
+			{"offset past the end", ScoreRange{Min: 0, Max: 4}, false, 10, -1, []string{}},
+			{"empty", ScoreRange{Min: 3, Max: 1}, false, 0, -1, []string{}},
+		}
+
+		for _, tc := range testCases {
+			out := members(z.RangeByScore(tc.r, tc.rev, tc.offset, tc.count))
+			if !slices.Equal(out, tc.exp) {
+				t.Errorf("%s: Expected %v but got %v", tc.name, tc.exp, out)
+			}
+			if tc.offset == 0 && tc.count < 0 && !tc.rev {
+				if n := z.CountByScore(tc.r); n != len(tc.exp) {
+					t.Errorf("%s: Expected count %d but got %d", tc.name, len(tc.exp), n)
+				}
+			}
+		}
+	})
+
+	t.Run("range by lex", func(t *testing.T) {
+		z := NewZSet()
+		for _, m := range []string{"a", "b", "c", "d"} {
+			z.Add(m, 0)
+		}
+
+		r := LexRange{Min: LexBound{Value: "b"}, Max: LexBound{Inf: 1}}
+		if out := members(z.RangeByLex(r, false, 0, -1)); !slices.Equal(out, []string{"b", "c", "d"}) {
+			t.Errorf("Expected b, c and d but got %v", out)
+		}
+
+		r = LexRange{Min: LexBound{Inf: -1}, Max: LexBound{Value: "c", Exclusive: true}}
+		if out := members(z.RangeByLex(r, true, 0, -1)); !slices.Equal(out, []string{"b", "a"}) {
+			t.Errorf("Expected b and a but got %v", out)
+		}
+	})
+}
+
+// returns a sorted set of the members, scored by their position
+func zsetOf(ms ...string) *ZSet {
+	z := NewZSet()
+	for i, m := range ms {
+		z.Add(m, float64(i))
+	}
+	return z
+}
+
+func members(items []ZItem) []string {
+	out := []string{}
+	for _, item := range items {
+		out = append(out, item.Member)
+	}
+	return out
+}